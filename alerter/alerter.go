@@ -0,0 +1,78 @@
+// Package alerter detects high load alerts using hysteresis.
+package alerter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// MetricLoad is a metric name for the club load.
+const MetricLoad = "load"
+
+// Detector fires an alert when a metric value crosses above the high threshold
+// and does not fire again until the value drops below the reset threshold.
+type Detector struct {
+	active map[string]bool // metric name -> alert is fired and not reset yet
+	high   float64
+	reset  float64
+	mu     sync.Mutex
+}
+
+// New creates a new Detector with the given thresholds.
+func New(high, reset float64) (*Detector, error) {
+	if reset >= high {
+		return nil, errors.New("reset threshold must be less than high threshold")
+	}
+
+	return &Detector{active: make(map[string]bool), high: high, reset: reset}, nil
+}
+
+// Check updates the metric state and returns true if a new alert should be fired.
+func (d *Detector) Check(metric string, value float64) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.active[metric] {
+		if value < d.reset {
+			d.active[metric] = false
+		}
+		return false
+	}
+
+	if value > d.high {
+		d.active[metric] = true
+		return true
+	}
+
+	return false
+}
+
+// Run checks load of events from eventCh and forwards them to the returned channel.
+// The notify function is called for every new alert, the returned channel is closed when eventCh is closed.
+func (d *Detector) Run(ctx context.Context, eventCh <-chan databaser.Event, notify func(databaser.Event)) <-chan databaser.Event {
+	outCh := make(chan databaser.Event, 1)
+
+	go func() {
+		defer close(outCh)
+		slog.InfoContext(ctx, "alerter starting", "high", d.high, "reset", d.reset)
+
+		for event := range eventCh {
+			if d.Check(MetricLoad, event.FloatLoad()) {
+				notify(event)
+			}
+
+			select {
+			case outCh <- event:
+			case <-ctx.Done():
+				slog.InfoContext(ctx, "stopping alerter")
+				return
+			}
+		}
+	}()
+
+	return outCh
+}
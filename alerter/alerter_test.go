@@ -0,0 +1,117 @@
+package alerter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		high    float64
+		reset   float64
+		wantErr bool
+	}{
+		{name: "valid thresholds", high: 80, reset: 60},
+		{name: "equal thresholds", high: 80, reset: 80, wantErr: true},
+		{name: "reset above high", high: 60, reset: 80, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := New(tt.high, tt.reset)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d == nil {
+				t.Fatal("expected detector, got nil")
+			}
+		})
+	}
+}
+
+func TestDetector_Check(t *testing.T) {
+	d, err := New(80, 60)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// sustained spike fires once, drops between thresholds don't reset the alert
+	values := []float64{50, 85, 90, 95, 70, 85, 65, 90, 55, 70, 81, 82}
+	want := []bool{false, true, false, false, false, false, false, false, false, false, true, false}
+
+	for i, value := range values {
+		if got := d.Check(MetricLoad, value); got != want[i] {
+			t.Errorf("Check(%v) step %d = %v, want %v", value, i, got, want[i])
+		}
+	}
+}
+
+func TestDetector_CheckMetrics(t *testing.T) {
+	d, err := New(80, 60)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if !d.Check("first", 90) {
+		t.Error("first metric alert expected")
+	}
+	if !d.Check("second", 90) {
+		t.Error("second metric alert is independent and expected")
+	}
+	if d.Check("first", 95) {
+		t.Error("first metric alert is not reset")
+	}
+}
+
+func TestDetector_Run(t *testing.T) {
+	d, err := New(80, 60)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventCh := make(chan databaser.Event)
+	var alerts []databaser.Event
+	outCh := d.Run(ctx, eventCh, func(event databaser.Event) {
+		alerts = append(alerts, event)
+	})
+
+	baseTime := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	loads := []uint8{50, 85, 90, 95, 50, 85}
+
+	go func() {
+		defer close(eventCh)
+		for i, load := range loads {
+			eventCh <- databaser.Event{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Load: load}
+		}
+	}()
+
+	var forwarded int
+	for event := range outCh {
+		if event.Load != loads[forwarded] {
+			t.Errorf("forwarded event %d load = %d, want %d", forwarded, event.Load, loads[forwarded])
+		}
+		forwarded++
+	}
+
+	if forwarded != len(loads) {
+		t.Errorf("forwarded %d events, want %d", forwarded, len(loads))
+	}
+	if len(alerts) != 2 {
+		t.Errorf("got %d alerts, want 2", len(alerts))
+	}
+}
@@ -0,0 +1,107 @@
+package alerter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// NotifyFunc sends a high load alert of the event to the subscribed user.
+type NotifyFunc func(ctx context.Context, subscription databaser.Subscription, event databaser.Event) error
+
+// Subscriber sends high load alerts to users subscribed to them.
+// A user is notified when the main club load is not less than the subscription threshold,
+// but not more often than once per Cooldown, the last notification time is stored in the database.
+type Subscriber struct {
+	Db           *databaser.DB
+	notify       NotifyFunc
+	Cooldown     time.Duration
+	QueryTimeout time.Duration
+	mu           sync.RWMutex
+}
+
+// SetNotify sets the function to send alerts, events are skipped until it is set.
+func (s *Subscriber) SetNotify(notify NotifyFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.notify = notify
+}
+
+// getNotify returns the function to send alerts or nil if it is not set yet.
+func (s *Subscriber) getNotify() NotifyFunc {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.notify
+}
+
+// Run checks events from eventCh for subscriptions and forwards them to the returned channel.
+// The returned channel is closed when eventCh is closed.
+func (s *Subscriber) Run(ctx context.Context, eventCh <-chan databaser.Event) <-chan databaser.Event {
+	outCh := make(chan databaser.Event, 1)
+
+	go func() {
+		defer close(outCh)
+		slog.InfoContext(ctx, "subscriber starting", "cooldown", s.Cooldown)
+
+		for event := range eventCh {
+			if err := s.Check(ctx, event); err != nil {
+				slog.ErrorContext(ctx, "subscriber check failed", "error", err, "event", &event)
+			}
+
+			select {
+			case outCh <- event:
+			case <-ctx.Done():
+				slog.InfoContext(ctx, "stopping subscriber")
+				return
+			}
+		}
+	}()
+
+	return outCh
+}
+
+// Check sends alerts of the event to subscribed users, whose thresholds are reached and cooldowns are passed.
+// Events of additional clubs are ignored.
+func (s *Subscriber) Check(ctx context.Context, event databaser.Event) error {
+	if event.ClubID != databaser.DefaultClubID {
+		return nil
+	}
+
+	notify := s.getNotify()
+	if notify == nil {
+		slog.DebugContext(ctx, "subscriber notify is not set, event is skipped", "event", &event)
+		return nil
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, s.QueryTimeout)
+	subscriptions, err := s.Db.GetAlertSubscriptions(queryCtx, event.Load)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("get subscriptions: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		now := time.Now().UTC()
+		if now.Sub(subscription.Notified) < s.Cooldown {
+			continue
+		}
+
+		if err = notify(ctx, subscription, event); err != nil {
+			slog.ErrorContext(ctx, "subscriber notify failed", "error", err, "user", subscription.UserID)
+			continue
+		}
+
+		queryCtx, cancel = context.WithTimeout(ctx, s.QueryTimeout)
+		err = s.Db.SetSubscriptionNotified(queryCtx, subscription.UserID, now)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("set subscription notified: %w", err)
+		}
+	}
+
+	return nil
+}
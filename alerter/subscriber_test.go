@@ -0,0 +1,107 @@
+package alerter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func newTestDB(t *testing.T) *databaser.DB {
+	t.Helper()
+	ctx := context.Background()
+	db, err := databaser.New(ctx, ":memory:", 1)
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("failed to close test database: %v", err)
+		}
+	})
+	if err = db.Init(ctx); err != nil {
+		t.Fatalf("failed to init test database: %v", err)
+	}
+	return db
+}
+
+func TestSubscriber_Run(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES
+		(1, ?, 'low', '', '', ?, ?), (2, ?, 'high', '', '', ?, ?), (3, ?, 'failed', '', '', ?, ?)`,
+		databaser.UserApproved, now, now, databaser.UserApproved, now, now, databaser.UserApproved, now, now)
+	if err != nil {
+		t.Fatalf("failed to insert users: %v", err)
+	}
+	for userID, threshold := range map[int64]uint8{1: 50, 2: 80, 3: 50} {
+		if err = db.SetSubscription(ctx, userID, threshold); err != nil {
+			t.Fatalf("SetSubscription() error = %v", err)
+		}
+	}
+
+	s := &Subscriber{Db: db, Cooldown: time.Hour, QueryTimeout: time.Second}
+	notified := make(map[int64][]uint8)
+	s.SetNotify(func(_ context.Context, subscription databaser.Subscription, event databaser.Event) error {
+		if subscription.UserID == 3 {
+			return errors.New("blocked by user")
+		}
+		notified[subscription.UserID] = append(notified[subscription.UserID], event.Load)
+		return nil
+	})
+
+	events := []databaser.Event{
+		{Timestamp: now, Load: 40},
+		{Timestamp: now.Add(time.Minute), Load: 60},
+		{Timestamp: now.Add(2 * time.Minute), Load: 95, ClubID: 2},
+		{Timestamp: now.Add(3 * time.Minute), Load: 85},
+		{Timestamp: now.Add(4 * time.Minute), Load: 90},
+	}
+	eventCh := make(chan databaser.Event, len(events))
+	for _, event := range events {
+		eventCh <- event
+	}
+	close(eventCh)
+
+	var forwarded int
+	for range s.Run(ctx, eventCh) {
+		forwarded++
+	}
+
+	if forwarded != len(events) {
+		t.Errorf("forwarded %d events, want %d", forwarded, len(events))
+	}
+	// the cooldown prevents repeated alerts, additional clubs are ignored
+	if got := notified[1]; len(got) != 1 || got[0] != 60 {
+		t.Errorf("user 1 alerts = %v, want [60]", got)
+	}
+	if got := notified[2]; len(got) != 1 || got[0] != 85 {
+		t.Errorf("user 2 alerts = %v, want [85]", got)
+	}
+
+	// failed notifications are retried with next events
+	subscriptions, err := db.GetAlertSubscriptions(ctx, 100)
+	if err != nil {
+		t.Fatalf("GetAlertSubscriptions() error = %v", err)
+	}
+	for _, subscription := range subscriptions {
+		sent := subscription.UserID != 3
+		if sent != subscription.Notified.After(now.Add(-time.Minute)) {
+			t.Errorf("user %d notified = %v, sent %v", subscription.UserID, subscription.Notified, sent)
+		}
+	}
+}
+
+func TestSubscriber_Check_NoNotify(t *testing.T) {
+	s := &Subscriber{Cooldown: time.Hour, QueryTimeout: time.Second}
+
+	// the database is not used until the notify function is set
+	if err := s.Check(context.Background(), databaser.Event{Timestamp: time.Now(), Load: 100}); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+// Package backuper provides functionality to periodically back up the database to a directory.
+package backuper
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// Backuper holds the configuration for the scheduled database backups.
+type Backuper struct {
+	Db           *databaser.DB
+	Dir          string
+	Period       time.Duration
+	QueryTimeout time.Duration
+}
+
+// Run begins the periodic backup process, the first backup is created after the period.
+func (bk *Backuper) Run(ctx context.Context) <-chan struct{} {
+	doneCh := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(bk.Period)
+		defer ticker.Stop()
+		slog.Info("backuper starting", "period", bk.Period, "dir", bk.Dir)
+
+		for {
+			select {
+			case <-ctx.Done():
+				slog.Info("stopping backuper")
+				close(doneCh)
+				return
+			case <-ticker.C:
+				slog.Info("wake up backuper")
+				if err := bk.Backup(ctx); err != nil {
+					slog.Error("backuper error", "error", err)
+				}
+			}
+		}
+	}()
+
+	return doneCh
+}
+
+// Backup writes a new backup file to the directory.
+func (bk *Backuper) Backup(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, bk.QueryTimeout)
+	defer cancel()
+
+	backupPath, size, err := bk.Db.BackupToDir(ctx, bk.Dir)
+	if err != nil {
+		return fmt.Errorf("backup database: %w", err)
+	}
+
+	slog.InfoContext(ctx, "database backup created", "path", backupPath, "size", size)
+	return nil
+}
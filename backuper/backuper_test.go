@@ -0,0 +1,56 @@
+package backuper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func TestBackuper_Backup(t *testing.T) {
+	db, err := databaser.New(context.Background(), ":memory:", 1)
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if closeErr := db.Close(); closeErr != nil {
+			t.Errorf("failed to close test database: %v", closeErr)
+		}
+	})
+
+	bk := &Backuper{
+		Db:           db,
+		Dir:          filepath.Join(t.TempDir(), "backups"),
+		Period:       time.Hour,
+		QueryTimeout: 5 * time.Second,
+	}
+
+	if err = bk.Backup(context.Background()); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(bk.Dir)
+	if err != nil {
+		t.Fatalf("failed to read backup directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("backup files = %d, want 1", len(entries))
+	}
+}
+
+func TestBackuper_Run(t *testing.T) {
+	bk := &Backuper{Period: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	doneCh := bk.Run(ctx)
+	cancel()
+
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatal("backuper did not stop")
+	}
+}
@@ -0,0 +1,138 @@
+// Package cacher keeps recent events of the main club in memory to build short period graphs without database queries.
+package cacher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// Cache is a ring buffer of the most recent events bounded by the number of events.
+// All events since the covered time are kept, so a period starting after it and not longer than maxAge
+// is read from the cache. Events changed in the database by other ways are not synchronized.
+type Cache struct {
+	from   time.Time // events with timestamp >= from are cached
+	events []databaser.Event
+	maxAge time.Duration
+	start  int // index of the oldest event
+	size   int
+	mu     sync.RWMutex
+}
+
+// New creates a new empty Cache for at most size events not older than maxAge.
+func New(size int, maxAge time.Duration) (*Cache, error) {
+	if size < 1 {
+		return nil, errors.New("cache size must be greater than zero")
+	}
+	if maxAge <= 0 {
+		return nil, errors.New("cache max age must be greater than zero")
+	}
+
+	return &Cache{events: make([]databaser.Event, size), maxAge: maxAge, from: time.Now().UTC()}, nil
+}
+
+// Seed replaces cached events by the main club events of the max age period from the database.
+func (c *Cache) Seed(ctx context.Context, db *databaser.DB) error {
+	events, err := db.GetEvents(ctx, c.maxAge)
+	if err != nil {
+		return fmt.Errorf("seed cache: %w", err)
+	}
+	// the query period started a bit earlier, so all events since this time are loaded
+	from := time.Now().UTC().Add(-c.maxAge)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.start, c.size, c.from = 0, 0, from
+	for _, event := range events {
+		c.add(event)
+	}
+
+	slog.InfoContext(ctx, "cache seeded", "events", c.size, "from", c.from)
+	return nil
+}
+
+// Add appends the event to the cache, the oldest event is evicted if the cache is full.
+// Events older than the last cached one are ignored, they are only in the database.
+func (c *Cache) Add(event databaser.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(event)
+}
+
+// add appends the event, it must be called with the locked mutex.
+func (c *Cache) add(event databaser.Event) {
+	if c.size > 0 && !event.Timestamp.After(c.at(c.size-1).Timestamp) {
+		return
+	}
+
+	if c.size == len(c.events) {
+		c.evict()
+	}
+
+	c.events[(c.start+c.size)%len(c.events)] = event
+	c.size++
+}
+
+// evict removes the oldest event, the cache doesn't cover its timestamp anymore.
+func (c *Cache) evict() {
+	c.from = c.at(0).Timestamp.Add(time.Nanosecond)
+	c.start = (c.start + 1) % len(c.events)
+	c.size--
+}
+
+// at returns the i-th event from the oldest one.
+func (c *Cache) at(i int) databaser.Event {
+	return c.events[(c.start+i)%len(c.events)]
+}
+
+// Events returns cached events to the current time minus the given period ordered by timestamp.
+// It returns false if the period is not covered by the cache, so events should be read from the database.
+func (c *Cache) Events(period time.Duration) ([]databaser.Event, bool) {
+	since := time.Now().UTC().Add(-period)
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if period > c.maxAge || since.Before(c.from) {
+		return nil, false
+	}
+
+	var events []databaser.Event
+	for i := range c.size {
+		if event := c.at(i); !event.Timestamp.Before(since) {
+			events = append(events, event)
+		}
+	}
+
+	return events, true
+}
+
+// Run adds events from eventCh to the cache and forwards them to the returned channel.
+// The returned channel is closed when eventCh is closed.
+func (c *Cache) Run(ctx context.Context, eventCh <-chan databaser.Event) <-chan databaser.Event {
+	outCh := make(chan databaser.Event, 1)
+
+	go func() {
+		defer close(outCh)
+		slog.InfoContext(ctx, "cacher starting", "size", len(c.events), "maxAge", c.maxAge)
+
+		for event := range eventCh {
+			c.Add(event)
+
+			select {
+			case outCh <- event:
+			case <-ctx.Done():
+				slog.InfoContext(ctx, "stopping cacher")
+				return
+			}
+		}
+	}()
+
+	return outCh
+}
@@ -0,0 +1,214 @@
+package cacher
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func newTestDB(t *testing.T) *databaser.DB {
+	t.Helper()
+	db, err := databaser.New(context.Background(), ":memory:", 1)
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("failed to close test database: %v", err)
+		}
+	})
+	return db
+}
+
+// recentEvents returns count events every minute before the current time, the last one is the newest.
+func recentEvents(count int) []databaser.Event {
+	now := time.Now().UTC().Truncate(time.Second)
+	events := make([]databaser.Event, count)
+
+	for i := range events {
+		events[i] = databaser.Event{Timestamp: now.Add(-time.Duration(count-i) * time.Minute), Load: uint8(i % 100)}
+	}
+
+	return events
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		maxAge  time.Duration
+		wantErr bool
+	}{
+		{name: "valid", size: 10, maxAge: time.Hour},
+		{name: "zero size", maxAge: time.Hour, wantErr: true},
+		{name: "zero max age", size: 10, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.size, tt.maxAge)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCache_Events(t *testing.T) {
+	cache, err := New(30, time.Hour)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, ok := cache.Events(time.Minute); ok {
+		t.Error("empty cache should not cover past periods")
+	}
+
+	events := recentEvents(40)
+	db := newTestDB(t)
+	if err = db.SaveManyEvents(context.Background(), events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+	if err = cache.Seed(context.Background(), db); err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		period  time.Duration
+		wantLen int
+		wantHit bool
+	}{
+		{name: "short period", period: 10*time.Minute + 30*time.Second, wantLen: 10, wantHit: true},
+		{name: "all cached events", period: 30*time.Minute + 30*time.Second, wantLen: 30, wantHit: true},
+		{name: "evicted events", period: 35 * time.Minute},
+		{name: "longer than max age", period: 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cache.Events(tt.period)
+			if ok != tt.wantHit {
+				t.Fatalf("Events() hit = %v, want %v", ok, tt.wantHit)
+			}
+			if !ok {
+				return
+			}
+
+			// cached events are the same as in the database
+			want, dbErr := db.GetEvents(context.Background(), tt.period)
+			if dbErr != nil {
+				t.Fatalf("GetEvents() error = %v", dbErr)
+			}
+			if len(got) != tt.wantLen || len(want) != tt.wantLen {
+				t.Fatalf("got %d cached and %d database events, want %d", len(got), len(want), tt.wantLen)
+			}
+			for i := range want {
+				if !got[i].Timestamp.Equal(want[i].Timestamp) || got[i].Load != want[i].Load {
+					t.Errorf("event %d = %v, want %v", i, &got[i], &want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCache_Add(t *testing.T) {
+	cache, err := New(3, time.Hour)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = cache.Seed(context.Background(), newTestDB(t)); err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+
+	events := recentEvents(4)
+	for _, event := range events {
+		cache.Add(event)
+	}
+	// an old event is ignored
+	cache.Add(databaser.Event{Timestamp: events[0].Timestamp, Load: 99})
+
+	if _, ok := cache.Events(4*time.Minute + 30*time.Second); ok {
+		t.Error("period with the evicted event should not be cached")
+	}
+
+	got, ok := cache.Events(3*time.Minute + 30*time.Second)
+	if !ok {
+		t.Fatal("expected cache hit")
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want 3", len(got))
+	}
+	for i, event := range got {
+		if want := events[i+1]; !event.Timestamp.Equal(want.Timestamp) || event.Load != want.Load {
+			t.Errorf("event %d = %v, want %v", i, &event, &want)
+		}
+	}
+}
+
+func TestCache_Run(t *testing.T) {
+	cache, err := New(100, time.Hour)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = cache.Seed(context.Background(), newTestDB(t)); err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+
+	eventCh := make(chan databaser.Event)
+	outCh := cache.Run(context.Background(), eventCh)
+	events := recentEvents(5)
+
+	go func() {
+		defer close(eventCh)
+		for _, event := range events {
+			eventCh <- event
+		}
+	}()
+
+	var n int
+	for event := range outCh {
+		if !event.Timestamp.Equal(events[n].Timestamp) {
+			t.Errorf("forwarded event %d = %v, want %v", n, &event, &events[n])
+		}
+		n++
+	}
+	if n != len(events) {
+		t.Errorf("forwarded %d events, want %d", n, len(events))
+	}
+
+	got, ok := cache.Events(10 * time.Minute)
+	if !ok || len(got) != len(events) {
+		t.Errorf("Events() = %d events, hit %v, want %d", len(got), ok, len(events))
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	cache, err := New(50, time.Hour)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = cache.Seed(context.Background(), newTestDB(t)); err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for _, event := range recentEvents(200) {
+			cache.Add(event)
+		}
+	})
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				if events, ok := cache.Events(30 * time.Minute); ok && len(events) > 30 {
+					t.Errorf("got %d events for 30 minutes", len(events))
+				}
+			}
+		})
+	}
+	wg.Wait()
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// proxyNone is the proxy value to connect directly ignoring proxy environment variables.
+const proxyNone = "none"
+
+// HTTPClient contains settings of the HTTP client of a component, every component has its own client.
+type HTTPClient struct {
+	ProxyURL       *url.URL          `toml:"-"`
+	Headers        map[string]string `toml:"headers"`
+	Proxy          string            `toml:"proxy"`
+	RequestTimeout time.Duration     `toml:"-"`
+	Timeout        int               `toml:"timeout"`
+}
+
+func (c *HTTPClient) validate() error {
+	if c.Timeout < 0 {
+		return newFieldError("timeout", errors.New("must not be negative"))
+	}
+
+	switch c.Proxy = strings.TrimSpace(c.Proxy); c.Proxy {
+	case "", proxyNone:
+		c.ProxyURL = nil
+	default:
+		u, err := url.Parse(c.Proxy)
+		if err != nil {
+			return newFieldError("proxy", fmt.Errorf("invalid URL: %w", err))
+		}
+		if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5" {
+			return newFieldError("proxy", fmt.Errorf("invalid scheme %q, must be http, https or socks5", u.Scheme))
+		}
+		if u.Host == "" {
+			return newFieldError("proxy", errors.New("missing host"))
+		}
+		c.ProxyURL = u
+	}
+
+	for name := range c.Headers {
+		if name == "" || strings.ContainsAny(name, " :\t\r\n") {
+			return newFieldError("headers", fmt.Errorf("invalid header name %q", name))
+		}
+	}
+
+	c.RequestTimeout = time.Duration(c.Timeout) * time.Second
+	return nil
+}
+
+// NewClient returns a new HTTP client with the settings. Every call creates a client with its own transport,
+// so components don't share connections. Headers are added to requests which don't have them.
+func (c *HTTPClient) NewClient() *http.Client {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+
+	switch {
+	case c.ProxyURL != nil:
+		transport.Proxy = http.ProxyURL(c.ProxyURL)
+	case c.Proxy == proxyNone:
+		transport.Proxy = nil
+	}
+
+	var rt http.RoundTripper = transport
+	if len(c.Headers) > 0 {
+		headers := make(http.Header, len(c.Headers))
+		for name, value := range c.Headers {
+			headers.Set(name, value)
+		}
+		rt = &headerTransport{base: transport, headers: headers}
+	}
+
+	return &http.Client{Transport: rt, Timeout: c.RequestTimeout}
+}
+
+// headerTransport adds default headers to requests.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers http.Header
+}
+
+// RoundTrip implements http.RoundTripper, the request is cloned because it must not be modified.
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	for name, values := range t.headers {
+		if req.Header.Get(name) == "" {
+			req.Header[name] = values
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
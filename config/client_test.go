@@ -0,0 +1,149 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPClient_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		client    HTTPClient
+		wantProxy string
+		wantErr   bool
+	}{
+		{name: "empty", client: HTTPClient{}},
+		{name: "direct", client: HTTPClient{Proxy: "none"}},
+		{name: "http proxy", client: HTTPClient{Proxy: " http://proxy:3128 "}, wantProxy: "http://proxy:3128"},
+		{name: "socks5 proxy", client: HTTPClient{Proxy: "socks5://proxy:1080"}, wantProxy: "socks5://proxy:1080"},
+		{name: "headers", client: HTTPClient{Timeout: 10, Headers: map[string]string{"User-Agent": "ggp"}}},
+		{name: "negative timeout", client: HTTPClient{Timeout: -1}, wantErr: true},
+		{name: "proxy scheme", client: HTTPClient{Proxy: "ftp://proxy"}, wantErr: true},
+		{name: "proxy host", client: HTTPClient{Proxy: "http://"}, wantErr: true},
+		{name: "empty header", client: HTTPClient{Headers: map[string]string{"": "value"}}, wantErr: true},
+		{name: "invalid header", client: HTTPClient{Headers: map[string]string{"X Key": "value"}}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.client.validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := time.Duration(tc.client.Timeout) * time.Second; tc.client.RequestTimeout != got {
+				t.Errorf("request timeout = %v, want %v", tc.client.RequestTimeout, got)
+			}
+			if tc.wantProxy == "" {
+				if tc.client.ProxyURL != nil {
+					t.Errorf("proxy URL = %v, want nil", tc.client.ProxyURL)
+				}
+			} else if tc.client.ProxyURL == nil || tc.client.ProxyURL.String() != tc.wantProxy {
+				t.Errorf("proxy URL = %v, want %s", tc.client.ProxyURL, tc.wantProxy)
+			}
+		})
+	}
+}
+
+func TestHTTPClient_NewClient(t *testing.T) {
+	fetcherCfg := HTTPClient{Timeout: 7, Proxy: "http://proxy:3128", Headers: map[string]string{"x-api-key": "secret"}}
+	holidayerCfg := HTTPClient{Proxy: "none"}
+	for _, c := range []*HTTPClient{&fetcherCfg, &holidayerCfg} {
+		if err := c.validate(); err != nil {
+			t.Fatalf("validate() error = %v", err)
+		}
+	}
+
+	fetcherClient, holidayerClient := fetcherCfg.NewClient(), holidayerCfg.NewClient()
+	if fetcherClient.Timeout != 7*time.Second {
+		t.Errorf("fetcher client timeout = %v, want 7s", fetcherClient.Timeout)
+	}
+	if holidayerClient.Timeout != 0 {
+		t.Errorf("holidayer client timeout = %v, want 0", holidayerClient.Timeout)
+	}
+
+	// the fetcher transport is wrapped to add headers
+	ht, ok := fetcherClient.Transport.(*headerTransport)
+	if !ok {
+		t.Fatalf("fetcher client transport = %T, want *headerTransport", fetcherClient.Transport)
+	}
+	fetcherTransport, ok := ht.base.(*http.Transport)
+	if !ok {
+		t.Fatalf("fetcher base transport = %T, want *http.Transport", ht.base)
+	}
+	holidayerTransport, ok := holidayerClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("holidayer client transport = %T, want *http.Transport", holidayerClient.Transport)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+	proxyURL, err := fetcherTransport.Proxy(req)
+	if err != nil {
+		t.Fatalf("fetcher proxy error = %v", err)
+	}
+	if proxyURL == nil || proxyURL.String() != "http://proxy:3128" {
+		t.Errorf("fetcher proxy = %v, want http://proxy:3128", proxyURL)
+	}
+	if holidayerTransport.Proxy != nil {
+		t.Error("holidayer proxy is set, want direct connections")
+	}
+
+	// new clients don't share transports
+	if other := holidayerCfg.NewClient(); other.Transport == holidayerClient.Transport {
+		t.Error("clients share the transport")
+	}
+}
+
+func TestHTTPClient_Headers(t *testing.T) {
+	received := make(chan http.Header, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := HTTPClient{Proxy: "none", Headers: map[string]string{"User-Agent": "ggp", "X-Api-Key": "secret"}}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+	client := cfg.NewClient()
+
+	for _, userAgent := range []string{"", "custom"} {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		if userAgent != "" {
+			req.Header.Set("User-Agent", userAgent)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request error = %v", err)
+		}
+		if err = resp.Body.Close(); err != nil {
+			t.Errorf("failed to close body: %v", err)
+		}
+
+		headers := <-received
+		if got := headers.Get("X-Api-Key"); got != "secret" {
+			t.Errorf("X-Api-Key = %q, want secret", got)
+		}
+		// request headers are not replaced
+		want := userAgent
+		if want == "" {
+			want = "ggp"
+		}
+		if got := headers.Get("User-Agent"); got != want {
+			t.Errorf("User-Agent = %q, want %q", got, want)
+		}
+		if req.Header.Get("X-Api-Key") != "" {
+			t.Error("original request is modified")
+		}
+	}
+}
@@ -4,9 +4,14 @@ package config
 import (
 	"errors"
 	"fmt"
+	"math"
+	"net"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/pelletier/go-toml/v2"
@@ -14,42 +19,128 @@ import (
 
 // Config represents the application configuration.
 type Config struct {
-	Telegram  Telegram  `toml:"telegram"`
-	Base      Base      `toml:"base"`
-	Database  Database  `toml:"database"`
-	Fetcher   Fetcher   `toml:"fetcher"`
-	Holidayer Holidayer `toml:"holidayer"`
-	Predictor Predictor `toml:"predictor"`
+	Telegram      Telegram      `toml:"telegram"`
+	Base          Base          `toml:"base"`
+	Database      Database      `toml:"database"`
+	Fetcher       Fetcher       `toml:"fetcher"`
+	Holidayer     Holidayer     `toml:"holidayer"`
+	Predictor     Predictor     `toml:"predictor"`
+	Alerter       Alerter       `toml:"alerter"`
+	Subscriptions Subscriptions `toml:"subscriptions"`
+	Retention     Retention     `toml:"retention"`
+	Cache         Cache         `toml:"cache"`
+	Features      Features      `toml:"-"`
+}
+
+// Features contains flags of optional subsystems, they are set from sections "active" values.
+// A subsystem can depend on others, e.g. alerter checks fetched events, so it is disabled without fetcher.
+type Features struct {
+	Fetcher       bool
+	Alerter       bool
+	Subscriptions bool
+	Holidayer     bool
+	Predictor     bool
+	Retention     bool
+	Cache         bool
+	Telegram      bool
+	Metrics       bool
+	Backup        bool
 }
 
 // Base contains base application settings.
 type Base struct {
-	TimeLocation *time.Location     `toml:"-"`
-	AdminIDs     map[int64]struct{} `toml:"-"`
-	Timezone     string             `toml:"timezone"`
-	Admins       []int64            `toml:"admins"`
-	Debug        bool               `toml:"debug"`
+	TimeLocation  *time.Location     `toml:"-"`
+	AdminIDs      map[int64]struct{} `toml:"-"`
+	Timezone      string             `toml:"timezone"`
+	WeekStart     string             `toml:"week_start"`
+	Language      string             `toml:"language"`
+	HourFormat    string             `toml:"hour_format"`
+	HourLayout    string             `toml:"-"`
+	HourEndLayout string             `toml:"-"`
+	MetricsAddr   string             `toml:"metrics_addr"`
+	Admins        []int64            `toml:"admins"`
+	FirstWeekday  time.Weekday       `toml:"-"`
+	Capacity      uint8              `toml:"capacity"`
+	Debug         bool               `toml:"debug"`
 }
 
+const (
+	// defaultHourFormat is a 24-hour layout of hour labels.
+	defaultHourFormat = "15:04"
+	// hourRangeSeparator splits the hour format into layouts of the hour start and end.
+	hourRangeSeparator = "–"
+)
+
+// Default database memory settings in MiB.
+const (
+	defaultCacheSize = 32
+	defaultMmapSize  = 128
+)
+
+// defaultBusyTimeout is the default database busy timeout in milliseconds.
+const defaultBusyTimeout = 5000
+
+// defaultRetentionPeriod is the pruner period in seconds if only database retention_days is set.
+const defaultRetentionPeriod = 86400
+
 // Database contains database connection settings.
 type Database struct {
-	Path         string        `toml:"path"`
-	Timeout      time.Duration `toml:"-"`
-	QueryTimeout int           `toml:"query_timeout"`
-	Threads      uint8         `toml:"threads"`
+	Path           string        `toml:"path"`
+	SeedCSV        string        `toml:"seed_csv"`
+	BackupDir      string        `toml:"backup_dir"`
+	Timeout        time.Duration `toml:"-"`
+	BackupInterval time.Duration `toml:"-"`
+	RetentionTTL   time.Duration `toml:"-"`
+	CacheSize      int64         `toml:"cache_size"`
+	MmapSize       int64         `toml:"mmap_size"`
+	QueryTimeout   int           `toml:"query_timeout"`
+	BusyTimeout    int           `toml:"busy_timeout"`
+	BackupPeriod   int           `toml:"backup_period"`
+	RetentionDays  int           `toml:"retention_days"`
+	Threads        uint8         `toml:"threads"`
+}
+
+// Club is an additional club to fetch, the main club has URL of the fetcher section and ID 0.
+type Club struct {
+	URL string `toml:"url"`
+	ID  int    `toml:"id"`
 }
 
 // Fetcher contains fetcher configuration.
 type Fetcher struct {
-	Token   string        `toml:"token"`
-	URL     string        `toml:"url"`
-	Timeout time.Duration `toml:"-"`
-	Period  int           `toml:"period"`
-	Active  bool          `toml:"active"`
+	Token           string        `toml:"token"`
+	AuthType        string        `toml:"auth_type"`
+	AuthHeader      string        `toml:"auth_header"`
+	Username        string        `toml:"username"`
+	Password        string        `toml:"password"`
+	URL             string        `toml:"url"`
+	EventWebhook    string        `toml:"event_webhook"`
+	Transform       string        `toml:"transform"`
+	Clubs           []Club        `toml:"clubs"`
+	Client          HTTPClient    `toml:"client"`
+	Timeout         time.Duration `toml:"-"`
+	BatchTimeout    time.Duration `toml:"-"`
+	BreakerCooldown time.Duration `toml:"-"`
+	RetryBase       time.Duration `toml:"-"`
+	SummaryInterval time.Duration `toml:"-"`
+	Period          int           `toml:"period"`
+	BatchSize       int           `toml:"batch_size"`
+	BatchPeriod     int           `toml:"batch_period"`
+	BreakerFailures int           `toml:"breaker_failures"`
+	BreakerPeriod   int           `toml:"breaker_period"`
+	Workers         int           `toml:"workers"`
+	Retries         int           `toml:"retries"`
+	RetryBaseMs     int           `toml:"retry_base_ms"`
+	SummaryPeriod   int           `toml:"summary_period"`
+	TransformScale  float64       `toml:"transform_scale"`
+	TransformOffset float64       `toml:"transform_offset"`
+	Active          bool          `toml:"active"`
+	UseResponseTime bool          `toml:"use_response_time"`
 }
 
 // Holidayer contains holidayer configuration.
 type Holidayer struct {
+	Client  HTTPClient    `toml:"client"`
 	URL     string        `toml:"url"`
 	Timeout time.Duration `toml:"-"`
 	Period  int           `toml:"period"`
@@ -58,17 +149,89 @@ type Holidayer struct {
 
 // Predictor contains predictor configuration.
 type Predictor struct {
-	Hours        uint8         `toml:"hours"`
-	Active       bool          `toml:"active"`
-	LoadSize     int           `toml:"load_size"`
-	Timeout      time.Duration `toml:"-"`
-	QueryTimeout int           `toml:"query_timeout"`
+	Hours               uint8         `toml:"hours"`
+	Active              bool          `toml:"active"`
+	ScaleConfidence     bool          `toml:"scale_confidence"`
+	GlobalBlend         bool          `toml:"global_blend"`
+	GlobalBlendWeight   float64       `toml:"global_blend_weight"`
+	AnomalyThreshold    float64       `toml:"anomaly_threshold"`
+	DecayLambda         float64       `toml:"decay_lambda"`
+	MinWeight           float64       `toml:"min_weight"`
+	ResetWeight         float64       `toml:"reset_weight"`
+	ConfidenceThreshold float64       `toml:"confidence_threshold"`
+	HolidayMargin       int           `toml:"holiday_margin"`
+	WarmupEvents        uint64        `toml:"warmup_events"`
+	TrackAccuracy       bool          `toml:"track_accuracy"`
+	SmoothWindow        int           `toml:"smooth_window"`
+	LoadSize            int           `toml:"load_size"`
+	LoadRetries         int           `toml:"load_retries"`
+	Timeout             time.Duration `toml:"-"`
+	QueryTimeout        int           `toml:"query_timeout"`
+	RecentMaxAge        time.Duration `toml:"-"`
+	RecentAge           int           `toml:"recent_age"`
+	RecentCount         int           `toml:"recent_count"`
+	Refresh             time.Duration `toml:"-"`
+	RefreshPeriod       int           `toml:"refresh_period"`
+}
+
+// Retention contains events rollup and retention configuration.
+// Completed days are rolled up into daily statistics, raw events older than RawDays are deleted,
+// daily statistics are deleted after AggregateDays. Zero values keep data forever.
+type Retention struct {
+	RawTTL        time.Duration `toml:"-"`
+	AggregateTTL  time.Duration `toml:"-"`
+	Timeout       time.Duration `toml:"-"`
+	RawDays       int           `toml:"raw_days"`
+	AggregateDays int           `toml:"aggregate_days"`
+	Period        int           `toml:"period"`
+	Active        bool          `toml:"active"`
+}
+
+// Alerter contains high load alerts configuration.
+// An alert is fired when the load is above High and fired again only after the load drops below Reset.
+type Alerter struct {
+	High   uint8 `toml:"high"`
+	Reset  uint8 `toml:"reset"`
+	Active bool  `toml:"active"`
+}
+
+// Subscriptions contains configuration of high load alerts sent to subscribed users.
+// A user is alerted not more often than once per Cooldown.
+type Subscriptions struct {
+	Cooldown time.Duration `toml:"-"`
+	Period   int           `toml:"period"`
+	Active   bool          `toml:"active"`
+}
+
+// Cache contains configuration of the in-memory cache of recent fetched events.
+// Graphs of periods not longer than MaxAge are built from the cache if it has all events of the period.
+type Cache struct {
+	MaxAge time.Duration `toml:"-"`
+	Size   int           `toml:"size"`
+	Period int           `toml:"period"`
+	Active bool          `toml:"active"`
 }
 
 // Telegram contains Telegram bot configuration.
 type Telegram struct {
-	Token  string `toml:"token"`
-	Active bool   `toml:"active"`
+	Token               string        `toml:"token"`
+	GraphFormat         string        `toml:"graph_format"`
+	Watermark           string        `toml:"watermark"`
+	Timeout             time.Duration `toml:"-"`
+	MaxPeriod           time.Duration `toml:"-"`
+	HandlerTimeout      int           `toml:"handler_timeout"`
+	MaxDuration         int           `toml:"max_duration"`
+	StalePeriods        int           `toml:"stale_periods"`
+	RateLimit           int           `toml:"rate_limit"`
+	RateBurst           int           `toml:"rate_burst"`
+	Active              bool          `toml:"active"`
+	ShowPoints          bool          `toml:"show_points"`
+	ShowTypical         bool          `toml:"show_typical"`
+	ShowConfidence      bool          `toml:"show_confidence"`
+	CompactGraph        bool          `toml:"compact_graph"`
+	KeepOverlap         bool          `toml:"keep_overlap"`
+	NotifyRepeatedStart bool          `toml:"notify_repeated_start"`
+	AllowWipeEvents     bool          `toml:"allow_wipe_events"`
 }
 
 // Load reads and parses a TOML configuration file.
@@ -93,45 +256,214 @@ func Load(path string) (*Config, error) {
 	return cfg, nil
 }
 
+// reloadable contains paths of fields which are applied to running components on reload.
+var reloadable = map[string]struct{}{
+	"base.admins":                 {},
+	"fetcher.period":              {},
+	"holidayer.period":            {},
+	"predictor.scale_confidence":  {},
+	"predictor.anomaly_threshold": {},
+}
+
+// NotReloadable returns sorted paths of fields changed in the other config which can't be applied without restart.
+// All fields of sections are compared, so only fields of the reloadable list are not returned.
+func (c *Config) NotReloadable(other *Config) []string {
+	var (
+		fields   []string
+		sections = reflect.ValueOf(c).Elem()
+		others   = reflect.ValueOf(other).Elem()
+	)
+
+	for i := range sections.NumField() {
+		section, ok := tomlName(sections.Type().Field(i))
+		if !ok {
+			continue
+		}
+
+		value, otherValue := sections.Field(i), others.Field(i)
+		for j := range value.NumField() {
+			name, ok := tomlName(value.Type().Field(j))
+			if !ok {
+				continue
+			}
+
+			path := section + "." + name
+			if _, ok = reloadable[path]; ok {
+				continue
+			}
+			if !reflect.DeepEqual(value.Field(j).Interface(), otherValue.Field(j).Interface()) {
+				fields = append(fields, path)
+			}
+		}
+	}
+
+	slices.Sort(fields)
+	return fields
+}
+
+// tomlName returns the TOML key of the struct field, it's false for fields which are not read from the file.
+func tomlName(field reflect.StructField) (string, bool) {
+	name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+	return name, name != "" && name != "-"
+}
+
+// FieldError is a config validation error of a field, Field is a full path like "holidayer.period".
+type FieldError struct {
+	Err   error
+	Field string
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// newFieldError returns a validation error of the field.
+func newFieldError(field string, err error) *FieldError {
+	return &FieldError{Field: field, Err: err}
+}
+
+// sectionError adds the section name to the field path of err.
+func sectionError(section string, err error) error {
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		return newFieldError(section+"."+fieldErr.Field, fieldErr.Err)
+	}
+	return fmt.Errorf("%s: %w", section, err)
+}
+
 func (c *Config) validate() error {
 	err := c.Base.validate()
 	if err != nil {
-		return fmt.Errorf("base: %w", err)
+		return sectionError("base", err)
 	}
 	err = c.Database.validate()
 	if err != nil {
-		return fmt.Errorf("database: %w", err)
+		return sectionError("database", err)
 	}
 	err = c.Fetcher.validate()
 	if err != nil {
-		return fmt.Errorf("fetcher: %w", err)
+		return sectionError("fetcher", err)
 	}
 	err = c.Holidayer.validate()
 	if err != nil {
-		return fmt.Errorf("holidayer: %w", err)
+		return sectionError("holidayer", err)
 	}
 	err = c.Predictor.validate()
 	if err != nil {
-		return fmt.Errorf("predictor: %w", err)
+		return sectionError("predictor", err)
+	}
+	err = c.Retention.validate()
+	if err != nil {
+		return sectionError("retention", err)
+	}
+	err = c.setRetentionDays()
+	if err != nil {
+		return err
+	}
+	err = c.Alerter.validate()
+	if err != nil {
+		return sectionError("alerter", err)
+	}
+	err = c.Subscriptions.validate()
+	if err != nil {
+		return sectionError("subscriptions", err)
+	}
+	err = c.Cache.validate()
+	if err != nil {
+		return sectionError("cache", err)
 	}
 	err = c.Telegram.validate()
 	if err != nil {
-		return fmt.Errorf("telegram: %w", err)
+		return sectionError("telegram", err)
+	}
+	c.setFeatures()
+	return nil
+}
+
+// setRetentionDays applies database retention_days to the retention settings, so old events are pruned
+// even if the retention section is not active. It can't differ from raw_days of the active retention section.
+func (c *Config) setRetentionDays() error {
+	if c.Database.RetentionDays == 0 {
+		return nil
+	}
+
+	if !c.Retention.Active {
+		c.Retention = Retention{Active: true, Period: defaultRetentionPeriod, Timeout: defaultRetentionPeriod * time.Second}
+	}
+	switch c.Retention.RawDays {
+	case 0:
+		c.Retention.RawDays, c.Retention.RawTTL = c.Database.RetentionDays, c.Database.RetentionTTL
+	case c.Database.RetentionDays:
+	default:
+		return newFieldError("database.retention_days", errors.New("must be equal to retention.raw_days"))
 	}
 	return nil
 }
 
+// setFeatures sets feature flags by active sections.
+func (c *Config) setFeatures() {
+	c.Features = Features{
+		Fetcher:       c.Fetcher.Active,
+		Alerter:       c.Alerter.Active && c.Fetcher.Active,
+		Subscriptions: c.Subscriptions.Active && c.Fetcher.Active && c.Telegram.Active,
+		Cache:         c.Cache.Active && c.Fetcher.Active,
+		Holidayer:     c.Holidayer.Active,
+		Predictor:     c.Predictor.Active,
+		Retention:     c.Retention.Active,
+		Telegram:      c.Telegram.Active,
+		Metrics:       c.Base.MetricsAddr != "",
+		Backup:        c.Database.BackupPeriod > 0,
+	}
+}
+
 func (b *Base) validate() error {
 	if b.Timezone == "" {
 		b.TimeLocation = time.UTC
 	} else {
 		location, err := time.LoadLocation(b.Timezone)
 		if err != nil {
-			return fmt.Errorf("invalid timezone %q: %w", b.Timezone, err)
+			return newFieldError("timezone", fmt.Errorf("invalid value %q: %w", b.Timezone, err))
 		}
 		b.TimeLocation = location
 	}
 
+	switch strings.ToLower(b.WeekStart) {
+	case "", "monday":
+		b.FirstWeekday = time.Monday
+	case "sunday":
+		b.FirstWeekday = time.Sunday
+	default:
+		return newFieldError("week_start", fmt.Errorf("invalid value %q, must be monday or sunday", b.WeekStart))
+	}
+
+	switch b.Language = strings.ToLower(b.Language); b.Language {
+	case "":
+		b.Language = "ru"
+	case "ru", "en":
+	default:
+		return newFieldError("language", fmt.Errorf("invalid value %q, must be ru or en", b.Language))
+	}
+
+	if err := b.validateHourFormat(); err != nil {
+		return err
+	}
+
+	if b.MetricsAddr = strings.TrimSpace(b.MetricsAddr); b.MetricsAddr != "" {
+		if _, _, err := net.SplitHostPort(b.MetricsAddr); err != nil {
+			return newFieldError("metrics_addr", fmt.Errorf("invalid address %q: %w", b.MetricsAddr, err))
+		}
+	}
+
+	if b.Capacity > 100 {
+		return newFieldError("capacity", fmt.Errorf("invalid value %d, must not be greater than 100", b.Capacity))
+	}
+
 	b.AdminIDs = make(map[int64]struct{}, len(b.Admins))
 	for _, adminID := range b.Admins {
 		b.AdminIDs[adminID] = struct{}{}
@@ -139,27 +471,103 @@ func (b *Base) validate() error {
 	return nil
 }
 
+// validateHourFormat checks the hour labels format, it is a time layout or two layouts of a range
+// separated by "–", e.g. "15:04–15:04". Every layout must keep the hour, so "3 PM" is valid, but "3" is not.
+func (b *Base) validateHourFormat() error {
+	if b.HourFormat == "" {
+		b.HourFormat = defaultHourFormat
+	}
+
+	layouts := strings.Split(b.HourFormat, hourRangeSeparator)
+	if len(layouts) > 2 {
+		return newFieldError("hour_format", fmt.Errorf("invalid value %q, must be a layout or a range of two layouts", b.HourFormat))
+	}
+
+	ref := time.Date(2006, time.January, 2, 19, 0, 0, 0, time.UTC)
+	for _, layout := range layouts {
+		parsed, err := time.Parse(layout, ref.Format(layout))
+		if err != nil || parsed.Hour() != ref.Hour() {
+			return newFieldError("hour_format", fmt.Errorf("invalid value %q, layout %q does not show the hour", b.HourFormat, layout))
+		}
+	}
+
+	b.HourLayout, b.HourEndLayout = layouts[0], ""
+	if len(layouts) == 2 {
+		b.HourEndLayout = layouts[1]
+	}
+	return nil
+}
+
+// HourTime returns the time formatted by the hour layout in the configured location.
+func (b *Base) HourTime(t time.Time) string {
+	return t.In(b.TimeLocation).Format(b.HourLayout)
+}
+
+// HourLabel returns the label of the hour starting at t, it is a range if the hour format has the end layout.
+func (b *Base) HourLabel(t time.Time) string {
+	label := b.HourTime(t)
+	if b.HourEndLayout != "" {
+		label += hourRangeSeparator + t.Add(time.Hour).In(b.TimeLocation).Format(b.HourEndLayout)
+	}
+	return label
+}
+
 func (d *Database) validate() error {
 	if d.Path == "" {
-		return errors.New("path is required")
+		return newFieldError("path", errors.New("is required"))
 	}
 	if d.QueryTimeout <= 0 {
-		return errors.New("query_timeout must be greater than zero")
+		return newFieldError("query_timeout", errors.New("must be greater than zero"))
+	}
+	if d.CacheSize < 0 {
+		return newFieldError("cache_size", errors.New("must not be negative"))
+	}
+	if d.MmapSize < 0 {
+		return newFieldError("mmap_size", errors.New("must not be negative"))
+	}
+	if d.BusyTimeout < 0 {
+		return newFieldError("busy_timeout", errors.New("must not be negative"))
+	}
+	if d.BackupPeriod < 0 {
+		return newFieldError("backup_period", errors.New("must not be negative"))
+	}
+	if d.BackupPeriod > 0 && d.BackupDir == "" {
+		return newFieldError("backup_dir", errors.New("is required for scheduled backups"))
+	}
+	if d.RetentionDays < 0 {
+		return newFieldError("retention_days", errors.New("must not be negative"))
 	}
 	d.Timeout = time.Duration(d.QueryTimeout) * time.Second
+	d.BackupInterval = time.Duration(d.BackupPeriod) * time.Second
+	d.RetentionTTL = time.Duration(d.RetentionDays) * 24 * time.Hour
 	if d.Threads == 0 {
 		d.Threads = 1
 	}
+	if d.CacheSize == 0 {
+		d.CacheSize = defaultCacheSize
+	}
+	if d.MmapSize == 0 {
+		d.MmapSize = defaultMmapSize
+	}
+	if d.BusyTimeout == 0 {
+		d.BusyTimeout = defaultBusyTimeout
+	}
 	return nil
 }
 
-// AuthToken returns the authorization token with Bearer prefix.
-func (f *Fetcher) AuthToken() string {
-	const prefix = "Bearer "
-	if f.Token == "" {
-		return ""
-	}
-	return prefix + f.Token
+// CacheSizeKiB returns the page cache size in KiB.
+func (d *Database) CacheSizeKiB() int64 {
+	return d.CacheSize << 10
+}
+
+// MmapSizeBytes returns the max size of memory-mapped I/O in bytes.
+func (d *Database) MmapSizeBytes() int64 {
+	return d.MmapSize << 20
+}
+
+// BusyTimeoutDuration returns the time to wait for a database locked by another connection.
+func (d *Database) BusyTimeoutDuration() time.Duration {
+	return time.Duration(d.BusyTimeout) * time.Millisecond
 }
 
 func (f *Fetcher) validate() error {
@@ -167,16 +575,153 @@ func (f *Fetcher) validate() error {
 		return nil
 	}
 	if f.Period <= 0 {
-		return errors.New("period must be greater than zero")
+		return newFieldError("period", errors.New("must be greater than zero"))
+	}
+	if err := f.validateAuth(); err != nil {
+		return err
+	}
+	if f.BatchSize < 0 {
+		return newFieldError("batch_size", errors.New("must not be negative"))
+	}
+	if f.BatchPeriod < 0 {
+		return newFieldError("batch_period", errors.New("must not be negative"))
+	}
+	if f.BreakerFailures < 0 {
+		return newFieldError("breaker_failures", errors.New("must not be negative"))
+	}
+	if f.BreakerFailures > 0 && f.BreakerPeriod <= 0 {
+		return newFieldError("breaker_period", errors.New("must be greater than zero if breaker is enabled"))
+	}
+	if f.Retries < 0 {
+		return newFieldError("retries", errors.New("must not be negative"))
+	}
+	if f.Retries > 0 && f.RetryBaseMs <= 0 {
+		return newFieldError("retry_base_ms", errors.New("must be greater than zero if retries are enabled"))
 	}
-	if f.Token == "" {
-		return errors.New("token is required")
+	if f.SummaryPeriod < 0 {
+		return newFieldError("summary_period", errors.New("must not be negative"))
 	}
 	err := validateHTTPURL(f.URL)
 	if err != nil {
-		return fmt.Errorf("url: %w", err)
+		return newFieldError("url", err)
+	}
+	if f.EventWebhook != "" {
+		if err = validateHTTPURL(f.EventWebhook); err != nil {
+			return newFieldError("event_webhook", err)
+		}
+	}
+	err = f.validateTransform()
+	if err != nil {
+		return err
+	}
+	err = f.validateClubs()
+	if err != nil {
+		return err
+	}
+	if f.Workers < 0 {
+		return newFieldError("workers", errors.New("must not be negative"))
+	}
+	if err = f.Client.validate(); err != nil {
+		return sectionError("client", err)
 	}
 	f.Timeout = time.Duration(f.Period) * time.Second
+	f.BatchTimeout = time.Duration(f.BatchPeriod) * time.Second
+	f.BreakerCooldown = time.Duration(f.BreakerPeriod) * time.Second
+	f.RetryBase = time.Duration(f.RetryBaseMs) * time.Millisecond
+	f.SummaryInterval = time.Duration(f.SummaryPeriod) * time.Second
+	return nil
+}
+
+// validateAuth checks credentials required by the authorization type, bearer is the default one.
+func (f *Fetcher) validateAuth() error {
+	switch f.AuthType = strings.ToLower(f.AuthType); f.AuthType {
+	case "", "bearer":
+		f.AuthType = "bearer"
+		if f.Token == "" {
+			return newFieldError("token", errors.New("is required"))
+		}
+	case "basic":
+		if f.Username == "" {
+			return newFieldError("username", errors.New("is required for basic auth"))
+		}
+		if f.Password == "" {
+			return newFieldError("password", errors.New("is required for basic auth"))
+		}
+	case "header":
+		if f.AuthHeader == "" {
+			f.AuthHeader = "X-API-Key"
+		}
+		if strings.ContainsAny(f.AuthHeader, " :\t\r\n") {
+			return newFieldError("auth_header", fmt.Errorf("invalid header name %q", f.AuthHeader))
+		}
+		if f.Token == "" {
+			return newFieldError("token", errors.New("is required"))
+		}
+	default:
+		return newFieldError("auth_type", fmt.Errorf("invalid value %q, must be bearer, basic or header", f.AuthType))
+	}
+	return nil
+}
+
+// validateClubs checks additional clubs, their IDs must be positive and unique.
+func (f *Fetcher) validateClubs() error {
+	ids := make(map[int]struct{}, len(f.Clubs))
+
+	for i, club := range f.Clubs {
+		field := fmt.Sprintf("clubs[%d]", i)
+		if club.ID < 1 {
+			return newFieldError(field+".id", errors.New("must be greater than zero"))
+		}
+		if _, ok := ids[club.ID]; ok {
+			return newFieldError(field+".id", fmt.Errorf("duplicate club %d", club.ID))
+		}
+		ids[club.ID] = struct{}{}
+
+		if err := validateHTTPURL(club.URL); err != nil {
+			return newFieldError(field+".url", err)
+		}
+	}
+
+	return nil
+}
+
+// HasClub returns true if the club is fetched, the main club is always available.
+func (f *Fetcher) HasClub(id int) bool {
+	if id == 0 {
+		return true
+	}
+	for _, club := range f.Clubs {
+		if club.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTransform checks the load transform, a linear one must map some loads into the range 0..100,
+// the results out of this range are clamped.
+func (f *Fetcher) validateTransform() error {
+	switch f.Transform = strings.ToLower(f.Transform); f.Transform {
+	case "":
+		f.Transform = "none"
+	case "none", "invert":
+	case "linear":
+		if math.IsNaN(f.TransformScale) || math.IsInf(f.TransformScale, 0) || f.TransformScale == 0 {
+			return newFieldError("transform_scale", errors.New("must be a non-zero number"))
+		}
+		if math.IsNaN(f.TransformOffset) || math.IsInf(f.TransformOffset, 0) {
+			return newFieldError("transform_offset", errors.New("must be a number"))
+		}
+		low, high := f.TransformOffset, f.TransformScale*100+f.TransformOffset
+		if low > high {
+			low, high = high, low
+		}
+		if high < 0 || low > 100 {
+			return newFieldError("transform_offset", fmt.Errorf("linear transform maps all loads to [%g, %g], out of range 0..100", low, high))
+		}
+	default:
+		return newFieldError("transform", fmt.Errorf("invalid value %q, must be none, invert or linear", f.Transform))
+	}
 	return nil
 }
 
@@ -185,11 +730,14 @@ func (h *Holidayer) validate() error {
 		return nil
 	}
 	if h.Period <= 0 {
-		return errors.New("period must be greater than zero")
+		return newFieldError("period", errors.New("must be greater than zero"))
 	}
 	err := validateHTTPURL(h.URL)
 	if err != nil {
-		return fmt.Errorf("url: %w", err)
+		return newFieldError("url", err)
+	}
+	if err = h.Client.validate(); err != nil {
+		return sectionError("client", err)
 	}
 	h.Timeout = time.Duration(h.Period) * time.Second
 	return nil
@@ -200,25 +748,148 @@ func (p *Predictor) validate() error {
 		return nil
 	}
 	if p.Hours < 1 || p.Hours > 24 {
-		return errors.New("hours must be between 1 and 24")
+		return newFieldError("hours", errors.New("must be between 1 and 24"))
 	}
 	if p.LoadSize < 1 {
-		return errors.New("load_size must be greater than zero")
+		return newFieldError("load_size", errors.New("must be greater than zero"))
+	}
+	if p.LoadRetries < 0 {
+		return newFieldError("load_retries", errors.New("must not be negative"))
 	}
 	if p.QueryTimeout <= 0 {
-		return errors.New("query_timeout must be greater than zero")
+		return newFieldError("query_timeout", errors.New("must be greater than zero"))
+	}
+	if p.GlobalBlend && (p.GlobalBlendWeight <= 0 || p.GlobalBlendWeight > 1) {
+		return newFieldError("global_blend_weight", errors.New("must be in range (0, 1]"))
+	}
+	if p.AnomalyThreshold < 0 {
+		return newFieldError("anomaly_threshold", errors.New("must not be negative"))
+	}
+	if p.DecayLambda < 0 {
+		return newFieldError("decay_lambda", errors.New("must not be negative"))
+	}
+	if p.MinWeight < 0 {
+		return newFieldError("min_weight", errors.New("must not be negative"))
+	}
+	if p.ConfidenceThreshold < 0 {
+		return newFieldError("confidence_threshold", errors.New("must not be negative"))
+	}
+	if p.ResetWeight < 0 || p.ResetWeight >= 1 {
+		return newFieldError("reset_weight", errors.New("must be in range (0, 1) or zero"))
+	}
+	if p.HolidayMargin < 0 {
+		return newFieldError("holiday_margin", errors.New("must not be negative"))
+	}
+	if p.SmoothWindow < 0 || p.SmoothWindow > 1 && p.SmoothWindow%2 == 0 {
+		return newFieldError("smooth_window", errors.New("must be an odd positive number or zero"))
+	}
+	if p.RecentAge < 0 {
+		return newFieldError("recent_age", errors.New("must not be negative"))
+	}
+	if p.RecentCount < 0 {
+		return newFieldError("recent_count", errors.New("must not be negative"))
+	}
+	if p.RefreshPeriod < 0 {
+		return newFieldError("refresh_period", errors.New("must not be negative"))
 	}
 	p.Timeout = time.Duration(p.QueryTimeout) * time.Second
+	p.RecentMaxAge = time.Duration(p.RecentAge) * time.Second
+	p.Refresh = time.Duration(p.RefreshPeriod) * time.Second
+	return nil
+}
+
+func (r *Retention) validate() error {
+	if !r.Active {
+		return nil
+	}
+	if r.Period <= 0 {
+		return newFieldError("period", errors.New("must be greater than zero"))
+	}
+	if r.RawDays < 0 {
+		return newFieldError("raw_days", errors.New("must not be negative"))
+	}
+	if r.AggregateDays < 0 {
+		return newFieldError("aggregate_days", errors.New("must not be negative"))
+	}
+	if r.AggregateDays > 0 && (r.RawDays == 0 || r.AggregateDays < r.RawDays) {
+		return newFieldError("aggregate_days", errors.New("must not be less than raw_days"))
+	}
+	r.Timeout = time.Duration(r.Period) * time.Second
+	r.RawTTL = time.Duration(r.RawDays) * 24 * time.Hour
+	r.AggregateTTL = time.Duration(r.AggregateDays) * 24 * time.Hour
+	return nil
+}
+
+func (a *Alerter) validate() error {
+	if !a.Active {
+		return nil
+	}
+	if a.High < 1 || a.High > 100 {
+		return newFieldError("high", errors.New("must be between 1 and 100"))
+	}
+	if a.Reset >= a.High {
+		return newFieldError("reset", errors.New("must be less than high"))
+	}
+	return nil
+}
+
+func (s *Subscriptions) validate() error {
+	if !s.Active {
+		return nil
+	}
+	if s.Period < 0 {
+		return newFieldError("period", errors.New("must not be negative"))
+	}
+	s.Cooldown = time.Duration(s.Period) * time.Second
+	return nil
+}
+
+func (c *Cache) validate() error {
+	if !c.Active {
+		return nil
+	}
+	if c.Size < 1 {
+		return newFieldError("size", errors.New("must be greater than zero"))
+	}
+	if c.Period <= 0 {
+		return newFieldError("period", errors.New("must be greater than zero"))
+	}
+	c.MaxAge = time.Duration(c.Period) * time.Second
 	return nil
 }
 
 func (t *Telegram) validate() error {
+	switch t.GraphFormat {
+	case "":
+		t.GraphFormat = "png"
+	case "png", "webp", "svg":
+	default:
+		return newFieldError("graph_format", fmt.Errorf("invalid value %q, must be png, webp or svg", t.GraphFormat))
+	}
+
 	if !t.Active {
 		return nil
 	}
 	if t.Token == "" {
-		return errors.New("token is required")
+		return newFieldError("token", errors.New("is required"))
+	}
+	if t.HandlerTimeout < 0 {
+		return newFieldError("handler_timeout", errors.New("must not be negative"))
+	}
+	if t.StalePeriods < 0 {
+		return newFieldError("stale_periods", errors.New("must not be negative"))
+	}
+	if t.MaxDuration < 0 {
+		return newFieldError("max_duration", errors.New("must not be negative"))
+	}
+	if t.RateLimit < 0 {
+		return newFieldError("rate_limit", errors.New("must not be negative"))
+	}
+	if t.RateBurst < 0 {
+		return newFieldError("rate_burst", errors.New("must not be negative"))
 	}
+	t.Timeout = time.Duration(t.HandlerTimeout) * time.Second
+	t.MaxPeriod = time.Duration(t.MaxDuration) * time.Hour
 	return nil
 }
 
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -41,6 +43,10 @@ period = 300
 token = "secret"
 url = "https://api.example.com/data"
 
+[[fetcher.clubs]]
+id = 2
+url = "https://api.example.com/club2"
+
 [holidayer]
 active = true
 period = 86400
@@ -51,10 +57,14 @@ active = true
 hours = 4
 load_size = 50
 query_timeout = 10
+scale_confidence = true
 
 [telegram]
 active = true
 token = "bot_token"
+show_points = true
+show_typical = true
+watermark = "GGP"
 `,
 		},
 		{
@@ -77,6 +87,30 @@ active = false
 active = false
 `,
 		},
+		{
+			name: "duplicate fetcher clubs",
+			content: `
+[database]
+path = "test.db"
+query_timeout = 5
+
+[fetcher]
+active = true
+period = 300
+token = "secret"
+url = "https://api.example.com/data"
+
+[[fetcher.clubs]]
+id = 2
+url = "https://api.example.com/club2"
+
+[[fetcher.clubs]]
+id = 2
+url = "https://api.example.com/club3"
+`,
+			wantErr:    true,
+			errContain: "fetcher.clubs[1].id",
+		},
 		{
 			name:       "invalid toml syntax",
 			content:    `invalid [[[`,
@@ -145,20 +179,39 @@ func TestLoad_FileNotFound(t *testing.T) {
 
 func TestBase_Validate(t *testing.T) {
 	tests := []struct {
-		name    string
-		wantTZ  string
-		base    Base
-		wantErr bool
+		name        string
+		wantTZ      string
+		wantLang    string
+		base        Base
+		wantWeekday time.Weekday
+		wantErr     bool
 	}{
 		{
-			name:   "empty timezone defaults to UTC",
-			base:   Base{},
-			wantTZ: "UTC",
+			name:        "empty timezone defaults to UTC",
+			base:        Base{},
+			wantTZ:      "UTC",
+			wantWeekday: time.Monday,
+		},
+		{
+			name:        "week starts on monday",
+			base:        Base{WeekStart: "monday"},
+			wantWeekday: time.Monday,
+		},
+		{
+			name:        "week starts on sunday",
+			base:        Base{WeekStart: "Sunday"},
+			wantWeekday: time.Sunday,
+		},
+		{
+			name:    "invalid week start",
+			base:    Base{WeekStart: "friday"},
+			wantErr: true,
 		},
 		{
-			name:   "valid timezone",
-			base:   Base{Timezone: "America/New_York"},
-			wantTZ: "America/New_York",
+			name:        "valid timezone",
+			base:        Base{Timezone: "America/New_York"},
+			wantTZ:      "America/New_York",
+			wantWeekday: time.Monday,
 		},
 		{
 			name:    "invalid timezone",
@@ -166,8 +219,76 @@ func TestBase_Validate(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "admins populated to map",
-			base: Base{Admins: []int64{1, 2, 3}},
+			name:        "admins populated to map",
+			base:        Base{Admins: []int64{1, 2, 3}},
+			wantWeekday: time.Monday,
+		},
+		{
+			name:        "empty language defaults to ru",
+			base:        Base{},
+			wantLang:    "ru",
+			wantWeekday: time.Monday,
+		},
+		{
+			name:        "english language",
+			base:        Base{Language: "EN"},
+			wantLang:    "en",
+			wantWeekday: time.Monday,
+		},
+		{
+			name:    "unsupported language",
+			base:    Base{Language: "es"},
+			wantErr: true,
+		},
+		{
+			name:        "metrics address",
+			base:        Base{MetricsAddr: " 127.0.0.1:9090 "},
+			wantWeekday: time.Monday,
+		},
+		{
+			name:        "metrics address without host",
+			base:        Base{MetricsAddr: ":9090"},
+			wantWeekday: time.Monday,
+		},
+		{
+			name:    "metrics address without port",
+			base:    Base{MetricsAddr: "localhost"},
+			wantErr: true,
+		},
+		{
+			name:        "capacity",
+			base:        Base{Capacity: 100},
+			wantWeekday: time.Monday,
+		},
+		{
+			name:    "capacity above 100",
+			base:    Base{Capacity: 101},
+			wantErr: true,
+		},
+		{
+			name:    "hour format without hour",
+			base:    Base{HourFormat: "Jan 2"},
+			wantErr: true,
+		},
+		{
+			name:    "ambiguous 12-hour format",
+			base:    Base{HourFormat: "3:04"},
+			wantErr: true,
+		},
+		{
+			name:    "hour range of three layouts",
+			base:    Base{HourFormat: "15–15–15"},
+			wantErr: true,
+		},
+		{
+			name:    "hour range with ambiguous start",
+			base:    Base{HourFormat: "3–3 PM"},
+			wantErr: true,
+		},
+		{
+			name:    "hour range with invalid end",
+			base:    Base{HourFormat: "15:04–04"},
+			wantErr: true,
 		},
 	}
 
@@ -190,6 +311,14 @@ func TestBase_Validate(t *testing.T) {
 				t.Errorf("timezone = %q, want %q", tc.base.TimeLocation.String(), tc.wantTZ)
 			}
 
+			if tc.base.FirstWeekday != tc.wantWeekday {
+				t.Errorf("first weekday = %v, want %v", tc.base.FirstWeekday, tc.wantWeekday)
+			}
+
+			if tc.wantLang != "" && tc.base.Language != tc.wantLang {
+				t.Errorf("language = %q, want %q", tc.base.Language, tc.wantLang)
+			}
+
 			if tc.base.Admins != nil {
 				for _, id := range tc.base.Admins {
 					if _, ok := tc.base.AdminIDs[id]; !ok {
@@ -201,12 +330,59 @@ func TestBase_Validate(t *testing.T) {
 	}
 }
 
+func TestBase_HourLabel(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	ts := time.Date(2026, 3, 10, 16, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		format   string
+		location *time.Location
+		wantTime string
+		want     string
+	}{
+		{name: "default 24-hour", location: time.UTC, wantTime: "16:00", want: "16:00"},
+		{name: "24-hour in location", format: "15:04", location: moscow, wantTime: "19:00", want: "19:00"},
+		{name: "24-hour range", format: "15:04–15:04", location: moscow, wantTime: "19:00", want: "19:00–20:00"},
+		{name: "12-hour", format: "3 PM", location: moscow, wantTime: "7 PM", want: "7 PM"},
+		{name: "12-hour full range", format: "3 PM–3 PM", location: time.UTC, wantTime: "4 PM", want: "4 PM–5 PM"},
+		{name: "hour only", format: "15h", location: time.UTC, wantTime: "16h", want: "16h"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			base := Base{HourFormat: tc.format}
+			if tc.location != time.UTC {
+				base.Timezone = tc.location.String()
+			}
+
+			if err := base.validate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := base.HourTime(ts); got != tc.wantTime {
+				t.Errorf("HourTime() = %q, want %q", got, tc.wantTime)
+			}
+			if got := base.HourLabel(ts); got != tc.want {
+				t.Errorf("HourLabel() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestDatabase_Validate(t *testing.T) {
 	tests := []struct {
-		name        string
-		db          Database
-		wantErr     bool
-		wantTimeout time.Duration
+		name           string
+		db             Database
+		wantErr        bool
+		wantTimeout    time.Duration
+		wantCache      int64
+		wantMmap       int64
+		wantBusy       time.Duration
+		wantBackupTick time.Duration
 	}{
 		{
 			name:    "empty path",
@@ -227,6 +403,51 @@ func TestDatabase_Validate(t *testing.T) {
 			name:        "valid config",
 			db:          Database{Path: "test.db", QueryTimeout: 10},
 			wantTimeout: 10 * time.Second,
+			wantCache:   32,
+			wantMmap:    128,
+			wantBusy:    5 * time.Second,
+		},
+		{
+			name:        "custom memory settings",
+			db:          Database{Path: "test.db", QueryTimeout: 10, CacheSize: 64, MmapSize: 256, BusyTimeout: 1500},
+			wantTimeout: 10 * time.Second,
+			wantCache:   64,
+			wantMmap:    256,
+			wantBusy:    1500 * time.Millisecond,
+		},
+		{
+			name:    "negative cache size",
+			db:      Database{Path: "test.db", QueryTimeout: 10, CacheSize: -1},
+			wantErr: true,
+		},
+		{
+			name:    "negative mmap size",
+			db:      Database{Path: "test.db", QueryTimeout: 10, MmapSize: -1},
+			wantErr: true,
+		},
+		{
+			name:    "negative busy timeout",
+			db:      Database{Path: "test.db", QueryTimeout: 10, BusyTimeout: -1},
+			wantErr: true,
+		},
+		{
+			name:    "negative backup period",
+			db:      Database{Path: "test.db", QueryTimeout: 10, BackupDir: "backups", BackupPeriod: -1},
+			wantErr: true,
+		},
+		{
+			name:    "backup period without dir",
+			db:      Database{Path: "test.db", QueryTimeout: 10, BackupPeriod: 3600},
+			wantErr: true,
+		},
+		{
+			name:           "scheduled backups",
+			db:             Database{Path: "test.db", QueryTimeout: 10, BackupDir: "backups", BackupPeriod: 3600},
+			wantTimeout:    10 * time.Second,
+			wantCache:      32,
+			wantMmap:       128,
+			wantBusy:       5 * time.Second,
+			wantBackupTick: time.Hour,
 		},
 	}
 
@@ -247,6 +468,18 @@ func TestDatabase_Validate(t *testing.T) {
 			if tc.db.Timeout != tc.wantTimeout {
 				t.Errorf("timeout = %v, want %v", tc.db.Timeout, tc.wantTimeout)
 			}
+			if tc.db.CacheSize != tc.wantCache || tc.db.CacheSizeKiB() != tc.wantCache*1024 {
+				t.Errorf("cache size = %d (%d KiB), want %d", tc.db.CacheSize, tc.db.CacheSizeKiB(), tc.wantCache)
+			}
+			if tc.db.MmapSize != tc.wantMmap || tc.db.MmapSizeBytes() != tc.wantMmap<<20 {
+				t.Errorf("mmap size = %d (%d bytes), want %d", tc.db.MmapSize, tc.db.MmapSizeBytes(), tc.wantMmap)
+			}
+			if got := tc.db.BusyTimeoutDuration(); got != tc.wantBusy {
+				t.Errorf("busy timeout = %v, want %v", got, tc.wantBusy)
+			}
+			if tc.db.BackupInterval != tc.wantBackupTick {
+				t.Errorf("backup interval = %v, want %v", tc.db.BackupInterval, tc.wantBackupTick)
+			}
 		})
 	}
 }
@@ -289,6 +522,131 @@ func TestFetcher_Validate(t *testing.T) {
 			name:    "valid http",
 			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost:8080/data"},
 		},
+		{
+			name:    "valid batching",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", BatchSize: 10, BatchPeriod: 600},
+		},
+		{
+			name:    "negative batch size",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", BatchSize: -1},
+			wantErr: true,
+		},
+		{
+			name:    "negative batch period",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", BatchPeriod: -1},
+			wantErr: true,
+		},
+		{
+			name:    "valid breaker",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", BreakerFailures: 3, BreakerPeriod: 300},
+		},
+		{
+			name:    "negative breaker failures",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", BreakerFailures: -1},
+			wantErr: true,
+		},
+		{
+			name:    "invert transform",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", Transform: "Invert"},
+		},
+		{
+			name: "linear transform",
+			fetcher: Fetcher{
+				Active: true, Period: 60, Token: "tok", URL: "http://localhost/data",
+				Transform: "linear", TransformScale: 1.25, TransformOffset: -10,
+			},
+		},
+		{
+			name:    "unknown transform",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", Transform: "log"},
+			wantErr: true,
+		},
+		{
+			name:    "linear transform zero scale",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", Transform: "linear"},
+			wantErr: true,
+		},
+		{
+			name: "linear transform out of range",
+			fetcher: Fetcher{
+				Active: true, Period: 60, Token: "tok", URL: "http://localhost/data",
+				Transform: "linear", TransformScale: 0.5, TransformOffset: 150,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "breaker without period",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", BreakerFailures: 3},
+			wantErr: true,
+		},
+		{
+			name: "valid clubs",
+			fetcher: Fetcher{
+				Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", Workers: 2,
+				Clubs: []Club{{ID: 2, URL: "http://localhost/club2"}, {ID: 3, URL: "https://example.com/club3"}},
+			},
+		},
+		{
+			name: "club without id",
+			fetcher: Fetcher{
+				Active: true, Period: 60, Token: "tok", URL: "http://localhost/data",
+				Clubs: []Club{{URL: "http://localhost/club"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate clubs",
+			fetcher: Fetcher{
+				Active: true, Period: 60, Token: "tok", URL: "http://localhost/data",
+				Clubs: []Club{{ID: 2, URL: "http://localhost/a"}, {ID: 2, URL: "http://localhost/b"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "club with invalid url",
+			fetcher: Fetcher{
+				Active: true, Period: 60, Token: "tok", URL: "http://localhost/data",
+				Clubs: []Club{{ID: 2, URL: "ftp://localhost/club"}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "valid retries",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", Retries: 3, RetryBaseMs: 500},
+		},
+		{
+			name:    "negative retries",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", Retries: -1},
+			wantErr: true,
+		},
+		{
+			name:    "retries without base delay",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", Retries: 3},
+			wantErr: true,
+		},
+		{
+			name:    "summary period",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", SummaryPeriod: 3600},
+		},
+		{
+			name:    "negative summary period",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", SummaryPeriod: -1},
+			wantErr: true,
+		},
+		{
+			name:    "negative workers",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", Workers: -1},
+			wantErr: true,
+		},
+		{
+			name:    "event webhook",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", EventWebhook: "https://example.com/hook"},
+		},
+		{
+			name:    "invalid event webhook",
+			fetcher: Fetcher{Active: true, Period: 60, Token: "tok", URL: "http://localhost/data", EventWebhook: "ftp://example.com/hook"},
+			wantErr: true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -309,90 +667,254 @@ func TestFetcher_Validate(t *testing.T) {
 			if tc.fetcher.Active && tc.fetcher.Timeout != time.Duration(tc.fetcher.Period)*time.Second {
 				t.Error("timeout not set correctly")
 			}
+
+			if tc.fetcher.Active && tc.fetcher.BatchTimeout != time.Duration(tc.fetcher.BatchPeriod)*time.Second {
+				t.Error("batch timeout not set correctly")
+			}
+
+			if tc.fetcher.Active && tc.fetcher.BreakerCooldown != time.Duration(tc.fetcher.BreakerPeriod)*time.Second {
+				t.Error("breaker cooldown not set correctly")
+			}
+
+			if tc.fetcher.Active && tc.fetcher.RetryBase != time.Duration(tc.fetcher.RetryBaseMs)*time.Millisecond {
+				t.Error("retry base not set correctly")
+			}
+
+			if tc.fetcher.Active && tc.fetcher.SummaryInterval != time.Duration(tc.fetcher.SummaryPeriod)*time.Second {
+				t.Error("summary interval not set correctly")
+			}
+
+			if tc.fetcher.Active && (tc.fetcher.Transform == "" || tc.fetcher.Transform != strings.ToLower(tc.fetcher.Transform)) {
+				t.Errorf("transform not normalized: %q", tc.fetcher.Transform)
+			}
 		})
 	}
 }
 
-func TestFetcher_AuthToken(t *testing.T) {
+func TestRetentionValidate(t *testing.T) {
 	tests := []struct {
-		name  string
-		token string
-		want  string
+		name      string
+		retention Retention
+		wantErr   bool
 	}{
-		{"empty token", "", ""},
-		{"simple token", "abc123", "Bearer abc123"},
+		{name: "inactive", retention: Retention{}},
+		{name: "valid", retention: Retention{Active: true, Period: 86400, RawDays: 90}},
+		{name: "valid with aggregates ttl", retention: Retention{Active: true, Period: 86400, RawDays: 90, AggregateDays: 365}},
+		{name: "zero period", retention: Retention{Active: true, RawDays: 90}, wantErr: true},
+		{name: "rollup only", retention: Retention{Active: true, Period: 86400}},
+		{name: "negative raw days", retention: Retention{Active: true, Period: 86400, RawDays: -1}, wantErr: true},
+		{name: "aggregate days without raw days", retention: Retention{Active: true, Period: 86400, AggregateDays: 30}, wantErr: true},
+		{name: "negative aggregate days", retention: Retention{Active: true, Period: 86400, RawDays: 90, AggregateDays: -1}, wantErr: true},
+		{name: "aggregate days less than raw", retention: Retention{Active: true, Period: 86400, RawDays: 90, AggregateDays: 30}, wantErr: true},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			f := Fetcher{Token: tc.token}
-			if got := f.AuthToken(); got != tc.want {
-				t.Errorf("AuthToken() = %q, want %q", got, tc.want)
+			err := tc.retention.validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if err == nil && tc.retention.Active {
+				if tc.retention.RawTTL != time.Duration(tc.retention.RawDays)*24*time.Hour {
+					t.Error("raw ttl not set correctly")
+				}
+				if tc.retention.AggregateTTL != time.Duration(tc.retention.AggregateDays)*24*time.Hour {
+					t.Error("aggregate ttl not set correctly")
+				}
 			}
 		})
 	}
 }
 
-func TestHolidayer_Validate(t *testing.T) {
+func TestAlerterValidate(t *testing.T) {
 	tests := []struct {
-		name      string
-		holidayer Holidayer
-		wantErr   bool
+		name    string
+		alerter Alerter
+		wantErr bool
 	}{
-		{
-			name:      "inactive skips validation",
-			holidayer: Holidayer{Active: false},
-		},
-		{
-			name:      "active with zero period",
-			holidayer: Holidayer{Active: true, Period: 0},
-			wantErr:   true,
-		},
-		{
-			name:      "active with invalid url",
-			holidayer: Holidayer{Active: true, Period: 86400, URL: "not-a-url"},
-			wantErr:   true,
-		},
-		{
-			name:      "valid config",
-			holidayer: Holidayer{Active: true, Period: 86400, URL: "https://calendar.example.com"},
-		},
+		{name: "inactive", alerter: Alerter{}},
+		{name: "valid", alerter: Alerter{Active: true, High: 80, Reset: 60}},
+		{name: "zero high", alerter: Alerter{Active: true, High: 0}, wantErr: true},
+		{name: "high above 100", alerter: Alerter{Active: true, High: 101, Reset: 60}, wantErr: true},
+		{name: "reset equal high", alerter: Alerter{Active: true, High: 80, Reset: 80}, wantErr: true},
+		{name: "reset above high", alerter: Alerter{Active: true, High: 60, Reset: 80}, wantErr: true},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			err := tc.holidayer.validate()
+			err := tc.alerter.validate()
+			if (err != nil) != tc.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
 
-			if tc.wantErr {
-				if err == nil {
-					t.Fatal("expected error")
-				}
-				return
+func TestCache_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cache   Cache
+		wantErr bool
+	}{
+		{name: "inactive", cache: Cache{}},
+		{name: "valid", cache: Cache{Active: true, Size: 2000, Period: 86400}},
+		{name: "zero size", cache: Cache{Active: true, Period: 86400}, wantErr: true},
+		{name: "zero period", cache: Cache{Active: true, Size: 2000}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cache.validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tc.wantErr)
 			}
 
-			if err != nil {
-				t.Fatalf("unexpected error: %v", err)
+			if err == nil && tc.cache.MaxAge != time.Duration(tc.cache.Period)*time.Second {
+				t.Errorf("max age = %v, want %d seconds", tc.cache.MaxAge, tc.cache.Period)
 			}
 		})
 	}
 }
 
-func TestPredictor_Validate(t *testing.T) {
+func TestSubscriptions_Validate(t *testing.T) {
 	tests := []struct {
-		name      string
-		predictor Predictor
-		wantErr   bool
+		name          string
+		subscriptions Subscriptions
+		want          time.Duration
+		wantErr       bool
 	}{
-		{
-			name:      "inactive skips validation",
-			predictor: Predictor{Active: false},
-		},
-		{
-			name:      "hours zero",
-			predictor: Predictor{Active: true, Hours: 0, LoadSize: 100, QueryTimeout: 10},
-			wantErr:   true,
-		},
-		{
+		{name: "inactive", subscriptions: Subscriptions{Period: -1}},
+		{name: "valid", subscriptions: Subscriptions{Active: true, Period: 3600}, want: time.Hour},
+		{name: "zero period", subscriptions: Subscriptions{Active: true}},
+		{name: "negative period", subscriptions: Subscriptions{Active: true, Period: -1}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.subscriptions.validate()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if tc.subscriptions.Cooldown != tc.want {
+				t.Errorf("cooldown = %v, want %v", tc.subscriptions.Cooldown, tc.want)
+			}
+		})
+	}
+}
+
+func TestFetcher_ValidateAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		fetcher    Fetcher
+		wantType   string
+		wantHeader string
+		wantField  string
+	}{
+		{name: "default bearer", fetcher: Fetcher{Token: "tok"}, wantType: "bearer"},
+		{name: "bearer", fetcher: Fetcher{AuthType: "Bearer", Token: "tok"}, wantType: "bearer"},
+		{name: "bearer without token", fetcher: Fetcher{AuthType: "bearer"}, wantField: "token"},
+		{name: "basic", fetcher: Fetcher{AuthType: "basic", Username: "user", Password: "pass"}, wantType: "basic"},
+		{name: "basic without username", fetcher: Fetcher{AuthType: "basic", Password: "pass"}, wantField: "username"},
+		{name: "basic without password", fetcher: Fetcher{AuthType: "basic", Username: "user"}, wantField: "password"},
+		{name: "default header", fetcher: Fetcher{AuthType: "header", Token: "key"}, wantType: "header", wantHeader: "X-API-Key"},
+		{
+			name:       "custom header",
+			fetcher:    Fetcher{AuthType: "header", AuthHeader: "X-Token", Token: "key"},
+			wantType:   "header",
+			wantHeader: "X-Token",
+		},
+		{name: "invalid header", fetcher: Fetcher{AuthType: "header", AuthHeader: "X Token", Token: "key"}, wantField: "auth_header"},
+		{name: "header without token", fetcher: Fetcher{AuthType: "header"}, wantField: "token"},
+		{name: "unknown type", fetcher: Fetcher{AuthType: "digest", Token: "tok"}, wantField: "auth_type"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.fetcher.validateAuth()
+
+			if tc.wantField != "" {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != tc.wantField {
+					t.Fatalf("validateAuth() error = %v, want field %q", err, tc.wantField)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("validateAuth() error = %v", err)
+			}
+			if tc.fetcher.AuthType != tc.wantType {
+				t.Errorf("auth type = %q, want %q", tc.fetcher.AuthType, tc.wantType)
+			}
+			if tc.fetcher.AuthHeader != tc.wantHeader {
+				t.Errorf("auth header = %q, want %q", tc.fetcher.AuthHeader, tc.wantHeader)
+			}
+		})
+	}
+}
+
+func TestHolidayer_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		holidayer Holidayer
+		wantErr   bool
+	}{
+		{
+			name:      "inactive skips validation",
+			holidayer: Holidayer{Active: false},
+		},
+		{
+			name:      "active with zero period",
+			holidayer: Holidayer{Active: true, Period: 0},
+			wantErr:   true,
+		},
+		{
+			name:      "active with invalid url",
+			holidayer: Holidayer{Active: true, Period: 86400, URL: "not-a-url"},
+			wantErr:   true,
+		},
+		{
+			name:      "valid config",
+			holidayer: Holidayer{Active: true, Period: 86400, URL: "https://calendar.example.com"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.holidayer.validate()
+
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestPredictor_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		predictor Predictor
+		wantErr   bool
+	}{
+		{
+			name:      "inactive skips validation",
+			predictor: Predictor{Active: false},
+		},
+		{
+			name:      "hours zero",
+			predictor: Predictor{Active: true, Hours: 0, LoadSize: 100, QueryTimeout: 10},
+			wantErr:   true,
+		},
+		{
 			name:      "hours above 24",
 			predictor: Predictor{Active: true, Hours: 25, LoadSize: 100, QueryTimeout: 10},
 			wantErr:   true,
@@ -424,6 +946,112 @@ func TestPredictor_Validate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name:      "valid global blend",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, GlobalBlend: true, GlobalBlendWeight: 0.5},
+		},
+		{
+			name:      "global blend zero weight",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, GlobalBlend: true},
+			wantErr:   true,
+		},
+		{
+			name:      "global blend weight above 1",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, GlobalBlend: true, GlobalBlendWeight: 1.5},
+			wantErr:   true,
+		},
+		{
+			name:      "valid anomaly threshold",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, AnomalyThreshold: 3},
+		},
+		{
+			name:      "negative anomaly threshold",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, AnomalyThreshold: -1},
+			wantErr:   true,
+		},
+		{
+			name: "valid decay and confidence",
+			predictor: Predictor{
+				Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, DecayLambda: 0.05, MinWeight: 1, ConfidenceThreshold: 40,
+				ResetWeight: 1e-4,
+			},
+		},
+		{
+			name:      "negative reset weight",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, ResetWeight: -1e-6},
+			wantErr:   true,
+		},
+		{
+			name:      "reset weight of one",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, ResetWeight: 1},
+			wantErr:   true,
+		},
+		{
+			name:      "negative decay lambda",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, DecayLambda: -0.1},
+			wantErr:   true,
+		},
+		{
+			name:      "negative min weight",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, MinWeight: -1},
+			wantErr:   true,
+		},
+		{
+			name:      "negative confidence threshold",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, ConfidenceThreshold: -20},
+			wantErr:   true,
+		},
+		{
+			name:      "negative holiday margin",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, HolidayMargin: -1},
+			wantErr:   true,
+		},
+		{
+			name:      "valid smooth window",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, SmoothWindow: 3},
+		},
+		{
+			name:      "even smooth window",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, SmoothWindow: 4},
+			wantErr:   true,
+		},
+		{
+			name:      "negative smooth window",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, SmoothWindow: -1},
+			wantErr:   true,
+		},
+		{
+			name:      "valid recent limits",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, RecentAge: 3600, RecentCount: 40},
+		},
+		{
+			name:      "negative recent age",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, RecentAge: -1},
+			wantErr:   true,
+		},
+		{
+			name:      "negative recent count",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, RecentCount: -1},
+			wantErr:   true,
+		},
+		{
+			name:      "load retries",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, LoadRetries: 3},
+		},
+		{
+			name:      "negative load retries",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, LoadRetries: -1},
+			wantErr:   true,
+		},
+		{
+			name:      "refresh period",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, RefreshPeriod: 3600},
+		},
+		{
+			name:      "negative refresh period",
+			predictor: Predictor{Active: true, Hours: 4, LoadSize: 100, QueryTimeout: 10, RefreshPeriod: -1},
+			wantErr:   true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -463,6 +1091,60 @@ func TestTelegram_Validate(t *testing.T) {
 			name:     "valid config",
 			telegram: Telegram{Active: true, Token: "123456:ABC"},
 		},
+		{
+			name:     "webp graph format",
+			telegram: Telegram{Active: true, Token: "123456:ABC", GraphFormat: "webp"},
+		},
+		{
+			name:     "svg graph format",
+			telegram: Telegram{Active: true, Token: "123456:ABC", GraphFormat: "svg"},
+		},
+		{
+			name:     "invalid graph format",
+			telegram: Telegram{Active: true, Token: "123456:ABC", GraphFormat: "gif"},
+			wantErr:  true,
+		},
+		{
+			name:     "handler timeout",
+			telegram: Telegram{Active: true, Token: "123456:ABC", HandlerTimeout: 30},
+		},
+		{
+			name:     "negative handler timeout",
+			telegram: Telegram{Active: true, Token: "123456:ABC", HandlerTimeout: -1},
+			wantErr:  true,
+		},
+		{
+			name:     "stale periods",
+			telegram: Telegram{Active: true, Token: "123456:ABC", StalePeriods: 3},
+		},
+		{
+			name:     "negative stale periods",
+			telegram: Telegram{Active: true, Token: "123456:ABC", StalePeriods: -1},
+			wantErr:  true,
+		},
+		{
+			name:     "max duration",
+			telegram: Telegram{Active: true, Token: "123456:ABC", MaxDuration: 2160},
+		},
+		{
+			name:     "negative max duration",
+			telegram: Telegram{Active: true, Token: "123456:ABC", MaxDuration: -1},
+			wantErr:  true,
+		},
+		{
+			name:     "rate limit",
+			telegram: Telegram{Active: true, Token: "123456:ABC", RateLimit: 6, RateBurst: 3},
+		},
+		{
+			name:     "negative rate limit",
+			telegram: Telegram{Active: true, Token: "123456:ABC", RateLimit: -1},
+			wantErr:  true,
+		},
+		{
+			name:     "negative rate burst",
+			telegram: Telegram{Active: true, Token: "123456:ABC", RateLimit: 6, RateBurst: -1},
+			wantErr:  true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -479,6 +1161,18 @@ func TestTelegram_Validate(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
+
+			if tc.telegram.GraphFormat == "" {
+				t.Error("graph format should be set by default")
+			}
+
+			if tc.telegram.Active && tc.telegram.Timeout != time.Duration(tc.telegram.HandlerTimeout)*time.Second {
+				t.Error("handler timeout not set correctly")
+			}
+
+			if tc.telegram.Active && tc.telegram.MaxPeriod != time.Duration(tc.telegram.MaxDuration)*time.Hour {
+				t.Error("max period not set correctly")
+			}
 		})
 	}
 }
@@ -516,3 +1210,394 @@ func writeTempConfig(t *testing.T, content string) string {
 	}
 	return path
 }
+
+func TestConfig_SetFeatures(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		want   Features
+	}{
+		{
+			name: "all disabled",
+		},
+		{
+			name: "all enabled",
+			config: Config{
+				Fetcher:       Fetcher{Active: true},
+				Alerter:       Alerter{Active: true},
+				Subscriptions: Subscriptions{Active: true},
+				Holidayer:     Holidayer{Active: true},
+				Predictor:     Predictor{Active: true},
+				Retention:     Retention{Active: true},
+				Cache:         Cache{Active: true},
+				Telegram:      Telegram{Active: true},
+			},
+			want: Features{
+				Fetcher: true, Alerter: true, Subscriptions: true, Holidayer: true,
+				Predictor: true, Retention: true, Cache: true, Telegram: true,
+			},
+		},
+		{
+			name:   "cache without fetcher",
+			config: Config{Cache: Cache{Active: true}},
+			want:   Features{},
+		},
+		{
+			name:   "fetcher only",
+			config: Config{Fetcher: Fetcher{Active: true}},
+			want:   Features{Fetcher: true},
+		},
+		{
+			name:   "alerter without fetcher",
+			config: Config{Alerter: Alerter{Active: true}},
+			want:   Features{},
+		},
+		{
+			name:   "alerter with fetcher",
+			config: Config{Fetcher: Fetcher{Active: true}, Alerter: Alerter{Active: true}},
+			want:   Features{Fetcher: true, Alerter: true},
+		},
+		{
+			name:   "subscriptions without telegram",
+			config: Config{Fetcher: Fetcher{Active: true}, Subscriptions: Subscriptions{Active: true}},
+			want:   Features{Fetcher: true},
+		},
+		{
+			name:   "predictor without fetcher",
+			config: Config{Predictor: Predictor{Active: true}},
+			want:   Features{Predictor: true},
+		},
+		{
+			name:   "metrics address",
+			config: Config{Base: Base{MetricsAddr: ":9090"}},
+			want:   Features{Metrics: true},
+		},
+		{
+			name:   "database backup",
+			config: Config{Database: Database{BackupPeriod: 3600}},
+			want:   Features{Backup: true},
+		},
+		{
+			name:   "bot with holidayer and retention",
+			config: Config{Holidayer: Holidayer{Active: true}, Retention: Retention{Active: true}, Telegram: Telegram{Active: true}},
+			want:   Features{Holidayer: true, Retention: true, Telegram: true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.setFeatures()
+
+			if tc.config.Features != tc.want {
+				t.Errorf("features = %+v, want %+v", tc.config.Features, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfig_RetentionDays(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention Retention
+		days      int
+		want      Retention
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:      "inactive retention",
+			retention: Retention{Period: 60, RawDays: 90},
+			days:      30,
+			want:      Retention{Active: true, Period: 86400, Timeout: 24 * time.Hour, RawDays: 30, RawTTL: 30 * 24 * time.Hour},
+		},
+		{
+			name:      "active retention without raw days",
+			retention: Retention{Active: true, Period: 60},
+			days:      30,
+			want:      Retention{Active: true, Period: 60, Timeout: time.Minute, RawDays: 30, RawTTL: 30 * 24 * time.Hour},
+		},
+		{
+			name:      "active retention with equal raw days",
+			retention: Retention{Active: true, Period: 60, RawDays: 30, AggregateDays: 365},
+			days:      30,
+			want: Retention{
+				Active: true, Period: 60, Timeout: time.Minute, RawDays: 30, RawTTL: 30 * 24 * time.Hour,
+				AggregateDays: 365, AggregateTTL: 365 * 24 * time.Hour,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := Config{
+				Database:  Database{Path: "test.db", QueryTimeout: 10, RetentionDays: tc.days},
+				Retention: tc.retention,
+			}
+
+			if err := c.validate(); err != nil {
+				t.Fatalf("validate() error = %v", err)
+			}
+			if c.Retention != tc.want {
+				t.Errorf("retention = %+v, want %+v", c.Retention, tc.want)
+			}
+			if c.Features.Retention != tc.want.Active {
+				t.Errorf("retention feature = %v, want %v", c.Features.Retention, tc.want.Active)
+			}
+		})
+	}
+}
+
+func TestConfig_ValidateFieldPath(t *testing.T) {
+	database := Database{Path: "test.db", QueryTimeout: 10}
+
+	tests := []struct {
+		name      string
+		config    Config
+		wantField string
+	}{
+		{
+			name:      "base week start",
+			config:    Config{Base: Base{WeekStart: "friday"}, Database: database},
+			wantField: "base.week_start",
+		},
+		{
+			name:      "database path",
+			config:    Config{Database: Database{QueryTimeout: 10}},
+			wantField: "database.path",
+		},
+		{
+			name:      "fetcher url",
+			config:    Config{Database: database, Fetcher: Fetcher{Active: true, Period: 60, Token: "token", URL: "ftp://host"}},
+			wantField: "fetcher.url",
+		},
+		{
+			name: "fetcher client proxy",
+			config: Config{Database: database, Fetcher: Fetcher{
+				Active: true, Period: 60, Token: "token", URL: "https://host", Client: HTTPClient{Proxy: "ftp://proxy"},
+			}},
+			wantField: "fetcher.client.proxy",
+		},
+		{
+			name:      "holidayer period",
+			config:    Config{Database: database, Holidayer: Holidayer{Active: true, URL: "https://example.com"}},
+			wantField: "holidayer.period",
+		},
+		{
+			name:      "predictor hours",
+			config:    Config{Database: database, Predictor: Predictor{Active: true, Hours: 30}},
+			wantField: "predictor.hours",
+		},
+		{
+			name:      "retention aggregate days",
+			config:    Config{Database: database, Retention: Retention{Active: true, Period: 60, RawDays: 10, AggregateDays: 5}},
+			wantField: "retention.aggregate_days",
+		},
+		{
+			name:      "database retention days",
+			config:    Config{Database: Database{Path: "test.db", QueryTimeout: 10, RetentionDays: -1}},
+			wantField: "database.retention_days",
+		},
+		{
+			name: "database retention days with other raw days",
+			config: Config{
+				Database:  Database{Path: "test.db", QueryTimeout: 10, RetentionDays: 30},
+				Retention: Retention{Active: true, Period: 60, RawDays: 10},
+			},
+			wantField: "database.retention_days",
+		},
+		{
+			name:      "cache size",
+			config:    Config{Database: database, Cache: Cache{Active: true, Period: 60}},
+			wantField: "cache.size",
+		},
+		{
+			name:      "alerter reset",
+			config:    Config{Database: database, Alerter: Alerter{Active: true, High: 50, Reset: 60}},
+			wantField: "alerter.reset",
+		},
+		{
+			name:      "subscriptions period",
+			config:    Config{Database: database, Subscriptions: Subscriptions{Active: true, Period: -1}},
+			wantField: "subscriptions.period",
+		},
+		{
+			name:      "telegram token",
+			config:    Config{Database: database, Telegram: Telegram{Active: true}},
+			wantField: "telegram.token",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.validate()
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("error %q is not a field error", err)
+			}
+
+			if fieldErr.Field != tc.wantField {
+				t.Errorf("field = %q, want %q", fieldErr.Field, tc.wantField)
+			}
+
+			if !strings.HasPrefix(err.Error(), tc.wantField+": ") {
+				t.Errorf("error %q should start with the field path %q", err.Error(), tc.wantField)
+			}
+		})
+	}
+}
+
+func TestConfig_NotReloadable(t *testing.T) {
+	base := Config{
+		Base:     Base{Timezone: "UTC", Admins: []int64{1}},
+		Database: Database{Path: "test.db"},
+		Fetcher:  Fetcher{Active: true, Period: 60, URL: "https://example.com", Token: "token"},
+		Telegram: Telegram{Active: true, Token: "bot-token"},
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{
+			name:   "no changes",
+			change: func(*Config) {},
+		},
+		{
+			name: "reloadable fields",
+			change: func(c *Config) {
+				c.Base.Admins = []int64{1, 2}
+				c.Fetcher.Period = 120
+				c.Holidayer.Period = 3600
+				c.Predictor.ScaleConfidence = true
+			},
+		},
+		{
+			name: "database path and telegram token",
+			change: func(c *Config) {
+				c.Database.Path = "other.db"
+				c.Telegram.Token = "other-token"
+			},
+			want: []string{"database.path", "telegram.token"},
+		},
+		{
+			name: "metrics address",
+			change: func(c *Config) {
+				c.Base.MetricsAddr = ":9090"
+			},
+			want: []string{"base.metrics_addr"},
+		},
+		{
+			name: "allow wipe events",
+			change: func(c *Config) {
+				c.Telegram.AllowWipeEvents = true
+			},
+			want: []string{"telegram.allow_wipe_events"},
+		},
+		{
+			name: "seed csv",
+			change: func(c *Config) {
+				c.Database.SeedCSV = "seed.csv"
+			},
+			want: []string{"database.seed_csv"},
+		},
+		{
+			name: "backup schedule",
+			change: func(c *Config) {
+				c.Database.BackupDir = "backups"
+				c.Database.BackupPeriod = 3600
+			},
+			want: []string{"database.backup_dir", "database.backup_period"},
+		},
+		{
+			name: "rate limit",
+			change: func(c *Config) {
+				c.Telegram.RateLimit = 6
+			},
+			want: []string{"telegram.rate_limit"},
+		},
+		{
+			name: "track accuracy",
+			change: func(c *Config) {
+				c.Predictor.TrackAccuracy = true
+			},
+			want: []string{"predictor.track_accuracy"},
+		},
+		{
+			name: "fetcher url",
+			change: func(c *Config) {
+				c.Fetcher.URL = "https://example.org"
+				c.Fetcher.Period = 30
+			},
+			want: []string{"fetcher.url"},
+		},
+		{
+			name: "fetcher clubs",
+			change: func(c *Config) {
+				c.Fetcher.Clubs = []Club{{ID: 2, URL: "https://example.org"}}
+			},
+			want: []string{"fetcher.clubs"},
+		},
+		{
+			name: "predictor decay",
+			change: func(c *Config) {
+				c.Predictor.DecayLambda = 0.1
+				c.Predictor.MinWeight = 0.01
+				c.Predictor.ResetWeight = 0.001
+			},
+			want: []string{"predictor.decay_lambda", "predictor.min_weight", "predictor.reset_weight"},
+		},
+		{
+			name: "fetcher auth",
+			change: func(c *Config) {
+				c.Fetcher.AuthType = "basic"
+				c.Fetcher.Username = "user"
+				c.Fetcher.Password = "password"
+				c.Fetcher.AuthHeader = "X-Auth"
+			},
+			want: []string{"fetcher.auth_header", "fetcher.auth_type", "fetcher.password", "fetcher.username"},
+		},
+		{
+			name: "database cache pragmas",
+			change: func(c *Config) {
+				c.Database.CacheSize = -4000
+				c.Database.MmapSize = 1 << 20
+			},
+			want: []string{"database.cache_size", "database.mmap_size"},
+		},
+		{
+			name: "database busy timeout",
+			change: func(c *Config) {
+				c.Database.BusyTimeout = 10000
+			},
+			want: []string{"database.busy_timeout"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			other := base
+			tc.change(&other)
+
+			got := base.NotReloadable(&other)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("NotReloadable() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFetcher_HasClub(t *testing.T) {
+	f := Fetcher{Clubs: []Club{{ID: 2, URL: "http://localhost/club2"}}}
+
+	for id, want := range map[int]bool{0: true, 2: true, 3: false, -1: false} {
+		if got := f.HasClub(id); got != want {
+			t.Errorf("HasClub(%d) = %v, want %v", id, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package databaser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backupLayout is a time layout of backup file names, they are sorted by time.
+const backupLayout = "20060102-150405"
+
+// ErrBackupExists is returned if the backup file already exists, it is not overwritten.
+var ErrBackupExists = errors.New("backup file already exists")
+
+// Backup writes a consistent copy of the database to destPath, the database is not locked for readers and writers.
+// The parent directory is created if it is missing.
+func (db *DB) Backup(ctx context.Context, destPath string) error {
+	cleanPath := filepath.Clean(destPath)
+	if err := os.MkdirAll(filepath.Dir(cleanPath), 0o750); err != nil {
+		return fmt.Errorf("create backup directory: %w", err)
+	}
+
+	// VACUUM INTO fails only for a non-empty file, so an existing file is checked explicitly
+	if _, err := os.Stat(cleanPath); err == nil {
+		return fmt.Errorf("%w: %q", ErrBackupExists, cleanPath)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("check backup file: %w", err)
+	}
+
+	slog.DebugContext(ctx, "Backup", "path", cleanPath)
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?;", cleanPath); err != nil {
+		return fmt.Errorf("vacuum into %q: %w", cleanPath, err)
+	}
+
+	return nil
+}
+
+// BackupToDir writes a backup to the directory with a name of the current UTC time.
+// It returns the path and the size of the backup file.
+func (db *DB) BackupToDir(ctx context.Context, dir string) (string, int64, error) {
+	backupPath := BackupPath(dir, time.Now())
+	if err := db.Backup(ctx, backupPath); err != nil {
+		return "", 0, err
+	}
+
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("stat backup file: %w", err)
+	}
+
+	return backupPath, info.Size(), nil
+}
+
+// BackupPath returns the path of a backup file in the directory, its name contains the time in UTC.
+func BackupPath(dir string, now time.Time) string {
+	return filepath.Join(dir, "ggp-"+now.UTC().Format(backupLayout)+".sqlite")
+}
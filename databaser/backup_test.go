@@ -0,0 +1,85 @@
+package databaser
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackup(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	event := Event{Timestamp: time.Now().UTC().Truncate(time.Second), Load: 42}
+	if err := db.SaveEvent(ctx, event); err != nil {
+		t.Fatalf("SaveEvent() error = %v", err)
+	}
+
+	// the missing directory is created
+	backupPath := filepath.Join(t.TempDir(), "backups", "nested", "backup.sqlite")
+	if err := db.Backup(ctx, backupPath); err != nil {
+		t.Fatalf("Backup() error = %v", err)
+	}
+
+	backup, err := New(ctx, backupPath, 1)
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	t.Cleanup(func() {
+		if closeErr := backup.Close(); closeErr != nil {
+			t.Errorf("failed to close backup: %v", closeErr)
+		}
+	})
+
+	latest, err := backup.GetLatestEvent(ctx)
+	if err != nil {
+		t.Fatalf("GetLatestEvent() from backup error = %v", err)
+	}
+	if !latest.Timestamp.Equal(event.Timestamp) || latest.Load != event.Load {
+		t.Errorf("backup event = %v, want %v", latest, event)
+	}
+
+	// an existing file is not overwritten, even an empty one
+	emptyPath := filepath.Join(t.TempDir(), "empty.sqlite")
+	if err = os.WriteFile(emptyPath, nil, 0o600); err != nil {
+		t.Fatalf("failed to create file: %v", err)
+	}
+	for _, path := range []string{backupPath, emptyPath} {
+		if err = db.Backup(ctx, path); !errors.Is(err, ErrBackupExists) {
+			t.Errorf("Backup(%s) error = %v, want %v", filepath.Base(path), err, ErrBackupExists)
+		}
+	}
+}
+
+func TestBackupToDir(t *testing.T) {
+	db := newTestDB(t)
+	dir := filepath.Join(t.TempDir(), "backups")
+
+	backupPath, size, err := db.BackupToDir(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("BackupToDir() error = %v", err)
+	}
+	if filepath.Dir(backupPath) != dir {
+		t.Errorf("backup path = %q, want in %q", backupPath, dir)
+	}
+
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		t.Fatalf("failed to stat backup: %v", err)
+	}
+	if size <= 0 || size != info.Size() {
+		t.Errorf("backup size = %d, want %d", size, info.Size())
+	}
+}
+
+func TestBackupPath(t *testing.T) {
+	now := time.Date(2025, 6, 15, 15, 4, 5, 0, time.FixedZone("UTC+3", 3*3600))
+	want := filepath.Join("backups", "ggp-20250615-120405.sqlite")
+
+	if got := BackupPath("backups", now); got != want {
+		t.Errorf("BackupPath() = %q, want %q", got, want)
+	}
+}
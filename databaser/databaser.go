@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	_ "modernc.org/sqlite" // SQLite driver
@@ -16,29 +17,85 @@ import (
 //go:embed init.sql
 var initSQL string
 
+// Default memory settings of the database connection.
+const (
+	DefaultCacheSize = 32768     // in KiB, 32 MiB page cache
+	DefaultMmapSize  = 134217728 // in bytes, 128 MiB memory-mapped I/O
+)
+
+// DefaultBusyTimeout is the default time to wait for a locked database before "database is locked" error.
+const DefaultBusyTimeout = 5 * time.Second
+
 // DB wraps sqlx.DB for database operations.
 type DB struct {
 	*sqlx.DB
 }
 
+// Option configures optional database settings.
+type Option func(*options)
+
+// options contains optional database settings.
+type options struct {
+	cacheSize   int64
+	mmapSize    int64
+	busyTimeout time.Duration
+}
+
+// WithCacheSize sets the page cache size in KiB, it's allocated in memory as pages are read.
+func WithCacheSize(kib int64) Option {
+	return func(o *options) {
+		o.cacheSize = kib
+	}
+}
+
+// WithMmapSize sets the max size of memory-mapped I/O in bytes, zero value disables it.
+// The mapped file pages are shared with the OS page cache, so it doesn't increase the process heap.
+func WithMmapSize(size int64) Option {
+	return func(o *options) {
+		o.mmapSize = size
+	}
+}
+
+// WithBusyTimeout sets the time to wait for a database locked by another connection, zero value disables waiting.
+// The timeout is applied with millisecond precision.
+func WithBusyTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.busyTimeout = timeout
+	}
+}
+
 // New creates a new database connection.
-func New(ctx context.Context, path string, threads uint8) (*DB, error) {
-	db, err := sqlx.Open("sqlite", path)
-	if err != nil {
-		return nil, fmt.Errorf("open database: %w", err)
+// Write-ahead logging allows reading during writes, it's not used for in-memory databases.
+func New(ctx context.Context, path string, threads uint8, opts ...Option) (*DB, error) {
+	o := options{cacheSize: DefaultCacheSize, mmapSize: DefaultMmapSize, busyTimeout: DefaultBusyTimeout}
+	for _, opt := range opts {
+		opt(&o)
 	}
+
 	if threads == 0 {
 		return nil, errors.New("threads must be greater than 0")
 	}
+	if o.cacheSize < 0 || o.mmapSize < 0 {
+		return nil, errors.New("cache and mmap sizes must not be negative")
+	}
+	if o.busyTimeout < 0 {
+		return nil, errors.New("busy timeout must not be negative")
+	}
+
+	db, err := sqlx.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
 
+	busyMs := o.busyTimeout.Milliseconds()
 	pragmas := []string{
-		"PRAGMA journal_mode=WAL",    // write-ahead logging
-		"PRAGMA synchronous=NORMAL",  // balance between performance and safety
-		"PRAGMA cache_size=-32768",   // 32 mb cache (hegative value means size in KB)
-		"PRAGMA mmap_size=134217728", // 128 mb mmap
-		"PRAGMA temp_store=MEMORY",   // store temporary tables in memory
-		"PRAGMA busy_timeout=5000",   // 5 sec busy timeout
-		"PRAGMA foreign_keys=ON",     // enable foreign key constraints
+		"PRAGMA journal_mode=WAL",                         // write-ahead logging
+		"PRAGMA synchronous=NORMAL",                       // balance between performance and safety
+		fmt.Sprintf("PRAGMA cache_size=-%d", o.cacheSize), // negative value means size in KiB
+		fmt.Sprintf("PRAGMA mmap_size=%d", o.mmapSize),    // memory-mapped I/O, 0 disables it
+		"PRAGMA temp_store=MEMORY",                        // store temporary tables in memory
+		fmt.Sprintf("PRAGMA busy_timeout=%d", busyMs),     // wait for locks of other connections in milliseconds
+		"PRAGMA foreign_keys=ON",                          // enable foreign key constraints
 		fmt.Sprintf("PRAGMA threads=%d", threads),
 	}
 
@@ -68,16 +125,102 @@ func New(ctx context.Context, path string, threads uint8) (*DB, error) {
 	return result, nil
 }
 
-// Init initializes the database schema.
+// Init initializes the database schema and applies migrations of existing tables.
 func (db *DB) Init(ctx context.Context) error {
 	_, err := db.ExecContext(ctx, initSQL)
 	if err != nil {
 		return fmt.Errorf("create schema error: %w", err)
 	}
 
+	if err = db.migrateEventsClub(ctx); err != nil {
+		return fmt.Errorf("migrate events: %w", err)
+	}
+
+	if err = db.migrateHolidaysSource(ctx); err != nil {
+		return fmt.Errorf("migrate holidays: %w", err)
+	}
+
 	return nil
 }
 
+// migrateEventsClub adds club_id column to the events table created before multiple clubs support.
+// SQLite can't change a primary key, so the table is rebuilt, existing events belong to DefaultClubID.
+func (db *DB) migrateEventsClub(ctx context.Context) error {
+	const (
+		checkQuery = `SELECT COUNT(*) FROM pragma_table_info('events') WHERE name = 'club_id';`
+		createNew  = `CREATE TABLE events_new
+(
+    timestamp DATETIME NOT NULL,
+    club_id   INTEGER  NOT NULL DEFAULT 0,
+    load      INTEGER  NOT NULL DEFAULT 0,
+    PRIMARY KEY (timestamp, club_id)
+);`
+		copyEvents = `INSERT INTO events_new (timestamp, club_id, load) SELECT timestamp, 0, load FROM events;`
+		dropOld    = `DROP TABLE events;`
+		renameNew  = `ALTER TABLE events_new RENAME TO events;`
+		createIdx  = `CREATE INDEX IF NOT EXISTS idx_events_load ON events (load);`
+	)
+
+	var found int
+	if err := db.GetContext(ctx, &found, checkQuery); err != nil {
+		return fmt.Errorf("check club_id column: %w", err)
+	}
+	if found > 0 {
+		return nil
+	}
+
+	slog.InfoContext(ctx, "migrating events table to multiple clubs")
+	return InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		for _, query := range []string{createNew, copyEvents, dropOld, renameNew, createIdx} {
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("exec %q: %w", query, err)
+			}
+		}
+		return nil
+	})
+}
+
+// migrateHolidaysSource adds source column to the holidays table created before manual holidays support,
+// existing holidays are fetched ones.
+func (db *DB) migrateHolidaysSource(ctx context.Context) error {
+	const (
+		checkQuery = `SELECT COUNT(*) FROM pragma_table_info('holidays') WHERE name = 'source';`
+		alterQuery = `ALTER TABLE holidays ADD COLUMN source INTEGER NOT NULL DEFAULT 0;`
+	)
+
+	var found int
+	if err := db.GetContext(ctx, &found, checkQuery); err != nil {
+		return fmt.Errorf("check source column: %w", err)
+	}
+	if found > 0 {
+		return nil
+	}
+
+	slog.InfoContext(ctx, "migrating holidays table to manual holidays")
+	if _, err := db.ExecContext(ctx, alterQuery); err != nil {
+		return fmt.Errorf("add source column: %w", err)
+	}
+	return nil
+}
+
+// IntegrityCheck runs SQLite integrity check and reports whether the database is healthy.
+func (db *DB) IntegrityCheck(ctx context.Context) (bool, error) {
+	const query = `PRAGMA integrity_check;`
+	var results []string
+
+	err := db.SelectContext(ctx, &results, query)
+	if err != nil {
+		return false, fmt.Errorf("integrity check: %w", err)
+	}
+
+	if len(results) == 1 && results[0] == "ok" {
+		return true, nil
+	}
+
+	slog.ErrorContext(ctx, "database integrity check failed", "problems", results)
+	return false, nil
+}
+
 // Close closes the database connection.
 func (db *DB) Close() error {
 	return db.DB.Close()
@@ -3,6 +3,10 @@ package databaser
 import (
 	"context"
 	"database/sql/driver"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -59,6 +63,172 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNew_MemoryPragmas(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantCache int64
+		wantMmap  int64
+	}{
+		{name: "defaults", wantCache: -DefaultCacheSize, wantMmap: DefaultMmapSize},
+		{name: "custom", opts: []Option{WithCacheSize(2048), WithMmapSize(1 << 20)}, wantCache: -2048, wantMmap: 1 << 20},
+		{name: "mmap disabled", opts: []Option{WithMmapSize(0)}, wantCache: -DefaultCacheSize, wantMmap: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db, err := New(ctx, filepath.Join(t.TempDir(), "test.db"), 1, tt.opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			t.Cleanup(func() {
+				if closeErr := db.Close(); closeErr != nil {
+					t.Errorf("Close() error = %v", closeErr)
+				}
+			})
+
+			var cacheSize, mmapSize int64
+			if err = db.GetContext(ctx, &cacheSize, "PRAGMA cache_size;"); err != nil {
+				t.Fatalf("failed to get cache_size: %v", err)
+			}
+			if err = db.GetContext(ctx, &mmapSize, "PRAGMA mmap_size;"); err != nil {
+				t.Fatalf("failed to get mmap_size: %v", err)
+			}
+
+			if cacheSize != tt.wantCache {
+				t.Errorf("cache_size = %d, want %d", cacheSize, tt.wantCache)
+			}
+			if mmapSize != tt.wantMmap {
+				t.Errorf("mmap_size = %d, want %d", mmapSize, tt.wantMmap)
+			}
+		})
+	}
+}
+
+func TestNew_NegativeSizes(t *testing.T) {
+	ctx := context.Background()
+	for _, opt := range []Option{WithCacheSize(-1), WithMmapSize(-1), WithBusyTimeout(-time.Second)} {
+		if db, err := New(ctx, ":memory:", 1, opt); err == nil {
+			_ = db.Close()
+			t.Error("expected error for negative size")
+		}
+	}
+}
+
+func TestNew_JournalPragmas(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		opts        []Option
+		wantJournal string
+		wantBusy    int64
+	}{
+		{name: "file defaults", path: "test.db", wantJournal: "wal", wantBusy: DefaultBusyTimeout.Milliseconds()},
+		{name: "file custom busy timeout", path: "test.db", opts: []Option{WithBusyTimeout(1500 * time.Millisecond)}, wantJournal: "wal", wantBusy: 1500},
+		{name: "in-memory", path: ":memory:", wantJournal: "memory", wantBusy: DefaultBusyTimeout.Milliseconds()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			path := tt.path
+			if path != ":memory:" {
+				path = filepath.Join(t.TempDir(), path)
+			}
+
+			db, err := New(ctx, path, 1, tt.opts...)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			t.Cleanup(func() {
+				if closeErr := db.Close(); closeErr != nil {
+					t.Errorf("Close() error = %v", closeErr)
+				}
+			})
+
+			var (
+				journal     string
+				busy        int64
+				synchronous int
+			)
+			if err = db.GetContext(ctx, &journal, "PRAGMA journal_mode;"); err != nil {
+				t.Fatalf("failed to get journal_mode: %v", err)
+			}
+			if err = db.GetContext(ctx, &busy, "PRAGMA busy_timeout;"); err != nil {
+				t.Fatalf("failed to get busy_timeout: %v", err)
+			}
+			if err = db.GetContext(ctx, &synchronous, "PRAGMA synchronous;"); err != nil {
+				t.Fatalf("failed to get synchronous: %v", err)
+			}
+
+			if journal != tt.wantJournal {
+				t.Errorf("journal_mode = %q, want %q", journal, tt.wantJournal)
+			}
+			if busy != tt.wantBusy {
+				t.Errorf("busy_timeout = %d, want %d", busy, tt.wantBusy)
+			}
+			if synchronous != 1 { // NORMAL
+				t.Errorf("synchronous = %d, want 1", synchronous)
+			}
+		})
+	}
+}
+
+func TestNew_ConcurrentConnections(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	dbs := make([]*DB, 2)
+	for i := range dbs {
+		db, err := New(ctx, path, 1)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		t.Cleanup(func() {
+			if closeErr := db.Close(); closeErr != nil {
+				t.Errorf("Close() error = %v", closeErr)
+			}
+		})
+		dbs[i] = db
+	}
+
+	const writes = 50
+	baseTime := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	errCh := make(chan error, len(dbs))
+
+	var wg sync.WaitGroup
+	for i, db := range dbs {
+		wg.Go(func() {
+			for j := range writes {
+				// every write is a transaction, so the connections hold the write lock in turn
+				err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+					event := &Event{Timestamp: baseTime.Add(time.Duration(j) * time.Minute), ClubID: i, Load: uint8(j)}
+					return SaveManyEventsTx(ctx, tx, []*Event{event})
+				})
+				if err != nil {
+					errCh <- err
+					return
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		t.Errorf("concurrent write error = %v", err)
+	}
+
+	count, err := dbs[0].CountEvents(ctx)
+	if err != nil {
+		t.Fatalf("CountEvents() error = %v", err)
+	}
+	if count != len(dbs)*writes {
+		t.Errorf("CountEvents() = %d, want %d", count, len(dbs)*writes)
+	}
+}
+
 func TestInit_CreatesTablesIdempotently(t *testing.T) {
 	db := newTestDB(t)
 	ctx := context.Background()
@@ -145,6 +315,29 @@ func TestSaveEvent_DuplicateTimestamp(t *testing.T) {
 	}
 }
 
+func TestUpsertEvent(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	ts := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	if err := db.UpsertEvent(ctx, Event{Timestamp: ts, Load: 50}); err != nil {
+		t.Fatalf("first UpsertEvent() error = %v", err)
+	}
+
+	if err := db.UpsertEvent(ctx, Event{Timestamp: ts, Load: 60}); err != nil {
+		t.Fatalf("second UpsertEvent() error = %v", err)
+	}
+
+	var loads []uint8
+	if err := db.SelectContext(ctx, &loads, `SELECT load FROM events;`); err != nil {
+		t.Fatalf("failed to select events: %v", err)
+	}
+
+	if len(loads) != 1 || loads[0] != 60 {
+		t.Errorf("loads = %v, want [60]", loads)
+	}
+}
+
 func TestSaveManyEvents(t *testing.T) {
 	db := newTestDB(t)
 	ctx := context.Background()
@@ -262,107 +455,729 @@ func TestGetEvents(t *testing.T) {
 	}
 }
 
-func TestGetEvents_OrderedByTimestamp(t *testing.T) {
+func TestIterateEvents(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	baseTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := make([]Event, 10)
+	for i := range events {
+		events[i] = Event{Timestamp: baseTime.Add(time.Duration(i) * time.Hour), Load: uint8(i)}
+	}
+
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("SaveManyEvents() error = %v", err)
+	}
+
+	var got []Event
+	for event, err := range db.IterateEvents(ctx, baseTime.Add(2*time.Hour), baseTime.Add(7*time.Hour)) {
+		if err != nil {
+			t.Fatalf("IterateEvents() error = %v", err)
+		}
+		got = append(got, event)
+	}
+
+	if len(got) != 5 {
+		t.Fatalf("IterateEvents() returned %d events, want 5", len(got))
+	}
+
+	for i, event := range got {
+		if want := uint8(i + 2); event.Load != want {
+			t.Errorf("event[%d].Load = %d, want %d", i, event.Load, want)
+		}
+	}
+}
+
+func TestGetAllEventsIter(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	baseTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := make([]Event, 10)
+	for i := range events {
+		events[i] = Event{Timestamp: baseTime.Add(time.Duration(i) * time.Hour), Load: uint8(i)}
+	}
+	events = append(events, Event{Timestamp: baseTime.Add(5 * time.Hour), ClubID: 7, Load: 99})
+
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("SaveManyEvents() error = %v", err)
+	}
+
+	tests := []struct {
+		since     time.Time
+		name      string
+		wantCount int
+		wantFirst uint8
+	}{
+		{name: "all events", wantCount: 10},
+		{name: "since time", since: baseTime.Add(4 * time.Hour), wantCount: 6, wantFirst: 4},
+		{name: "no events", since: baseTime.Add(24 * time.Hour)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []Event
+			for event, err := range db.GetAllEventsIter(ctx, tt.since) {
+				if err != nil {
+					t.Fatalf("GetAllEventsIter() error = %v", err)
+				}
+				got = append(got, event)
+			}
+
+			if len(got) != tt.wantCount {
+				t.Fatalf("GetAllEventsIter() returned %d events, want %d", len(got), tt.wantCount)
+			}
+			for i, event := range got {
+				if want := tt.wantFirst + uint8(i); event.Load != want || event.ClubID != DefaultClubID {
+					t.Errorf("event[%d] = %v, want load %d of the main club", i, &event, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIterateEvents_EarlyBreak(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	baseTime := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := make([]Event, 10)
+	for i := range events {
+		events[i] = Event{Timestamp: baseTime.Add(time.Duration(i) * time.Hour), Load: uint8(i)}
+	}
+
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("SaveManyEvents() error = %v", err)
+	}
+
+	count := 0
+	for _, err := range db.IterateEvents(ctx, baseTime, baseTime.Add(24*time.Hour)) {
+		if err != nil {
+			t.Fatalf("IterateEvents() error = %v", err)
+		}
+		count++
+		if count == 3 {
+			break
+		}
+	}
+
+	if count != 3 {
+		t.Errorf("iterated %d events, want 3", count)
+	}
+
+	// the only connection must be released after break
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	all, err := db.GetAllEvents(queryCtx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() after break error = %v", err)
+	}
+	if len(all) != len(events) {
+		t.Errorf("GetAllEvents() returned %d events, want %d", len(all), len(events))
+	}
+}
+
+func TestIterateEvents_ClosedDB(t *testing.T) {
+	ctx := context.Background()
+	db, err := New(ctx, ":memory:", 1)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var gotErr error
+	for _, iterErr := range db.IterateEvents(ctx, time.Time{}, time.Now()) {
+		gotErr = iterErr
+	}
+
+	if gotErr == nil {
+		t.Error("IterateEvents() on closed database should return error")
+	}
+}
+
+func TestGetEvents_OrderedByTimestamp(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	// Insert in reverse order
+	events := []Event{
+		{Timestamp: now.Add(-30 * time.Minute), Load: 70},
+		{Timestamp: now.Add(-2 * time.Hour), Load: 50},
+		{Timestamp: now.Add(-1 * time.Hour), Load: 60},
+	}
+
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("SaveManyEvents() error = %v", err)
+	}
+
+	got, err := db.GetEvents(ctx, 3*time.Hour)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+
+	// Should be ordered ascending by timestamp
+	for i := 1; i < len(got); i++ {
+		if !got[i].Timestamp.After(got[i-1].Timestamp) {
+			t.Errorf("events not ordered: [%d]=%v >= [%d]=%v",
+				i-1, got[i-1].Timestamp, i, got[i].Timestamp)
+		}
+	}
+}
+
+func TestGetAllEvents(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	// Insert 10 events
+	var events []Event
+	base := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		events = append(events, Event{
+			Timestamp: base.Add(time.Duration(i) * time.Hour),
+			Load:      uint8(i * 10),
+		})
+	}
+
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("SaveManyEvents() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		limit     int
+		offset    int
+		wantCount int
+		wantFirst uint8
+	}{
+		{
+			name:      "first page",
+			limit:     3,
+			offset:    0,
+			wantCount: 3,
+			wantFirst: 0,
+		},
+		{
+			name:      "second page",
+			limit:     3,
+			offset:    3,
+			wantCount: 3,
+			wantFirst: 30,
+		},
+		{
+			name:      "last partial page",
+			limit:     5,
+			offset:    8,
+			wantCount: 2,
+			wantFirst: 80,
+		},
+		{
+			name:      "offset beyond data",
+			limit:     5,
+			offset:    100,
+			wantCount: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.GetAllEvents(ctx, tt.limit, tt.offset)
+			if err != nil {
+				t.Errorf("GetAllEvents() error = %v", err)
+				return
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("GetAllEvents() returned %d events, want %d", len(got), tt.wantCount)
+			}
+			if tt.wantCount > 0 && got[0].Load != tt.wantFirst {
+				t.Errorf("first event load = %d, want %d", got[0].Load, tt.wantFirst)
+			}
+		})
+	}
+}
+
+func TestGetAllEvents_Order(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	base := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	// saved in mixed order, so the result order is defined by the query only
+	events := []Event{
+		{Timestamp: base.Add(2 * time.Hour), Load: 20},
+		{Timestamp: base, Load: 0},
+		{Timestamp: base.Add(3 * time.Hour), Load: 30},
+		{Timestamp: base.Add(time.Hour), Load: 10},
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("SaveManyEvents() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		get       func(ctx context.Context, limit, offset int) ([]Event, error)
+		offset    int
+		wantLoads []uint8
+	}{
+		{name: "ascending", get: db.GetAllEvents, wantLoads: []uint8{0, 10, 20, 30}},
+		{name: "ascending page", get: db.GetAllEvents, offset: 2, wantLoads: []uint8{20, 30}},
+		{name: "descending", get: db.GetAllEventsDesc, wantLoads: []uint8{30, 20, 10, 0}},
+		{name: "descending page", get: db.GetAllEventsDesc, offset: 2, wantLoads: []uint8{10, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get(ctx, 10, tt.offset)
+			if err != nil {
+				t.Fatalf("get events error = %v", err)
+			}
+
+			loads := make([]uint8, len(got))
+			for i, e := range got {
+				loads[i] = e.Load
+			}
+
+			if !slices.Equal(loads, tt.wantLoads) {
+				t.Errorf("loads = %v, want %v", loads, tt.wantLoads)
+			}
+		})
+	}
+}
+
+func TestCollapseFlat(t *testing.T) {
+	tests := []struct {
+		name        string
+		loads       []uint8
+		tolerance   uint8
+		wantRemoved int64
+		wantLoads   []uint8
+	}{
+		{
+			name:      "too few events",
+			loads:     []uint8{10, 10},
+			wantLoads: []uint8{10, 10},
+		},
+		{
+			name:        "flat series",
+			loads:       []uint8{10, 10, 10, 10, 10},
+			wantRemoved: 3,
+			wantLoads:   []uint8{10, 10},
+		},
+		{
+			name:        "flat series with fluctuations, zero tolerance",
+			loads:       []uint8{10, 11, 10, 11, 10},
+			wantRemoved: 0,
+			wantLoads:   []uint8{10, 11, 10, 11, 10},
+		},
+		{
+			name:        "flat series with fluctuations",
+			loads:       []uint8{10, 11, 10, 11, 10},
+			tolerance:   1,
+			wantRemoved: 3,
+			wantLoads:   []uint8{10, 10},
+		},
+		{
+			name:        "several runs keep endpoints",
+			loads:       []uint8{10, 10, 10, 50, 51, 50, 52, 20},
+			tolerance:   2,
+			wantRemoved: 3,
+			wantLoads:   []uint8{10, 10, 50, 52, 20},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			ctx := context.Background()
+			baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+			events := make([]Event, len(tt.loads))
+			for i, load := range tt.loads {
+				events[i] = Event{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Load: load}
+			}
+			if err := db.SaveManyEvents(ctx, events); err != nil {
+				t.Fatalf("failed to save events: %v", err)
+			}
+
+			removed, err := db.CollapseFlat(ctx, tt.tolerance)
+			if err != nil {
+				t.Fatalf("CollapseFlat() error = %v", err)
+			}
+			if removed != tt.wantRemoved {
+				t.Errorf("CollapseFlat() removed = %d, want %d", removed, tt.wantRemoved)
+			}
+
+			got, err := db.GetAllEvents(ctx, 100, 0)
+			if err != nil {
+				t.Fatalf("GetAllEvents() error = %v", err)
+			}
+			if len(got) != len(tt.wantLoads) {
+				t.Fatalf("got %d events, want %d", len(got), len(tt.wantLoads))
+			}
+			for i, event := range got {
+				if event.Load != tt.wantLoads[i] {
+					t.Errorf("event %d load = %d, want %d", i, event.Load, tt.wantLoads[i])
+				}
+			}
+			if !got[0].Timestamp.Equal(events[0].Timestamp) || !got[len(got)-1].Timestamp.Equal(events[len(events)-1].Timestamp) {
+				t.Error("first and last events should be kept")
+			}
+		})
+	}
+}
+
+func TestCollapseFlat_Clubs(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	// interleaved flat runs of two clubs are collapsed separately
+	var events []Event
+	for i := range 4 {
+		ts := baseTime.Add(time.Duration(i) * time.Minute)
+		events = append(events, Event{Timestamp: ts, Load: 10}, Event{Timestamp: ts, ClubID: 2, Load: 90})
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	removed, err := db.CollapseFlat(ctx, 0)
+	if err != nil {
+		t.Fatalf("CollapseFlat() error = %v", err)
+	}
+	if removed != 4 {
+		t.Errorf("CollapseFlat() removed = %d, want 4", removed)
+	}
+
+	for _, clubID := range []int{DefaultClubID, 2} {
+		var count int
+		if err = db.GetContext(ctx, &count, `SELECT COUNT(*) FROM events WHERE club_id = ?;`, clubID); err != nil {
+			t.Fatalf("failed to count events: %v", err)
+		}
+		if count != 2 {
+			t.Errorf("club %d has %d events, want 2", clubID, count)
+		}
+	}
+}
+
+func TestGetLatestEvent(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	if _, err := db.GetLatestEvent(ctx); !errors.Is(err, ErrEventNotFound) {
+		t.Fatalf("expected ErrEventNotFound, got %v", err)
+	}
+
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Timestamp: baseTime.Add(time.Hour), Load: 30},
+		{Timestamp: baseTime, Load: 10},
+		{Timestamp: baseTime.Add(2 * time.Hour), ClubID: 2, Load: 90},
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	event, err := db.GetLatestEvent(ctx)
+	if err != nil {
+		t.Fatalf("GetLatestEvent() error = %v", err)
+	}
+	if !event.Timestamp.Equal(baseTime.Add(time.Hour)) || event.Load != 30 {
+		t.Errorf("GetLatestEvent() = %v, want the latest main club event", event)
+	}
+}
+
+func TestCountEventsSince(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Timestamp: baseTime, Load: 10},
+		{Timestamp: baseTime.Add(time.Hour), Load: 20},
+		{Timestamp: baseTime.Add(2 * time.Hour), Load: 30},
+		{Timestamp: baseTime.Add(2 * time.Hour), ClubID: 2, Load: 90},
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	tests := []struct {
+		since time.Time
+		name  string
+		want  int
+	}{
+		{name: "all", since: baseTime.Add(-time.Hour), want: 3},
+		{name: "inclusive", since: baseTime.Add(time.Hour), want: 2},
+		{name: "none", since: baseTime.Add(3 * time.Hour), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := db.CountEventsSince(ctx, tt.since)
+			if err != nil {
+				t.Fatalf("CountEventsSince() error = %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("CountEventsSince() = %d, want %d", count, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountEvents_EventTimeRange(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	count, err := db.CountEvents(ctx)
+	if err != nil || count != 0 {
+		t.Fatalf("CountEvents() on empty db = %d, %v, want 0", count, err)
+	}
+	minTime, maxTime, err := db.EventTimeRange(ctx)
+	if err != nil || !minTime.IsZero() || !maxTime.IsZero() {
+		t.Fatalf("EventTimeRange() on empty db = %v, %v, %v, want zero times", minTime, maxTime, err)
+	}
+
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Timestamp: baseTime.Add(time.Hour), Load: 30},
+		{Timestamp: baseTime, Load: 10},
+		{Timestamp: baseTime.Add(2 * time.Hour), ClubID: 2, Load: 90},
+	}
+	if err = db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	if count, err = db.CountEvents(ctx); err != nil || count != 3 {
+		t.Errorf("CountEvents() = %d, %v, want 3", count, err)
+	}
+
+	minTime, maxTime, err = db.EventTimeRange(ctx)
+	if err != nil {
+		t.Fatalf("EventTimeRange() error = %v", err)
+	}
+	if !minTime.Equal(baseTime) || !maxTime.Equal(baseTime.Add(2*time.Hour)) {
+		t.Errorf("EventTimeRange() = %v, %v, want %v, %v", minTime, maxTime, baseTime, baseTime.Add(2*time.Hour))
+	}
+}
+
+func TestGetHourlyAverages(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	// the hour three hours ago, so all events are in the requested period
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-3 * time.Hour)
+
+	events := []Event{
+		{Timestamp: hour.Add(5 * time.Minute), Load: 10},
+		{Timestamp: hour.Add(30 * time.Minute), Load: 21},
+		{Timestamp: hour.Add(59 * time.Minute), Load: 30},
+		// the next hour is empty
+		{Timestamp: hour.Add(2*time.Hour + 10*time.Minute), Load: 50},
+		{Timestamp: hour.Add(2*time.Hour + 20*time.Minute), Load: 55},
+		// other clubs and old events are not counted
+		{Timestamp: hour.Add(30 * time.Minute), ClubID: 2, Load: 90},
+		{Timestamp: hour.Add(-24 * time.Hour), Load: 90},
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	got, err := db.GetHourlyAverages(ctx, 4*time.Hour)
+	if err != nil {
+		t.Fatalf("GetHourlyAverages() error = %v", err)
+	}
+
+	want := []Event{
+		{Timestamp: hour, Load: 20, MinLoad: 10, MaxLoad: 30},
+		{Timestamp: hour.Add(2 * time.Hour), Load: 53, MinLoad: 50, MaxLoad: 55}, // 52.5 is rounded half away from zero
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !got[i].Timestamp.Equal(want[i].Timestamp) || got[i].Load != want[i].Load || got[i].ClubID != DefaultClubID ||
+			got[i].MinLoad != want[i].MinLoad || got[i].MaxLoad != want[i].MaxLoad {
+			t.Errorf("event %d = %v, want %v", i, &got[i], &want[i])
+		}
+	}
+
+	club, err := db.GetClubHourlyAverages(ctx, 4*time.Hour, 2)
+	if err != nil {
+		t.Fatalf("GetClubHourlyAverages() error = %v", err)
+	}
+	if len(club) != 1 || club[0].ClubID != 2 || club[0].Load != 90 || !club[0].Timestamp.Equal(hour) {
+		t.Errorf("unexpected club hourly averages: %v", club)
+	}
+}
+
+func TestGetClubEvents(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	events := []Event{
+		{Timestamp: now.Add(-2 * time.Hour), Load: 10},
+		{Timestamp: now.Add(-2 * time.Hour), ClubID: 2, Load: 20},
+		{Timestamp: now.Add(-time.Hour), ClubID: 2, Load: 30},
+		{Timestamp: now.Add(-time.Hour), ClubID: 3, Load: 40},
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		clubID    int
+		wantLoads []uint8
+	}{
+		{name: "main club", clubID: DefaultClubID, wantLoads: []uint8{10}},
+		{name: "second club", clubID: 2, wantLoads: []uint8{20, 30}},
+		{name: "unknown club", clubID: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.GetClubEvents(ctx, 3*time.Hour, tt.clubID)
+			if err != nil {
+				t.Fatalf("GetClubEvents() error = %v", err)
+			}
+			if len(got) != len(tt.wantLoads) {
+				t.Fatalf("got %d events, want %d", len(got), len(tt.wantLoads))
+			}
+			for i, event := range got {
+				if event.ClubID != tt.clubID || event.Load != tt.wantLoads[i] {
+					t.Errorf("unexpected event %d: %v", i, &event)
+				}
+			}
+		})
+	}
+
+	// events of other clubs are not used for predictions
+	all, err := db.GetAllEvents(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+	if len(all) != 1 || all[0].Load != 10 {
+		t.Errorf("GetAllEvents() = %v, want only main club events", all)
+	}
+}
+
+func TestSaveEvent_SameTimestampClubs(t *testing.T) {
 	db := newTestDB(t)
 	ctx := context.Background()
+	ts := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
 
-	now := time.Now().UTC().Truncate(time.Second)
-	// Insert in reverse order
-	events := []Event{
-		{Timestamp: now.Add(-30 * time.Minute), Load: 70},
-		{Timestamp: now.Add(-2 * time.Hour), Load: 50},
-		{Timestamp: now.Add(-1 * time.Hour), Load: 60},
+	if err := db.SaveEvent(ctx, Event{Timestamp: ts, Load: 10}); err != nil {
+		t.Fatalf("SaveEvent() error = %v", err)
 	}
-
-	if err := db.SaveManyEvents(ctx, events); err != nil {
-		t.Fatalf("SaveManyEvents() error = %v", err)
+	if err := db.SaveEvent(ctx, Event{Timestamp: ts, ClubID: 2, Load: 20}); err != nil {
+		t.Errorf("SaveEvent() of another club error = %v", err)
+	}
+	if err := db.SaveEvent(ctx, Event{Timestamp: ts, ClubID: 2, Load: 30}); err == nil {
+		t.Error("expected duplicate error for the same club and timestamp")
 	}
+}
 
-	got, err := db.GetEvents(ctx, 3*time.Hour)
+func TestInit_MigrateEventsClub(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "old.db")
+
+	old, err := sqlx.Open("sqlite", path)
 	if err != nil {
-		t.Fatalf("GetEvents() error = %v", err)
+		t.Fatalf("failed to open database: %v", err)
+	}
+	_, err = old.ExecContext(ctx, `
+CREATE TABLE events
+(
+    timestamp DATETIME NOT NULL PRIMARY KEY,
+    load      INTEGER  NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_events_load ON events (load);
+INSERT INTO events (timestamp, load) VALUES ('2024-01-15 10:00:00+00:00', 10), ('2024-01-15 11:00:00+00:00', 20);`)
+	if err != nil {
+		t.Fatalf("failed to create old schema: %v", err)
+	}
+	if err = old.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
 	}
 
-	// Should be ordered ascending by timestamp
-	for i := 1; i < len(got); i++ {
-		if !got[i].Timestamp.After(got[i-1].Timestamp) {
-			t.Errorf("events not ordered: [%d]=%v >= [%d]=%v",
-				i-1, got[i-1].Timestamp, i, got[i].Timestamp)
+	db, err := New(ctx, path, 1)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if closeErr := db.Close(); closeErr != nil {
+			t.Errorf("Close() error = %v", closeErr)
 		}
+	})
+
+	events, err := db.GetAllEvents(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+	if len(events) != 2 || events[0].Load != 10 || events[1].Load != 20 || events[1].ClubID != DefaultClubID {
+		t.Fatalf("unexpected migrated events: %v", events)
+	}
+
+	ts := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	if err = db.SaveEvent(ctx, Event{Timestamp: ts, ClubID: 2, Load: 30}); err != nil {
+		t.Errorf("SaveEvent() of another club after migration error = %v", err)
+	}
+
+	// the migration is applied once
+	if err = db.Init(ctx); err != nil {
+		t.Errorf("Init() after migration error = %v", err)
 	}
 }
 
-func TestGetAllEvents(t *testing.T) {
-	db := newTestDB(t)
+func TestInit_MigrateHolidaysSource(t *testing.T) {
 	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "old.db")
 
-	// Insert 10 events
-	var events []Event
-	base := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
-	for i := 0; i < 10; i++ {
-		events = append(events, Event{
-			Timestamp: base.Add(time.Duration(i) * time.Hour),
-			Load:      uint8(i * 10),
-		})
+	old, err := sqlx.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	_, err = old.ExecContext(ctx, `
+CREATE TABLE holidays
+(
+    day     DATE         NOT NULL PRIMARY KEY,
+    title   VARCHAR(255) NOT NULL,
+    created DATETIME DEFAULT '1970-01-01 00:00:00'
+);
+INSERT INTO holidays (day, title) VALUES ('2024-01-01', 'New Year');`)
+	if err != nil {
+		t.Fatalf("failed to create old schema: %v", err)
+	}
+	if err = old.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
 	}
 
-	if err := db.SaveManyEvents(ctx, events); err != nil {
-		t.Fatalf("SaveManyEvents() error = %v", err)
+	db, err := New(ctx, path, 1)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
 	}
+	t.Cleanup(func() {
+		if closeErr := db.Close(); closeErr != nil {
+			t.Errorf("Close() error = %v", closeErr)
+		}
+	})
 
-	tests := []struct {
-		name      string
-		limit     int
-		offset    int
-		wantCount int
-		wantFirst uint8
-	}{
-		{
-			name:      "first page",
-			limit:     3,
-			offset:    0,
-			wantCount: 3,
-			wantFirst: 0,
-		},
-		{
-			name:      "second page",
-			limit:     3,
-			offset:    3,
-			wantCount: 3,
-			wantFirst: 30,
-		},
-		{
-			name:      "last partial page",
-			limit:     5,
-			offset:    8,
-			wantCount: 2,
-			wantFirst: 80,
-		},
-		{
-			name:      "offset beyond data",
-			limit:     5,
-			offset:    100,
-			wantCount: 0,
-		},
+	got, err := db.GetHolidays(ctx, 2024, time.UTC)
+	if err != nil {
+		t.Fatalf("GetHolidays() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Title != "New Year" || got[0].Source != HolidayFetched {
+		t.Fatalf("unexpected migrated holidays: %+v", got)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := db.GetAllEvents(ctx, tt.limit, tt.offset)
-			if err != nil {
-				t.Errorf("GetAllEvents() error = %v", err)
-				return
-			}
-			if len(got) != tt.wantCount {
-				t.Errorf("GetAllEvents() returned %d events, want %d", len(got), tt.wantCount)
-			}
-			if tt.wantCount > 0 && got[0].Load != tt.wantFirst {
-				t.Errorf("first event load = %d, want %d", got[0].Load, tt.wantFirst)
-			}
-		})
+	// the second start doesn't migrate again
+	if err = db.Init(ctx); err != nil {
+		t.Errorf("Init() of migrated database error = %v", err)
 	}
 }
-
 func TestNewEventFromCSVRecord(t *testing.T) {
 	loc := time.UTC
 
@@ -500,6 +1315,85 @@ func TestSaveManyEventsTx_EmptySlice(t *testing.T) {
 	}
 }
 
+func TestMergeManyEventsTx(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	existing := Event{Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), Load: 50}
+	if err := db.SaveEvent(ctx, existing); err != nil {
+		t.Fatalf("SaveEvent() error = %v", err)
+	}
+
+	events := []*Event{
+		{Timestamp: existing.Timestamp, Load: 90},
+		{Timestamp: time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC), Load: 60},
+	}
+
+	var inserted int64
+	err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		var mergeErr error
+		inserted, mergeErr = MergeManyEventsTx(ctx, tx, events)
+		return mergeErr
+	})
+	if err != nil {
+		t.Fatalf("MergeManyEventsTx() error = %v", err)
+	}
+	if inserted != 1 {
+		t.Errorf("inserted = %d, want 1", inserted)
+	}
+
+	got, err := db.GetAllEvents(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+	for _, event := range got {
+		if event.Timestamp.Equal(existing.Timestamp) && event.Load != existing.Load {
+			t.Errorf("existing event load = %d, want %d", event.Load, existing.Load)
+		}
+	}
+}
+
+func TestMergeManyEvents(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	existing := Event{Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), Load: 50}
+	if err := db.SaveEvent(ctx, existing); err != nil {
+		t.Fatalf("SaveEvent() error = %v", err)
+	}
+
+	events := []Event{
+		{Timestamp: existing.Timestamp, Load: 90},
+		{Timestamp: existing.Timestamp, ClubID: 2, Load: 70},
+		{Timestamp: time.Date(2024, 1, 15, 11, 0, 0, 0, time.UTC), Load: 60},
+	}
+
+	inserted, err := db.MergeManyEvents(ctx, events)
+	if err != nil {
+		t.Fatalf("MergeManyEvents() error = %v", err)
+	}
+	if inserted != 2 {
+		t.Errorf("inserted = %d, want 2", inserted)
+	}
+
+	got, err := db.GetAllEvents(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+	for _, event := range got {
+		if event.Timestamp.Equal(existing.Timestamp) && event.ClubID == DefaultClubID && event.Load != existing.Load {
+			t.Errorf("existing event load = %d, want %d", event.Load, existing.Load)
+		}
+	}
+
+	if inserted, err = db.MergeManyEvents(ctx, nil); err != nil || inserted != 0 {
+		t.Errorf("MergeManyEvents(nil) = %d, %v, want 0, nil", inserted, err)
+	}
+}
+
 func TestInTransaction_Commit(t *testing.T) {
 	db := newTestDB(t)
 	ctx := context.Background()
@@ -691,6 +1585,111 @@ func TestSaveManyHolidaysTx_DeletesExistingYearRange(t *testing.T) {
 	}
 }
 
+func TestSaveManyHolidaysTx_KeepsManual(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	loc := time.UTC
+
+	manual := []Holiday{
+		{Day: dateOnly(2024, 12, 24, loc), Title: "Closed"},
+		{Day: dateOnly(2024, 1, 1, loc), Title: "Manual New Year"},
+	}
+	for _, h := range manual {
+		if err := db.AddHoliday(ctx, h); err != nil {
+			t.Fatalf("AddHoliday() error = %v", err)
+		}
+	}
+
+	fetched := []Holiday{
+		{Day: dateOnly(2024, 1, 1, loc), Title: "New Year"},
+		{Day: dateOnly(2024, 5, 9, loc), Title: "Victory Day"},
+	}
+	for range 2 {
+		err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+			return SaveManyHolidaysTx(ctx, tx, fetched)
+		})
+		if err != nil {
+			t.Fatalf("SaveManyHolidaysTx() error = %v", err)
+		}
+	}
+
+	got, err := db.GetHolidays(ctx, 2024, loc)
+	if err != nil {
+		t.Fatalf("GetHolidays() error = %v", err)
+	}
+
+	want := []struct {
+		day    string
+		title  string
+		source uint8
+	}{
+		{day: "2024-01-01", title: "Manual New Year", source: HolidayManual},
+		{day: "2024-05-09", title: "Victory Day", source: HolidayFetched},
+		{day: "2024-12-24", title: "Closed", source: HolidayManual},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d holidays, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Day.String() != w.day || got[i].Title != w.title || got[i].Source != w.source {
+			t.Errorf("holiday %d = %s %q %d, want %s %q %d",
+				i, got[i].Day.String(), got[i].Title, got[i].Source, w.day, w.title, w.source)
+		}
+	}
+}
+
+func TestAddHoliday(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	loc := time.UTC
+	day := dateOnly(2025, 12, 24, loc)
+
+	if err := db.AddHoliday(ctx, Holiday{Day: day, Title: "Closed"}); err != nil {
+		t.Fatalf("AddHoliday() error = %v", err)
+	}
+	if err := db.AddHoliday(ctx, Holiday{Day: day, Title: "Closed for repair"}); err != nil {
+		t.Fatalf("AddHoliday() of the same day error = %v", err)
+	}
+
+	got, err := db.GetHolidays(ctx, 2025, loc)
+	if err != nil {
+		t.Fatalf("GetHolidays() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d holidays, want 1", len(got))
+	}
+	if got[0].Title != "Closed for repair" || got[0].Source != HolidayManual || got[0].Created.IsZero() {
+		t.Errorf("holiday = %+v, want updated manual holiday with created time", got[0])
+	}
+}
+
+func TestDeleteHoliday(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	loc := time.UTC
+	day := dateOnly(2025, 12, 24, loc)
+
+	if err := db.AddHoliday(ctx, Holiday{Day: day, Title: "Closed"}); err != nil {
+		t.Fatalf("AddHoliday() error = %v", err)
+	}
+
+	if err := db.DeleteHoliday(ctx, day); err != nil {
+		t.Fatalf("DeleteHoliday() error = %v", err)
+	}
+
+	got, err := db.GetHolidays(ctx, 2025, loc)
+	if err != nil {
+		t.Fatalf("GetHolidays() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %d holidays, want 0", len(got))
+	}
+
+	if err = db.DeleteHoliday(ctx, day); !errors.Is(err, ErrHolidayNotFound) {
+		t.Errorf("DeleteHoliday() of missing holiday error = %v, want %v", err, ErrHolidayNotFound)
+	}
+}
+
 func TestSaveManyHolidaysTx_EmptySlice(t *testing.T) {
 	db := newTestDB(t)
 	ctx := context.Background()
@@ -1013,6 +2012,33 @@ func TestClose(t *testing.T) {
 	}
 }
 
+func TestIntegrityCheck(t *testing.T) {
+	db := newTestDB(t)
+
+	ok, err := db.IntegrityCheck(context.Background())
+	if err != nil {
+		t.Fatalf("IntegrityCheck() error = %v", err)
+	}
+	if !ok {
+		t.Error("IntegrityCheck() = false, want true for healthy database")
+	}
+}
+
+func TestIntegrityCheck_ClosedDB(t *testing.T) {
+	ctx := context.Background()
+	db, err := New(ctx, ":memory:", 1)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if _, err = db.IntegrityCheck(ctx); err == nil {
+		t.Error("IntegrityCheck() on closed database should fail")
+	}
+}
+
 // Helper functions
 
 func dateOnly(year int, month time.Month, day int, loc *time.Location) *DateOnly {
@@ -1032,3 +2058,41 @@ func containsAt(s, substr string) bool {
 	}
 	return false
 }
+
+func TestDeleteAllEvents(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	deleted, err := db.DeleteAllEvents(ctx)
+	if err != nil {
+		t.Fatalf("DeleteAllEvents() error = %v", err)
+	}
+	if deleted != 0 {
+		t.Errorf("DeleteAllEvents() of empty table = %d, want 0", deleted)
+	}
+
+	baseTime := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Timestamp: baseTime, Load: 10},
+		{Timestamp: baseTime.Add(time.Hour), Load: 20},
+		{Timestamp: baseTime.Add(time.Hour), ClubID: 2, Load: 90},
+	}
+	if err = db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	if deleted, err = db.DeleteAllEvents(ctx); err != nil {
+		t.Fatalf("DeleteAllEvents() error = %v", err)
+	}
+	if deleted != int64(len(events)) {
+		t.Errorf("DeleteAllEvents() = %d, want %d", deleted, len(events))
+	}
+
+	var count int
+	if err = db.GetContext(ctx, &count, "SELECT COUNT(*) FROM events;"); err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("events count = %d, want 0", count)
+	}
+}
@@ -2,7 +2,10 @@ package databaser
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"iter"
 	"log/slog"
 	"strconv"
 	"time"
@@ -10,11 +13,24 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-// Event represents a load event with a timestamp and load percentage.
+// DefaultClubID is the club identifier of the main club.
+// Its events are used for predictions and daily statistics, other clubs are only stored.
+const DefaultClubID = 0
+
+// ErrEventNotFound is returned when there are no events to get.
+var ErrEventNotFound = errors.New("event not found")
+
+// Event represents a load event of a club with a timestamp and load percentage.
+// Predict and Confidence are set only for predicted events,
+// MinLoad and MaxLoad are set only for aggregated events, where Load is the average.
 type Event struct {
-	Timestamp time.Time `db:"timestamp"`
-	Load      uint8     `db:"load"`
-	Predict   float64   `db:"-"`
+	Timestamp  time.Time `db:"timestamp"`
+	ClubID     int       `db:"club_id"`
+	Load       uint8     `db:"load"`
+	MinLoad    uint8     `db:"-"`
+	MaxLoad    uint8     `db:"-"`
+	Predict    float64   `db:"-"`
+	Confidence float64   `db:"-"`
 }
 
 // NewEventFromCSVRecord creates an Event from a CSV record.
@@ -43,12 +59,12 @@ func (e *Event) FloatLoad() float64 {
 
 // LogValue implements slog.LogValuer for Event.
 func (e *Event) LogValue() slog.Value {
-	return slog.StringValue(fmt.Sprintf("{timestamp: '%s', load: %d}", e.Timestamp.Format(time.RFC3339), e.Load))
+	return slog.StringValue(fmt.Sprintf("{timestamp: '%s', club: %d, load: %d}", e.Timestamp.Format(time.RFC3339), e.ClubID, e.Load))
 }
 
 // SaveEvent stores an event in the database.
 func (db *DB) SaveEvent(ctx context.Context, event Event) error {
-	const query = `INSERT INTO events (timestamp, load) VALUES (:timestamp, :load);`
+	const query = `INSERT INTO events (timestamp, club_id, load) VALUES (:timestamp, :club_id, :load);`
 
 	_, err := db.NamedExecContext(ctx, query, event)
 	if err != nil {
@@ -58,13 +74,25 @@ func (db *DB) SaveEvent(ctx context.Context, event Event) error {
 	return nil
 }
 
+// UpsertEvent stores a single event in the database, replacing an existing event of the club with the same timestamp.
+func (db *DB) UpsertEvent(ctx context.Context, event Event) error {
+	const query = `INSERT OR REPLACE INTO events (timestamp, club_id, load) VALUES (:timestamp, :club_id, :load);`
+
+	_, err := db.NamedExecContext(ctx, query, event)
+	if err != nil {
+		return fmt.Errorf("upsert event: %w", err)
+	}
+
+	return nil
+}
+
 // SaveManyEvents stores multiple events in the database.
 func (db *DB) SaveManyEvents(ctx context.Context, events []Event) error {
 	if len(events) == 0 {
 		return nil
 	}
 
-	const query = `INSERT OR REPLACE INTO events (timestamp, load) VALUES (:timestamp, :load);`
+	const query = `INSERT OR REPLACE INTO events (timestamp, club_id, load) VALUES (:timestamp, :club_id, :load);`
 
 	_, err := db.NamedExecContext(ctx, query, events)
 	if err != nil {
@@ -74,16 +102,43 @@ func (db *DB) SaveManyEvents(ctx context.Context, events []Event) error {
 	return nil
 }
 
-// GetEvents retrieves events to the current time minus the given period.
+// MergeManyEvents stores multiple events in the database, events with existing timestamps of the club are skipped.
+// It returns the number of inserted events.
+func (db *DB) MergeManyEvents(ctx context.Context, events []Event) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	const query = `INSERT OR IGNORE INTO events (timestamp, club_id, load) VALUES (:timestamp, :club_id, :load);`
+
+	result, err := db.NamedExecContext(ctx, query, events)
+	if err != nil {
+		return 0, fmt.Errorf("merge events: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("merge events rows affected: %w", err)
+	}
+
+	return n, nil
+}
+
+// GetEvents retrieves events of the main club to the current time minus the given period.
 func (db *DB) GetEvents(ctx context.Context, period time.Duration) ([]Event, error) {
-	const query = `SELECT timestamp, load FROM events WHERE timestamp >= ? ORDER BY timestamp;`
+	return db.GetClubEvents(ctx, period, DefaultClubID)
+}
+
+// GetClubEvents retrieves events of the club to the current time minus the given period.
+func (db *DB) GetClubEvents(ctx context.Context, period time.Duration, clubID int) ([]Event, error) {
+	const query = `SELECT timestamp, club_id, load FROM events WHERE timestamp >= ? AND club_id = ? ORDER BY timestamp;`
 	var (
 		ts     = time.Now().UTC().Add(-period)
 		events []Event
 	)
 
-	slog.DebugContext(ctx, "GetEvents", "query", query, "since", ts)
-	err := db.SelectContext(ctx, &events, query, ts)
+	slog.DebugContext(ctx, "GetClubEvents", "query", query, "since", ts, "club", clubID)
+	err := db.SelectContext(ctx, &events, query, ts, clubID)
 	if err != nil {
 		return nil, fmt.Errorf("failed select events: %w", err)
 	}
@@ -91,13 +146,133 @@ func (db *DB) GetEvents(ctx context.Context, period time.Duration) ([]Event, err
 	return events, nil
 }
 
-// GetAllEvents retrieves all events with pagination.
+// GetHourlyAverages retrieves average loads of the main club by hours to the current time minus the given period.
+func (db *DB) GetHourlyAverages(ctx context.Context, period time.Duration) ([]Event, error) {
+	return db.GetClubHourlyAverages(ctx, period, DefaultClubID)
+}
+
+// GetClubHourlyAverages retrieves average loads of the club by hours to the current time minus the given period.
+// Every event has the UTC start time of its hour and the min and max loads of the hour, hours without events are absent.
+func (db *DB) GetClubHourlyAverages(ctx context.Context, period time.Duration, clubID int) ([]Event, error) {
+	// timestamps are stored as UTC text, so its "2006-01-02 15" prefix is the hour,
+	// grouping loses the column type, so hours are selected as text to be parsed
+	const (
+		query = `SELECT substr(timestamp, 1, 13) AS hour, CAST(ROUND(AVG(load)) AS INTEGER) AS load,
+			MIN(load) AS min_load, MAX(load) AS max_load FROM events WHERE timestamp >= ? AND club_id = ? GROUP BY hour ORDER BY hour;`
+		hourLayout = "2006-01-02 15"
+	)
+	var (
+		ts   = time.Now().UTC().Add(-period)
+		rows []struct {
+			Hour    string `db:"hour"`
+			Load    uint8  `db:"load"`
+			MinLoad uint8  `db:"min_load"`
+			MaxLoad uint8  `db:"max_load"`
+		}
+	)
+
+	slog.DebugContext(ctx, "GetClubHourlyAverages", "query", query, "since", ts, "club", clubID)
+	if err := db.SelectContext(ctx, &rows, query, ts, clubID); err != nil {
+		return nil, fmt.Errorf("failed select hourly averages: %w", err)
+	}
+
+	events := make([]Event, len(rows))
+	for i, row := range rows {
+		hour, err := time.ParseInLocation(hourLayout, row.Hour, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("failed parse hour %q: %w", row.Hour, err)
+		}
+		events[i] = Event{Timestamp: hour, ClubID: clubID, Load: row.Load, MinLoad: row.MinLoad, MaxLoad: row.MaxLoad}
+	}
+
+	return events, nil
+}
+
+// GetLatestEvent retrieves the most recent event of the main club.
+func (db *DB) GetLatestEvent(ctx context.Context) (*Event, error) {
+	const query = `SELECT timestamp, club_id, load FROM events WHERE club_id = ? ORDER BY timestamp DESC LIMIT 1;`
+
+	var event Event
+	err := db.GetContext(ctx, &event, query, DefaultClubID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEventNotFound
+		}
+		return nil, fmt.Errorf("select latest event: %w", err)
+	}
+
+	return &event, nil
+}
+
+// CountEvents returns the number of stored events of all clubs.
+func (db *DB) CountEvents(ctx context.Context) (int, error) {
+	const query = `SELECT COUNT(*) FROM events;`
+	var count int
+
+	slog.DebugContext(ctx, "CountEvents", "query", query)
+	if err := db.GetContext(ctx, &count, query); err != nil {
+		return 0, fmt.Errorf("failed count events: %w", err)
+	}
+
+	return count, nil
+}
+
+// CountEventsSince returns the number of stored events of the main club since the given time.
+func (db *DB) CountEventsSince(ctx context.Context, since time.Time) (int, error) {
+	const query = `SELECT COUNT(*) FROM events WHERE timestamp >= ? AND club_id = ?;`
+	var count int
+
+	slog.DebugContext(ctx, "CountEventsSince", "query", query, "since", since)
+	if err := db.GetContext(ctx, &count, query, since.UTC(), DefaultClubID); err != nil {
+		return 0, fmt.Errorf("failed count events since: %w", err)
+	}
+
+	return count, nil
+}
+
+// EventTimeRange returns timestamps of the earliest and the latest stored events of all clubs.
+// Both timestamps are zero if there are no events.
+func (db *DB) EventTimeRange(ctx context.Context) (time.Time, time.Time, error) {
+	// aggregate functions lose the column type, so timestamps are selected as rows to be parsed
+	const (
+		queryMin = `SELECT timestamp FROM events ORDER BY timestamp LIMIT 1;`
+		queryMax = `SELECT timestamp FROM events ORDER BY timestamp DESC LIMIT 1;`
+	)
+	var minTime, maxTime time.Time
+
+	slog.DebugContext(ctx, "EventTimeRange", "queryMin", queryMin, "queryMax", queryMax)
+	if err := db.GetContext(ctx, &minTime, queryMin); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, time.Time{}, nil
+		}
+		return time.Time{}, time.Time{}, fmt.Errorf("select earliest event: %w", err)
+	}
+
+	if err := db.GetContext(ctx, &maxTime, queryMax); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("select latest event: %w", err)
+	}
+
+	return minTime, maxTime, nil
+}
+
+// GetAllEvents retrieves all events of the main club with pagination, ordered from the oldest to the newest.
 func (db *DB) GetAllEvents(ctx context.Context, limit, offset int) ([]Event, error) {
-	const query = `SELECT timestamp, load FROM events ORDER BY timestamp LIMIT ? OFFSET ?;`
+	const query = `SELECT timestamp, club_id, load FROM events WHERE club_id = ? ORDER BY timestamp LIMIT ? OFFSET ?;`
+	return db.getAllEvents(ctx, query, limit, offset)
+}
+
+// GetAllEventsDesc retrieves all events of the main club with pagination, ordered from the newest to the oldest.
+func (db *DB) GetAllEventsDesc(ctx context.Context, limit, offset int) ([]Event, error) {
+	const query = `SELECT timestamp, club_id, load FROM events WHERE club_id = ? ORDER BY timestamp DESC LIMIT ? OFFSET ?;`
+	return db.getAllEvents(ctx, query, limit, offset)
+}
+
+// getAllEvents retrieves events page of the main club by the query with club, limit and offset parameters.
+func (db *DB) getAllEvents(ctx context.Context, query string, limit, offset int) ([]Event, error) {
 	var events []Event
 
 	slog.DebugContext(ctx, "GetAllEvents", "query", query, "limit", limit, "offset", offset)
-	err := db.SelectContext(ctx, &events, query, limit, offset)
+	err := db.SelectContext(ctx, &events, query, DefaultClubID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed select all events: %w", err)
 	}
@@ -105,13 +280,175 @@ func (db *DB) GetAllEvents(ctx context.Context, limit, offset int) ([]Event, err
 	return events, nil
 }
 
+// IterateEvents streams events of the main club from the [start, end) time range ordered by timestamp.
+// The rows are closed when the iteration is finished or stopped.
+func (db *DB) IterateEvents(ctx context.Context, start, end time.Time) iter.Seq2[Event, error] {
+	const query = `SELECT timestamp, club_id, load FROM events
+		WHERE timestamp >= ? AND timestamp < ? AND club_id = ? ORDER BY timestamp;`
+
+	slog.DebugContext(ctx, "IterateEvents", "query", query, "start", start, "end", end)
+	return db.iterateEvents(ctx, query, start.UTC(), end.UTC(), DefaultClubID)
+}
+
+// GetAllEventsIter streams all events of the main club since the given time ordered by timestamp,
+// the zero time means all stored events. The rows are closed when the iteration is finished or stopped.
+func (db *DB) GetAllEventsIter(ctx context.Context, since time.Time) iter.Seq2[Event, error] {
+	const query = `SELECT timestamp, club_id, load FROM events WHERE timestamp >= ? AND club_id = ? ORDER BY timestamp;`
+
+	slog.DebugContext(ctx, "GetAllEventsIter", "query", query, "since", since)
+	return db.iterateEvents(ctx, query, since.UTC(), DefaultClubID)
+}
+
+// iterateEvents streams events selected by the query with arguments.
+func (db *DB) iterateEvents(ctx context.Context, query string, args ...any) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		rows, err := db.QueryxContext(ctx, query, args...)
+		if err != nil {
+			yield(Event{}, fmt.Errorf("failed query events: %w", err))
+			return
+		}
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil {
+				slog.ErrorContext(ctx, "failed to close events rows", "error", closeErr)
+			}
+		}()
+
+		for rows.Next() {
+			var event Event
+			if err = rows.StructScan(&event); err != nil {
+				yield(Event{}, fmt.Errorf("failed scan event: %w", err))
+				return
+			}
+
+			if !yield(event, nil) {
+				return
+			}
+		}
+
+		if err = rows.Err(); err != nil {
+			yield(Event{}, fmt.Errorf("failed iterate events: %w", err))
+		}
+	}
+}
+
+// DeleteAllEvents removes all events of all clubs, daily statistics are kept.
+// It returns the number of removed events.
+func (db *DB) DeleteAllEvents(ctx context.Context) (int64, error) {
+	const query = `DELETE FROM events;`
+	var deleted int64
+
+	err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, query)
+		if err != nil {
+			return fmt.Errorf("delete events: %w", err)
+		}
+
+		if deleted, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("get rows affected for delete events: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("delete all events: %w", err)
+	}
+
+	slog.InfoContext(ctx, "deleted all events", "deleted", deleted)
+	return deleted, nil
+}
+
+// CollapseFlat removes intermediate events of runs where the load stays within tolerance of the run's first event.
+// The first and the last events of every run are kept, so the load shape is preserved.
+// Runs are found for every club separately. It returns the number of removed events.
+func (db *DB) CollapseFlat(ctx context.Context, tolerance uint8) (int64, error) {
+	const (
+		selectQuery = `SELECT rowid, club_id, load FROM events ORDER BY club_id, timestamp;`
+		deleteQuery = `DELETE FROM events WHERE rowid = ?;`
+	)
+	type row struct {
+		ID     int64 `db:"rowid"`
+		ClubID int   `db:"club_id"`
+		Load   uint8 `db:"load"`
+	}
+	var removed int64
+
+	err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		var rows []row
+		if err := tx.SelectContext(ctx, &rows, selectQuery); err != nil {
+			return fmt.Errorf("select events: %w", err)
+		}
+
+		if len(rows) < 3 {
+			return nil
+		}
+
+		stmt, err := tx.PreparexContext(ctx, deleteQuery)
+		if err != nil {
+			return fmt.Errorf("prepare delete: %w", err)
+		}
+		defer func() {
+			if closeErr := stmt.Close(); closeErr != nil {
+				slog.ErrorContext(ctx, "failed to close statement", "error", closeErr)
+			}
+		}()
+
+		start, last := rows[0], rows[0]
+		for _, r := range rows[1:] {
+			if r.ClubID != start.ClubID || max(r.Load, start.Load)-min(r.Load, start.Load) > tolerance {
+				start, last = r, r
+				continue
+			}
+
+			if last.ID != start.ID {
+				// the run continues, so its previous last event is intermediate
+				if _, err = stmt.ExecContext(ctx, last.ID); err != nil {
+					return fmt.Errorf("delete event: %w", err)
+				}
+				removed++
+			}
+			last = r
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("collapse flat events: %w", err)
+	}
+
+	slog.InfoContext(ctx, "collapsed flat events", "tolerance", tolerance, "removed", removed)
+	return removed, nil
+}
+
+// MergeManyEventsTx stores multiple events in the database within a transaction,
+// events with existing timestamps are skipped. It returns the number of inserted events.
+func MergeManyEventsTx(ctx context.Context, tx *sqlx.Tx, events []*Event) (int64, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	const query = `INSERT OR IGNORE INTO events (timestamp, club_id, load) VALUES (:timestamp, :club_id, :load);`
+
+	result, err := tx.NamedExecContext(ctx, query, events)
+	if err != nil {
+		return 0, fmt.Errorf("merge events: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("merge events rows affected: %w", err)
+	}
+
+	return n, nil
+}
+
 // SaveManyEventsTx stores multiple events in the database within a transaction.
 func SaveManyEventsTx(ctx context.Context, tx *sqlx.Tx, events []*Event) error {
 	if len(events) == 0 {
 		return nil
 	}
 
-	const query = `INSERT OR REPLACE INTO events (timestamp, load) VALUES (:timestamp, :load);`
+	const query = `INSERT OR REPLACE INTO events (timestamp, club_id, load) VALUES (:timestamp, :club_id, :load);`
 
 	_, err := tx.NamedExecContext(ctx, query, events)
 	if err != nil {
@@ -2,6 +2,7 @@ package databaser
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -9,11 +10,21 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrHolidayNotFound is returned when a holiday operation fails because the holiday doesn't exist.
+var ErrHolidayNotFound = errors.New("holiday not found")
+
+// Holiday source constants, manual holidays are added by admins and kept on holidays fetching.
+const (
+	HolidayFetched = 0
+	HolidayManual  = 1
+)
+
 // Holiday represents a holiday with a date and title.
 type Holiday struct {
 	Created time.Time `db:"created"`
 	Day     *DateOnly `db:"day"`
 	Title   string    `db:"title"`
+	Source  uint8     `db:"source"`
 }
 
 // LogValue implements slog.LogValuer for Event.
@@ -21,7 +32,8 @@ func (h *Holiday) LogValue() slog.Value {
 	return slog.StringValue(fmt.Sprintf("{date: '%s', title: '%s'}", h.Day.String(), h.Title))
 }
 
-// SaveManyHolidaysTx stores multiple holidays in the database within a transaction.
+// SaveManyHolidaysTx stores multiple fetched holidays in the database within a transaction.
+// Fetched holidays of the years of the given ones are replaced, manual holidays are kept and win over fetched ones.
 func SaveManyHolidaysTx(ctx context.Context, tx *sqlx.Tx, holidays []Holiday) error {
 	if len(holidays) == 0 {
 		return nil
@@ -37,12 +49,14 @@ func SaveManyHolidaysTx(ctx context.Context, tx *sqlx.Tx, holidays []Holiday) er
 		}
 	}
 
+	// inserted holidays are fetched ones, manual holidays of the same days are kept
 	const (
-		queryDelete = `DELETE FROM holidays WHERE day BETWEEN ? AND ?;`
-		queryInsert = `INSERT OR REPLACE INTO holidays (day, title, created) VALUES (:day, :title, :created);`
+		queryDelete = `DELETE FROM holidays WHERE day BETWEEN ? AND ? AND source = ?;`
+		queryInsert = `INSERT INTO holidays (day, title, created, source) VALUES (:day, :title, :created, 0)
+ON CONFLICT (day) DO NOTHING;`
 	)
 
-	resultDelete, err := tx.ExecContext(ctx, queryDelete, minDay.StartOfYear(), maxDay.EndOfYear())
+	resultDelete, err := tx.ExecContext(ctx, queryDelete, minDay.StartOfYear(), maxDay.EndOfYear(), HolidayFetched)
 	if err != nil {
 		return fmt.Errorf("delete existing holidays: %w", err)
 	}
@@ -72,7 +86,7 @@ func SaveManyHolidaysTx(ctx context.Context, tx *sqlx.Tx, holidays []Holiday) er
 func (db *DB) GetHolidays(ctx context.Context, year int, location *time.Location) ([]Holiday, error) {
 	day := DateOnly(time.Date(year, 1, 1, 0, 0, 0, 0, location))
 
-	const query = `SELECT day, title, created FROM holidays WHERE day BETWEEN ? AND ? ORDER BY day;`
+	const query = `SELECT day, title, created, source FROM holidays WHERE day BETWEEN ? AND ? ORDER BY day;`
 	var holidays []Holiday
 
 	slog.DebugContext(ctx, "GetHolidays", "query", query, "start", day.StartOfYear(), "end", day.EndOfYear())
@@ -88,3 +102,42 @@ func (db *DB) GetHolidays(ctx context.Context, year int, location *time.Location
 
 	return holidays, nil
 }
+
+// AddHoliday stores the manual holiday, it replaces a holiday of the same day.
+func (db *DB) AddHoliday(ctx context.Context, holiday Holiday) error {
+	const query = `INSERT INTO holidays (day, title, created, source) VALUES (:day, :title, :created, :source)
+ON CONFLICT (day) DO UPDATE SET title = excluded.title, created = excluded.created, source = excluded.source;`
+
+	holiday.Source = HolidayManual
+	if holiday.Created.IsZero() {
+		holiday.Created = time.Now().UTC()
+	}
+
+	if _, err := db.NamedExecContext(ctx, query, holiday); err != nil {
+		return fmt.Errorf("add holiday: %w", err)
+	}
+
+	slog.InfoContext(ctx, "added holiday", "holiday", &holiday)
+	return nil
+}
+
+// DeleteHoliday removes the holiday of the day, fetched holidays can be restored by the next fetching.
+func (db *DB) DeleteHoliday(ctx context.Context, date *DateOnly) error {
+	const query = `DELETE FROM holidays WHERE day = ?;`
+
+	result, err := db.ExecContext(ctx, query, date)
+	if err != nil {
+		return fmt.Errorf("delete holiday: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("get rows affected for delete holiday: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("delete holiday: %w: day %s", ErrHolidayNotFound, date.String())
+	}
+
+	return nil
+}
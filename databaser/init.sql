@@ -13,8 +13,10 @@ CREATE INDEX IF NOT EXISTS idx_users_approved ON users (status, updated);
 
 CREATE TABLE IF NOT EXISTS events
 (
-    timestamp DATETIME NOT NULL PRIMARY KEY,
-    load      INTEGER  NOT NULL DEFAULT 0
+    timestamp DATETIME NOT NULL,
+    club_id   INTEGER  NOT NULL DEFAULT 0,
+    load      INTEGER  NOT NULL DEFAULT 0,
+    PRIMARY KEY (timestamp, club_id)
 );
 CREATE INDEX IF NOT EXISTS idx_events_load ON events (load);
 
@@ -22,12 +24,45 @@ CREATE TABLE IF NOT EXISTS holidays
 (
     day     DATE         NOT NULL PRIMARY KEY,
     title   VARCHAR(255) NOT NULL,
-    created DATETIME DEFAULT '1970-01-01 00:00:00'
+    created DATETIME DEFAULT '1970-01-01 00:00:00',
+    source  INTEGER      NOT NULL DEFAULT 0
 );
+-- source: 0 - fetched, 1 - manual
 
+CREATE TABLE IF NOT EXISTS daily_stats
+(
+    day      DATE    NOT NULL PRIMARY KEY,
+    min_load INTEGER NOT NULL DEFAULT 0,
+    avg_load REAL    NOT NULL DEFAULT 0,
+    max_load INTEGER NOT NULL DEFAULT 0,
+    count    INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS subscriptions
+(
+    user_id   INTEGER  NOT NULL PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    threshold INTEGER  NOT NULL DEFAULT 0,
+    notified  DATETIME NOT NULL DEFAULT '1970-01-01 00:00:00'
+);
+-- notified: time of the last high load alert sent to the user
+
+CREATE TABLE IF NOT EXISTS predictions
+(
+    target     DATETIME NOT NULL PRIMARY KEY,
+    load       REAL     NOT NULL,
+    confidence REAL     NOT NULL DEFAULT 0,
+    created    DATETIME NOT NULL,
+    actual     INTEGER,
+    error      REAL
+);
+-- target: start of the predicted hour of the main club, actual and error are set by the first event of the hour
 
 -- Migrations
 -- 2025-12-06 14:04:33 UTC
 -- ALTER TABLE holidays ADD COLUMN created DATETIME DEFAULT '1970-01-01 00:00:00';
 --- 2025-12-09 14:07:47
 -- DROP TABLE IF EXISTS users;
+--- 2026-10-17
+-- events: club_id column and PRIMARY KEY (timestamp, club_id), it's applied on start by migrateEventsClub
+--- 2026-10-18
+-- holidays: source column, it's applied on start by migrateHolidaysSource
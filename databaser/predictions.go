@@ -0,0 +1,79 @@
+package databaser
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Prediction is a stored load prediction of the main club for an hour.
+// Actual and Error are set when the first real event of the hour arrives.
+type Prediction struct {
+	Target     time.Time `db:"target"`
+	Created    time.Time `db:"created"`
+	Actual     *uint8    `db:"actual"`
+	Error      *float64  `db:"error"`
+	Load       float64   `db:"load"`
+	Confidence float64   `db:"confidence"`
+}
+
+// SavePrediction stores the prediction for the hour of its target time,
+// an existing prediction of the hour is kept, so the earliest one is compared with the real load.
+func (db *DB) SavePrediction(ctx context.Context, prediction Prediction) error {
+	const query = `INSERT INTO predictions (target, load, confidence, created) VALUES (:target, :load, :confidence, :created)
+ON CONFLICT (target) DO NOTHING;`
+
+	prediction.Target = prediction.Target.UTC().Truncate(time.Hour)
+	if prediction.Created.IsZero() {
+		prediction.Created = time.Now().UTC()
+	}
+
+	if _, err := db.NamedExecContext(ctx, query, prediction); err != nil {
+		return fmt.Errorf("save prediction: %w", err)
+	}
+
+	return nil
+}
+
+// ReconcilePrediction stores the event load and the absolute prediction error for the prediction of the event hour.
+// Only the first event of the hour is compared, it returns false if there is no prediction to reconcile.
+func (db *DB) ReconcilePrediction(ctx context.Context, event Event) (bool, error) {
+	const query = `UPDATE predictions SET actual = ?, error = ABS(load - ?) WHERE target = ? AND actual IS NULL;`
+
+	target := event.Timestamp.UTC().Truncate(time.Hour)
+	result, err := db.ExecContext(ctx, query, event.Load, event.FloatLoad(), target)
+	if err != nil {
+		return false, fmt.Errorf("reconcile prediction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected for reconcile prediction: %w", err)
+	}
+
+	if rowsAffected > 0 {
+		slog.DebugContext(ctx, "reconciled prediction", "target", target, "load", event.Load)
+	}
+	return rowsAffected > 0, nil
+}
+
+// PredictionAccuracy returns the mean absolute error of reconciled predictions
+// with target time within the period before now and the number of them.
+func (db *DB) PredictionAccuracy(ctx context.Context, since time.Duration) (float64, int, error) {
+	const query = `SELECT COALESCE(AVG(error), 0) AS mae, COUNT(*) AS n FROM predictions
+WHERE error IS NOT NULL AND target >= ?;`
+	var row struct {
+		MAE float64 `db:"mae"`
+		N   int     `db:"n"`
+	}
+
+	start := time.Now().UTC().Add(-since)
+	slog.DebugContext(ctx, "PredictionAccuracy", "query", query, "start", start)
+
+	if err := db.GetContext(ctx, &row, query, start); err != nil {
+		return 0, 0, fmt.Errorf("failed prediction accuracy: %w", err)
+	}
+
+	return row.MAE, row.N, nil
+}
@@ -0,0 +1,74 @@
+package databaser
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPredictions(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	mae, n, err := db.PredictionAccuracy(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("PredictionAccuracy() error = %v", err)
+	}
+	if mae != 0 || n != 0 {
+		t.Errorf("PredictionAccuracy() = %v, %d, want 0, 0 without predictions", mae, n)
+	}
+
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	predictions := []Prediction{
+		{Target: hour.Add(10 * time.Minute), Load: 40, Confidence: 0.5},
+		{Target: hour.Add(20 * time.Minute), Load: 90, Confidence: 0.9}, // the same hour, ignored
+		{Target: hour.Add(time.Hour), Load: 20, Confidence: 0.5},
+		{Target: hour.Add(2 * time.Hour), Load: 10, Confidence: 0.5},
+	}
+	for _, p := range predictions {
+		if err = db.SavePrediction(ctx, p); err != nil {
+			t.Fatalf("SavePrediction(%v) error = %v", p.Target, err)
+		}
+	}
+
+	var count int
+	if err = db.GetContext(ctx, &count, `SELECT COUNT(*) FROM predictions;`); err != nil {
+		t.Fatalf("failed to count predictions: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("predictions count = %d, want 3", count)
+	}
+
+	events := []struct {
+		event Event
+		want  bool
+	}{
+		{event: Event{Timestamp: hour.Add(5 * time.Minute), Load: 50}, want: true},
+		{event: Event{Timestamp: hour.Add(15 * time.Minute), Load: 99}, want: false}, // already reconciled
+		{event: Event{Timestamp: hour.Add(time.Hour + time.Minute), Load: 14}, want: true},
+		{event: Event{Timestamp: hour.Add(-time.Hour), Load: 30}, want: false}, // no prediction
+	}
+	for _, e := range events {
+		ok, reconcileErr := db.ReconcilePrediction(ctx, e.event)
+		if reconcileErr != nil {
+			t.Fatalf("ReconcilePrediction(%v) error = %v", e.event.Timestamp, reconcileErr)
+		}
+		if ok != e.want {
+			t.Errorf("ReconcilePrediction(%v) = %v, want %v", e.event.Timestamp, ok, e.want)
+		}
+	}
+
+	mae, n, err = db.PredictionAccuracy(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("PredictionAccuracy() error = %v", err)
+	}
+	// errors: |40-50| = 10, |20-14| = 6
+	if n != 2 || math.Abs(mae-8) > 1e-9 {
+		t.Errorf("PredictionAccuracy() = %v, %d, want 8, 2", mae, n)
+	}
+
+	if _, n, err = db.PredictionAccuracy(ctx, time.Minute); err != nil || n != 0 {
+		t.Errorf("PredictionAccuracy(1m) = %d, %v, want 0 reconciled predictions", n, err)
+	}
+}
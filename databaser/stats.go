@@ -0,0 +1,229 @@
+package databaser
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DailyStats is an aggregate of events load for one day.
+type DailyStats struct {
+	Day   *DateOnly `db:"day"`
+	Avg   float64   `db:"avg_load"`
+	Count int64     `db:"count"`
+	Min   uint8     `db:"min_load"`
+	Max   uint8     `db:"max_load"`
+}
+
+// LogValue implements slog.LogValuer for DailyStats.
+func (s *DailyStats) LogValue() slog.Value {
+	return slog.StringValue(
+		fmt.Sprintf("{day: '%s', min: %d, avg: %.2f, max: %d, count: %d}", s.Day.String(), s.Min, s.Avg, s.Max, s.Count),
+	)
+}
+
+// WeekdayLoad is an average load of events for one weekday.
+type WeekdayLoad struct {
+	Avg     float64
+	Count   int64
+	Weekday time.Weekday
+}
+
+// LoadByWeekday calculates the average load of the main club events from the [start, end) time range
+// by weekdays in the location. Weekdays without events are skipped, the result is ordered from the busiest one,
+// weekdays with equal loads are ordered by the week starting from the first weekday.
+// It takes a time range instead of a period and returns counts, so a weekday without events
+// isn't reported as one with zero load.
+func (db *DB) LoadByWeekday(
+	ctx context.Context, start, end time.Time, location *time.Location, first time.Weekday,
+) ([]WeekdayLoad, error) {
+	const daysInWeek = 7
+	var (
+		sums   [daysInWeek]int64
+		counts [daysInWeek]int64
+	)
+
+	for event, err := range db.IterateEvents(ctx, start, end) {
+		if err != nil {
+			return nil, fmt.Errorf("load by weekday: %w", err)
+		}
+
+		wd := event.Timestamp.In(location).Weekday()
+		sums[wd] += int64(event.Load)
+		counts[wd]++
+	}
+
+	loads := make([]WeekdayLoad, 0, daysInWeek)
+	for i := range daysInWeek {
+		wd := (int(first) + i) % daysInWeek
+		if count := counts[wd]; count > 0 {
+			loads = append(loads, WeekdayLoad{Weekday: time.Weekday(wd), Avg: float64(sums[wd]) / float64(count), Count: count})
+		}
+	}
+
+	slices.SortStableFunc(loads, func(a, b WeekdayLoad) int {
+		return cmp.Compare(b.Avg, a.Avg)
+	})
+	return loads, nil
+}
+
+// GetDailyStats retrieves daily statistics for the [start, end] days range ordered by day.
+func (db *DB) GetDailyStats(ctx context.Context, start, end time.Time) ([]DailyStats, error) {
+	const query = `SELECT day, min_load, avg_load, max_load, count FROM daily_stats WHERE day BETWEEN ? AND ? ORDER BY day;`
+	var stats []DailyStats
+
+	slog.DebugContext(ctx, "GetDailyStats", "query", query, "start", start, "end", end)
+	err := db.SelectContext(ctx, &stats, query, start.Format(time.DateOnly), end.Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed select daily stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// RollupDay calculates statistics of the main club events for the day in the location and saves them.
+// Existing statistics are replaced, the day without events is skipped, so pruned days keep their aggregates.
+func (db *DB) RollupDay(ctx context.Context, day time.Time, location *time.Location) error {
+	const query = `SELECT timestamp, load FROM events WHERE timestamp >= ? AND timestamp < ? AND club_id = ? ORDER BY timestamp;`
+
+	y, m, d := day.In(location).Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, location)
+	end := start.AddDate(0, 0, 1)
+
+	err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		var events []Event
+		if err := tx.SelectContext(ctx, &events, query, start.UTC(), end.UTC(), DefaultClubID); err != nil {
+			return fmt.Errorf("select events: %w", err)
+		}
+
+		return upsertDailyStatsTx(ctx, tx, aggregateDays(events, location))
+	})
+
+	if err != nil {
+		return fmt.Errorf("rollup day %s: %w", start.Format(time.DateOnly), err)
+	}
+
+	slog.DebugContext(ctx, "rolled up day", "day", start.Format(time.DateOnly))
+	return nil
+}
+
+// PruneEvents aggregates events of the main club before the start of the day of before time into daily statistics,
+// and deletes events of all clubs. Only complete days are pruned, so their aggregates are not overwritten later.
+// It returns the number of deleted events.
+func (db *DB) PruneEvents(ctx context.Context, before time.Time, location *time.Location) (int64, error) {
+	const (
+		selectQuery = `SELECT timestamp, load FROM events WHERE timestamp < ? AND club_id = ? ORDER BY timestamp;`
+		deleteQuery = `DELETE FROM events WHERE timestamp < ?;`
+	)
+	var deleted int64
+
+	y, m, d := before.In(location).Date()
+	cutoff := time.Date(y, m, d, 0, 0, 0, 0, location).UTC()
+
+	err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		var events []Event
+		if err := tx.SelectContext(ctx, &events, selectQuery, cutoff, DefaultClubID); err != nil {
+			return fmt.Errorf("select events: %w", err)
+		}
+
+		if err := upsertDailyStatsTx(ctx, tx, aggregateDays(events, location)); err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, deleteQuery, cutoff)
+		if err != nil {
+			return fmt.Errorf("delete events: %w", err)
+		}
+
+		if deleted, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("get rows affected for delete events: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("prune events: %w", err)
+	}
+
+	slog.InfoContext(ctx, "pruned events", "before", cutoff, "deleted", deleted)
+	return deleted, nil
+}
+
+// PruneDailyStats deletes daily statistics for days before the day of before time.
+// It returns the number of deleted rows.
+func (db *DB) PruneDailyStats(ctx context.Context, before time.Time, location *time.Location) (int64, error) {
+	const query = `DELETE FROM daily_stats WHERE day < ?;`
+	day := before.In(location).Format(time.DateOnly)
+
+	result, err := db.ExecContext(ctx, query, day)
+	if err != nil {
+		return 0, fmt.Errorf("delete daily stats: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("get rows affected for delete daily stats: %w", err)
+	}
+
+	slog.InfoContext(ctx, "pruned daily stats", "before", day, "deleted", deleted)
+	return deleted, nil
+}
+
+// aggregateDays groups events by days in the location and calculates their statistics.
+// The events must be ordered by timestamp.
+func aggregateDays(events []Event, location *time.Location) []DailyStats {
+	var (
+		stats []DailyStats
+		sum   float64
+	)
+
+	for i := range events {
+		y, m, d := events[i].Timestamp.In(location).Date()
+		day := DateOnly(time.Date(y, m, d, 0, 0, 0, 0, location))
+		load := events[i].Load
+
+		n := len(stats)
+		if n == 0 || stats[n-1].Day.String() != day.String() {
+			if n > 0 {
+				stats[n-1].Avg = sum / float64(stats[n-1].Count)
+			}
+			stats = append(stats, DailyStats{Day: &day, Min: load, Max: load})
+			sum = 0
+			n++
+		}
+
+		current := &stats[n-1]
+		current.Min = min(current.Min, load)
+		current.Max = max(current.Max, load)
+		current.Count++
+		sum += float64(load)
+	}
+
+	if n := len(stats); n > 0 {
+		stats[n-1].Avg = sum / float64(stats[n-1].Count)
+	}
+
+	return stats
+}
+
+// upsertDailyStatsTx inserts or replaces daily statistics within a transaction.
+func upsertDailyStatsTx(ctx context.Context, tx *sqlx.Tx, stats []DailyStats) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	const query = `INSERT OR REPLACE INTO daily_stats (day, min_load, avg_load, max_load, count) 
+		VALUES (:day, :min_load, :avg_load, :max_load, :count);`
+
+	_, err := tx.NamedExecContext(ctx, query, stats)
+	if err != nil {
+		return fmt.Errorf("upsert daily stats: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,387 @@
+package databaser
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func seedLoads(t *testing.T, db *DB, start time.Time, step time.Duration, loads ...uint8) {
+	t.Helper()
+
+	events := make([]Event, len(loads))
+	for i, load := range loads {
+		events[i] = Event{Timestamp: start.Add(time.Duration(i) * step), Load: load}
+	}
+
+	if err := db.SaveManyEvents(context.Background(), events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+}
+
+func TestAggregateDays(t *testing.T) {
+	location := time.FixedZone("UTC+3", 3*3600)
+	events := []Event{
+		{Timestamp: time.Date(2024, 1, 14, 20, 0, 0, 0, time.UTC), Load: 10}, // 2024-01-14 23:00 local
+		{Timestamp: time.Date(2024, 1, 14, 21, 0, 0, 0, time.UTC), Load: 20}, // 2024-01-15 00:00 local
+		{Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), Load: 60},
+		{Timestamp: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), Load: 40},
+	}
+
+	stats := aggregateDays(events, location)
+	if len(stats) != 2 {
+		t.Fatalf("got %d days, want 2", len(stats))
+	}
+
+	tests := []struct {
+		day   string
+		min   uint8
+		max   uint8
+		avg   float64
+		count int64
+	}{
+		{day: "2024-01-14", min: 10, max: 10, avg: 10, count: 1},
+		{day: "2024-01-15", min: 20, max: 60, avg: 40, count: 3},
+	}
+
+	for i, tt := range tests {
+		s := stats[i]
+		if s.Day.String() != tt.day || s.Min != tt.min || s.Max != tt.max || s.Count != tt.count || math.Abs(s.Avg-tt.avg) > 1e-9 {
+			t.Errorf("stats[%d] = %v, want day=%s min=%d max=%d avg=%v count=%d",
+				i, s.LogValue(), tt.day, tt.min, tt.max, tt.avg, tt.count)
+		}
+	}
+
+	if got := aggregateDays(nil, location); len(got) != 0 {
+		t.Errorf("aggregateDays(nil) = %v, want empty", got)
+	}
+}
+
+func TestLoadByWeekday(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	location := time.FixedZone("UTC+3", 3*3600)
+	monday := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC) // 03:00 local
+
+	seedLoads(t, db, monday, time.Hour, 20, 40)
+	seedLoads(t, db, monday.AddDate(0, 0, 1), time.Hour, 70, 90) // tuesday
+	seedLoads(t, db, monday.AddDate(0, 0, 7), time.Hour, 50)     // next monday
+	// sunday 22:00 UTC is monday 01:00 local
+	seedLoads(t, db, monday.AddDate(0, 0, 6).Add(22*time.Hour), time.Hour, 10)
+	if err := db.SaveEvent(ctx, Event{Timestamp: monday.AddDate(0, 0, 2), ClubID: 2, Load: 100}); err != nil {
+		t.Fatalf("failed to save club event: %v", err)
+	}
+
+	loads, err := db.LoadByWeekday(ctx, monday, monday.AddDate(0, 0, 14), location, time.Monday)
+	if err != nil {
+		t.Fatalf("LoadByWeekday() error = %v", err)
+	}
+
+	want := []WeekdayLoad{
+		{Weekday: time.Tuesday, Avg: 80, Count: 2},
+		{Weekday: time.Monday, Avg: 30, Count: 4},
+	}
+	if len(loads) != len(want) {
+		t.Fatalf("got %d weekdays, want %d: %v", len(loads), len(want), loads)
+	}
+	for i, w := range want {
+		if loads[i].Weekday != w.Weekday || loads[i].Count != w.Count || math.Abs(loads[i].Avg-w.Avg) > 1e-9 {
+			t.Errorf("loads[%d] = %+v, want %+v", i, loads[i], w)
+		}
+	}
+
+	// the range end is excluded
+	loads, err = db.LoadByWeekday(ctx, monday, monday.Add(time.Hour), location, time.Monday)
+	if err != nil {
+		t.Fatalf("LoadByWeekday() error = %v", err)
+	}
+	if len(loads) != 1 || loads[0].Count != 1 || loads[0].Avg != 20 {
+		t.Errorf("LoadByWeekday() of one hour = %+v", loads)
+	}
+}
+
+func TestLoadByWeekday_FirstWeekday(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	saturday := time.Date(2024, 1, 13, 10, 0, 0, 0, time.UTC)
+
+	// equal loads of saturday, sunday and monday
+	for i := range 3 {
+		seedLoads(t, db, saturday.AddDate(0, 0, i), time.Hour, 50)
+	}
+
+	tests := []struct {
+		first time.Weekday
+		want  []time.Weekday
+	}{
+		{first: time.Monday, want: []time.Weekday{time.Monday, time.Saturday, time.Sunday}},
+		{first: time.Sunday, want: []time.Weekday{time.Sunday, time.Monday, time.Saturday}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.first.String(), func(t *testing.T) {
+			loads, err := db.LoadByWeekday(ctx, saturday, saturday.AddDate(0, 0, 3), time.UTC, tt.first)
+			if err != nil {
+				t.Fatalf("LoadByWeekday() error = %v", err)
+			}
+
+			got := make([]time.Weekday, 0, len(loads))
+			for _, load := range loads {
+				got = append(got, load.Weekday)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("weekdays = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadByWeekday_LocalWeekday(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	// monday 2024-01-15 in UTC
+	seedLoads(t, db, time.Date(2024, 1, 15, 2, 0, 0, 0, time.UTC), time.Hour, 80)  // sunday 21:00 in New York
+	seedLoads(t, db, time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), time.Hour, 40) // monday in both zones
+	seedLoads(t, db, time.Date(2024, 1, 15, 20, 0, 0, 0, time.UTC), time.Hour, 10) // tuesday 05:00 in Tokyo
+
+	start, end := time.Date(2024, 1, 14, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		location *time.Location
+		want     map[time.Weekday]float64
+	}{
+		{name: "utc", location: time.UTC, want: map[time.Weekday]float64{time.Monday: 130.0 / 3}},
+		{name: "new york", location: newYork, want: map[time.Weekday]float64{time.Sunday: 80, time.Monday: 25}},
+		{name: "tokyo", location: tokyo, want: map[time.Weekday]float64{time.Monday: 60, time.Tuesday: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loads, loadErr := db.LoadByWeekday(ctx, start, end, tt.location, time.Monday)
+			if loadErr != nil {
+				t.Fatalf("LoadByWeekday() error = %v", loadErr)
+			}
+
+			if len(loads) != len(tt.want) {
+				t.Fatalf("got %d weekdays, want %d: %+v", len(loads), len(tt.want), loads)
+			}
+			for _, load := range loads {
+				if want, ok := tt.want[load.Weekday]; !ok || math.Abs(load.Avg-want) > 1e-9 {
+					t.Errorf("unexpected %s average %v, want %v", load.Weekday, load.Avg, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRollupDay(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	location := time.FixedZone("UTC+3", 3*3600)
+
+	// 2024-01-15 local day is [2024-01-14 21:00, 2024-01-15 21:00) UTC
+	seedLoads(t, db, time.Date(2024, 1, 14, 20, 0, 0, 0, time.UTC), time.Hour, 90, 10, 30, 20)
+	seedLoads(t, db, time.Date(2024, 1, 15, 21, 0, 0, 0, time.UTC), time.Hour, 100)
+
+	day := time.Date(2024, 1, 15, 12, 0, 0, 0, location)
+	check := func(wantMax uint8, wantAvg float64, wantCount int64) {
+		t.Helper()
+
+		stats, err := db.GetDailyStats(ctx, day, day)
+		if err != nil {
+			t.Fatalf("GetDailyStats() error = %v", err)
+		}
+		if len(stats) != 1 {
+			t.Fatalf("got %d daily stats, want 1", len(stats))
+		}
+
+		s := stats[0]
+		if s.Day.String() != "2024-01-15" || s.Min != 10 || s.Max != wantMax || s.Count != wantCount || math.Abs(s.Avg-wantAvg) > 1e-9 {
+			t.Errorf("unexpected daily stats: %v", s.LogValue())
+		}
+	}
+
+	if err := db.RollupDay(ctx, day, location); err != nil {
+		t.Fatalf("RollupDay() error = %v", err)
+	}
+	check(30, 20, 3)
+
+	// repeated rollup upserts the same day
+	if err := db.RollupDay(ctx, day, location); err != nil {
+		t.Fatalf("RollupDay() error = %v", err)
+	}
+	check(30, 20, 3)
+
+	// new events of the day update its statistics
+	seedLoads(t, db, time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), time.Hour, 60)
+	if err := db.RollupDay(ctx, day, location); err != nil {
+		t.Fatalf("RollupDay() error = %v", err)
+	}
+	check(60, 30, 4)
+}
+
+func TestRollupDay_NoEvents(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	seedLoads(t, db, day, time.Hour, 10, 20)
+	if _, err := db.PruneEvents(ctx, day.Add(24*time.Hour), time.UTC); err != nil {
+		t.Fatalf("PruneEvents() error = %v", err)
+	}
+
+	// the pruned day has no raw events, its aggregate must be kept
+	if err := db.RollupDay(ctx, day, time.UTC); err != nil {
+		t.Fatalf("RollupDay() error = %v", err)
+	}
+
+	stats, err := db.GetDailyStats(ctx, day, day)
+	if err != nil {
+		t.Fatalf("GetDailyStats() error = %v", err)
+	}
+	if len(stats) != 1 || stats[0].Count != 2 {
+		t.Errorf("unexpected daily stats: %v", stats)
+	}
+}
+
+func TestPruneEvents(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	day1 := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	seedLoads(t, db, day1, 6*time.Hour, 10, 20, 30, 40)           // 2024-01-15
+	seedLoads(t, db, day1.Add(24*time.Hour), 6*time.Hour, 50, 70) // 2024-01-16
+	seedLoads(t, db, day1.Add(48*time.Hour), 6*time.Hour, 80, 90) // 2024-01-17
+
+	// the day of cutoff is not complete, so only the first two days are pruned
+	deleted, err := db.PruneEvents(ctx, day1.Add(48*time.Hour+time.Hour), time.UTC)
+	if err != nil {
+		t.Fatalf("PruneEvents() error = %v", err)
+	}
+	if deleted != 6 {
+		t.Errorf("PruneEvents() deleted = %d, want 6", deleted)
+	}
+
+	events, err := db.GetAllEvents(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("got %d raw events, want 2", len(events))
+	}
+
+	stats, err := db.GetDailyStats(ctx, day1, day1.Add(72*time.Hour))
+	if err != nil {
+		t.Fatalf("GetDailyStats() error = %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("got %d daily stats, want 2", len(stats))
+	}
+	if s := stats[0]; s.Day.String() != "2024-01-15" || s.Min != 10 || s.Max != 40 || s.Avg != 25 || s.Count != 4 {
+		t.Errorf("unexpected first day stats: %v", s.LogValue())
+	}
+	if s := stats[1]; s.Day.String() != "2024-01-16" || s.Min != 50 || s.Max != 70 || s.Avg != 60 || s.Count != 2 {
+		t.Errorf("unexpected second day stats: %v", s.LogValue())
+	}
+
+	// repeated pruning doesn't change existing aggregates
+	deleted, err = db.PruneEvents(ctx, day1.Add(48*time.Hour+time.Hour), time.UTC)
+	if err != nil {
+		t.Fatalf("PruneEvents() error = %v", err)
+	}
+	if deleted != 0 {
+		t.Errorf("repeated PruneEvents() deleted = %d, want 0", deleted)
+	}
+
+	stats, err = db.GetDailyStats(ctx, day1, day1.Add(72*time.Hour))
+	if err != nil {
+		t.Fatalf("GetDailyStats() error = %v", err)
+	}
+	if len(stats) != 2 || stats[0].Count != 4 {
+		t.Errorf("aggregates changed after repeated pruning: %v", stats)
+	}
+}
+
+func TestPruneEvents_OtherTables(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	seedLoads(t, db, day, 6*time.Hour, 10, 20)
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (id, status, username) VALUES (1, 1, 'user');`); err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO holidays (day, title, created) VALUES ('2024-01-01', 'New Year', ?);`, day); err != nil {
+		t.Fatalf("failed to insert holiday: %v", err)
+	}
+
+	deleted, err := db.PruneEvents(ctx, day.Add(72*time.Hour), time.UTC)
+	if err != nil {
+		t.Fatalf("PruneEvents() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("PruneEvents() deleted = %d, want 2", deleted)
+	}
+
+	for _, table := range []string{"users", "holidays"} {
+		var count int
+		if err = db.GetContext(ctx, &count, `SELECT COUNT(*) FROM `+table+`;`); err != nil {
+			t.Fatalf("failed to count %s: %v", table, err)
+		}
+		if count != 1 {
+			t.Errorf("got %d rows in %s after pruning, want 1", count, table)
+		}
+	}
+}
+
+func TestPruneDailyStats(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	day1 := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	seedLoads(t, db, day1, 24*time.Hour, 10, 20, 30)
+
+	if _, err := db.PruneEvents(ctx, day1.Add(72*time.Hour), time.UTC); err != nil {
+		t.Fatalf("PruneEvents() error = %v", err)
+	}
+
+	deleted, err := db.PruneDailyStats(ctx, day1.Add(48*time.Hour), time.UTC)
+	if err != nil {
+		t.Fatalf("PruneDailyStats() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("PruneDailyStats() deleted = %d, want 2", deleted)
+	}
+
+	stats, err := db.GetDailyStats(ctx, day1, day1.Add(72*time.Hour))
+	if err != nil {
+		t.Fatalf("GetDailyStats() error = %v", err)
+	}
+	if len(stats) != 1 || stats[0].Day.String() != "2024-01-17" {
+		t.Errorf("unexpected daily stats: %v", stats)
+	}
+}
+
+func TestPruneEvents_ClosedDB(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.DB.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
+	}
+
+	if _, err := db.PruneEvents(context.Background(), time.Now(), time.UTC); err == nil {
+		t.Error("expected error for closed database")
+	}
+}
@@ -0,0 +1,72 @@
+package databaser
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Subscription is a user subscription to high load alerts.
+type Subscription struct {
+	Notified  time.Time `db:"notified"`
+	UserID    int64     `db:"user_id"`
+	Threshold uint8     `db:"threshold"`
+}
+
+// SetSubscription subscribes the user to alerts when the load is not less than threshold,
+// an existing subscription is updated keeping its last notification time.
+func (db *DB) SetSubscription(ctx context.Context, userID int64, threshold uint8) error {
+	const query = `INSERT INTO subscriptions (user_id, threshold) VALUES (?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET threshold = excluded.threshold;`
+
+	slog.DebugContext(ctx, "SetSubscription", "query", query, "user", userID, "threshold", threshold)
+	if _, err := db.ExecContext(ctx, query, userID, threshold); err != nil {
+		return fmt.Errorf("upsert subscription: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteSubscription removes the user subscription, it returns false if the user was not subscribed.
+func (db *DB) DeleteSubscription(ctx context.Context, userID int64) (bool, error) {
+	const query = `DELETE FROM subscriptions WHERE user_id = ?;`
+
+	result, err := db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return false, fmt.Errorf("delete subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("get rows affected for delete subscription: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetAlertSubscriptions returns subscriptions of approved users with thresholds not greater than the load.
+func (db *DB) GetAlertSubscriptions(ctx context.Context, load uint8) ([]Subscription, error) {
+	const query = `SELECT s.user_id, s.threshold, s.notified FROM subscriptions AS s
+		INNER JOIN users AS u ON u.id = s.user_id
+		WHERE u.status = ? AND s.threshold <= ? ORDER BY s.user_id;`
+
+	var subscriptions []Subscription
+	slog.DebugContext(ctx, "GetAlertSubscriptions", "query", query, "load", load)
+	if err := db.SelectContext(ctx, &subscriptions, query, UserApproved, load); err != nil {
+		return nil, fmt.Errorf("select alert subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// SetSubscriptionNotified saves the time of the last alert sent to the user.
+func (db *DB) SetSubscriptionNotified(ctx context.Context, userID int64, notified time.Time) error {
+	const query = `UPDATE subscriptions SET notified = ? WHERE user_id = ?;`
+
+	if _, err := db.ExecContext(ctx, query, notified.UTC(), userID); err != nil {
+		return fmt.Errorf("update subscription notified: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package databaser
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSubscriptions(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES
+		(1, ?, 'approved1', '', '', ?, ?),
+		(2, ?, 'approved2', '', '', ?, ?),
+		(3, ?, 'rejected', '', '', ?, ?)`,
+		UserApproved, now, now,
+		UserApproved, now, now,
+		UserRejected, now, now)
+	if err != nil {
+		t.Fatalf("failed to insert users: %v", err)
+	}
+
+	for userID, threshold := range map[int64]uint8{1: 50, 2: 90, 3: 10} {
+		if err = db.SetSubscription(ctx, userID, threshold); err != nil {
+			t.Fatalf("SetSubscription(%d) error = %v", userID, err)
+		}
+	}
+
+	subscriptions, err := db.GetAlertSubscriptions(ctx, 60)
+	if err != nil {
+		t.Fatalf("GetAlertSubscriptions() error = %v", err)
+	}
+	if len(subscriptions) != 1 || subscriptions[0].UserID != 1 || subscriptions[0].Threshold != 50 {
+		t.Fatalf("GetAlertSubscriptions() = %+v, want only user 1 with threshold 50", subscriptions)
+	}
+	if !subscriptions[0].Notified.Before(now) {
+		t.Errorf("new subscription notified = %v, want zero-like time", subscriptions[0].Notified)
+	}
+
+	// the threshold is updated, the notification time is kept
+	if err = db.SetSubscriptionNotified(ctx, 1, now); err != nil {
+		t.Fatalf("SetSubscriptionNotified() error = %v", err)
+	}
+	if err = db.SetSubscription(ctx, 1, 70); err != nil {
+		t.Fatalf("SetSubscription() update error = %v", err)
+	}
+
+	subscriptions, err = db.GetAlertSubscriptions(ctx, 95)
+	if err != nil {
+		t.Fatalf("GetAlertSubscriptions() error = %v", err)
+	}
+	if len(subscriptions) != 2 {
+		t.Fatalf("GetAlertSubscriptions() returned %d, want 2", len(subscriptions))
+	}
+	if s := subscriptions[0]; s.UserID != 1 || s.Threshold != 70 || !s.Notified.Equal(now) {
+		t.Errorf("updated subscription = %+v, want threshold 70 and notified %v", s, now)
+	}
+
+	deleted, err := db.DeleteSubscription(ctx, 2)
+	if err != nil {
+		t.Fatalf("DeleteSubscription() error = %v", err)
+	}
+	if !deleted {
+		t.Error("DeleteSubscription() = false, want true")
+	}
+	if deleted, err = db.DeleteSubscription(ctx, 2); err != nil || deleted {
+		t.Errorf("DeleteSubscription() twice = %v, %v, want false, nil", deleted, err)
+	}
+
+	// subscriptions are deleted with users
+	if err = db.DeleteUser(ctx, 1); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	var count int
+	if err = db.GetContext(ctx, &count, `SELECT COUNT(*) FROM subscriptions;`); err != nil {
+		t.Fatalf("failed to count subscriptions: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("subscriptions count = %d after user deletion, want 1", count)
+	}
+}
@@ -16,9 +16,9 @@ var ErrUserNotFound = errors.New("user not found")
 
 // User status constants.
 const (
-	userPending  = 0
-	userApproved = 1
-	userRejected = 2
+	UserPending  = 0
+	UserApproved = 1
+	UserRejected = 2
 )
 
 // User represents a user in the database.
@@ -41,17 +41,17 @@ func (user *User) String() string {
 
 // IsPending checks if the user is pending.
 func (user *User) IsPending() bool {
-	return user.Status == userPending
+	return user.Status == UserPending
 }
 
 // IsApproved checks if the user is approved.
 func (user *User) IsApproved() bool {
-	return user.Status == userApproved
+	return user.Status == UserApproved
 }
 
 // IsRejected checks if the user is rejected.
 func (user *User) IsRejected() bool {
-	return user.Status == userRejected
+	return user.Status == UserRejected
 }
 
 // LogValue implements slog.LogValuer for User.
@@ -94,7 +94,7 @@ func (db *DB) GetApprovedUsers(ctx context.Context) ([]User, error) {
 	const query = `SELECT id, status, username, first_name, last_name, created, updated FROM users WHERE status = ?;`
 
 	var users []User
-	err := db.SelectContext(ctx, &users, query, userApproved)
+	err := db.SelectContext(ctx, &users, query, UserApproved)
 	if err != nil {
 		return nil, fmt.Errorf("select approved users: %w", err)
 	}
@@ -107,7 +107,7 @@ func (db *DB) GetPendingUsers(ctx context.Context) ([]User, error) {
 	const query = `SELECT id, status, username, first_name, last_name, created, updated FROM users WHERE status = ?;`
 
 	var users []User
-	err := db.SelectContext(ctx, &users, query, userPending)
+	err := db.SelectContext(ctx, &users, query, UserPending)
 	if err != nil {
 		return nil, fmt.Errorf("select pending users: %w", err)
 	}
@@ -115,11 +115,24 @@ func (db *DB) GetPendingUsers(ctx context.Context) ([]User, error) {
 	return users, nil
 }
 
+// GetRejectedUsers retrieves all rejected users from the database.
+func (db *DB) GetRejectedUsers(ctx context.Context) ([]User, error) {
+	const query = `SELECT id, status, username, first_name, last_name, created, updated FROM users WHERE status = ?;`
+
+	var users []User
+	err := db.SelectContext(ctx, &users, query, UserRejected)
+	if err != nil {
+		return nil, fmt.Errorf("select rejected users: %w", err)
+	}
+
+	return users, nil
+}
+
 // ApproveUser sets the approved flag to true for a user by ID.
 func (db *DB) ApproveUser(ctx context.Context, userID int64) error {
 	const query = `UPDATE users SET status = ?, updated = ? WHERE id = ? AND status = ?;`
 
-	result, err := db.ExecContext(ctx, query, userApproved, time.Now().UTC(), userID, userPending)
+	result, err := db.ExecContext(ctx, query, UserApproved, time.Now().UTC(), userID, UserPending)
 	if err != nil {
 		return fmt.Errorf("update user approval: %w", err)
 	}
@@ -136,11 +149,41 @@ func (db *DB) ApproveUser(ctx context.Context, userID int64) error {
 	return nil
 }
 
+// ApproveAllPending approves all pending users in one transaction and returns their IDs.
+func (db *DB) ApproveAllPending(ctx context.Context) ([]int64, error) {
+	const (
+		selectQuery = `SELECT id FROM users WHERE status = ? ORDER BY id;`
+		updateQuery = `UPDATE users SET status = ?, updated = ? WHERE status = ?;`
+	)
+	var ids []int64
+
+	err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		if err := tx.SelectContext(ctx, &ids, selectQuery, UserPending); err != nil {
+			return fmt.Errorf("select pending users: %w", err)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, updateQuery, UserApproved, time.Now().UTC(), UserPending); err != nil {
+			return fmt.Errorf("update pending users: %w", err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("approve all pending users: %w", err)
+	}
+
+	return ids, nil
+}
+
 // RejectUser sets the approved flag to false for a user by ID.
 func (db *DB) RejectUser(ctx context.Context, userID int64) error {
 	const query = `UPDATE users SET status = ?, updated = ? WHERE id = ? AND status != ?;`
 
-	result, err := db.ExecContext(ctx, query, userRejected, time.Now().UTC(), userID, userRejected)
+	result, err := db.ExecContext(ctx, query, UserRejected, time.Now().UTC(), userID, UserRejected)
 	if err != nil {
 		return fmt.Errorf("update user rejection: %w", err)
 	}
@@ -157,6 +200,27 @@ func (db *DB) RejectUser(ctx context.Context, userID int64) error {
 	return nil
 }
 
+// CountUsersByStatus returns the number of users for every status, statuses without users are absent.
+func (db *DB) CountUsersByStatus(ctx context.Context) (map[uint8]int, error) {
+	const query = `SELECT status, COUNT(*) AS count FROM users GROUP BY status;`
+	var rows []struct {
+		Status uint8 `db:"status"`
+		Count  int   `db:"count"`
+	}
+
+	slog.DebugContext(ctx, "CountUsersByStatus", "query", query)
+	if err := db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed count users: %w", err)
+	}
+
+	counts := make(map[uint8]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+}
+
 // DeleteUser removes a user by ID from the database.
 func (db *DB) DeleteUser(ctx context.Context, userID int64) error {
 	const query = `DELETE FROM users WHERE id = ?;`
@@ -178,8 +242,8 @@ func (db *DB) DeleteUser(ctx context.Context, userID int64) error {
 	return nil
 }
 
-// GetOrCreateUser retrieves a user by ID or creates a new one if not found.
-func GetOrCreateUser(ctx context.Context, tx *sqlx.Tx, id int64, username, firstName, lastName string) (*User, error) {
+// GetOrCreateUser retrieves a user by ID or creates a new one if not found, the flag is true if the user is created.
+func GetOrCreateUser(ctx context.Context, tx *sqlx.Tx, id int64, username, firstName, lastName string) (*User, bool, error) {
 	const (
 		queryInsert = `INSERT INTO users (id, status, username, first_name, last_name, created, updated) 
 			VALUES (:id, 0, :username, :first_name, :last_name, :created, :updated);`
@@ -194,17 +258,17 @@ func GetOrCreateUser(ctx context.Context, tx *sqlx.Tx, id int64, username, first
 		if errors.Is(err, sql.ErrNoRows) {
 			slog.DebugContext(ctx, "user not found, creating new", "id", id)
 		} else {
-			return nil, fmt.Errorf("select user: %w", err)
+			return nil, false, fmt.Errorf("select user: %w", err)
 		}
 	} else {
-		return &user, nil
+		return &user, false, nil
 	}
 
 	// create a new user
 	now := time.Now().UTC()
 	user = User{
 		ID:        id,
-		Status:    userPending,
+		Status:    UserPending,
 		Username:  username,
 		FirstName: firstName,
 		LastName:  lastName,
@@ -214,18 +278,18 @@ func GetOrCreateUser(ctx context.Context, tx *sqlx.Tx, id int64, username, first
 
 	result, err := tx.NamedExecContext(ctx, queryInsert, &user)
 	if err != nil {
-		return nil, fmt.Errorf("insert user: %w", err)
+		return nil, false, fmt.Errorf("insert user: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return nil, fmt.Errorf("get rows affected for insert user: %w", err)
+		return nil, false, fmt.Errorf("get rows affected for insert user: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return nil, fmt.Errorf("no user affected with id %d to create", id)
+		return nil, false, fmt.Errorf("no user affected with id %d to create", id)
 	}
 
 	slog.InfoContext(ctx, "created new user", "user", &user)
-	return &user, nil
+	return &user, true, nil
 }
@@ -3,6 +3,7 @@ package databaser
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
@@ -13,7 +14,7 @@ func TestUser_String(t *testing.T) {
 	now := time.Date(2024, 6, 15, 10, 30, 0, 0, time.UTC)
 	user := User{
 		ID:        123,
-		Status:    userApproved,
+		Status:    UserApproved,
 		Username:  "testuser",
 		FirstName: "John",
 		LastName:  "Doe",
@@ -45,21 +46,21 @@ func TestUser_StatusMethods(t *testing.T) {
 	}{
 		{
 			name:         "pending user",
-			status:       userPending,
+			status:       UserPending,
 			wantPending:  true,
 			wantApproved: false,
 			wantRejected: false,
 		},
 		{
 			name:         "approved user",
-			status:       userApproved,
+			status:       UserApproved,
 			wantPending:  false,
 			wantApproved: true,
 			wantRejected: false,
 		},
 		{
 			name:         "rejected user",
-			status:       userRejected,
+			status:       UserRejected,
 			wantPending:  false,
 			wantApproved: false,
 			wantRejected: true,
@@ -109,7 +110,7 @@ func TestGetUser(t *testing.T) {
 	now := time.Now().UTC().Truncate(time.Second)
 	_, err := db.ExecContext(ctx,
 		`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		100, userApproved, "testuser", "Test", "User", now, now)
+		100, UserApproved, "testuser", "Test", "User", now, now)
 	if err != nil {
 		t.Fatalf("failed to insert test user: %v", err)
 	}
@@ -175,10 +176,10 @@ func TestGetUsers(t *testing.T) {
 		status   uint8
 		username string
 	}{
-		{1, userPending, "pending1"},
-		{2, userApproved, "approved1"},
-		{3, userRejected, "rejected1"},
-		{4, userPending, "pending2"},
+		{1, UserPending, "pending1"},
+		{2, UserApproved, "approved1"},
+		{3, UserRejected, "rejected1"},
+		{4, UserPending, "pending2"},
 	}
 
 	for _, u := range testUsers {
@@ -225,10 +226,10 @@ func TestGetApprovedUsers(t *testing.T) {
 		(2, ?, 'approved1', '', '', ?, ?),
 		(3, ?, 'approved2', '', '', ?, ?),
 		(4, ?, 'rejected', '', '', ?, ?)`,
-		userPending, now, now,
-		userApproved, now, now,
-		userApproved, now, now,
-		userRejected, now, now)
+		UserPending, now, now,
+		UserApproved, now, now,
+		UserApproved, now, now,
+		UserRejected, now, now)
 	if err != nil {
 		t.Fatalf("failed to insert users: %v", err)
 	}
@@ -248,6 +249,41 @@ func TestGetApprovedUsers(t *testing.T) {
 	}
 }
 
+func TestCountUsersByStatus(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	counts, err := db.CountUsersByStatus(ctx)
+	if err != nil {
+		t.Fatalf("CountUsersByStatus() on empty db error = %v", err)
+	}
+	if len(counts) != 0 {
+		t.Errorf("CountUsersByStatus() on empty db = %v, want empty", counts)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES
+		(1, ?, 'pending', '', '', ?, ?),
+		(2, ?, 'approved1', '', '', ?, ?),
+		(3, ?, 'approved2', '', '', ?, ?)`,
+		UserPending, now, now,
+		UserApproved, now, now,
+		UserApproved, now, now)
+	if err != nil {
+		t.Fatalf("failed to insert users: %v", err)
+	}
+
+	counts, err = db.CountUsersByStatus(ctx)
+	if err != nil {
+		t.Fatalf("CountUsersByStatus() error = %v", err)
+	}
+	want := map[uint8]int{UserPending: 1, UserApproved: 2}
+	if len(counts) != len(want) || counts[UserPending] != 1 || counts[UserApproved] != 2 || counts[UserRejected] != 0 {
+		t.Errorf("CountUsersByStatus() = %v, want %v", counts, want)
+	}
+}
+
 func TestGetPendingUsers(t *testing.T) {
 	db := newTestDB(t)
 	ctx := context.Background()
@@ -269,10 +305,10 @@ func TestGetPendingUsers(t *testing.T) {
 		(2, ?, 'pending2', '', '', ?, ?),
 		(3, ?, 'approved', '', '', ?, ?),
 		(4, ?, 'rejected', '', '', ?, ?)`,
-		userPending, now, now,
-		userPending, now, now,
-		userApproved, now, now,
-		userRejected, now, now)
+		UserPending, now, now,
+		UserPending, now, now,
+		UserApproved, now, now,
+		UserRejected, now, now)
 	if err != nil {
 		t.Fatalf("failed to insert users: %v", err)
 	}
@@ -292,6 +328,84 @@ func TestGetPendingUsers(t *testing.T) {
 	}
 }
 
+func TestGetRejectedUsers(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES
+		(1, ?, 'pending', '', '', ?, ?),
+		(2, ?, 'approved', '', '', ?, ?),
+		(3, ?, 'rejected', '', '', ?, ?)`,
+		UserPending, now, now,
+		UserApproved, now, now,
+		UserRejected, now, now)
+	if err != nil {
+		t.Fatalf("failed to insert users: %v", err)
+	}
+
+	users, err := db.GetRejectedUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetRejectedUsers() error = %v", err)
+	}
+	if len(users) != 1 || users[0].ID != 3 || !users[0].IsRejected() {
+		t.Errorf("GetRejectedUsers() = %v, want only the rejected user", users)
+	}
+}
+
+func TestApproveAllPending(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	ids, err := db.ApproveAllPending(ctx)
+	if err != nil {
+		t.Fatalf("ApproveAllPending() on empty db error = %v", err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("ApproveAllPending() on empty db = %v, want none", ids)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES
+		(3, ?, 'pending2', '', '', ?, ?),
+		(1, ?, 'pending1', '', '', ?, ?),
+		(2, ?, 'approved', '', '', ?, ?),
+		(4, ?, 'rejected', '', '', ?, ?)`,
+		UserPending, now, now,
+		UserPending, now, now,
+		UserApproved, now, now,
+		UserRejected, now, now)
+	if err != nil {
+		t.Fatalf("failed to insert users: %v", err)
+	}
+
+	ids, err = db.ApproveAllPending(ctx)
+	if err != nil {
+		t.Fatalf("ApproveAllPending() error = %v", err)
+	}
+	if !slices.Equal(ids, []int64{1, 3}) {
+		t.Errorf("ApproveAllPending() = %v, want [1 3]", ids)
+	}
+
+	approved, err := db.GetApprovedUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetApprovedUsers() error = %v", err)
+	}
+	if len(approved) != 3 {
+		t.Errorf("approved users = %d, want 3", len(approved))
+	}
+
+	pending, err := db.GetPendingUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetPendingUsers() error = %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("pending users = %d, want 0", len(pending))
+	}
+}
+
 func TestApproveUser(t *testing.T) {
 	db := newTestDB(t)
 	ctx := context.Background()
@@ -309,7 +423,7 @@ func TestApproveUser(t *testing.T) {
 			setup: func() int64 {
 				_, err := db.ExecContext(ctx,
 					`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, '', '', '', ?, ?)`,
-					1, userPending, now, now)
+					1, UserPending, now, now)
 				if err != nil {
 					t.Fatalf("setup failed: %v", err)
 				}
@@ -330,7 +444,7 @@ func TestApproveUser(t *testing.T) {
 			setup: func() int64 {
 				_, err := db.ExecContext(ctx,
 					`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, '', '', '', ?, ?)`,
-					2, userApproved, now, now)
+					2, UserApproved, now, now)
 				if err != nil {
 					t.Fatalf("setup failed: %v", err)
 				}
@@ -344,7 +458,7 @@ func TestApproveUser(t *testing.T) {
 			setup: func() int64 {
 				_, err := db.ExecContext(ctx,
 					`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, '', '', '', ?, ?)`,
-					3, userRejected, now, now)
+					3, UserRejected, now, now)
 				if err != nil {
 					t.Fatalf("setup failed: %v", err)
 				}
@@ -396,7 +510,7 @@ func TestRejectUser(t *testing.T) {
 			setup: func() int64 {
 				_, err := db.ExecContext(ctx,
 					`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, '', '', '', ?, ?)`,
-					10, userPending, now, now)
+					10, UserPending, now, now)
 				if err != nil {
 					t.Fatalf("setup failed: %v", err)
 				}
@@ -409,7 +523,7 @@ func TestRejectUser(t *testing.T) {
 			setup: func() int64 {
 				_, err := db.ExecContext(ctx,
 					`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, '', '', '', ?, ?)`,
-					11, userApproved, now, now)
+					11, UserApproved, now, now)
 				if err != nil {
 					t.Fatalf("setup failed: %v", err)
 				}
@@ -430,7 +544,7 @@ func TestRejectUser(t *testing.T) {
 			setup: func() int64 {
 				_, err := db.ExecContext(ctx,
 					`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, '', '', '', ?, ?)`,
-					12, userRejected, now, now)
+					12, UserRejected, now, now)
 				if err != nil {
 					t.Fatalf("setup failed: %v", err)
 				}
@@ -482,7 +596,7 @@ func TestDeleteUser(t *testing.T) {
 			setup: func() int64 {
 				_, err := db.ExecContext(ctx,
 					`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, '', '', '', ?, ?)`,
-					20, userPending, now, now)
+					20, UserPending, now, now)
 				if err != nil {
 					t.Fatalf("setup failed: %v", err)
 				}
@@ -528,7 +642,7 @@ func TestDeleteUser_DeleteTwice(t *testing.T) {
 	now := time.Now().UTC().Truncate(time.Second)
 	_, err := db.ExecContext(ctx,
 		`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, '', '', '', ?, ?)`,
-		30, userPending, now, now)
+		30, UserPending, now, now)
 	if err != nil {
 		t.Fatalf("insert failed: %v", err)
 	}
@@ -547,16 +661,23 @@ func TestGetOrCreateUser_CreateNew(t *testing.T) {
 	db := newTestDB(t)
 	ctx := context.Background()
 
-	var user *User
+	var (
+		user    *User
+		created bool
+	)
 	err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
 		var err error
-		user, err = GetOrCreateUser(ctx, tx, 100, "newuser", "New", "User")
+		user, created, err = GetOrCreateUser(ctx, tx, 100, "newuser", "New", "User")
 		return err
 	})
 	if err != nil {
 		t.Fatalf("GetOrCreateUser() error = %v", err)
 	}
 
+	if !created {
+		t.Error("new user should be created")
+	}
+
 	if user.ID != 100 {
 		t.Errorf("user.ID = %d, want 100", user.ID)
 	}
@@ -585,22 +706,29 @@ func TestGetOrCreateUser_GetExisting(t *testing.T) {
 	now := time.Now().UTC().Truncate(time.Second)
 	_, err := db.ExecContext(ctx,
 		`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		200, userApproved, "existinguser", "Existing", "User", now, now)
+		200, UserApproved, "existinguser", "Existing", "User", now, now)
 	if err != nil {
 		t.Fatalf("insert failed: %v", err)
 	}
 
-	var user *User
+	var (
+		user    *User
+		created bool
+	)
 	err = InTransaction(ctx, db, func(tx *sqlx.Tx) error {
 		var txErr error
 		// Call with different data - should return existing user
-		user, txErr = GetOrCreateUser(ctx, tx, 200, "different", "Different", "Name")
+		user, created, txErr = GetOrCreateUser(ctx, tx, 200, "different", "Different", "Name")
 		return txErr
 	})
 	if err != nil {
 		t.Fatalf("GetOrCreateUser() error = %v", err)
 	}
 
+	if created {
+		t.Error("existing user should not be created")
+	}
+
 	// Should return existing user data, not the new parameters
 	if user.Username != "existinguser" {
 		t.Errorf("user.Username = %q, want %q", user.Username, "existinguser")
@@ -613,6 +741,44 @@ func TestGetOrCreateUser_GetExisting(t *testing.T) {
 	}
 }
 
+func TestGetOrCreateUser_CreatedFlag(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	// the first call creates the user, next ones fetch it
+	for i, want := range []bool{true, false, false} {
+		var created bool
+		err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+			var txErr error
+			_, created, txErr = GetOrCreateUser(ctx, tx, 500, "flaguser", "Flag", "User")
+			return txErr
+		})
+		if err != nil {
+			t.Fatalf("call %d GetOrCreateUser() error = %v", i, err)
+		}
+		if created != want {
+			t.Errorf("call %d created = %v, want %v", i, created, want)
+		}
+	}
+
+	// a deleted user is created again
+	if err := db.DeleteUser(ctx, 500); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	var created bool
+	err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+		var txErr error
+		_, created, txErr = GetOrCreateUser(ctx, tx, 500, "flaguser", "Flag", "User")
+		return txErr
+	})
+	if err != nil {
+		t.Fatalf("GetOrCreateUser() after delete error = %v", err)
+	}
+	if !created {
+		t.Error("deleted user should be created again")
+	}
+}
+
 func TestGetOrCreateUser_EmptyFields(t *testing.T) {
 	db := newTestDB(t)
 	ctx := context.Background()
@@ -620,7 +786,7 @@ func TestGetOrCreateUser_EmptyFields(t *testing.T) {
 	var user *User
 	err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
 		var err error
-		user, err = GetOrCreateUser(ctx, tx, 300, "", "", "")
+		user, _, err = GetOrCreateUser(ctx, tx, 300, "", "", "")
 		return err
 	})
 	if err != nil {
@@ -641,7 +807,7 @@ func TestGetOrCreateUser_TransactionRollback(t *testing.T) {
 
 	testErr := errors.New("forced error")
 	err := InTransaction(ctx, db, func(tx *sqlx.Tx) error {
-		_, err := GetOrCreateUser(ctx, tx, 400, "rollbackuser", "Rollback", "User")
+		_, _, err := GetOrCreateUser(ctx, tx, 400, "rollbackuser", "Rollback", "User")
 		if err != nil {
 			return err
 		}
@@ -667,7 +833,7 @@ func TestApproveUser_UpdatesTimestamp(t *testing.T) {
 	oldTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
 	_, err := db.ExecContext(ctx,
 		`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, '', '', '', ?, ?)`,
-		500, userPending, oldTime, oldTime)
+		500, UserPending, oldTime, oldTime)
 	if err != nil {
 		t.Fatalf("insert failed: %v", err)
 	}
@@ -697,7 +863,7 @@ func TestRejectUser_UpdatesTimestamp(t *testing.T) {
 	oldTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
 	_, err := db.ExecContext(ctx,
 		`INSERT INTO users (id, status, username, first_name, last_name, created, updated) VALUES (?, ?, '', '', '', ?, ?)`,
-		600, userPending, oldTime, oldTime)
+		600, UserPending, oldTime, oldTime)
 	if err != nil {
 		t.Fatalf("insert failed: %v", err)
 	}
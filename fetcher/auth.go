@@ -0,0 +1,51 @@
+package fetcher
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Auth kinds of club API requests.
+const (
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+	AuthHeader = "header"
+)
+
+// Auth is the authorization of club API requests. An empty Kind means AuthBearer.
+// Token is the bearer token or the Header value for AuthHeader, Username and Password are used for AuthBasic.
+type Auth struct {
+	Kind     string
+	Header   string
+	Token    string
+	Username string
+	Password string
+}
+
+// Apply sets the authorization header of the request, nothing is set without credentials.
+func (a Auth) Apply(req *http.Request) {
+	switch a.Kind {
+	case AuthBasic:
+		if a.Username != "" {
+			req.SetBasicAuth(a.Username, a.Password)
+		}
+	case AuthHeader:
+		if a.Header != "" && a.Token != "" {
+			req.Header.Set(a.Header, a.Token)
+		}
+	default:
+		if a.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+a.Token)
+		}
+	}
+}
+
+// redact replaces secrets of the authorization in s by "***".
+func (a Auth) redact(s string) string {
+	for _, secret := range []string{a.Token, a.Password} {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, "***")
+		}
+	}
+	return s
+}
@@ -0,0 +1,105 @@
+package fetcher
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// errCircuitOpen is returned if a request is not made because the circuit breaker is open.
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// breakerState is a state of the circuit breaker.
+type breakerState uint8
+
+// Circuit breaker states.
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// String implements the Stringer interface for breakerState.
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	default:
+		return "half-open"
+	}
+}
+
+// breaker is a circuit breaker for upstream requests.
+// It opens after threshold consecutive failures, so requests are skipped during cooldown,
+// then a single request is allowed in the half-open state: its success closes the circuit,
+// and its failure opens it again. It's not thread-safe, the fetcher makes requests sequentially.
+// A nil breaker allows all requests.
+type breaker struct {
+	openedAt  time.Time
+	cooldown  time.Duration
+	club      int
+	threshold int
+	failures  int
+	state     breakerState
+}
+
+// newBreaker returns a circuit breaker of the club requests, or nil if the threshold is not positive.
+func newBreaker(club, threshold int, cooldown time.Duration) *breaker {
+	if threshold < 1 {
+		return nil
+	}
+	return &breaker{club: club, threshold: threshold, cooldown: cooldown}
+}
+
+// allow returns true if a request can be made now.
+func (b *breaker) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+
+	if b.state == breakerOpen {
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(breakerHalfOpen)
+	}
+
+	return true
+}
+
+// success registers a successful request.
+func (b *breaker) success() {
+	if b == nil {
+		return
+	}
+
+	b.failures = 0
+	b.setState(breakerClosed)
+}
+
+// failure registers a failed request.
+func (b *breaker) failure(now time.Time) {
+	if b == nil {
+		return
+	}
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = now
+		b.setState(breakerOpen)
+	}
+}
+
+// setState changes the breaker state and logs the transition.
+func (b *breaker) setState(state breakerState) {
+	if b.state == state {
+		return
+	}
+
+	slog.Warn("circuit breaker state changed",
+		"club", b.club, "from", b.state, "to", state, "failures", b.failures, "cooldown", b.cooldown,
+	)
+	b.state = state
+}
@@ -2,6 +2,7 @@
 package fetcher
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -11,9 +12,11 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/z0rr0/ggp/databaser"
+	"github.com/z0rr0/ggp/metrics"
 )
 
 const (
@@ -21,27 +24,90 @@ const (
 	maxResponseSize = 1 << 20
 	// maxLoadPercent is the maximum valid load percentage.
 	maxLoadPercent uint64 = 100
+	// defaultWorkers is the default max number of concurrent club requests.
+	defaultWorkers = 4
+	// maxResponseTimeSkew is the max difference between the club API time and the server time,
+	// the server time is used for events if the difference is larger.
+	maxResponseTimeSkew = time.Hour
 )
 
 // Club represents the JSON structure of the club data returned by the API.
 type Club struct {
 	Title       string `json:"title"`
 	CurrentLoad string `json:"currentLoad"` //nolint:tagliatelle
+	Timestamp   string `json:"timestamp,omitempty"`
 	ID          int    `json:"id"`
 }
 
+// reading is the club load reported by the API with the optional API time in RFC3339 format.
+type reading struct {
+	timestamp string
+	load      uint8
+}
+
+// Target is an additional club to fetch, its events are stored with the club ID.
+type Target struct {
+	URL string
+	ID  int
+}
+
+// target is a fetched club with its own circuit breaker.
+type target struct {
+	breaker *breaker
+	url     string
+	id      int
+}
+
+// ProbeResult is a result of a single load request made without saving.
+type ProbeResult struct {
+	Status  string // HTTP status, it's empty if there is no response
+	Latency time.Duration
+	Load    uint8
+}
+
 // Fetcher struct holds the configuration for the fetcher.
+// URL is the load source of the main club, Clubs are additional clubs fetched concurrently
+// by at most Workers requests at once, only the main club events are sent to the events channel.
+// If BatchSize is greater than 1, fetched events are buffered and saved together
+// when the buffer is full or BatchTimeout is expired.
+// If BreakerFailures is greater than 0, requests of a club are skipped for BreakerCooldown
+// after this number of consecutive failures, a request retried up to Retries times is one failure.
+// Retries use exponential backoff from RetryBase with jitter, client error responses are not retried.
+// Transform is applied to every fetched load, Auth authorizes club requests.
+// If UseResponseTime is true, events get the time reported by the club API, the server time is used without it.
+// If EventWebhook is set, every fetched event is posted to it as JSON independently of saving.
+// Metrics count results and durations of fetches, they are optional.
+// If SummaryPeriod is greater than 0, every successful fetch is logged at debug level
+// and the number of fetches with the average main club load is logged at info level once per this period.
 type Fetcher struct {
-	Db           *databaser.DB
-	Client       *http.Client
-	URL          string
-	Token        string
-	Timeout      time.Duration
-	QueryTimeout time.Duration
+	Transform       Transform
+	Auth            Auth
+	Db              *databaser.DB
+	Client          *http.Client
+	Metrics         *metrics.Metrics
+	periodCh        chan time.Duration
+	URL             string
+	EventWebhook    string
+	Clubs           []Target
+	targets         []*target
+	schedule        schedule
+	summary         summary
+	Timeout         time.Duration
+	QueryTimeout    time.Duration
+	BatchTimeout    time.Duration
+	BreakerCooldown time.Duration
+	RetryBase       time.Duration
+	SummaryPeriod   time.Duration
+	BatchSize       int
+	BreakerFailures int
+	Workers         int
+	Retries         int
+	UseResponseTime bool
 }
 
-// Run begins the periodic fetching process.
+// Run begins the periodic fetching process, it fails if the initial fetch of all clubs fails.
 func (f *Fetcher) Run(ctx context.Context) (<-chan struct{}, <-chan databaser.Event, error) {
+	f.targets = nil // reset circuit breakers
 	eventCh := make(chan databaser.Event, 1)
 	err := f.Fetch(ctx, eventCh)
 	if err != nil {
@@ -50,24 +116,70 @@ func (f *Fetcher) Run(ctx context.Context) (<-chan struct{}, <-chan databaser.Ev
 	}
 
 	doneCh := make(chan struct{})
+	f.periodCh = make(chan time.Duration, 1)
+	ticker := time.NewTicker(f.Timeout)
+	f.schedule.reset(time.Now(), f.Timeout)
+
 	go func() {
-		ticker := time.NewTicker(f.Timeout)
+		var (
+			buffer    []databaser.Event
+			flushCh   <-chan time.Time
+			summaryCh <-chan time.Time
+		)
+
+		if f.batching() && f.BatchTimeout > 0 {
+			flushTicker := time.NewTicker(f.BatchTimeout)
+			defer flushTicker.Stop()
+			flushCh = flushTicker.C
+		}
+
+		if f.SummaryPeriod > 0 {
+			summaryTicker := time.NewTicker(f.SummaryPeriod)
+			defer summaryTicker.Stop()
+			summaryCh = summaryTicker.C
+		}
+
 		defer func() {
 			ticker.Stop()
+			f.schedule.stop()
+			// save buffered events, ctx is already canceled here
+			buffer = f.flush(context.WithoutCancel(ctx), buffer)
+			if len(buffer) > 0 {
+				slog.Error("buffered events are lost", "count", len(buffer))
+			}
 			close(eventCh)
 			close(doneCh)
 		}()
-		slog.Info("fetcher starting", "period", f.Timeout)
+		slog.Info("fetcher starting",
+			"period", f.Timeout, "clubs", len(f.clubTargets()), "batchSize", f.BatchSize, "batchPeriod", f.BatchTimeout,
+		)
 
 		for {
 			select {
 			case <-ctx.Done():
 				slog.Info("stopping fetcher")
 				return
-			case <-ticker.C:
-				slog.Info("wake up fetcher")
-				if fetchErr := f.Fetch(ctx, eventCh); fetchErr != nil {
-					slog.Error("fetch error", "error", fetchErr)
+			case <-flushCh:
+				buffer = f.flush(ctx, buffer)
+			case <-summaryCh:
+				f.summary.flush(ctx, f.SummaryPeriod)
+			case period := <-f.periodCh:
+				ticker.Reset(period)
+				f.schedule.reset(time.Now(), period)
+				slog.Info("fetcher period changed", "period", period)
+			case tickTime := <-ticker.C:
+				f.schedule.tick(tickTime)
+				slog.Log(ctx, f.successLevel(), "wake up fetcher")
+				if !f.batching() {
+					if fetchErr := f.Fetch(ctx, eventCh); fetchErr != nil {
+						logFetchError(fetchErr)
+					}
+					continue
+				}
+
+				buffer = f.fetchToBuffer(ctx, eventCh, buffer)
+				if len(buffer) >= f.BatchSize {
+					buffer = f.flush(ctx, buffer)
 				}
 			}
 		}
@@ -76,31 +188,347 @@ func (f *Fetcher) Run(ctx context.Context) (<-chan struct{}, <-chan databaser.Ev
 	return doneCh, eventCh, nil
 }
 
-// Fetch retrieves the current load and saves it to the database.
+// SetPeriod changes the fetching period of the running fetcher, it's ignored if the fetcher is not running.
+func (f *Fetcher) SetPeriod(period time.Duration) {
+	select {
+	case f.periodCh <- period:
+	default:
+		slog.Warn("fetcher period is not changed", "period", period)
+	}
+}
+
+// NextFetch returns the ticker state of the running fetcher to know when the next fetch is scheduled.
+// It returns false if the fetcher is not running.
+func (f *Fetcher) NextFetch() (Schedule, bool) {
+	return f.schedule.get()
+}
+
+// Fetch retrieves the current load of all clubs and saves it to the database.
+// The main club event is sent to eventCh. It returns an error only if no club load is fetched,
+// failures of some clubs are logged.
 func (f *Fetcher) Fetch(ctx context.Context, eventCh chan<- databaser.Event) error {
+	start := time.Now()
+	err := f.fetch(ctx, eventCh)
+	f.Metrics.ObserveFetch(time.Since(start), err)
+	return err
+}
+
+// fetch retrieves, saves and sends events of Fetch.
+func (f *Fetcher) fetch(ctx context.Context, eventCh chan<- databaser.Event) error {
+	events, err := f.fetchEvents(ctx)
+	if len(events) == 0 {
+		return err
+	}
+	if err != nil {
+		logFetchError(err)
+	}
+	f.notify(ctx, events)
+
 	ctx, cancel := context.WithTimeout(ctx, f.QueryTimeout)
 	defer cancel()
 
-	load, err := f.getLoad(ctx)
+	if err = f.saveEvents(ctx, events); err != nil {
+		return fmt.Errorf("save events: %w", err)
+	}
+
+	sendMain(eventCh, events)
+	f.logFetched(ctx, events)
+	return nil
+}
+
+// fetchToBuffer retrieves events and appends them to the buffer instead of saving, the main club event is sent to eventCh.
+// The fetch is observed by metrics the same way as Fetch, it fails only if no club load is fetched.
+func (f *Fetcher) fetchToBuffer(ctx context.Context, eventCh chan<- databaser.Event, buffer []databaser.Event) []databaser.Event {
+	start := time.Now()
+	events, err := f.fetchEvents(ctx)
 	if err != nil {
-		return fmt.Errorf("get load: %w", err)
+		logFetchError(err)
 	}
+	if len(events) == 0 {
+		f.Metrics.ObserveFetch(time.Since(start), err)
+		return buffer
+	}
+	f.Metrics.ObserveFetch(time.Since(start), nil)
+
+	f.notify(ctx, events)
 
-	event := databaser.Event{Load: load, Timestamp: time.Now().UTC().Truncate(time.Second)}
-	if err = f.Db.SaveEvent(ctx, event); err != nil {
-		return fmt.Errorf("save event: %w", err)
+	buffer = append(buffer, events...)
+	sendMain(eventCh, events)
+	f.countFetched(events)
+	slog.Log(ctx, f.successLevel(), "fetched to buffer", "events", len(events), "buffered", len(buffer))
+
+	return buffer
+}
+
+// sendMain sends the main club event to the channel if it is fetched.
+func sendMain(eventCh chan<- databaser.Event, events []databaser.Event) {
+	for _, event := range events {
+		if event.ClubID == databaser.DefaultClubID {
+			eventCh <- event
+			return
+		}
+	}
+}
+
+// Probe makes a single load request and returns its result without saving anything.
+// It's used to check the upstream connection and credentials, the token is redacted in the returned error.
+// The load is returned as reported by the upstream API, without the transform.
+func (f *Fetcher) Probe(ctx context.Context) (ProbeResult, error) {
+	var result ProbeResult
+
+	start := time.Now()
+	r, status, err := f.requestLoad(ctx, f.URL)
+	result.Latency, result.Status = time.Since(start), status
+
+	if err != nil {
+		return result, f.redactError(err)
+	}
+
+	result.Load = r.load
+	return result, nil
+}
+
+// RawResult is a raw club API response with the load parsed from it.
+type RawResult struct {
+	Status string // HTTP status, it's empty if there is no response
+	Body   []byte
+	Load   uint8
+}
+
+// Raw makes a single load request like Probe and returns the response body with the parsed load.
+// The body is returned even if the load can't be parsed, so upstream format changes can be seen.
+// Authorization secrets are redacted in the body and the returned error.
+func (f *Fetcher) Raw(ctx context.Context) (RawResult, error) {
+	resp, err := f.request(ctx, f.URL)
+	result := RawResult{Status: resp.status, Body: []byte(f.Auth.redact(string(resp.body)))}
+	if err != nil {
+		return result, f.redactError(err)
 	}
 
-	eventCh <- event
-	slog.Info("fetched", "event", &event)
+	r, err := parseResponse(resp)
+	if err != nil {
+		return result, f.redactError(err)
+	}
+
+	result.Load = r.load
+	return result, nil
+}
+
+// redactError returns an error without the authorization secrets in its message.
+func (f *Fetcher) redactError(err error) error {
+	if msg := f.Auth.redact(err.Error()); msg != err.Error() {
+		return errors.New(msg)
+	}
+	return err
+}
+
+// batching returns true if fetched events should be buffered before saving.
+func (f *Fetcher) batching() bool {
+	return f.BatchSize > 1
+}
+
+// workers returns the max number of concurrent club requests.
+func (f *Fetcher) workers() int {
+	if f.Workers > 0 {
+		return f.Workers
+	}
+	return defaultWorkers
+}
+
+// clubTargets returns the main club and additional clubs to fetch, they are created once with circuit breakers.
+func (f *Fetcher) clubTargets() []*target {
+	if f.targets != nil {
+		return f.targets
+	}
+
+	clubs := append([]Target{{ID: databaser.DefaultClubID, URL: f.URL}}, f.Clubs...)
+	f.targets = make([]*target, 0, len(clubs))
+
+	for _, club := range clubs {
+		f.targets = append(f.targets, &target{
+			breaker: newBreaker(club.ID, f.BreakerFailures, f.BreakerCooldown),
+			url:     club.URL,
+			id:      club.ID,
+		})
+	}
+
+	return f.targets
+}
+
+// logFetchError logs a fetch error, skipped requests of the open circuit are not errors.
+// Joined errors of several clubs are logged separately.
+func logFetchError(err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			logFetchError(e)
+		}
+		return
+	}
+
+	if errors.Is(err, errCircuitOpen) {
+		slog.Debug("fetch skipped", "error", err)
+		return
+	}
+	slog.Error("fetch error", "error", err)
+}
+
+// fetchEvents retrieves the current load of all clubs as new events.
+// It returns fetched events and joined errors of failed clubs.
+func (f *Fetcher) fetchEvents(ctx context.Context) ([]databaser.Event, error) {
+	targets := f.clubTargets()
+	if len(targets) == 1 {
+		event, err := f.fetchEvent(ctx, targets[0])
+		if err != nil {
+			return nil, err
+		}
+		return []databaser.Event{event}, nil
+	}
+
+	var (
+		wg     sync.WaitGroup
+		events = make([]databaser.Event, len(targets))
+		errs   = make([]error, len(targets))
+		sem    = make(chan struct{}, f.workers())
+	)
+
+	for i, t := range targets {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			events[i], errs[i] = f.fetchEvent(ctx, t)
+		})
+	}
+	wg.Wait()
+
+	fetched := make([]databaser.Event, 0, len(targets))
+	for i := range targets {
+		if errs[i] == nil {
+			fetched = append(fetched, events[i])
+		}
+	}
+
+	return fetched, errors.Join(errs...)
+}
+
+// fetchEvent retrieves the current load of the club as a new event.
+// Failed requests are retried, every attempt is limited by QueryTimeout.
+func (f *Fetcher) fetchEvent(ctx context.Context, t *target) (databaser.Event, error) {
+	if !t.breaker.allow(time.Now()) {
+		return databaser.Event{}, fmt.Errorf("club %d: %w", t.id, errCircuitOpen)
+	}
+
+	var r reading
+	err := retry(ctx, f.Retries, f.RetryBase, func() error {
+		attemptCtx, cancel := context.WithTimeout(ctx, f.QueryTimeout)
+		defer cancel()
+
+		var loadErr error
+		r, loadErr = f.getLoad(attemptCtx, t.url)
+		return loadErr
+	})
+	if err != nil {
+		t.breaker.failure(time.Now())
+		return databaser.Event{}, fmt.Errorf("club %d: get load: %w", t.id, err)
+	}
+	t.breaker.success()
+
+	timestamp := time.Now().UTC()
+	if f.UseResponseTime {
+		timestamp = responseTime(r.timestamp, timestamp, t.id)
+	}
+
+	return databaser.Event{Load: r.load, ClubID: t.id, Timestamp: timestamp.Truncate(time.Second)}, nil
+}
+
+// responseTime returns the club API time in UTC, or the server time now if the API time is not set,
+// can't be parsed or differs from now by more than maxResponseTimeSkew.
+func responseTime(value string, now time.Time, clubID int) time.Time {
+	if value == "" {
+		slog.Debug("response time is not set", "club", clubID)
+		return now
+	}
+
+	ts, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		slog.Warn("invalid response time", "club", clubID, "value", value, "error", err)
+		return now
+	}
+
+	if skew := ts.Sub(now).Abs(); skew > maxResponseTimeSkew {
+		slog.Warn("response time is too far from server time", "club", clubID, "value", value, "skew", skew)
+		return now
+	}
+
+	return ts.UTC()
+}
+
+// flush saves buffered events to the database and returns the buffer to reuse.
+// Events are kept in the buffer if they are not saved, so the next flush retries them.
+func (f *Fetcher) flush(ctx context.Context, buffer []databaser.Event) []databaser.Event {
+	if len(buffer) == 0 {
+		return buffer
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, f.QueryTimeout)
+	defer cancel()
+
+	if err := f.saveEvents(ctx, buffer); err != nil {
+		slog.Error("flush events error", "error", err, "count", len(buffer))
+		return buffer
+	}
+
+	slog.Info("flushed events", "count", len(buffer))
+	return buffer[:0]
+}
+
+// saveEvents stores events to the database, existing events are not replaced, duplicates are logged and skipped.
+func (f *Fetcher) saveEvents(ctx context.Context, events []databaser.Event) error {
+	inserted, err := f.Db.MergeManyEvents(ctx, events)
+	if err != nil {
+		return err
+	}
+
+	if skipped := int64(len(events)) - inserted; skipped > 0 {
+		slog.Warn("duplicate events are skipped", "count", skipped)
+	}
 	return nil
 }
 
-// getLoad makes an HTTP request to fetch the current load.
-func (f *Fetcher) getLoad(ctx context.Context) (uint8, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.URL, nil)
+// getLoad makes an HTTP request to fetch the current load from the URL and applies the transform to it.
+func (f *Fetcher) getLoad(ctx context.Context, url string) (reading, error) {
+	r, _, err := f.requestLoad(ctx, url)
+	if err != nil {
+		return reading{}, err
+	}
+	r.load = f.Transform.Apply(r.load)
+	return r, nil
+}
+
+// requestLoad makes an HTTP request to fetch the current load and the optional API time from the URL,
+// it also returns the response status if there is one.
+func (f *Fetcher) requestLoad(ctx context.Context, url string) (reading, string, error) {
+	resp, err := f.request(ctx, url)
 	if err != nil {
-		return 0, fmt.Errorf("create request: %w", err)
+		return reading{}, "", err
+	}
+
+	r, err := parseResponse(resp)
+	return r, resp.status, err
+}
+
+// response is a raw club API response, the body is limited by maxResponseSize.
+type response struct {
+	status      string
+	contentType string
+	body        []byte
+	code        int
+}
+
+// request makes a club API request by url and reads its response.
+func (f *Fetcher) request(ctx context.Context, url string) (response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return response{}, fmt.Errorf("create request: %w", err)
 	}
 
 	req.Header.Set("Accept", "application/json, text/javascript, */*; q=0.01")
@@ -108,7 +536,7 @@ func (f *Fetcher) getLoad(ctx context.Context) (uint8, error) {
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Pragma", "no-cache")
 	req.Header.Set("DNT", "1")
-	req.Header.Set("Authorization", f.Token)
+	f.Auth.Apply(req)
 	req.Header.Set("Referer", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:145.0) Gecko/20100101 Firefox/145.0")
 	req.Header.Set("User-Agent",
 		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/142.0.0.0 Safari/537.36")
@@ -121,7 +549,7 @@ func (f *Fetcher) getLoad(ctx context.Context) (uint8, error) {
 
 	resp, err := f.Client.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("do request: %w", err)
+		return response{}, fmt.Errorf("do request: %w", err)
 	}
 	defer func() {
 		// drain remaining body to allow connection reuse
@@ -134,34 +562,41 @@ func (f *Fetcher) getLoad(ctx context.Context) (uint8, error) {
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("unexpected status: %s", resp.Status)
+	result := response{status: resp.Status, contentType: resp.Header.Get("Content-Type"), code: resp.StatusCode}
+	if result.body, err = io.ReadAll(io.LimitReader(resp.Body, maxResponseSize)); err != nil {
+		return result, fmt.Errorf("read body: %w", err)
+	}
+
+	return result, nil
+}
+
+// parseResponse returns the club load of the successful JSON response.
+func parseResponse(resp response) (reading, error) {
+	if resp.code != http.StatusOK {
+		return reading{}, &statusError{status: resp.status, code: resp.code}
 	}
 
-	ct := resp.Header.Get("Content-Type")
-	if !strings.HasPrefix(ct, "application/json") {
-		return 0, fmt.Errorf("unexpected content-type: %s", ct)
+	if !strings.HasPrefix(resp.contentType, "application/json") {
+		return reading{}, fmt.Errorf("unexpected content-type: %s", resp.contentType)
 	}
 
 	var club Club
-	dec := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize))
-
-	if err = dec.Decode(&club); err != nil {
-		return 0, fmt.Errorf("decode JSON: %w", err)
+	if err := json.NewDecoder(bytes.NewReader(resp.body)).Decode(&club); err != nil {
+		return reading{}, fmt.Errorf("decode JSON: %w", err)
 	}
 
 	if club.CurrentLoad == "" {
-		return 0, errors.New("currentLoad is not set")
+		return reading{}, errors.New("currentLoad is not set")
 	}
 
 	p, err := strconv.ParseUint(strings.TrimRight(club.CurrentLoad, "%"), 10, 8)
 	if err != nil {
-		return 0, fmt.Errorf("parse currentLoad=%q: %w", club.CurrentLoad, err)
+		return reading{}, fmt.Errorf("parse currentLoad=%q: %w", club.CurrentLoad, err)
 	}
 
 	if p > maxLoadPercent {
-		return 0, fmt.Errorf("load %d exceeds maximum %d%%", p, maxLoadPercent)
+		return reading{}, fmt.Errorf("load %d exceeds maximum %d%%", p, maxLoadPercent)
 	}
 
-	return uint8(p), nil
+	return reading{load: uint8(p), timestamp: club.Timestamp}, nil
 }
@@ -1,14 +1,21 @@
 package fetcher
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/z0rr0/ggp/databaser"
+	"github.com/z0rr0/ggp/metrics"
 )
 
 func newTestDB(t *testing.T) *databaser.DB {
@@ -216,19 +223,19 @@ func TestGetLoad(t *testing.T) {
 			f := &Fetcher{
 				Client:       server.Client(),
 				URL:          server.URL,
-				Token:        "test-token",
+				Auth:         Auth{Token: "test-token"},
 				QueryTimeout: 5 * time.Second,
 			}
 
 			ctx := context.Background()
-			load, err := f.getLoad(ctx)
+			load, err := f.getLoad(ctx, f.URL)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getLoad() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if load != tt.wantLoad {
-				t.Errorf("getLoad() = %d, want %d", load, tt.wantLoad)
+			if load.load != tt.wantLoad {
+				t.Errorf("getLoad() = %d, want %d", load.load, tt.wantLoad)
 			}
 		})
 	}
@@ -249,14 +256,14 @@ func TestGetLoad_ContextTimeout(t *testing.T) {
 	f := &Fetcher{
 		Client:       client,
 		URL:          server.URL,
-		Token:        "test-token",
+		Auth:         Auth{Token: "test-token"},
 		QueryTimeout: 5 * time.Second,
 	}
 
 	ctx := context.Background()
 	errCh := make(chan error, 1)
 	go func() {
-		_, err := f.getLoad(ctx)
+		_, err := f.getLoad(ctx, f.URL)
 		errCh <- err
 	}()
 
@@ -282,14 +289,14 @@ func TestGetLoad_ContextCanceled(t *testing.T) {
 	f := &Fetcher{
 		Client:       server.Client(),
 		URL:          server.URL,
-		Token:        "test-token",
+		Auth:         Auth{Token: "test-token"},
 		QueryTimeout: 5 * time.Second,
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately
 
-	_, err := f.getLoad(ctx)
+	_, err := f.getLoad(ctx, f.URL)
 	if err == nil {
 		t.Error("expected context canceled error, got nil")
 	}
@@ -307,7 +314,7 @@ func TestFetch(t *testing.T) {
 		Db:           db,
 		Client:       server.Client(),
 		URL:          server.URL,
-		Token:        "test-token",
+		Auth:         Auth{Token: "test-token"},
 		QueryTimeout: 5 * time.Second,
 	}
 
@@ -332,6 +339,323 @@ func TestFetch(t *testing.T) {
 	}
 }
 
+func TestFetch_UseResponseTime(t *testing.T) {
+	responseTS := time.Now().UTC().Add(-10 * time.Minute).Truncate(time.Second)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, Club{ID: 1, CurrentLoad: "50%", Timestamp: responseTS.Format(time.RFC3339)})
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name            string
+		useResponseTime bool
+	}{
+		{name: "server time", useResponseTime: false},
+		{name: "response time", useResponseTime: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Fetcher{
+				Db:              newTestDB(t),
+				Client:          server.Client(),
+				URL:             server.URL,
+				Auth:            Auth{Token: "test-token"},
+				QueryTimeout:    5 * time.Second,
+				UseResponseTime: tt.useResponseTime,
+			}
+
+			eventCh := make(chan databaser.Event, 1)
+			if err := f.Fetch(context.Background(), eventCh); err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+
+			event := <-eventCh
+			if event.Load != 50 {
+				t.Errorf("expected load 50, got %d", event.Load)
+			}
+			if got := event.Timestamp.Equal(responseTS); got != tt.useResponseTime {
+				t.Errorf("timestamp = %v, response time %v, want equal %v", event.Timestamp, responseTS, tt.useResponseTime)
+			}
+		})
+	}
+}
+
+func TestResponseTime(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		want  time.Time
+		name  string
+		value string
+	}{
+		{name: "empty", value: "", want: now},
+		{name: "invalid", value: "yesterday", want: now},
+		{name: "too early", value: "2025-03-01T10:59:59Z", want: now},
+		{name: "too late", value: "2025-03-01T13:00:01Z", want: now},
+		{name: "valid", value: "2025-03-01T11:45:00Z", want: time.Date(2025, 3, 1, 11, 45, 0, 0, time.UTC)},
+		{name: "valid offset", value: "2025-03-01T15:30:00+03:00", want: time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := responseTime(tt.value, now, 1)
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("responseTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	const token = "secret-token"
+
+	tests := []struct {
+		name       string
+		club       Club
+		statusCode int
+		wantStatus string
+		wantLoad   uint8
+		wantErr    bool
+	}{
+		{
+			name:       "success",
+			club:       Club{ID: 1, Title: "Test", CurrentLoad: "42%"},
+			statusCode: http.StatusOK,
+			wantStatus: "200 OK",
+			wantLoad:   42,
+		},
+		{
+			name:       "http error",
+			statusCode: http.StatusUnauthorized,
+			wantStatus: "401 Unauthorized",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid load with token",
+			club:       Club{ID: 1, Title: "Test", CurrentLoad: token},
+			statusCode: http.StatusOK,
+			wantStatus: "200 OK",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.statusCode != http.StatusOK {
+					w.WriteHeader(tt.statusCode)
+					return
+				}
+				writeJSON(t, w, tt.club)
+			}))
+			defer server.Close()
+
+			// no database, probe must not save anything
+			f := &Fetcher{Client: server.Client(), URL: server.URL, Auth: Auth{Token: token}}
+
+			result, err := f.Probe(context.Background())
+
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", result.Status, tt.wantStatus)
+			}
+			if result.Latency <= 0 {
+				t.Errorf("latency = %v, want positive", result.Latency)
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				if strings.Contains(err.Error(), token) {
+					t.Errorf("error contains token: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Probe() error = %v", err)
+			}
+			if result.Load != tt.wantLoad {
+				t.Errorf("load = %d, want %d", result.Load, tt.wantLoad)
+			}
+		})
+	}
+}
+
+func TestRaw(t *testing.T) {
+	const token = "secret-token"
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		statusCode  int
+		wantBody    string
+		wantStatus  string
+		wantLoad    uint8
+		wantErr     bool
+	}{
+		{
+			name:        "success",
+			body:        `{"id":1,"title":"Test","currentLoad":"42%","extra":[1,2]}`,
+			contentType: "application/json",
+			statusCode:  http.StatusOK,
+			wantBody:    `{"id":1,"title":"Test","currentLoad":"42%","extra":[1,2]}`,
+			wantStatus:  "200 OK",
+			wantLoad:    42,
+		},
+		{
+			name:        "changed format",
+			body:        `{"id":1,"load":{"current":42}}`,
+			contentType: "application/json",
+			statusCode:  http.StatusOK,
+			wantBody:    `{"id":1,"load":{"current":42}}`,
+			wantStatus:  "200 OK",
+			wantErr:     true,
+		},
+		{
+			name:        "error with auth echo",
+			body:        `{"error":"invalid token secret-token"}`,
+			contentType: "application/json",
+			statusCode:  http.StatusUnauthorized,
+			wantBody:    `{"error":"invalid token ***"}`,
+			wantStatus:  "401 Unauthorized",
+			wantErr:     true,
+		},
+		{
+			name:        "html",
+			body:        "<html>maintenance</html>",
+			contentType: "text/html",
+			statusCode:  http.StatusOK,
+			wantBody:    "<html>maintenance</html>",
+			wantStatus:  "200 OK",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.statusCode)
+				if _, err := w.Write([]byte(tt.body)); err != nil {
+					t.Errorf("failed to write response: %v", err)
+				}
+			}))
+			defer server.Close()
+
+			// no database, raw request must not save anything
+			f := &Fetcher{Client: server.Client(), URL: server.URL, Auth: Auth{Token: token}}
+
+			result, err := f.Raw(context.Background())
+
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", result.Status, tt.wantStatus)
+			}
+			if string(result.Body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", result.Body, tt.wantBody)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Raw() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result.Load != tt.wantLoad {
+				t.Errorf("load = %d, want %d", result.Load, tt.wantLoad)
+			}
+		})
+	}
+}
+
+func TestFetch_Metrics(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writeJSON(t, w, Club{ID: 1, CurrentLoad: "42%"})
+	}))
+	defer server.Close()
+
+	m := metrics.New()
+	f := &Fetcher{
+		Db:           newTestDB(t),
+		Client:       server.Client(),
+		URL:          server.URL,
+		Auth:         Auth{Token: "test-token"},
+		QueryTimeout: 5 * time.Second,
+		Metrics:      m,
+	}
+
+	eventCh := make(chan databaser.Event, 2)
+	ctx := context.Background()
+
+	for range 2 {
+		if err := f.Fetch(ctx, eventCh); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+
+	fail.Store(true)
+	if err := f.Fetch(ctx, eventCh); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if got := m.Fetches(metrics.FetchSuccess); got != 2 {
+		t.Errorf("success fetches = %v, want 2", got)
+	}
+	if got := m.Fetches(metrics.FetchFailure); got != 1 {
+		t.Errorf("failed fetches = %v, want 1", got)
+	}
+	if got := m.FetchDurations(); got != 3 {
+		t.Errorf("fetch durations = %d, want 3", got)
+	}
+}
+
+func TestFetchToBuffer_Metrics(t *testing.T) {
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writeJSON(t, w, Club{ID: 1, CurrentLoad: "42%"})
+	}))
+	defer server.Close()
+
+	m := metrics.New()
+	f := &Fetcher{
+		Db:           newTestDB(t),
+		Client:       server.Client(),
+		URL:          server.URL,
+		Auth:         Auth{Token: "test-token"},
+		QueryTimeout: 5 * time.Second,
+		BatchSize:    10,
+		Metrics:      m,
+	}
+
+	eventCh := make(chan databaser.Event, 1)
+	ctx := context.Background()
+
+	buffer := f.fetchToBuffer(ctx, eventCh, nil)
+	fail.Store(true)
+	buffer = f.fetchToBuffer(ctx, eventCh, buffer)
+
+	if len(buffer) != 1 {
+		t.Errorf("buffered %d events, want 1", len(buffer))
+	}
+	if got := m.Fetches(metrics.FetchSuccess); got != 1 {
+		t.Errorf("success fetches = %v, want 1", got)
+	}
+	if got := m.Fetches(metrics.FetchFailure); got != 1 {
+		t.Errorf("failed fetches = %v, want 1", got)
+	}
+	if got := m.FetchDurations(); got != 2 {
+		t.Errorf("fetch durations = %d, want 2", got)
+	}
+}
+
 func TestFetch_HTTPError(t *testing.T) {
 	db := newTestDB(t)
 
@@ -344,7 +668,7 @@ func TestFetch_HTTPError(t *testing.T) {
 		Db:           db,
 		Client:       server.Client(),
 		URL:          server.URL,
-		Token:        "test-token",
+		Auth:         Auth{Token: "test-token"},
 		QueryTimeout: 5 * time.Second,
 	}
 
@@ -378,7 +702,7 @@ func TestRun(t *testing.T) {
 		Db:           db,
 		Client:       server.Client(),
 		URL:          server.URL,
-		Token:        "test-token",
+		Auth:         Auth{Token: "test-token"},
 		Timeout:      50 * time.Millisecond,
 		QueryTimeout: 5 * time.Second,
 	}
@@ -405,6 +729,174 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRun_SetPeriod(t *testing.T) {
+	db := newTestDB(t)
+
+	var requestCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount.Add(1)
+		writeJSON(t, w, Club{ID: 1, Title: "Test", CurrentLoad: "50%"})
+	}))
+	defer server.Close()
+
+	f := &Fetcher{
+		Db:           db,
+		Client:       server.Client(),
+		URL:          server.URL,
+		Auth:         Auth{Token: "test-token"},
+		Timeout:      time.Hour,
+		QueryTimeout: 5 * time.Second,
+	}
+
+	// not running fetcher ignores the period change
+	f.SetPeriod(time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	doneCh, eventCh, err := f.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	f.SetPeriod(20 * time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	drainEvents(eventCh)
+	<-doneCh
+
+	if n := requestCount.Load(); n < 2 {
+		t.Errorf("expected requests after the period change, got %d", n)
+	}
+}
+
+// captureLogs replaces the default logger by a debug level text logger of the returned buffer until the test end.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	logger := slog.Default()
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(logger) })
+
+	return buf
+}
+
+// logLines returns lines of the buffer with the level and the message.
+func logLines(buf *bytes.Buffer, level, msg string) []string {
+	// the text handler quotes messages with spaces
+	if strings.Contains(msg, " ") {
+		msg = strconv.Quote(msg)
+	}
+	prefix := "level=" + level + " msg=" + msg + " "
+
+	var lines []string
+	for line := range strings.SplitSeq(buf.String(), "\n") {
+		if _, after, ok := strings.Cut(line, " "); ok && strings.HasPrefix(after, prefix) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestRun_Summary(t *testing.T) {
+	tests := []struct {
+		name          string
+		summaryPeriod time.Duration
+		successLevel  string
+	}{
+		{name: "disabled", successLevel: "INFO"},
+		{name: "enabled", summaryPeriod: 60 * time.Millisecond, successLevel: "DEBUG"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(t, w, Club{ID: 1, Title: "Test", CurrentLoad: "50%"})
+			}))
+			defer server.Close()
+
+			f := &Fetcher{
+				Db:            db,
+				Client:        server.Client(),
+				URL:           server.URL,
+				Auth:          Auth{Token: "test-token"},
+				Timeout:       20 * time.Millisecond,
+				QueryTimeout:  5 * time.Second,
+				SummaryPeriod: tt.summaryPeriod,
+			}
+			buf := captureLogs(t)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			doneCh, eventCh, err := f.Run(ctx)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			// events are not checked, but the channel is read to not block the fetcher
+			go func() {
+				for range eventCh {
+					continue
+				}
+			}()
+
+			time.Sleep(150 * time.Millisecond)
+			cancel()
+			<-doneCh
+
+			if lines := logLines(buf, tt.successLevel, "fetched"); len(lines) < 2 {
+				t.Errorf("fetched lines at %s = %d, want every fetch", tt.successLevel, len(lines))
+			}
+
+			summaries := logLines(buf, "INFO", "fetch summary")
+			if tt.summaryPeriod == 0 {
+				if len(summaries) != 0 {
+					t.Errorf("summaries = %v, want none", summaries)
+				}
+				return
+			}
+
+			if lines := logLines(buf, "INFO", "fetched"); len(lines) != 0 {
+				t.Errorf("fetched lines at INFO = %v, want none", lines)
+			}
+			if lines := logLines(buf, "INFO", "wake up fetcher"); len(lines) != 0 {
+				t.Errorf("wake up lines at INFO = %v, want none", lines)
+			}
+			if len(summaries) == 0 {
+				t.Fatal("summary is not logged")
+			}
+			if !strings.Contains(summaries[0], "avgLoad=50") || strings.Contains(summaries[0], "fetches=0") {
+				t.Errorf("summary = %q, want fetches with average load 50", summaries[0])
+			}
+		})
+	}
+}
+
+func TestSummary_Flush(t *testing.T) {
+	buf := captureLogs(t)
+	var s summary
+
+	s.add([]databaser.Event{{Load: 40}, {ClubID: 2, Load: 90}})
+	s.add([]databaser.Event{{Load: 60}})
+	s.flush(context.Background(), time.Hour)
+
+	lines := logLines(buf, "INFO", "fetch summary")
+	if len(lines) != 1 {
+		t.Fatalf("summary lines = %v, want one", lines)
+	}
+	for _, want := range []string{"period=1h0m0s", "fetches=2", "events=3", "avgLoad=50"} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("summary %q should contain %q", lines[0], want)
+		}
+	}
+
+	// counts are reset after the summary
+	buf.Reset()
+	s.flush(context.Background(), time.Hour)
+	if lines = logLines(buf, "INFO", "fetch summary"); len(lines) != 1 || !strings.Contains(lines[0], "fetches=0 events=0 avgLoad=0") {
+		t.Errorf("empty summary = %v, want zero counts", lines)
+	}
+}
+
 func TestRun_InitialFetchError(t *testing.T) {
 	db := newTestDB(t)
 
@@ -417,7 +909,7 @@ func TestRun_InitialFetchError(t *testing.T) {
 		Db:           db,
 		Client:       server.Client(),
 		URL:          server.URL,
-		Token:        "test-token",
+		Auth:         Auth{Token: "test-token"},
 		Timeout:      time.Second,
 		QueryTimeout: 5 * time.Second,
 	}
@@ -449,7 +941,7 @@ func TestRun_ContextCancellation(t *testing.T) {
 		Db:           db,
 		Client:       server.Client(),
 		URL:          server.URL,
-		Token:        "test-token",
+		Auth:         Auth{Token: "test-token"},
 		Timeout:      time.Hour, // Long timeout to ensure cancellation works
 		QueryTimeout: 5 * time.Second,
 	}
@@ -488,7 +980,7 @@ func TestRun_ChannelsClosed(t *testing.T) {
 		Db:           db,
 		Client:       server.Client(),
 		URL:          server.URL,
-		Token:        "test-token",
+		Auth:         Auth{Token: "test-token"},
 		Timeout:      50 * time.Millisecond,
 		QueryTimeout: 5 * time.Second,
 	}
@@ -518,11 +1010,13 @@ func TestRun_ChannelsClosed(t *testing.T) {
 	}
 }
 
-func TestFetch_EventTimestamp(t *testing.T) {
+func TestRun_BatchFlushOnCancel(t *testing.T) {
 	db := newTestDB(t)
 
+	var requests atomic.Int32
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		writeJSON(t, w, Club{ID: 1, CurrentLoad: "55%"})
+		load := requests.Add(1) % 100
+		writeJSON(t, w, Club{ID: 1, CurrentLoad: strconv.Itoa(int(load)) + "%"})
 	}))
 	defer server.Close()
 
@@ -530,34 +1024,144 @@ func TestFetch_EventTimestamp(t *testing.T) {
 		Db:           db,
 		Client:       server.Client(),
 		URL:          server.URL,
-		Token:        "test-token",
+		Auth:         Auth{Token: "test-token"},
+		Timeout:      10 * time.Millisecond,
 		QueryTimeout: 5 * time.Second,
+		BatchSize:    1000, // never full during the test
 	}
 
-	eventCh := make(chan databaser.Event, 1)
-	ctx := context.Background()
-
-	before := time.Now().UTC().Truncate(time.Second)
-	err := f.Fetch(ctx, eventCh)
-	after := time.Now().UTC().Truncate(time.Second).Add(time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
 
+	doneCh, eventCh, err := f.Run(ctx)
 	if err != nil {
-		t.Fatalf("Fetch() error = %v", err)
+		t.Fatalf("Run() error = %v", err)
 	}
 
-	event := <-eventCh
+	// initial event is saved immediately, the next ones are buffered,
+	// events with the same timestamp (truncated to seconds) are skipped, the first one is kept
+	loads := make(map[time.Time]uint8)
+	keepFirst := func(event databaser.Event) {
+		if _, ok := loads[event.Timestamp.UTC()]; !ok {
+			loads[event.Timestamp.UTC()] = event.Load
+		}
+	}
+	for range 3 {
+		keepFirst(<-eventCh)
+	}
 
-	if event.Timestamp.Before(before) || event.Timestamp.After(after) {
-		t.Errorf("timestamp %v not in expected range [%v, %v]", event.Timestamp, before, after)
+	cancel()
+	for event := range eventCh {
+		keepFirst(event)
 	}
+	<-doneCh
 
-	// Verify timestamp is truncated to seconds (no nanoseconds)
-	if event.Timestamp.Nanosecond() != 0 {
-		t.Errorf("timestamp should be truncated to seconds, got nanosecond=%d", event.Timestamp.Nanosecond())
+	events, err := db.GetAllEvents(context.Background(), 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+
+	if len(events) != len(loads) {
+		t.Errorf("saved %d events, want %d", len(events), len(loads))
+	}
+
+	for _, event := range events {
+		if want := loads[event.Timestamp.UTC()]; event.Load != want {
+			t.Errorf("event %v load = %d, want %d", event.Timestamp, event.Load, want)
+		}
 	}
 }
 
-func TestClubJSONUnmarshal(t *testing.T) {
+func TestFlush(t *testing.T) {
+	db := newTestDB(t)
+	f := &Fetcher{Db: db, QueryTimeout: 5 * time.Second, BatchSize: 10}
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	buffer := []databaser.Event{
+		{Timestamp: now.Add(-time.Minute), Load: 10},
+		{Timestamp: now, Load: 20},
+	}
+
+	buffer = f.flush(ctx, buffer)
+	if len(buffer) != 0 {
+		t.Errorf("buffer length = %d after flush, want 0", len(buffer))
+	}
+
+	events, err := db.GetAllEvents(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("saved %d events, want 2", len(events))
+	}
+
+	// duplicates don't replace existing events
+	buffer = f.flush(ctx, []databaser.Event{{Timestamp: now, Load: 50}})
+	if len(buffer) != 0 {
+		t.Errorf("buffer length = %d after duplicate flush, want 0", len(buffer))
+	}
+
+	events, err = db.GetAllEvents(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+	for _, event := range events {
+		if event.Timestamp.Equal(now) && event.Load != 20 {
+			t.Errorf("duplicate event load = %d, want 20", event.Load)
+		}
+	}
+
+	// failed flush keeps events to retry
+	if err = db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	buffer = f.flush(ctx, []databaser.Event{{Timestamp: now.Add(time.Minute), Load: 30}})
+	if len(buffer) != 1 {
+		t.Errorf("buffer length = %d after failed flush, want 1", len(buffer))
+	}
+}
+
+func TestFetch_EventTimestamp(t *testing.T) {
+	db := newTestDB(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, Club{ID: 1, CurrentLoad: "55%"})
+	}))
+	defer server.Close()
+
+	f := &Fetcher{
+		Db:           db,
+		Client:       server.Client(),
+		URL:          server.URL,
+		Auth:         Auth{Token: "test-token"},
+		QueryTimeout: 5 * time.Second,
+	}
+
+	eventCh := make(chan databaser.Event, 1)
+	ctx := context.Background()
+
+	before := time.Now().UTC().Truncate(time.Second)
+	err := f.Fetch(ctx, eventCh)
+	after := time.Now().UTC().Truncate(time.Second).Add(time.Second)
+
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	event := <-eventCh
+
+	if event.Timestamp.Before(before) || event.Timestamp.After(after) {
+		t.Errorf("timestamp %v not in expected range [%v, %v]", event.Timestamp, before, after)
+	}
+
+	// Verify timestamp is truncated to seconds (no nanoseconds)
+	if event.Timestamp.Nanosecond() != 0 {
+		t.Errorf("timestamp should be truncated to seconds, got nanosecond=%d", event.Timestamp.Nanosecond())
+	}
+}
+
+func TestClubJSONUnmarshal(t *testing.T) {
 	tests := []struct {
 		name     string
 		json     string
@@ -611,12 +1215,12 @@ func TestGetLoad_RequestHeaders(t *testing.T) {
 	f := &Fetcher{
 		Client:       server.Client(),
 		URL:          server.URL,
-		Token:        "Bearer secret-token",
+		Auth:         Auth{Token: "secret-token"},
 		QueryTimeout: 5 * time.Second,
 	}
 
 	ctx := context.Background()
-	_, err := f.getLoad(ctx)
+	_, err := f.getLoad(ctx, f.URL)
 	if err != nil {
 		t.Fatalf("getLoad() error = %v", err)
 	}
@@ -636,6 +1240,84 @@ func TestGetLoad_RequestHeaders(t *testing.T) {
 	}
 }
 
+func TestGetLoad_Auth(t *testing.T) {
+	tests := []struct {
+		name        string
+		auth        Auth
+		wantHeaders map[string]string
+		wantBasic   bool
+	}{
+		{
+			name:        "default bearer",
+			auth:        Auth{Token: "secret-token"},
+			wantHeaders: map[string]string{"Authorization": "Bearer secret-token", "X-API-Key": ""},
+		},
+		{
+			name:        "bearer",
+			auth:        Auth{Kind: AuthBearer, Token: "secret-token", Username: "user"},
+			wantHeaders: map[string]string{"Authorization": "Bearer secret-token"},
+		},
+		{
+			name:      "basic",
+			auth:      Auth{Kind: AuthBasic, Token: "unused", Username: "user", Password: "pass"},
+			wantBasic: true,
+		},
+		{
+			name:        "header",
+			auth:        Auth{Kind: AuthHeader, Header: "X-API-Key", Token: "api-key"},
+			wantHeaders: map[string]string{"X-API-Key": "api-key", "Authorization": ""},
+		},
+		{
+			name:        "no credentials",
+			wantHeaders: map[string]string{"Authorization": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured *http.Request
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				captured = r.Clone(context.Background())
+				writeJSON(t, w, Club{ID: 1, CurrentLoad: "50%"})
+			}))
+			defer server.Close()
+
+			f := &Fetcher{Client: server.Client(), URL: server.URL, Auth: tt.auth, QueryTimeout: 5 * time.Second}
+			if _, err := f.getLoad(context.Background(), f.URL); err != nil {
+				t.Fatalf("getLoad() error = %v", err)
+			}
+
+			for header, want := range tt.wantHeaders {
+				if got := captured.Header.Get(header); got != want {
+					t.Errorf("header %s = %q, want %q", header, got, want)
+				}
+			}
+
+			username, password, ok := captured.BasicAuth()
+			if ok != tt.wantBasic {
+				t.Fatalf("basic auth = %v, want %v", ok, tt.wantBasic)
+			}
+			if ok && (username != tt.auth.Username || password != tt.auth.Password) {
+				t.Errorf("basic auth = %q:%q, want %q:%q", username, password, tt.auth.Username, tt.auth.Password)
+			}
+		})
+	}
+}
+
+func TestRedactError_Auth(t *testing.T) {
+	f := &Fetcher{Auth: Auth{Kind: AuthBasic, Username: "user", Password: "pass-secret"}}
+
+	err := f.redactError(errors.New("bad response: pass-secret"))
+	if strings.Contains(err.Error(), "pass-secret") {
+		t.Errorf("error contains password: %v", err)
+	}
+
+	err = errors.New("plain error")
+	if got := f.redactError(err); got != err {
+		t.Errorf("redactError() = %v, want the same error", got)
+	}
+}
+
 func drainEvents(ch <-chan databaser.Event) {
 	for {
 		select {
@@ -648,3 +1330,631 @@ func drainEvents(ch <-chan databaser.Event) {
 		}
 	}
 }
+
+func TestFetch_CircuitBreaker(t *testing.T) {
+	db := newTestDB(t)
+	var (
+		requests atomic.Int32
+		failing  atomic.Bool
+	)
+	failing.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(t, w, Club{ID: 1, Title: "Test", CurrentLoad: "42%"})
+	}))
+	defer server.Close()
+
+	const cooldown = 50 * time.Millisecond
+	f := &Fetcher{
+		Db:              db,
+		Client:          server.Client(),
+		URL:             server.URL,
+		Auth:            Auth{Token: "test-token"},
+		QueryTimeout:    5 * time.Second,
+		BreakerFailures: 2,
+		BreakerCooldown: cooldown,
+	}
+	eventCh := make(chan databaser.Event, 1)
+	ctx := context.Background()
+	cb := f.clubTargets()[0].breaker
+
+	for i := range 2 {
+		if err := f.Fetch(ctx, eventCh); err == nil || errors.Is(err, errCircuitOpen) {
+			t.Fatalf("fetch %d: expected request error, got %v", i, err)
+		}
+	}
+	if cb.state != breakerOpen {
+		t.Fatalf("expected open state, got %s", cb.state)
+	}
+
+	if err := f.Fetch(ctx, eventCh); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected errCircuitOpen, got %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected 2 requests while open, got %d", n)
+	}
+
+	time.Sleep(cooldown + 10*time.Millisecond)
+	failing.Store(false)
+
+	if err := f.Fetch(ctx, eventCh); err != nil {
+		t.Fatalf("half-open probe error = %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("expected 3 requests after probe, got %d", n)
+	}
+	if cb.state != breakerClosed {
+		t.Errorf("expected closed state, got %s", cb.state)
+	}
+	if cb.failures != 0 {
+		t.Errorf("expected reset failures, got %d", cb.failures)
+	}
+
+	select {
+	case event := <-eventCh:
+		if event.Load != 42 {
+			t.Errorf("expected load 42, got %d", event.Load)
+		}
+	default:
+		t.Error("expected event on channel")
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newBreaker(0, 3, time.Minute)
+
+	for i := range 3 {
+		if !b.allow(now) {
+			t.Fatalf("request %d is not allowed in closed state", i)
+		}
+		b.failure(now)
+	}
+	if b.state != breakerOpen {
+		t.Fatalf("expected open state, got %s", b.state)
+	}
+	if b.allow(now.Add(30 * time.Second)) {
+		t.Error("request is allowed during cooldown")
+	}
+
+	// a failed probe opens the circuit again
+	probe := now.Add(time.Minute)
+	if !b.allow(probe) {
+		t.Fatal("probe is not allowed after cooldown")
+	}
+	if b.state != breakerHalfOpen {
+		t.Fatalf("expected half-open state, got %s", b.state)
+	}
+	b.failure(probe)
+	if b.state != breakerOpen {
+		t.Fatalf("expected open state after failed probe, got %s", b.state)
+	}
+	if b.allow(probe.Add(30 * time.Second)) {
+		t.Error("request is allowed during new cooldown")
+	}
+
+	probe = probe.Add(time.Minute)
+	if !b.allow(probe) {
+		t.Fatal("probe is not allowed after new cooldown")
+	}
+	b.success()
+	if b.state != breakerClosed || b.failures != 0 {
+		t.Errorf("expected closed state without failures, got %s with %d", b.state, b.failures)
+	}
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	b := newBreaker(0, 0, time.Minute)
+	if b != nil {
+		t.Fatal("expected nil breaker")
+	}
+
+	now := time.Now()
+	for range 10 {
+		b.failure(now)
+	}
+	if !b.allow(now) {
+		t.Error("disabled breaker must allow requests")
+	}
+}
+
+func TestTransformApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform Transform
+		load      uint8
+		want      uint8
+	}{
+		{name: "empty", load: 42, want: 42},
+		{name: "none", transform: Transform{Kind: TransformNone, Scale: 2}, load: 42, want: 42},
+		{name: "invert", transform: Transform{Kind: TransformInvert}, load: 30, want: 70},
+		{name: "invert zero", transform: Transform{Kind: TransformInvert}, load: 0, want: 100},
+		{name: "invert clamped", transform: Transform{Kind: TransformInvert}, load: 120, want: 0},
+		{name: "linear", transform: Transform{Kind: TransformLinear, Scale: 0.5, Offset: 10}, load: 50, want: 35},
+		{name: "linear rounded", transform: Transform{Kind: TransformLinear, Scale: 1.5}, load: 33, want: 50},
+		{name: "linear clamped high", transform: Transform{Kind: TransformLinear, Scale: 2, Offset: 5}, load: 60, want: 100},
+		{name: "linear clamped low", transform: Transform{Kind: TransformLinear, Scale: 1, Offset: -20}, load: 10, want: 0},
+		{name: "linear negative scale", transform: Transform{Kind: TransformLinear, Scale: -1, Offset: 100}, load: 25, want: 75},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.transform.Apply(tc.load); got != tc.want {
+				t.Errorf("Apply(%d) = %d, want %d", tc.load, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGetLoad_Transform(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, Club{ID: 1, Title: "Test", CurrentLoad: "25%"})
+	}))
+	defer server.Close()
+
+	f := &Fetcher{
+		Client:    server.Client(),
+		URL:       server.URL,
+		Auth:      Auth{Token: "test-token"},
+		Transform: Transform{Kind: TransformInvert},
+	}
+
+	load, err := f.getLoad(context.Background(), f.URL)
+	if err != nil {
+		t.Fatalf("getLoad() error = %v", err)
+	}
+	if load.load != 75 {
+		t.Errorf("expected inverted load 75, got %d", load.load)
+	}
+
+	result, err := f.Probe(context.Background())
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+	if result.Load != 25 {
+		t.Errorf("expected raw probe load 25, got %d", result.Load)
+	}
+}
+
+func TestFetch_MultipleClubs(t *testing.T) {
+	var active, maxActive atomic.Int32
+	newClubServer := func(load string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				cur := maxActive.Load()
+				if n <= cur || maxActive.CompareAndSwap(cur, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			if load == "" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			writeJSON(t, w, Club{ID: 1, Title: "Test", CurrentLoad: load})
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	tests := []struct {
+		name      string
+		loads     []string // the first is the main club
+		workers   int
+		wantLoads map[int]uint8
+		wantMain  bool
+		wantErr   bool
+	}{
+		{
+			name:      "all clubs",
+			loads:     []string{"10%", "20%", "30%"},
+			workers:   1,
+			wantLoads: map[int]uint8{0: 10, 1: 20, 2: 30},
+			wantMain:  true,
+		},
+		{
+			name:      "one club fails",
+			loads:     []string{"10%", "", "30%"},
+			workers:   2,
+			wantLoads: map[int]uint8{0: 10, 2: 30},
+			wantMain:  true,
+		},
+		{
+			name:      "main club fails",
+			loads:     []string{"", "20%", "30%"},
+			wantLoads: map[int]uint8{1: 20, 2: 30},
+		},
+		{
+			name:      "all clubs fail",
+			loads:     []string{"", "", ""},
+			wantLoads: map[int]uint8{},
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			active.Store(0)
+			maxActive.Store(0)
+			db := newTestDB(t)
+
+			f := &Fetcher{
+				Db:           db,
+				Client:       http.DefaultClient,
+				URL:          newClubServer(tc.loads[0]).URL,
+				Auth:         Auth{Token: "test-token"},
+				QueryTimeout: 5 * time.Second,
+				Workers:      tc.workers,
+			}
+			for i, load := range tc.loads[1:] {
+				f.Clubs = append(f.Clubs, Target{ID: i + 1, URL: newClubServer(load).URL})
+			}
+
+			eventCh := make(chan databaser.Event, 1)
+			err := f.Fetch(context.Background(), eventCh)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Fetch() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			workers := tc.workers
+			if workers == 0 {
+				workers = defaultWorkers
+			}
+			if n := maxActive.Load(); n > int32(workers) {
+				t.Errorf("max concurrent requests = %d, want <= %d", n, workers)
+			}
+
+			var rows []databaser.Event
+			if err = db.SelectContext(context.Background(), &rows, "SELECT timestamp, club_id, load FROM events;"); err != nil {
+				t.Fatalf("failed to select events: %v", err)
+			}
+			if len(rows) != len(tc.wantLoads) {
+				t.Fatalf("got %d saved events, want %d", len(rows), len(tc.wantLoads))
+			}
+			for _, row := range rows {
+				if want, ok := tc.wantLoads[row.ClubID]; !ok || row.Load != want {
+					t.Errorf("unexpected saved event %v", &row)
+				}
+			}
+
+			select {
+			case event := <-eventCh:
+				if !tc.wantMain {
+					t.Errorf("unexpected event on channel: %v", &event)
+				} else if event.ClubID != databaser.DefaultClubID {
+					t.Errorf("expected main club event, got %v", &event)
+				}
+			default:
+				if tc.wantMain {
+					t.Error("expected main club event on channel")
+				}
+			}
+		})
+	}
+}
+
+func TestRun_InitialFetchSomeClubs(t *testing.T) {
+	db := newTestDB(t)
+
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failed.Close()
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, Club{ID: 2, Title: "Test", CurrentLoad: "55%"})
+	}))
+	defer ok.Close()
+
+	f := &Fetcher{
+		Db:           db,
+		Client:       http.DefaultClient,
+		URL:          failed.URL,
+		Clubs:        []Target{{ID: 2, URL: ok.URL}},
+		Auth:         Auth{Token: "test-token"},
+		Timeout:      time.Hour,
+		QueryTimeout: 5 * time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	doneCh, _, err := f.Run(ctx)
+	if err != nil {
+		cancel()
+		t.Fatalf("Run() error = %v, want nil if some clubs are fetched", err)
+	}
+
+	cancel()
+	<-doneCh
+
+	events, err := db.GetClubEvents(context.Background(), time.Hour, 2)
+	if err != nil {
+		t.Fatalf("GetClubEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0].Load != 55 {
+		t.Errorf("unexpected club events: %v", events)
+	}
+}
+
+func TestFetch_Retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		failures     int32
+		wantRequests int32
+		wantErr      string
+	}{
+		{name: "recovered", status: http.StatusServiceUnavailable, failures: 2, wantRequests: 3},
+		{name: "exhausted", status: http.StatusServiceUnavailable, failures: 10, wantRequests: 4, wantErr: "failed after 4 attempt(s)"},
+		{name: "client error", status: http.StatusUnauthorized, failures: 10, wantRequests: 1, wantErr: "failed after 1 attempt(s)"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDB(t)
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= tc.failures {
+					w.WriteHeader(tc.status)
+					return
+				}
+				writeJSON(t, w, Club{ID: 1, Title: "Test", CurrentLoad: "42%"})
+			}))
+			defer server.Close()
+
+			f := &Fetcher{
+				Db:           db,
+				Client:       server.Client(),
+				URL:          server.URL,
+				Auth:         Auth{Token: "test-token"},
+				QueryTimeout: 5 * time.Second,
+				Retries:      3,
+				RetryBase:    time.Millisecond,
+			}
+			eventCh := make(chan databaser.Event, 1)
+
+			err := f.Fetch(context.Background(), eventCh)
+			if n := requests.Load(); n != tc.wantRequests {
+				t.Errorf("expected %d requests, got %d", tc.wantRequests, n)
+			}
+
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("expected error with %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if event := <-eventCh; event.Load != 42 {
+				t.Errorf("expected load 42, got %d", event.Load)
+			}
+		})
+	}
+}
+
+func TestRetry_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+
+	start := time.Now()
+	err := retry(ctx, 5, time.Hour, func() error {
+		calls++
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return errors.New("unavailable")
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retry waited %v after cancellation", elapsed)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	const base = 100 * time.Millisecond
+
+	for attempt := 1; attempt <= 4; attempt++ {
+		delay := base << (attempt - 1)
+		for range 20 {
+			got := backoff(base, attempt)
+			if got < delay/2 || got > delay {
+				t.Fatalf("backoff(%d) = %v, want in [%v, %v]", attempt, got, delay/2, delay)
+			}
+		}
+	}
+
+	if got := backoff(base, 100); got > maxRetryDelay || got < maxRetryDelay/2 {
+		t.Errorf("backoff is not limited: %v", got)
+	}
+	if got := backoff(0, 3); got != 0 {
+		t.Errorf("backoff without base = %v, want 0", got)
+	}
+}
+
+func TestSchedule_Remaining(t *testing.T) {
+	last := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	s := Schedule{Last: last, Period: 5 * time.Minute}
+
+	if next := s.Next(); !next.Equal(last.Add(5 * time.Minute)) {
+		t.Errorf("Next() = %v, want %v", next, last.Add(5*time.Minute))
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Duration
+	}{
+		{name: "just fetched", now: last, want: 5 * time.Minute},
+		{name: "in the middle", now: last.Add(2*time.Minute + 30*time.Second), want: 2*time.Minute + 30*time.Second},
+		{name: "due now", now: last.Add(5 * time.Minute), want: 0},
+		{name: "overdue", now: last.Add(7 * time.Minute), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Remaining(tt.now); got != tt.want {
+				t.Errorf("Remaining() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_NextFetch(t *testing.T) {
+	db := newTestDB(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, Club{ID: 1, Title: "Test", CurrentLoad: "50%"})
+	}))
+	defer server.Close()
+
+	f := &Fetcher{
+		Db:           db,
+		Client:       server.Client(),
+		URL:          server.URL,
+		Auth:         Auth{Token: "test-token"},
+		Timeout:      time.Hour,
+		QueryTimeout: 5 * time.Second,
+	}
+
+	if _, ok := f.NextFetch(); ok {
+		t.Error("NextFetch() of not running fetcher should return false")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	doneCh, eventCh, err := f.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	s, ok := f.NextFetch()
+	if !ok {
+		t.Fatal("NextFetch() of running fetcher should return true")
+	}
+	if s.Period != time.Hour {
+		t.Errorf("period = %v, want %v", s.Period, time.Hour)
+	}
+	if next := s.Next(); next.Before(start.Add(time.Hour)) || next.After(time.Now().Add(time.Hour)) {
+		t.Errorf("next fetch %v is not an hour after start %v", next, start)
+	}
+
+	f.SetPeriod(time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for s.Period != time.Minute && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		s, _ = f.NextFetch()
+	}
+	if s.Period != time.Minute {
+		t.Errorf("period after change = %v, want %v", s.Period, time.Minute)
+	}
+	if remaining := s.Remaining(time.Now()); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("remaining = %v, want in (0, 1m]", remaining)
+	}
+
+	cancel()
+	drainEvents(eventCh)
+	<-doneCh
+
+	if _, ok = f.NextFetch(); ok {
+		t.Error("NextFetch() of stopped fetcher should return false")
+	}
+}
+
+func TestFetch_EventWebhook(t *testing.T) {
+	tests := []struct {
+		name          string
+		webhookStatus int
+	}{
+		{name: "webhook accepted", webhookStatus: http.StatusNoContent},
+		{name: "webhook failed", webhookStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			received := make(chan WebhookEvent, 1)
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("/load", func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(t, w, Club{ID: 1, Title: "Test", CurrentLoad: "42%"})
+			})
+			mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("webhook method = %s, want POST", r.Method)
+				}
+				if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+					t.Errorf("webhook content-type = %q, want application/json", ct)
+				}
+
+				var event WebhookEvent
+				if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+					t.Errorf("failed to decode webhook payload: %v", err)
+				}
+				w.WriteHeader(tt.webhookStatus)
+				received <- event
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			f := &Fetcher{
+				Db:           db,
+				Client:       server.Client(),
+				URL:          server.URL + "/load",
+				EventWebhook: server.URL + "/hook",
+				Auth:         Auth{Token: "test-token"},
+				QueryTimeout: 5 * time.Second,
+			}
+
+			eventCh := make(chan databaser.Event, 1)
+			if err := f.Fetch(context.Background(), eventCh); err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			fetched := <-eventCh
+
+			select {
+			case event := <-received:
+				if event.Load != 42 || event.ClubID != databaser.DefaultClubID || !event.Timestamp.Equal(fetched.Timestamp) {
+					t.Errorf("webhook event = %+v, want %v", event, &fetched)
+				}
+			case <-time.After(webhookTimeout):
+				t.Fatal("webhook is not called")
+			}
+
+			// the event is saved independently of the webhook result
+			events, err := db.GetEvents(context.Background(), time.Hour)
+			if err != nil {
+				t.Fatalf("GetEvents() error = %v", err)
+			}
+			if len(events) != 1 {
+				t.Errorf("saved %d events, want 1", len(events))
+			}
+		})
+	}
+}
+
+func TestPostEvent_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	hookURL := server.URL
+	server.Close()
+
+	f := &Fetcher{Client: http.DefaultClient, EventWebhook: hookURL}
+	err := f.postEvent(context.Background(), databaser.Event{Timestamp: time.Now().UTC(), Load: 10})
+	if err == nil {
+		t.Error("expected error for unreachable webhook")
+	}
+}
@@ -0,0 +1,69 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// maxRetryDelay limits the backoff delay between attempts.
+const maxRetryDelay = time.Minute
+
+// statusError is an error of an unexpected HTTP response status.
+type statusError struct {
+	status string
+	code   int
+}
+
+// Error implements the error interface.
+func (e *statusError) Error() string {
+	return "unexpected status: " + e.status
+}
+
+// permanent returns true if the error can't be fixed by a retry, it's a client error response.
+func permanent(err error) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.code >= http.StatusBadRequest && se.code < http.StatusInternalServerError
+}
+
+// backoff returns the delay before the next attempt, it's doubled for every attempt
+// and a random jitter in the range [delay/2, delay] spreads requests of different clubs.
+func backoff(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+
+	delay := base << (attempt - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1) //nolint:gosec // jitter does not need a secure random
+}
+
+// retry calls fn until it succeeds, at most retries+1 times with backoff delays. Permanent errors are not retried.
+// The waiting between attempts is stopped if ctx is done. The returned error contains the number of made attempts.
+func retry(ctx context.Context, retries int, base time.Duration, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if attempt > retries || permanent(err) || ctx.Err() != nil {
+			return fmt.Errorf("failed after %d attempt(s): %w", attempt, err)
+		}
+
+		timer := time.NewTimer(backoff(base, attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed after %d attempt(s), retry canceled: %w", attempt, errors.Join(err, ctx.Err()))
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package fetcher
+
+import (
+	"sync"
+	"time"
+)
+
+// Schedule is a state of the fetcher ticker.
+type Schedule struct {
+	Last   time.Time     // time of the last scheduled fetch or period change
+	Period time.Duration // fetching period
+}
+
+// Next returns the time of the next scheduled fetch.
+func (s Schedule) Next() time.Time {
+	return s.Last.Add(s.Period)
+}
+
+// Remaining returns the duration from now to the next scheduled fetch, it's zero if the fetch is overdue.
+func (s Schedule) Remaining(now time.Time) time.Duration {
+	return max(s.Next().Sub(now), 0)
+}
+
+// schedule tracks the ticker state of the running fetcher, the ticker itself is internal to Run.
+type schedule struct {
+	state   Schedule
+	mu      sync.RWMutex
+	running bool
+}
+
+// reset sets the time when the ticker was started or fired and its period.
+func (s *schedule) reset(last time.Time, period time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state, s.running = Schedule{Last: last, Period: period}, true
+}
+
+// tick updates the time when the ticker fired keeping its period.
+func (s *schedule) tick(last time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Last = last
+}
+
+// stop marks the ticker as stopped.
+func (s *schedule) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+}
+
+// get returns the ticker state and false if the fetcher is not running.
+func (s *schedule) get() (Schedule, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state, s.running
+}
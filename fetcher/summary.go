@@ -0,0 +1,75 @@
+package fetcher
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// summary accumulates successful fetches between periodic summary logs.
+type summary struct {
+	mu       sync.Mutex
+	fetches  int
+	events   int
+	mainLoad int // sum of the main club loads
+	mainN    int // number of the main club events
+}
+
+// add counts a successful fetch of the events.
+func (s *summary) add(events []databaser.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fetches++
+	s.events += len(events)
+	for _, event := range events {
+		if event.ClubID == databaser.DefaultClubID {
+			s.mainLoad += int(event.Load)
+			s.mainN++
+		}
+	}
+}
+
+// flush logs the accumulated counts at info level and resets them.
+func (s *summary) flush(ctx context.Context, period time.Duration) {
+	s.mu.Lock()
+	fetches, events, mainLoad, mainN := s.fetches, s.events, s.mainLoad, s.mainN
+	s.fetches, s.events, s.mainLoad, s.mainN = 0, 0, 0, 0
+	s.mu.Unlock()
+
+	var avgLoad float64
+	if mainN > 0 {
+		avgLoad = float64(mainLoad) / float64(mainN)
+	}
+
+	slog.InfoContext(ctx, "fetch summary", "period", period, "fetches", fetches, "events", events, "avgLoad", avgLoad)
+}
+
+// successLevel returns the log level of every successful fetch,
+// it's debug if successes are logged by periodic summaries.
+func (f *Fetcher) successLevel() slog.Level {
+	if f.SummaryPeriod > 0 {
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// logFetched logs fetched events and counts them for the next summary if summaries are enabled.
+func (f *Fetcher) logFetched(ctx context.Context, events []databaser.Event) {
+	level := f.successLevel()
+	for _, event := range events {
+		slog.Log(ctx, level, "fetched", "event", &event)
+	}
+
+	f.countFetched(events)
+}
+
+// countFetched counts fetched events for the next summary if summaries are enabled.
+func (f *Fetcher) countFetched(events []databaser.Event) {
+	if f.SummaryPeriod > 0 {
+		f.summary.add(events)
+	}
+}
@@ -0,0 +1,37 @@
+package fetcher
+
+import "math"
+
+// Transform kinds.
+const (
+	TransformNone   = "none"
+	TransformInvert = "invert"
+	TransformLinear = "linear"
+)
+
+// maxLoad is the maximum load value in percents.
+const maxLoad = 100
+
+// Transform converts a load value reported by the upstream API to occupancy percents.
+// An empty Kind means TransformNone, Scale and Offset are used only for TransformLinear.
+type Transform struct {
+	Kind   string
+	Scale  float64
+	Offset float64
+}
+
+// Apply returns the transformed load clamped to the range [0, 100].
+func (t Transform) Apply(load uint8) uint8 {
+	value := float64(load)
+
+	switch t.Kind {
+	case TransformInvert:
+		value = maxLoad - value
+	case TransformLinear:
+		value = t.Scale*value + t.Offset
+	default:
+		return load
+	}
+
+	return uint8(math.Round(min(max(value, 0), maxLoad)))
+}
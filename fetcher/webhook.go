@@ -0,0 +1,80 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// webhookTimeout limits a single webhook request.
+const webhookTimeout = 5 * time.Second
+
+// WebhookEvent is the JSON payload of a fetched event sent to the event webhook.
+type WebhookEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	ClubID    int       `json:"club_id"`
+	Load      uint8     `json:"load"`
+}
+
+// notify sends fetched events to the event webhook in background, it doesn't wait for responses.
+// Failures are only logged, so the webhook doesn't affect fetching and saving.
+func (f *Fetcher) notify(ctx context.Context, events []databaser.Event) {
+	if f.EventWebhook == "" {
+		return
+	}
+
+	// requests are not canceled with the fetcher, they are limited by the own timeout
+	ctx = context.WithoutCancel(ctx)
+	for _, event := range events {
+		go func() {
+			if err := f.postEvent(ctx, event); err != nil {
+				slog.Warn("event webhook error", "error", err, "event", &event)
+			}
+		}()
+	}
+}
+
+// postEvent sends the event to the event webhook as JSON.
+func (f *Fetcher) postEvent(ctx context.Context, event databaser.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	payload, err := json.Marshal(WebhookEvent{Timestamp: event.Timestamp, ClubID: event.ClubID, Load: event.Load})
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.EventWebhook, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("do webhook request: %w", err)
+	}
+	defer func() {
+		// drain remaining body to allow connection reuse
+		if _, errCopy := io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseSize)); errCopy != nil {
+			slog.Error("drain webhook body error", "error", errCopy)
+		}
+
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.Error("close webhook body error", "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook response: %w", &statusError{status: resp.Status, code: resp.StatusCode})
+	}
+
+	return nil
+}
@@ -85,6 +85,7 @@ type HolidayParams struct {
 	URL          string
 	Timeout      time.Duration
 	QueryTimeout time.Duration
+	periodCh     chan time.Duration
 }
 
 // Run begins the periodic fetching process.
@@ -95,6 +96,7 @@ func (hp *HolidayParams) Run(ctx context.Context) (<-chan struct{}, error) {
 	}
 
 	doneCh := make(chan struct{})
+	hp.periodCh = make(chan time.Duration, 1)
 	go func() {
 		ticker := time.NewTicker(hp.Timeout)
 		defer ticker.Stop()
@@ -106,6 +108,9 @@ func (hp *HolidayParams) Run(ctx context.Context) (<-chan struct{}, error) {
 				slog.Info("stopping holidayer")
 				close(doneCh)
 				return
+			case period := <-hp.periodCh:
+				ticker.Reset(period)
+				slog.Info("holidayer period changed", "period", period)
 			case <-ticker.C:
 				slog.Info("wake up holidayer")
 				if fetchErr := hp.Fetch(ctx); fetchErr != nil {
@@ -118,6 +123,15 @@ func (hp *HolidayParams) Run(ctx context.Context) (<-chan struct{}, error) {
 	return doneCh, nil
 }
 
+// SetPeriod changes the fetching period of the running holidayer, it's ignored if the holidayer is not running.
+func (hp *HolidayParams) SetPeriod(period time.Duration) {
+	select {
+	case hp.periodCh <- period:
+	default:
+		slog.Warn("holidayer period is not changed", "period", period)
+	}
+}
+
 // Fetch retrieves the current load and saves it to the database.
 func (hp *HolidayParams) Fetch(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, hp.QueryTimeout)
@@ -2,15 +2,19 @@
 package importer
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"iter"
 	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -20,36 +24,143 @@ import (
 
 const chunkSize = 250
 
+// format is a format of imported data.
+type format uint8
+
+const (
+	formatCSV format = iota
+	formatJSON
+)
+
 type importReader struct {
 	db       *databaser.DB
 	reader   io.Reader
 	location *time.Location
 	err      error
+	format   format
+	merge    bool // events with existing timestamps are skipped instead of replaced
+}
+
+// jsonRecord is an event of the JSON array, time is in the same format as in CSV or RFC3339.
+type jsonRecord struct {
+	Time string `json:"time"`
+	Load int64  `json:"load"`
 }
 
-// ImportCSV imports events from a CSV file into the database.
+// ImportCSV imports events from a CSV file into the database, a file with ".gz" extension is decompressed.
 func ImportCSV(db *databaser.DB, importPath string, timeout time.Duration, location *time.Location) error {
+	return importFile(db, importPath, timeout, location, formatCSV)
+}
+
+// ImportJSON imports events from a JSON file into the database.
+// The file is an array of objects like {"time": "2025-11-22 23:27:27", "load": 7}, it's decoded as a stream.
+// A file with ".gz" extension is decompressed.
+func ImportJSON(db *databaser.DB, importPath string, timeout time.Duration, location *time.Location) error {
+	return importFile(db, importPath, timeout, location, formatJSON)
+}
+
+// MergeCSV imports events from a CSV file into the database, events with existing timestamps are skipped.
+// It returns the numbers of inserted and skipped events, a file with ".gz" extension is decompressed.
+func MergeCSV(db *databaser.DB, importPath string, timeout time.Duration, location *time.Location) (int, int, error) {
+	var inserted, skipped int
+	err := readFile(importPath, func(reader io.Reader) error {
+		r := &importReader{
+			db:       db,
+			reader:   reader,
+			location: location,
+			format:   formatCSV,
+			merge:    true,
+		}
+		var mergeErr error
+		inserted, skipped, mergeErr = r.InsertEvents(context.Background(), timeout)
+		return mergeErr
+	})
+	return inserted, skipped, err
+}
+
+// ValidateCSV parses events from a CSV file without writing them to the database and returns their number.
+// A file with ".gz" extension is decompressed.
+func ValidateCSV(importPath string, location *time.Location) (int, error) {
+	return validateFile(importPath, location, formatCSV)
+}
+
+// ValidateJSON parses events from a JSON file without writing them to the database and returns their number.
+// A file with ".gz" extension is decompressed.
+func ValidateJSON(importPath string, location *time.Location) (int, error) {
+	return validateFile(importPath, location, formatJSON)
+}
+
+// importFile imports events from a file of the given format into the database.
+func importFile(db *databaser.DB, importPath string, timeout time.Duration, location *time.Location, f format) error {
+	return readFile(importPath, func(reader io.Reader) error {
+		r := &importReader{
+			db:       db,
+			reader:   reader,
+			location: location,
+			format:   f,
+		}
+		_, _, err := r.InsertEvents(context.Background(), timeout)
+		return err
+	})
+}
+
+// validateFile parses events from a file of the given format and returns their number.
+func validateFile(importPath string, location *time.Location, f format) (int, error) {
+	var count int
+	err := readFile(importPath, func(reader io.Reader) error {
+		r := &importReader{
+			reader:   reader,
+			location: location,
+			format:   f,
+		}
+		var countErr error
+		count, countErr = r.CountEvents()
+		return countErr
+	})
+	return count, err
+}
+
+// readFile opens a file, decompresses it if it has ".gz" extension and calls fn with its reader.
+func readFile(importPath string, fn func(reader io.Reader) error) error {
 	cleanPath := filepath.Clean(importPath)
-	f, err := os.Open(cleanPath)
+	file, err := os.Open(cleanPath)
 	if err != nil {
 		return fmt.Errorf("open file %q: %w", cleanPath, err)
 	}
 	defer func() {
-		if closeErr := f.Close(); closeErr != nil {
+		if closeErr := file.Close(); closeErr != nil {
 			slog.Error("failed to close import file", "error", closeErr)
 		}
 	}()
 
-	r := &importReader{
-		db:       db,
-		reader:   f,
-		location: location,
+	var reader io.Reader = file
+	if strings.EqualFold(filepath.Ext(cleanPath), ".gz") {
+		gz, gzErr := gzip.NewReader(file)
+		if gzErr != nil {
+			return fmt.Errorf("open gzip file %q: %w", cleanPath, gzErr)
+		}
+		defer func() {
+			if closeErr := gz.Close(); closeErr != nil {
+				slog.Error("failed to close gzip reader", "error", closeErr)
+			}
+		}()
+		reader = gz
 	}
-	return r.InsertEvents(context.Background(), timeout)
+
+	return fn(reader)
 }
 
-// Read reads events from the CSV file and yields them as a sequence.
+// Read reads events from the file and yields them as a sequence.
 func (r *importReader) Read() iter.Seq[*databaser.Event] {
+	if r.format == formatJSON {
+		return r.readJSON()
+	}
+	return r.readCSV()
+}
+
+// readCSV reads events from the CSV file and yields them as a sequence.
+// Errors contain the 1-based data line number, the header is not counted.
+func (r *importReader) readCSV() iter.Seq[*databaser.Event] {
 	return func(yield func(*databaser.Event) bool) {
 		csvReader := csv.NewReader(r.reader)
 		if _, headerErr := csvReader.Read(); headerErr != nil {
@@ -70,7 +181,7 @@ func (r *importReader) Read() iter.Seq[*databaser.Event] {
 
 			event, err := databaser.NewEventFromCSVRecord(record, r.location)
 			if err != nil {
-				r.err = fmt.Errorf("parse record %v: %w", record, err)
+				r.err = fmt.Errorf("parse record at line %d %v: %w", i, record, err)
 				return
 			}
 			event.Timestamp = event.Timestamp.In(time.UTC) // save in UTC
@@ -83,6 +194,62 @@ func (r *importReader) Read() iter.Seq[*databaser.Event] {
 	}
 }
 
+// readJSON reads events from the JSON array token by token and yields them as a sequence.
+func (r *importReader) readJSON() iter.Seq[*databaser.Event] {
+	return func(yield func(*databaser.Event) bool) {
+		decoder := json.NewDecoder(r.reader)
+
+		token, err := decoder.Token()
+		if err != nil {
+			r.err = fmt.Errorf("json read array start: %w", err)
+			return
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			r.err = fmt.Errorf("json array expected, got %v", token)
+			return
+		}
+
+		for i := 1; decoder.More(); i++ {
+			var record jsonRecord
+			if err = decoder.Decode(&record); err != nil {
+				r.err = fmt.Errorf("json read item %d: %w", i, err)
+				return
+			}
+
+			event, parseErr := newEventFromJSONRecord(record, r.location)
+			if parseErr != nil {
+				r.err = fmt.Errorf("parse item %d %+v: %w", i, record, parseErr)
+				return
+			}
+
+			if !yield(event) {
+				return
+			}
+		}
+
+		if _, err = decoder.Token(); err != nil {
+			r.err = fmt.Errorf("json read array end: %w", err)
+		}
+	}
+}
+
+// newEventFromJSONRecord creates an Event in UTC from a JSON record.
+func newEventFromJSONRecord(record jsonRecord, location *time.Location) (*databaser.Event, error) {
+	timestamp, err := time.Parse(time.RFC3339, record.Time)
+	if err != nil {
+		timestamp, err = time.ParseInLocation(time.DateTime, record.Time, location)
+		if err != nil {
+			return nil, fmt.Errorf("parse timestamp %q: %w", record.Time, err)
+		}
+	}
+
+	if record.Load < 0 || record.Load > math.MaxUint8 {
+		return nil, fmt.Errorf("parse load %d: value out of range", record.Load)
+	}
+
+	return &databaser.Event{Timestamp: timestamp.In(time.UTC), Load: uint8(record.Load)}, nil
+}
+
 func (r *importReader) ReadChunk(size int) iter.Seq[[]*databaser.Event] {
 	return func(yield func([]*databaser.Event) bool) {
 		var i int
@@ -109,20 +276,37 @@ func (r *importReader) ReadChunk(size int) iter.Seq[[]*databaser.Event] {
 	}
 }
 
+// CountEvents reads all events without saving them and returns their number.
+// It stops at the first read or parse error.
+func (r *importReader) CountEvents() (int, error) {
+	count := 0
+	for range r.Read() {
+		count++
+	}
+
+	if r.err != nil {
+		return count, fmt.Errorf("validate events: %w", r.err)
+	}
+	return count, nil
+}
+
 // InsertEvents inserts events into the database within a specified timeout.
-func (r *importReader) InsertEvents(ctx context.Context, timeout time.Duration) error {
+// If the reader merges events, existing events are not changed.
+// It returns the numbers of inserted and skipped events.
+func (r *importReader) InsertEvents(ctx context.Context, timeout time.Duration) (int, int, error) {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	count := 0
+	var inserted, total int
 	err := databaser.InTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
 		for rows := range r.ReadChunk(chunkSize) {
-			if err := databaser.SaveManyEventsTx(ctx, tx, rows); err != nil {
+			n, err := r.saveChunk(ctx, tx, rows)
+			if err != nil {
 				return fmt.Errorf("save events: %w", err)
 			}
-			n := len(rows)
 			slog.Info("chunk imported events", "count", n)
-			count += n
+			inserted += n
+			total += len(rows)
 		}
 		// check for read errors that occurred during iteration
 		if r.err != nil {
@@ -132,9 +316,19 @@ func (r *importReader) InsertEvents(ctx context.Context, timeout time.Duration)
 	})
 
 	if err != nil {
-		return fmt.Errorf("insert events: %w", err)
+		return 0, 0, fmt.Errorf("insert events: %w", err)
+	}
+
+	slog.Info("total imported events", "count", inserted)
+	return inserted, total - inserted, nil
+}
+
+// saveChunk stores the events within the transaction and returns the number of inserted ones.
+func (r *importReader) saveChunk(ctx context.Context, tx *sqlx.Tx, rows []*databaser.Event) (int, error) {
+	if !r.merge {
+		return len(rows), databaser.SaveManyEventsTx(ctx, tx, rows)
 	}
 
-	slog.Info("total imported events", "count", count)
-	return nil
+	n, err := databaser.MergeManyEventsTx(ctx, tx, rows)
+	return int(n), err
 }
@@ -1,6 +1,8 @@
 package importer
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"os"
 	"path/filepath"
@@ -497,6 +499,9 @@ invalid-timestamp,20
 	if !strings.Contains(r.err.Error(), "parse record") {
 		t.Errorf("error should contain 'parse record', got: %v", r.err)
 	}
+	if !strings.Contains(r.err.Error(), "at line 2 ") {
+		t.Errorf("error should contain the data line number, got: %v", r.err)
+	}
 }
 
 func TestImportReader_InsertEvents(t *testing.T) {
@@ -515,10 +520,13 @@ func TestImportReader_InsertEvents(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	err := r.InsertEvents(ctx, 30*time.Second)
+	inserted, skipped, err := r.InsertEvents(ctx, 30*time.Second)
 	if err != nil {
 		t.Fatalf("InsertEvents() error = %v", err)
 	}
+	if inserted != 3 || skipped != 0 {
+		t.Errorf("InsertEvents() inserted = %d, skipped = %d, want 3 and 0", inserted, skipped)
+	}
 
 	events, err := db.GetEvents(ctx, 365*24*time.Hour)
 	if err != nil {
@@ -545,7 +553,7 @@ bad-data,20`
 	}
 
 	ctx := context.Background()
-	err := r.InsertEvents(ctx, 30*time.Second)
+	_, _, err := r.InsertEvents(ctx, 30*time.Second)
 	if err == nil {
 		t.Error("expected error for bad CSV data")
 	}
@@ -576,7 +584,7 @@ func TestImportReader_InsertEvents_ContextCanceled(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	err := r.InsertEvents(ctx, 30*time.Second)
+	_, _, err := r.InsertEvents(ctx, 30*time.Second)
 	if err == nil {
 		t.Error("expected error for canceled context")
 	}
@@ -713,3 +721,311 @@ func TestImportReader_Read_EventValues(t *testing.T) {
 		t.Errorf("expected timestamp %v, got %v", expectedTime, event.Timestamp)
 	}
 }
+
+func createTempJSON(t *testing.T, content string) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "test_import.json")
+	if err := os.WriteFile(filePath, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to create temp JSON file: %v", err)
+	}
+	return filePath
+}
+
+func TestImportJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		jsonContent string
+		errContains string
+		wantCount   int
+		wantErr     bool
+	}{
+		{
+			name: "valid JSON with multiple items",
+			jsonContent: `[
+				{"time": "2025-11-22 23:27:27", "load": 7},
+				{"time": "2025-11-23 00:08:16", "load": 3},
+				{"time": "2025-11-23T00:18:16Z", "load": 255}
+			]`,
+			wantCount: 3,
+		},
+		{
+			name:        "empty array",
+			jsonContent: `[]`,
+		},
+		{
+			name:        "load above maximum",
+			jsonContent: `[{"time": "2025-11-22 10:00:00", "load": 50}, {"time": "2025-11-22 10:10:00", "load": 256}]`,
+			wantErr:     true,
+			errContains: "out of range",
+		},
+		{
+			name:        "negative load",
+			jsonContent: `[{"time": "2025-11-22 10:00:00", "load": -1}]`,
+			wantErr:     true,
+			errContains: "out of range",
+		},
+		{
+			name:        "fractional load",
+			jsonContent: `[{"time": "2025-11-22 10:00:00", "load": 5.5}]`,
+			wantErr:     true,
+			errContains: "json read item 1",
+		},
+		{
+			name:        "invalid time",
+			jsonContent: `[{"time": "22.11.2025 10:00", "load": 5}]`,
+			wantErr:     true,
+			errContains: "parse timestamp",
+		},
+		{
+			name:        "not an array",
+			jsonContent: `{"time": "2025-11-22 10:00:00", "load": 5}`,
+			wantErr:     true,
+			errContains: "json array expected",
+		},
+		{
+			name:        "truncated array",
+			jsonContent: `[{"time": "2025-11-22 10:00:00", "load": 5},`,
+			wantErr:     true,
+		},
+		{
+			name:        "empty file",
+			jsonContent: ``,
+			wantErr:     true,
+			errContains: "array start",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			filePath := createTempJSON(t, tt.jsonContent)
+
+			err := ImportJSON(db, filePath, 30*time.Second, time.UTC)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ImportJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("error should contain %q, got: %v", tt.errContains, err)
+			}
+
+			// the whole import is aborted on error
+			events, err := db.GetEvents(context.Background(), 10*365*24*time.Hour)
+			if err != nil {
+				t.Fatalf("GetEvents() error = %v", err)
+			}
+			if len(events) != tt.wantCount {
+				t.Errorf("got %d events, want %d", len(events), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestImportJSON_Chunks(t *testing.T) {
+	db := newTestDB(t)
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	const count = chunkSize*2 + 10
+	var builder strings.Builder
+	builder.WriteString("[")
+	baseTime := time.Date(2025, 11, 1, 0, 0, 0, 0, moscow)
+	for i := range count {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(`{"time":"` + baseTime.Add(time.Duration(i)*time.Minute).Format(time.DateTime) + `","load":42}`)
+	}
+	builder.WriteString("]")
+
+	if err = ImportJSON(db, createTempJSON(t, builder.String()), 30*time.Second, moscow); err != nil {
+		t.Fatalf("ImportJSON() error = %v", err)
+	}
+
+	events, err := db.GetEvents(context.Background(), 10*365*24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	if len(events) != count {
+		t.Fatalf("got %d events, want %d", len(events), count)
+	}
+	if first := events[0]; !first.Timestamp.Equal(baseTime) || first.Timestamp.Location() != time.UTC || first.Load != 42 {
+		t.Errorf("first event = %v, want UTC %v with load 42", &first, baseTime)
+	}
+}
+
+func TestImportCSV_Gzip(t *testing.T) {
+	const csvContent = `time,load
+2025-11-22 23:27:27,7
+2025-11-23 00:08:16,3
+2025-11-23 00:18:16,3
+2025-11-23 00:28:16,2
+2025-11-23 00:38:16,2`
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if _, err := gz.Write([]byte(csvContent)); err != nil {
+		t.Fatalf("failed to compress CSV: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	files := map[string][]byte{"plain.csv": []byte(csvContent), "archive.csv.GZ": compressed.Bytes()}
+	counts := make(map[string]int, len(files))
+
+	for name, content := range files {
+		filePath := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(filePath, content, 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+
+		db := newTestDB(t)
+		if err := ImportCSV(db, filePath, 30*time.Second, time.UTC); err != nil {
+			t.Fatalf("ImportCSV(%s) error = %v", name, err)
+		}
+
+		events, err := db.GetEvents(context.Background(), 10*365*24*time.Hour)
+		if err != nil {
+			t.Fatalf("GetEvents() error = %v", err)
+		}
+		counts[name] = len(events)
+	}
+
+	if counts["plain.csv"] != 5 || counts["archive.csv.GZ"] != counts["plain.csv"] {
+		t.Errorf("events count = %v, want 5 for both files", counts)
+	}
+
+	// a plain file with the gzip extension is not imported
+	filePath := filepath.Join(tmpDir, "plain.csv.gz")
+	if err := os.WriteFile(filePath, []byte(csvContent), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := ImportCSV(newTestDB(t), filePath, 30*time.Second, time.UTC); err == nil || !strings.Contains(err.Error(), "gzip") {
+		t.Errorf("ImportCSV() error = %v, want gzip error", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		validate  func(string, *time.Location) (int, error)
+		wantCount int
+		wantErr   string
+	}{
+		{
+			name:      "valid csv",
+			file:      "data.csv",
+			content:   "time,load\n2025-11-22 23:27:27,7\n2025-11-23 00:08:16,3\n2025-11-23 00:18:16,3",
+			validate:  ValidateCSV,
+			wantCount: 3,
+		},
+		{
+			name:      "invalid csv record",
+			file:      "data.csv",
+			content:   "time,load\n2025-11-22 23:27:27,7\n2025-11-23 00:08:16,3\nbad,3\n2025-11-23 00:28:16,2",
+			validate:  ValidateCSV,
+			wantCount: 2,
+			wantErr:   "parse record at line 3",
+		},
+		{
+			name:      "valid json",
+			file:      "data.json",
+			content:   `[{"time": "2025-11-22 23:27:27", "load": 7}, {"time": "2025-11-23T00:08:16Z", "load": 3}]`,
+			validate:  ValidateJSON,
+			wantCount: 2,
+		},
+		{
+			name:      "invalid json item",
+			file:      "data.json",
+			content:   `[{"time": "2025-11-22 23:27:27", "load": 7}, {"time": "2025-11-23 00:08:16", "load": 300}]`,
+			validate:  ValidateJSON,
+			wantCount: 1,
+			wantErr:   "parse item 2",
+		},
+		{
+			name:     "missing file",
+			validate: ValidateCSV,
+			wantErr:  "open file",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "missing.csv")
+			if tc.file != "" {
+				filePath = filepath.Join(t.TempDir(), tc.file)
+				if err := os.WriteFile(filePath, []byte(tc.content), 0600); err != nil {
+					t.Fatalf("failed to write file: %v", err)
+				}
+			}
+
+			count, err := tc.validate(filePath, time.UTC)
+			if count != tc.wantCount {
+				t.Errorf("count = %d, want %d", count, tc.wantCount)
+			}
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeCSV(t *testing.T) {
+	const csvContent = `time,load
+2025-11-22 23:27:27,7
+2025-11-23 00:08:16,3
+2025-11-23 00:18:16,3`
+
+	db := newTestDB(t)
+	ctx := context.Background()
+	existing := databaser.Event{Timestamp: time.Date(2025, 11, 23, 0, 8, 16, 0, time.UTC), Load: 50}
+	if err := db.SaveEvent(ctx, existing); err != nil {
+		t.Fatalf("SaveEvent() error = %v", err)
+	}
+
+	filePath := createTempCSV(t, csvContent)
+	inserted, skipped, err := MergeCSV(db, filePath, 30*time.Second, time.UTC)
+	if err != nil {
+		t.Fatalf("MergeCSV() error = %v", err)
+	}
+	if inserted != 2 || skipped != 1 {
+		t.Errorf("MergeCSV() inserted = %d, skipped = %d, want 2 and 1", inserted, skipped)
+	}
+
+	// the seed is repeated on every start
+	inserted, skipped, err = MergeCSV(db, filePath, 30*time.Second, time.UTC)
+	if err != nil {
+		t.Fatalf("repeated MergeCSV() error = %v", err)
+	}
+	if inserted != 0 || skipped != 3 {
+		t.Errorf("repeated MergeCSV() inserted = %d, skipped = %d, want 0 and 3", inserted, skipped)
+	}
+
+	events, err := db.GetAllEvents(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("events count = %d, want 3", len(events))
+	}
+	for _, event := range events {
+		if event.Timestamp.Equal(existing.Timestamp) && event.Load != existing.Load {
+			t.Errorf("existing event load = %d, want %d", event.Load, existing.Load)
+		}
+	}
+
+	if _, _, err = MergeCSV(db, filepath.Join(t.TempDir(), "missing.csv"), time.Second, time.UTC); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
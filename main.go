@@ -8,22 +8,29 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strings"
 	"syscall"
+	"time"
 	_ "time/tzdata"
 
 	"github.com/go-telegram/bot"
 
+	"github.com/z0rr0/ggp/alerter"
+	"github.com/z0rr0/ggp/backuper"
+	"github.com/z0rr0/ggp/cacher"
 	"github.com/z0rr0/ggp/config"
 	"github.com/z0rr0/ggp/databaser"
 	"github.com/z0rr0/ggp/fetcher"
 	"github.com/z0rr0/ggp/holidayer"
 	"github.com/z0rr0/ggp/importer"
+	"github.com/z0rr0/ggp/metrics"
 	"github.com/z0rr0/ggp/predictor"
+	"github.com/z0rr0/ggp/pruner"
 	"github.com/z0rr0/ggp/watcher"
 )
 
@@ -43,6 +50,7 @@ func main() {
 	var (
 		configPath = "config.toml"
 		importPath string
+		validate   bool
 	)
 
 	defer func() {
@@ -56,7 +64,8 @@ func main() {
 	}()
 
 	flag.StringVar(&configPath, "config", configPath, "path to configuration file")
-	flag.StringVar(&importPath, "import", importPath, "path to import data from CSV file")
+	flag.StringVar(&importPath, "import", importPath, "path to import data from CSV or JSON (.json) file, gzip (.gz) files are decompressed")
+	flag.BoolVar(&validate, "validate", validate, "only parse the -import file and report the number of events, nothing is written")
 	flag.Parse()
 
 	cfg, err := config.Load(configPath)
@@ -73,10 +82,20 @@ func main() {
 		"go", GoVersion, "build", BuildDate, "debug", cfg.Base.Debug,
 	)
 
+	if validate {
+		validateImport(importPath, cfg.Base.TimeLocation)
+		return
+	}
+
 	dbCtx, dbCancel := context.WithTimeout(context.Background(), cfg.Database.Timeout)
 	defer dbCancel()
 
-	db, err := databaser.New(dbCtx, cfg.Database.Path, cfg.Database.Threads)
+	db, err := databaser.New(
+		dbCtx, cfg.Database.Path, cfg.Database.Threads,
+		databaser.WithCacheSize(cfg.Database.CacheSizeKiB()),
+		databaser.WithMmapSize(cfg.Database.MmapSizeBytes()),
+		databaser.WithBusyTimeout(cfg.Database.BusyTimeoutDuration()),
+	)
 	if err != nil {
 		slog.Error("failed to open database", "error", err)
 		return
@@ -87,43 +106,84 @@ func main() {
 		}
 	}()
 
+	// the import needs the migrated schema too
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err = db.Init(ctx); err != nil {
+		slog.Error("failed to init database", "error", err)
+		return
+	}
+
 	if importPath != "" {
 		slog.Info("importing data", "path", importPath)
-		err = importer.ImportCSV(db, importPath, cfg.Database.Timeout, cfg.Base.TimeLocation)
+		importFile := importer.ImportCSV
+		if isJSONImport(importPath) {
+			importFile = importer.ImportJSON
+		}
+		err = importFile(db, importPath, cfg.Database.Timeout, cfg.Base.TimeLocation)
 		if err != nil {
 			slog.Error("failed to import data", "error", err)
 		}
 		return
 	}
 
-	// not importing, start bot
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer cancel()
+	// not importing, seed and start bot
+	if cfg.Database.SeedCSV != "" {
+		if err = seedDatabase(db, cfg); err != nil {
+			slog.Error("failed to seed database", "error", err)
+			return
+		}
+	}
 
-	if err = db.Init(ctx); err != nil {
-		slog.Error("failed to init database", "error", err)
+	slog.Info("features", "features", cfg.Features)
+
+	appMetrics, metricsDoneCh, err := runMetrics(ctx, cfg)
+	if err != nil {
+		slog.Error("failed to start metrics server", "error", err)
 		return
 	}
 
-	fetchDoneCh, eventCh, err := runFetcher(ctx, cfg, db)
+	fetchWorker, fetchDoneCh, eventCh, err := runFetcher(ctx, cfg, db, appMetrics)
 	if err != nil {
 		slog.Error("failed to start fetcher", "error", err)
 		return
 	}
 
-	holidayerDoneCh, err := runHolidayer(ctx, cfg, db)
+	eventCh, err = runAlerter(ctx, cfg, eventCh)
+	if err != nil {
+		slog.Error("failed to start alerter", "error", err)
+		return
+	}
+
+	subscriber, eventCh := runSubscriber(ctx, cfg, db, eventCh)
+
+	cache, eventCh, err := runCacher(ctx, cfg, db, eventCh)
+	if err != nil {
+		slog.Error("failed to start cacher", "error", err)
+		return
+	}
+
+	holidayerWorker, holidayerDoneCh, err := runHolidayer(ctx, cfg, db)
 	if err != nil {
 		slog.Error("failed to start holidayer", "error", err)
 		return
 	}
 
-	predictorCtr, predictorCh, err := runPredictor(ctx, cfg, db, eventCh)
+	prunerDoneCh := runPruner(ctx, cfg, db)
+	backuperDoneCh := runBackuper(ctx, cfg, db)
+
+	predictorCtr, predictorCh, err := runPredictor(ctx, cfg, db, eventCh, appMetrics)
 	if err != nil {
 		slog.Error("failed to start predictor", "error", err)
 		return
 	}
 
-	err = runTelegramBot(ctx, cfg, db, predictorCtr)
+	admins := watcher.NewAdminSet(cfg.Base.AdminIDs)
+	r := newReloader(configPath, cfg, admins, fetchWorker, holidayerWorker, predictorCtr)
+	go r.Run(ctx)
+
+	err = runTelegramBot(ctx, cfg, db, predictorCtr, fetchWorker, subscriber, cache, admins)
 	if err != nil {
 		slog.Error("telegram bot failed", "error", err)
 		return
@@ -132,29 +192,105 @@ func main() {
 	// wait for termination
 	slog.Info("shutting down bot")
 	<-ctx.Done()
-	<-predictorCh
-	<-holidayerDoneCh
-	<-fetchDoneCh
+	// the predictor stops after the fetcher closes the events channel, so it counts all fetched events
+	waitDone(predictorCh, holidayerDoneCh, prunerDoneCh, backuperDoneCh, fetchDoneCh, metricsDoneCh)
 	slog.Info("stopped")
 }
 
-func runTelegramBot(ctx context.Context, cfg *config.Config, db *databaser.DB, pc *predictor.Controller) error {
-	if !cfg.Telegram.Active {
+// inactive logs that the subsystem is disabled and returns its closed done channel.
+func inactive(name string) <-chan struct{} {
+	slog.Info(name + " is inactive")
+	doneCh := make(chan struct{})
+	close(doneCh)
+	return doneCh
+}
+
+// waitDone waits for all subsystems done channels in the given order.
+func waitDone(doneChs ...<-chan struct{}) {
+	for _, doneCh := range doneChs {
+		<-doneCh
+	}
+}
+
+// isJSONImport returns true if the import file is JSON,
+// compressed files are detected by the importer, so the format is the extension before ".gz".
+func isJSONImport(importPath string) bool {
+	name := importPath
+	if strings.EqualFold(filepath.Ext(name), ".gz") {
+		name = name[:len(name)-len(".gz")]
+	}
+	return strings.EqualFold(filepath.Ext(name), ".json")
+}
+
+// seedDatabase merges events of the seed CSV file into the database, existing events are not changed.
+func seedDatabase(db *databaser.DB, cfg *config.Config) error {
+	slog.Info("seeding database", "path", cfg.Database.SeedCSV)
+
+	inserted, skipped, err := importer.MergeCSV(db, cfg.Database.SeedCSV, cfg.Database.Timeout, cfg.Base.TimeLocation)
+	if err != nil {
+		return fmt.Errorf("merge seed file: %w", err)
+	}
+
+	slog.Info("database seeded", "inserted", inserted, "skipped", skipped)
+	return nil
+}
+
+// validateImport parses the import file without writing to the database and logs the number of its events.
+func validateImport(importPath string, location *time.Location) {
+	if importPath == "" {
+		slog.Error("validate flag requires an import file")
+		return
+	}
+
+	slog.Info("validating import data", "path", importPath)
+	validateFile := importer.ValidateCSV
+	if isJSONImport(importPath) {
+		validateFile = importer.ValidateJSON
+	}
+
+	count, err := validateFile(importPath, location)
+	if err != nil {
+		slog.Error("failed to validate import data", "error", err, "valid", count)
+		return
+	}
+	slog.Info("import data is valid", "events", count)
+}
+
+func runTelegramBot(
+	ctx context.Context, cfg *config.Config, db *databaser.DB, pc *predictor.Controller,
+	fetchWorker *fetcher.Fetcher, subscriber *alerter.Subscriber, cache *cacher.Cache, admins *watcher.AdminSet,
+) error {
+	if !cfg.Features.Telegram {
 		slog.Info("telegram bot is inactive")
 		return nil
 	}
 	var (
 		mwLog   bot.Middleware = watcher.BotLoggingMiddleware
-		mwAuth  bot.Middleware = watcher.BotAuthMiddleware(cfg.Base.AdminIDs, db)
-		mwAdmin bot.Middleware = watcher.BotAdminOnlyMiddleware(cfg.Base.AdminIDs)
+		mwAuth  bot.Middleware = watcher.BotAuthMiddleware(admins, db, cfg.Base.Language)
+		mwAdmin bot.Middleware = watcher.BotAdminOnlyMiddleware(admins, cfg.Base.Language)
+		// rate limit is set per minute
+		mwRate bot.Middleware = watcher.BotRateLimitMiddleware(float64(cfg.Telegram.RateLimit)/60, cfg.Telegram.RateBurst, cfg.Base.Language)
 	)
 
 	botHandler := watcher.NewBotHandler(db, cfg, pc)
+	botHandler.SetAdmins(admins)
+	botHandler.SetCache(cache)
+	if fetchWorker != nil {
+		botHandler.SetFetcher(fetchWorker)
+	}
+	var mwMaintenance bot.Middleware = botHandler.MaintenanceMiddleware
+
 	b, err := bot.New(cfg.Telegram.Token, bot.WithDefaultHandler(mwLog(botHandler.WrapDefaultHandler)))
 	if err != nil {
 		return fmt.Errorf("failed to create bot: %w", err)
 	}
 
+	if subscriber != nil {
+		subscriber.SetNotify(func(ctx context.Context, subscription databaser.Subscription, event databaser.Event) error {
+			return botHandler.SendAlert(ctx, b, subscription, event)
+		})
+	}
+
 	ok, err := b.SetMyCommands(ctx, &bot.SetMyCommandsParams{Commands: watcher.Commands})
 	if err != nil {
 		return fmt.Errorf("failed to set bot commands: %w", err)
@@ -163,17 +299,50 @@ func runTelegramBot(ctx context.Context, cfg *config.Config, db *databaser.DB, p
 		return errors.New("bot commands are not set")
 	}
 
-	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdStart, bot.MatchTypeCommand, botHandler.WrapHandleStart, mwLog)
-	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdStop, bot.MatchTypeCommand, botHandler.WrapHandleStop, mwLog, mwAuth)
-	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdID, bot.MatchTypeCommand, botHandler.WrapHandleID, mwLog, mwAuth)
-	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdWeek, bot.MatchTypeCommand, botHandler.WrapHandleWeek, mwLog, mwAuth)
-	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdDay, bot.MatchTypeCommand, botHandler.WrapHandleDay, mwLog, mwAuth)
-	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdHalfDay, bot.MatchTypeCommand, botHandler.WrapHandleHalfDay, mwLog, mwAuth)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdStart, bot.MatchTypeCommand, botHandler.WrapHandleStart, mwLog, mwMaintenance)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdStop, bot.MatchTypeCommand, botHandler.WrapHandleStop, mwLog, mwMaintenance, mwAuth)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdID, bot.MatchTypeCommand, botHandler.WrapHandleID, mwLog, mwMaintenance, mwAuth)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdWeek, bot.MatchTypeCommand, botHandler.WrapHandleWeek, mwLog, mwMaintenance, mwAuth, mwRate)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdDay, bot.MatchTypeCommand, botHandler.WrapHandleDay, mwLog, mwMaintenance, mwAuth, mwRate)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdHalfDay, bot.MatchTypeCommand, botHandler.WrapHandleHalfDay, mwLog, mwMaintenance, mwAuth, mwRate)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdHolidays, bot.MatchTypeCommand, botHandler.WrapHandleHolidays, mwLog, mwMaintenance, mwAuth)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdToday, bot.MatchTypeCommand, botHandler.WrapHandleToday, mwLog, mwMaintenance, mwAuth)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdClub, bot.MatchTypeCommand, botHandler.WrapHandleClub, mwLog, mwMaintenance, mwAuth)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdCurrent, bot.MatchTypeCommand, botHandler.WrapHandleCurrent, mwLog, mwMaintenance, mwAuth)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdBusiestDay, bot.MatchTypeCommand, botHandler.WrapHandleBusiestDay, mwLog, mwMaintenance, mwAuth)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdCompare, bot.MatchTypeCommand, botHandler.WrapHandleCompare, mwLog, mwMaintenance, mwAuth, mwRate)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdAgain, bot.MatchTypeCommand, botHandler.WrapHandleAgain, mwLog, mwMaintenance, mwAuth)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdAlert, bot.MatchTypeCommand, botHandler.WrapHandleAlert, mwLog, mwMaintenance, mwAuth)
+	// callback queries have no message, so the handler checks access itself
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, watcher.CallbackPrefix, bot.MatchTypePrefix, botHandler.WrapHandleCallback)
+	b.RegisterHandler(bot.HandlerTypeCallbackQueryData, watcher.WipeCallbackPrefix, bot.MatchTypePrefix, botHandler.WrapHandleWipeCallback)
 
 	// admin handlers
 	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdUsers, bot.MatchTypeCommand, botHandler.WrapHandleUsers, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdUser, bot.MatchTypeCommand, botHandler.WrapHandleUser, mwLog, mwAdmin)
 	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdApprove, bot.MatchTypeCommand, botHandler.WrapHandleApprove, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdApproveAll, bot.MatchTypeCommand, botHandler.WrapHandleApproveAll, mwLog, mwAdmin)
 	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdReject, bot.MatchTypeCommand, botHandler.WrapHandleReject, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdIntegrity, bot.MatchTypeCommand, botHandler.WrapHandleIntegrity, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdMaintenance, bot.MatchTypeCommand, botHandler.WrapHandleMaintenance, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdAdmins, bot.MatchTypeCommand, botHandler.WrapHandleAdmins, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdExportModel, bot.MatchTypeCommand, botHandler.WrapHandleExportModel, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdCollapse, bot.MatchTypeCommand, botHandler.WrapHandleCollapse, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdInsert, bot.MatchTypeCommand, botHandler.WrapHandleInsert, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdPing, bot.MatchTypeCommand, botHandler.WrapHandlePing, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdRaw, bot.MatchTypeCommand, botHandler.WrapHandleRaw, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdReview, bot.MatchTypeCommand, botHandler.WrapHandleReview, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdNextFetch, bot.MatchTypeCommand, botHandler.WrapHandleNextFetch, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdTime, bot.MatchTypeCommand, botHandler.WrapHandleTime, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdExplain, bot.MatchTypeCommand, botHandler.WrapHandleExplain, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdBackup, bot.MatchTypeCommand, botHandler.WrapHandleBackup, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdExport, bot.MatchTypeCommand, botHandler.WrapHandleExport, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdStats, bot.MatchTypeCommand, botHandler.WrapHandleStats, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdAvailability, bot.MatchTypeCommand, botHandler.WrapHandleAvailability, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdExportUsers, bot.MatchTypeCommand, botHandler.WrapHandleExportUsers, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdWipeEvents, bot.MatchTypeCommand, botHandler.WrapHandleWipeEvents, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdAddHoliday, bot.MatchTypeCommand, botHandler.WrapHandleAddHoliday, mwLog, mwAdmin)
+	b.RegisterHandler(bot.HandlerTypeMessageText, watcher.CmdDelHoliday, bot.MatchTypeCommand, botHandler.WrapHandleDelHoliday, mwLog, mwAdmin)
 
 	slog.Info("bot is starting")
 	b.Start(ctx)
@@ -191,32 +360,115 @@ func initLogger(debug bool, w io.Writer) {
 	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})))
 }
 
-func runFetcher(ctx context.Context, cfg *config.Config, db *databaser.DB) (<-chan struct{}, <-chan databaser.Event, error) {
-	if !cfg.Fetcher.Active {
-		slog.Info("fetcher is inactive")
-		doneCh := make(chan struct{})
-		close(doneCh)
-		return doneCh, nil, nil
+func runFetcher(ctx context.Context, cfg *config.Config, db *databaser.DB, m *metrics.Metrics) (*fetcher.Fetcher, <-chan struct{}, <-chan databaser.Event, error) {
+	if !cfg.Features.Fetcher {
+		return nil, inactive("fetcher"), nil, nil
 	}
 
 	fetchWorker := &fetcher.Fetcher{
+		Db:  db,
+		URL: cfg.Fetcher.URL,
+		Auth: fetcher.Auth{
+			Kind:     cfg.Fetcher.AuthType,
+			Header:   cfg.Fetcher.AuthHeader,
+			Token:    cfg.Fetcher.Token,
+			Username: cfg.Fetcher.Username,
+			Password: cfg.Fetcher.Password,
+		},
+		EventWebhook:    cfg.Fetcher.EventWebhook,
+		Timeout:         cfg.Fetcher.Timeout,
+		QueryTimeout:    cfg.Database.Timeout,
+		BatchTimeout:    cfg.Fetcher.BatchTimeout,
+		BatchSize:       cfg.Fetcher.BatchSize,
+		BreakerCooldown: cfg.Fetcher.BreakerCooldown,
+		BreakerFailures: cfg.Fetcher.BreakerFailures,
+		Clubs:           clubTargets(cfg.Fetcher.Clubs),
+		Workers:         cfg.Fetcher.Workers,
+		Retries:         cfg.Fetcher.Retries,
+		RetryBase:       cfg.Fetcher.RetryBase,
+		SummaryPeriod:   cfg.Fetcher.SummaryInterval,
+		Client:          cfg.Fetcher.Client.NewClient(),
+		Transform: fetcher.Transform{
+			Kind:   cfg.Fetcher.Transform,
+			Scale:  cfg.Fetcher.TransformScale,
+			Offset: cfg.Fetcher.TransformOffset,
+		},
+		UseResponseTime: cfg.Fetcher.UseResponseTime,
+		Metrics:         m,
+	}
+
+	doneCh, eventCh, err := fetchWorker.Run(ctx)
+	return fetchWorker, doneCh, eventCh, err
+}
+
+// clubTargets converts additional clubs of the configuration to fetcher targets.
+func clubTargets(clubs []config.Club) []fetcher.Target {
+	targets := make([]fetcher.Target, 0, len(clubs))
+	for _, club := range clubs {
+		targets = append(targets, fetcher.Target{ID: club.ID, URL: club.URL})
+	}
+	return targets
+}
+
+func runAlerter(ctx context.Context, cfg *config.Config, eventCh <-chan databaser.Event) (<-chan databaser.Event, error) {
+	if !cfg.Features.Alerter {
+		slog.Info("alerter is inactive")
+		return eventCh, nil
+	}
+
+	detector, err := alerter.New(float64(cfg.Alerter.High), float64(cfg.Alerter.Reset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alerter: %w", err)
+	}
+
+	notify := func(event databaser.Event) {
+		slog.Warn("load is above threshold", "event", &event, "high", cfg.Alerter.High)
+	}
+
+	return detector.Run(ctx, eventCh, notify), nil
+}
+
+// runSubscriber starts sending high load alerts to subscribed users,
+// alerts are sent after the telegram bot sets the notify function.
+func runSubscriber(ctx context.Context, cfg *config.Config, db *databaser.DB, eventCh <-chan databaser.Event) (*alerter.Subscriber, <-chan databaser.Event) {
+	if !cfg.Features.Subscriptions {
+		slog.Info("subscriber is inactive")
+		return nil, eventCh
+	}
+
+	subscriber := &alerter.Subscriber{
 		Db:           db,
-		URL:          cfg.Fetcher.URL,
-		Token:        cfg.Fetcher.AuthToken(),
-		Timeout:      cfg.Fetcher.Timeout,
+		Cooldown:     cfg.Subscriptions.Cooldown,
 		QueryTimeout: cfg.Database.Timeout,
-		Client:       &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}},
 	}
 
-	return fetchWorker.Run(ctx)
+	return subscriber, subscriber.Run(ctx, eventCh)
 }
 
-func runHolidayer(ctx context.Context, cfg *config.Config, db *databaser.DB) (<-chan struct{}, error) {
-	if !cfg.Holidayer.Active {
-		slog.Info("holidayer is inactive")
-		doneCh := make(chan struct{})
-		close(doneCh)
-		return doneCh, nil
+func runCacher(ctx context.Context, cfg *config.Config, db *databaser.DB, eventCh <-chan databaser.Event) (*cacher.Cache, <-chan databaser.Event, error) {
+	if !cfg.Features.Cache {
+		slog.Info("cacher is inactive")
+		return nil, eventCh, nil
+	}
+
+	cache, err := cacher.New(cfg.Cache.Size, cfg.Cache.MaxAge)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create cache: %w", err)
+	}
+
+	seedCtx, cancel := context.WithTimeout(ctx, cfg.Database.Timeout)
+	defer cancel()
+
+	if err = cache.Seed(seedCtx, db); err != nil {
+		return nil, nil, err
+	}
+
+	return cache, cache.Run(ctx, eventCh), nil
+}
+
+func runHolidayer(ctx context.Context, cfg *config.Config, db *databaser.DB) (*holidayer.HolidayParams, <-chan struct{}, error) {
+	if !cfg.Features.Holidayer {
+		return nil, inactive("holidayer"), nil
 	}
 
 	holidayerWorker := &holidayer.HolidayParams{
@@ -225,18 +477,50 @@ func runHolidayer(ctx context.Context, cfg *config.Config, db *databaser.DB) (<-
 		URL:          cfg.Holidayer.URL,
 		Timeout:      cfg.Holidayer.Timeout,
 		QueryTimeout: cfg.Database.Timeout,
-		Client:       &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}},
+		Client:       cfg.Holidayer.Client.NewClient(),
+	}
+
+	doneCh, err := holidayerWorker.Run(ctx)
+	return holidayerWorker, doneCh, err
+}
+
+func runPruner(ctx context.Context, cfg *config.Config, db *databaser.DB) <-chan struct{} {
+	if !cfg.Features.Retention {
+		return inactive("pruner")
+	}
+
+	prunerWorker := &pruner.Pruner{
+		Db:           db,
+		Location:     cfg.Base.TimeLocation,
+		RawTTL:       cfg.Retention.RawTTL,
+		AggregateTTL: cfg.Retention.AggregateTTL,
+		Timeout:      cfg.Retention.Timeout,
+		QueryTimeout: cfg.Database.Timeout,
+	}
+
+	return prunerWorker.Run(ctx)
+}
+
+func runBackuper(ctx context.Context, cfg *config.Config, db *databaser.DB) <-chan struct{} {
+	if !cfg.Features.Backup {
+		return inactive("backuper")
+	}
+
+	backuperWorker := &backuper.Backuper{
+		Db:           db,
+		Dir:          cfg.Database.BackupDir,
+		Period:       cfg.Database.BackupInterval,
+		QueryTimeout: cfg.Database.Timeout,
 	}
 
-	return holidayerWorker.Run(ctx)
+	return backuperWorker.Run(ctx)
 }
 
-func runPredictor(ctx context.Context, cfg *config.Config, db *databaser.DB, eventCh <-chan databaser.Event) (*predictor.Controller, <-chan struct{}, error) {
-	if !cfg.Predictor.Active {
-		slog.Info("predictor is inactive")
-		doneCh := make(chan struct{})
-		close(doneCh)
-		return nil, doneCh, nil
+func runPredictor(
+	ctx context.Context, cfg *config.Config, db *databaser.DB, eventCh <-chan databaser.Event, m *metrics.Metrics,
+) (*predictor.Controller, <-chan struct{}, error) {
+	if !cfg.Features.Predictor {
+		return nil, inactive("predictor"), nil
 	}
 
 	controller, err := predictor.Run(ctx, db, eventCh, cfg)
@@ -244,5 +528,21 @@ func runPredictor(ctx context.Context, cfg *config.Config, db *databaser.DB, eve
 		return nil, nil, fmt.Errorf("failed to start predictor controller: %w", err)
 	}
 
+	controller.SetMetrics(m)
 	return controller, controller.Run(ctx), nil
 }
+
+// runMetrics starts the metrics server, metrics are nil if it is disabled.
+func runMetrics(ctx context.Context, cfg *config.Config) (*metrics.Metrics, <-chan struct{}, error) {
+	if !cfg.Features.Metrics {
+		return nil, inactive("metrics"), nil
+	}
+
+	m := metrics.New()
+	doneCh, err := m.Serve(ctx, cfg.Base.MetricsAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return m, doneCh, nil
+}
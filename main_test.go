@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/ggp/config"
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func TestSeedDatabase(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	seedPath := filepath.Join(dir, "seed.csv")
+	content := "time,load\n2025-01-01 10:00:00,10\n2025-01-01 11:00:00,20\n"
+	if err := os.WriteFile(seedPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write seed file: %v", err)
+	}
+
+	// a new database file, the same as on the first start
+	db, err := databaser.New(ctx, filepath.Join(dir, "empty.db"), 1)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() {
+		if closeErr := db.Close(); closeErr != nil {
+			t.Errorf("failed to close database: %v", closeErr)
+		}
+	})
+
+	if err = db.Init(ctx); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	cfg := &config.Config{
+		Base:     config.Base{TimeLocation: time.UTC},
+		Database: config.Database{SeedCSV: seedPath, Timeout: 5 * time.Second},
+	}
+
+	// the second seed skips existing events
+	for range 2 {
+		if err = seedDatabase(db, cfg); err != nil {
+			t.Fatalf("seedDatabase() error = %v", err)
+		}
+	}
+
+	count, err := db.CountEvents(ctx)
+	if err != nil {
+		t.Fatalf("CountEvents() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("events count = %d, want 2", count)
+	}
+}
+
+func TestRunPruner_RetentionDays(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	dir := t.TempDir()
+
+	cfgPath := filepath.Join(dir, "config.toml")
+	content := "[database]\npath = \"test.db\"\nquery_timeout = 5\nretention_days = 30\n"
+	if err := os.WriteFile(cfgPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	db, err := databaser.New(ctx, filepath.Join(dir, cfg.Database.Path), 1)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	t.Cleanup(func() {
+		if closeErr := db.Close(); closeErr != nil {
+			t.Errorf("failed to close database: %v", closeErr)
+		}
+	})
+
+	now := time.Now().UTC().Truncate(time.Second)
+	events := []databaser.Event{
+		{Timestamp: now.AddDate(0, 0, -40), Load: 10},
+		{Timestamp: now.Add(-time.Hour), Load: 20},
+	}
+	if err = db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	// the first prune is done on start
+	doneCh := runPruner(ctx, cfg, db)
+	cancel()
+	<-doneCh
+
+	count, err := db.CountEvents(context.Background())
+	if err != nil {
+		t.Fatalf("CountEvents() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("events count = %d, want 1", count)
+	}
+}
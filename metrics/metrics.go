@@ -0,0 +1,215 @@
+// Package metrics contains Prometheus metrics of the application and the HTTP server to expose them.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Path is the URL path of the metrics handler.
+const Path = "/metrics"
+
+// Fetch results are label values of the fetches counter.
+const (
+	FetchSuccess = "success"
+	FetchFailure = "failure"
+)
+
+const (
+	// namespace is the prefix of all metric names.
+	namespace = "ggp"
+	// shutdownTimeout is the max duration of the metrics server graceful shutdown.
+	shutdownTimeout = 5 * time.Second
+	// readHeaderTimeout limits the time to read request headers of the metrics server.
+	readHeaderTimeout = 5 * time.Second
+)
+
+// Metrics contains application metrics registered in its own registry.
+// All methods are safe to call on a nil value, so components work without metrics.
+type Metrics struct {
+	registry      *prometheus.Registry
+	fetches       *prometheus.CounterVec
+	fetchDuration prometheus.Histogram
+	predictedLoad prometheus.Gauge
+	actualLoad    prometheus.Gauge
+}
+
+// New returns metrics registered in a new registry with Go runtime and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		fetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "fetcher",
+			Name:      "fetches_total",
+			Help:      "Number of load fetches by result.",
+		}, []string{"result"}),
+		fetchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "fetcher",
+			Name:      "fetch_duration_seconds",
+			Help:      "Duration of load fetches including retries and saving.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		predictedLoad: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "predictor",
+			Name:      "predicted_load_percent",
+			Help:      "Typical load predicted for the time of the last fetched event.",
+		}),
+		actualLoad: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "predictor",
+			Name:      "actual_load_percent",
+			Help:      "Load of the last fetched event.",
+		}),
+	}
+
+	// label values are initialized, so both series are exposed before the first fetch
+	m.fetches.WithLabelValues(FetchSuccess)
+	m.fetches.WithLabelValues(FetchFailure)
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.fetches, m.fetchDuration, m.predictedLoad, m.actualLoad,
+	)
+	return m
+}
+
+// ObserveFetch counts the fetch result and its duration, a fetch failed if err is not nil.
+func (m *Metrics) ObserveFetch(duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	result := FetchSuccess
+	if err != nil {
+		result = FetchFailure
+	}
+
+	m.fetches.WithLabelValues(result).Inc()
+	m.fetchDuration.Observe(duration.Seconds())
+}
+
+// ObservePrediction sets the predicted and the actual load of the last event.
+func (m *Metrics) ObservePrediction(predicted float64, actual uint8) {
+	if m == nil {
+		return
+	}
+
+	m.predictedLoad.Set(predicted)
+	m.actualLoad.Set(float64(actual))
+}
+
+// Fetches returns the number of fetches with the result.
+func (m *Metrics) Fetches(result string) float64 {
+	if m == nil {
+		return 0
+	}
+	return counterValue(m.fetches.WithLabelValues(result))
+}
+
+// FetchDurations returns the number of observed fetch durations.
+func (m *Metrics) FetchDurations() uint64 {
+	if m == nil {
+		return 0
+	}
+
+	var metric dto.Metric
+	if err := m.fetchDuration.Write(&metric); err != nil {
+		return 0
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+// Prediction returns the predicted and the actual load of the last event.
+func (m *Metrics) Prediction() (float64, float64) {
+	if m == nil {
+		return 0, 0
+	}
+	return gaugeValue(m.predictedLoad), gaugeValue(m.actualLoad)
+}
+
+// Handler returns the HTTP handler exposing the metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Serve starts the metrics HTTP server on the address and stops it after the context cancellation.
+// It's a no-op if the address is empty or metrics are nil. The returned channel is closed after the server stop.
+func (m *Metrics) Serve(ctx context.Context, addr string) (<-chan struct{}, error) {
+	doneCh := make(chan struct{})
+	if m == nil || addr == "" {
+		slog.InfoContext(ctx, "metrics server is inactive")
+		close(doneCh)
+		return doneCh, nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(Path, m.Handler())
+
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: readHeaderTimeout}
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", addr)
+	if err != nil {
+		close(doneCh)
+		return doneCh, fmt.Errorf("listen metrics address: %w", err)
+	}
+
+	go func() {
+		defer close(doneCh)
+		slog.InfoContext(ctx, "metrics server started", "addr", listener.Addr().String())
+
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- server.Serve(listener)
+		}()
+
+		select {
+		case err := <-serveErr:
+			if !errors.Is(err, http.ErrServerClosed) {
+				slog.ErrorContext(ctx, "metrics server failed", "error", err)
+			}
+			return
+		case <-ctx.Done():
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.ErrorContext(ctx, "metrics server shutdown failed", "error", err)
+		}
+		slog.InfoContext(ctx, "metrics server stopped")
+	}()
+
+	return doneCh, nil
+}
+
+// counterValue returns the current value of the counter.
+func counterValue(c prometheus.Counter) float64 {
+	var metric dto.Metric
+	if err := c.Write(&metric); err != nil {
+		return 0
+	}
+	return metric.GetCounter().GetValue()
+}
+
+// gaugeValue returns the current value of the gauge.
+func gaugeValue(g prometheus.Gauge) float64 {
+	var metric dto.Metric
+	if err := g.Write(&metric); err != nil {
+		return 0
+	}
+	return metric.GetGauge().GetValue()
+}
@@ -0,0 +1,161 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_ObserveFetch(t *testing.T) {
+	m := New()
+
+	m.ObserveFetch(100*time.Millisecond, nil)
+	m.ObserveFetch(200*time.Millisecond, nil)
+	m.ObserveFetch(time.Second, errors.New("failed"))
+
+	if got := m.Fetches(FetchSuccess); got != 2 {
+		t.Errorf("Fetches(success) = %v, want 2", got)
+	}
+	if got := m.Fetches(FetchFailure); got != 1 {
+		t.Errorf("Fetches(failure) = %v, want 1", got)
+	}
+	if got := m.FetchDurations(); got != 3 {
+		t.Errorf("FetchDurations() = %d, want 3", got)
+	}
+}
+
+func TestMetrics_ObservePrediction(t *testing.T) {
+	m := New()
+
+	m.ObservePrediction(42.5, 50)
+	m.ObservePrediction(30, 25)
+
+	predicted, actual := m.Prediction()
+	if predicted != 30 || actual != 25 {
+		t.Errorf("Prediction() = %v, %v, want 30, 25", predicted, actual)
+	}
+}
+
+func TestMetrics_Nil(t *testing.T) {
+	var m *Metrics
+
+	m.ObserveFetch(time.Second, nil)
+	m.ObservePrediction(10, 20)
+
+	if got := m.Fetches(FetchSuccess); got != 0 {
+		t.Errorf("Fetches() = %v, want 0", got)
+	}
+	if got := m.FetchDurations(); got != 0 {
+		t.Errorf("FetchDurations() = %d, want 0", got)
+	}
+	if predicted, actual := m.Prediction(); predicted != 0 || actual != 0 {
+		t.Errorf("Prediction() = %v, %v, want zeros", predicted, actual)
+	}
+
+	doneCh, err := m.Serve(context.Background(), ":0")
+	if err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	select {
+	case <-doneCh:
+	default:
+		t.Error("done channel of nil metrics should be closed")
+	}
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.ObserveFetch(time.Second, nil)
+	m.ObservePrediction(40, 45)
+
+	server := httptest.NewServer(m.Handler())
+	defer server.Close()
+
+	body := getBody(t, server.URL)
+	for _, want := range []string{
+		`ggp_fetcher_fetches_total{result="success"} 1`,
+		`ggp_fetcher_fetches_total{result="failure"} 0`,
+		"ggp_fetcher_fetch_duration_seconds_count 1",
+		"ggp_predictor_predicted_load_percent 40",
+		"ggp_predictor_actual_load_percent 45",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics should contain %q", want)
+		}
+	}
+}
+
+func TestMetrics_Serve(t *testing.T) {
+	m := New()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	doneCh, err := m.Serve(ctx, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	cancel()
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("metrics server did not stop after context cancellation")
+	}
+}
+
+func TestMetrics_Serve_Empty(t *testing.T) {
+	doneCh, err := New().Serve(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	select {
+	case <-doneCh:
+	default:
+		t.Error("done channel should be closed for the empty address")
+	}
+}
+
+func TestMetrics_Serve_InvalidAddr(t *testing.T) {
+	doneCh, err := New().Serve(context.Background(), "invalid:address:0")
+	if err == nil {
+		t.Fatal("expected listen error")
+	}
+
+	select {
+	case <-doneCh:
+	default:
+		t.Error("done channel should be closed after the error")
+	}
+}
+
+func getBody(t *testing.T, url string) string {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, http.NoBody)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to get metrics: %v", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			t.Errorf("failed to close body: %v", closeErr)
+		}
+	}()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,114 @@
+package plotter
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// maxBandMargin is the margin of the confidence band around a prediction with zero confidence, in load percent.
+const maxBandMargin = 30.0
+
+// bandSeries is a semi-transparent area between lower and upper load values.
+type bandSeries struct {
+	name  string
+	xs    []time.Time
+	lower []float64
+	upper []float64
+	fill  drawing.Color
+}
+
+// newBandSeries returns the confidence band around the prediction, its margin grows as the confidence decreases.
+func newBandSeries(prediction []databaser.Event) *bandSeries {
+	band := &bandSeries{
+		name:  "Confidence",
+		xs:    make([]time.Time, 0, len(prediction)),
+		lower: make([]float64, 0, len(prediction)),
+		upper: make([]float64, 0, len(prediction)),
+		fill:  drawing.Color{R: 0xff, G: 0x00, B: 0x00, A: 0x30},
+	}
+
+	for _, event := range prediction {
+		margin := maxBandMargin * (1 - min(max(event.Confidence, 0), 1))
+		band.xs = append(band.xs, event.Timestamp)
+		band.lower = append(band.lower, max(event.Predict-margin, 0))
+		band.upper = append(band.upper, min(event.Predict+margin, 100))
+	}
+
+	return band
+}
+
+// newRangeBand returns the band between min and max loads of aggregated events.
+func newRangeBand(events []databaser.Event) *bandSeries {
+	band := &bandSeries{
+		name:  "Range",
+		xs:    make([]time.Time, 0, len(events)),
+		lower: make([]float64, 0, len(events)),
+		upper: make([]float64, 0, len(events)),
+		fill:  drawing.Color{R: 0x00, G: 0x00, B: 0xff, A: 0x20},
+	}
+
+	for _, event := range events {
+		band.xs = append(band.xs, event.Timestamp)
+		band.lower = append(band.lower, float64(event.MinLoad))
+		band.upper = append(band.upper, float64(event.MaxLoad))
+	}
+
+	return band
+}
+
+// hasLoadRange returns true if the events are aggregated and have load ranges.
+func hasLoadRange(events []databaser.Event) bool {
+	return slices.ContainsFunc(events, func(e databaser.Event) bool {
+		return e.MaxLoad > 0
+	})
+}
+
+// GetName returns the name of the series.
+func (bs *bandSeries) GetName() string {
+	return bs.name
+}
+
+// GetYAxis returns the y-axis of the series.
+func (bs *bandSeries) GetYAxis() chart.YAxisType {
+	return chart.YAxisPrimary
+}
+
+// GetStyle returns the style of the series.
+func (bs *bandSeries) GetStyle() chart.Style {
+	return chart.Style{FillColor: bs.fill}
+}
+
+// Validate checks the series has at least two points of both bounds.
+func (bs *bandSeries) Validate() error {
+	if len(bs.xs) < 2 || len(bs.lower) != len(bs.xs) || len(bs.upper) != len(bs.xs) {
+		return errors.New("band must have at least two points of both bounds")
+	}
+	return nil
+}
+
+// Render draws the band as a polygon, the upper bound from left to right and then the lower one back.
+func (bs *bandSeries) Render(r chart.Renderer, canvasBox chart.Box, xrange, yrange chart.Range, _ chart.Style) {
+	point := func(i int, y float64) (int, int) {
+		return canvasBox.Left + xrange.Translate(chart.TimeToFloat64(bs.xs[i])), canvasBox.Bottom - yrange.Translate(y)
+	}
+
+	r.SetFillColor(bs.GetStyle().FillColor)
+	r.SetStrokeColor(drawing.ColorTransparent)
+	r.SetStrokeWidth(0)
+
+	r.MoveTo(point(0, bs.upper[0]))
+	for i := 1; i < len(bs.xs); i++ {
+		r.LineTo(point(i, bs.upper[i]))
+	}
+	for i := len(bs.xs) - 1; i >= 0; i-- {
+		r.LineTo(point(i, bs.lower[i]))
+	}
+	r.Close()
+	r.Fill()
+}
@@ -0,0 +1,66 @@
+package plotter
+
+import (
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// WithMaxPoints limits the number of points of every series, longer series are downsampled by Downsample.
+// Zero or negative value means no limit, it's the default.
+func WithMaxPoints(maxPoints int) Option {
+	return func(o *options) {
+		o.maxPoints = maxPoints
+	}
+}
+
+// Downsample averages events by equal buckets, so the result contains at most maxPoints events.
+// Loads, predicted loads and confidences are averaged, the first and the last timestamps of the period are kept.
+// The load range of aggregated events is widened to cover all events of the bucket.
+// Events are returned as is if there are not so many or maxPoints is less than 2.
+func Downsample(events []databaser.Event, maxPoints int) []databaser.Event {
+	n := len(events)
+	if maxPoints < 2 || n <= maxPoints {
+		return events
+	}
+
+	size := (n + maxPoints - 1) / maxPoints
+	result := make([]databaser.Event, 0, maxPoints)
+
+	for start := 0; start < n; start += size {
+		end := min(start+size, n)
+		count := end - start
+
+		var (
+			sum                 int
+			predict, confidence float64
+			minLoad, maxLoad    = events[start].MinLoad, events[start].MaxLoad
+		)
+		for _, e := range events[start:end] {
+			sum += int(e.Load)
+			predict += e.Predict
+			confidence += e.Confidence
+			minLoad, maxLoad = min(minLoad, e.MinLoad), max(maxLoad, e.MaxLoad)
+		}
+
+		timestamp := events[start].Timestamp
+		switch {
+		case end == n:
+			timestamp = events[n-1].Timestamp
+		case start > 0:
+			first, last := events[start].Timestamp, events[end-1].Timestamp
+			timestamp = first.Add(last.Sub(first) / 2)
+		}
+
+		result = append(result, databaser.Event{
+			Timestamp: timestamp,
+			ClubID:    events[start].ClubID,
+			// #nosec G115 -- average of uint8 values fits in uint8
+			Load:       uint8((sum + count/2) / count),
+			MinLoad:    minLoad,
+			MaxLoad:    maxLoad,
+			Predict:    predict / float64(count),
+			Confidence: confidence / float64(count),
+		})
+	}
+
+	return result
+}
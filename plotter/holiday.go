@@ -0,0 +1,99 @@
+package plotter
+
+import (
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// holidayLabelOffset is a distance of holiday labels below the top of the load axis.
+const holidayLabelOffset = 5.0
+
+// holidayColor is a color of holiday markers.
+//
+//nolint:gochecknoglobals // package-level color constant
+var holidayColor = drawing.Color{R: 46, G: 139, B: 87, A: 200}
+
+// HolidayChecker checks holidays of graph days, predictor.HolidayChecker implements it.
+type HolidayChecker interface {
+	IsHoliday(t time.Time) bool
+	HolidayTitle(t time.Time) string
+}
+
+// WithHolidays adds vertical markers at the start of holidays within the time range of events,
+// they are labeled by holiday titles. Nil checker disables markers.
+func WithHolidays(checker HolidayChecker) Option {
+	return func(o *options) {
+		o.holidays = checker
+	}
+}
+
+// holidayMarker is a start of the holiday with its title.
+type holidayMarker struct {
+	start time.Time
+	title string
+}
+
+// holidayMarkers returns starts of holidays in the location within [first, last], other days are clipped.
+func holidayMarkers(checker HolidayChecker, first, last time.Time, location *time.Location) []holidayMarker {
+	if checker == nil || last.Before(first) {
+		return nil
+	}
+
+	var markers []holidayMarker
+	first, last = first.In(location), last.In(location)
+	year, month, day := first.Date()
+
+	for start := time.Date(year, month, day, 0, 0, 0, 0, location); !start.After(last); start = start.AddDate(0, 0, 1) {
+		if start.Before(first) || !checker.IsHoliday(start) {
+			continue
+		}
+		markers = append(markers, holidayMarker{start: start, title: checker.HolidayTitle(start)})
+	}
+
+	return markers
+}
+
+// holidaySeries returns vertical lines of the markers from zero to the top of the load axis
+// and the series of their labels, it's nil if there are no titles.
+func holidaySeries(markers []holidayMarker, top float64) ([]chart.Series, chart.Series) {
+	var (
+		lines  = make([]chart.Series, 0, len(markers))
+		labels = chart.AnnotationSeries{
+			Style: chart.Style{
+				FontSize:    8.0,
+				FontColor:   holidayColor,
+				StrokeColor: holidayColor,
+				FillColor:   chart.ColorWhite,
+			},
+		}
+	)
+
+	for _, marker := range markers {
+		lines = append(lines, chart.TimeSeries{
+			Name:    "Holiday",
+			XValues: []time.Time{marker.start, marker.start},
+			YValues: []float64{0, top},
+			Style: chart.Style{
+				StrokeColor:     holidayColor,
+				StrokeWidth:     1.0,
+				StrokeDashArray: []float64{4.0, 4.0},
+			},
+		})
+
+		if marker.title != "" {
+			labels.Annotations = append(labels.Annotations, chart.Value2{
+				XValue: chart.TimeToFloat64(marker.start),
+				YValue: top - holidayLabelOffset,
+				Label:  marker.title,
+			})
+		}
+	}
+
+	if len(labels.Annotations) == 0 {
+		return lines, nil
+	}
+
+	return lines, labels
+}
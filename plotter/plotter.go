@@ -5,11 +5,21 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/golang/freetype/truetype"
 	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
 
 	"github.com/z0rr0/ggp/databaser"
 )
@@ -35,6 +45,57 @@ const (
 	periodMonth  = time.Hour * 24 * 365 * 2
 )
 
+// GraphFormat is an image format of the generated graph.
+type GraphFormat string
+
+// Image formats of the generated graph.
+const (
+	FormatPNG  GraphFormat = "png"
+	FormatWebP GraphFormat = "webp"
+	FormatSVG  GraphFormat = "svg"
+)
+
+// ErrRender is returned by Graph if the chart image can't be rendered, e.g. due to memory allocation failure.
+var ErrRender = errors.New("render graph")
+
+// maxPointMarkers is a number of events above which point markers are not drawn, they become noise.
+const maxPointMarkers = 150
+
+// compactPadding is a padding of the compact graph in pixels, the default one is chart.DefaultBackgroundPadding.
+const compactPadding = 4
+
+const (
+	watermarkFontSize = 12.0
+	watermarkMargin   = 8 // pixels from the bottom-right corner
+)
+
+// Option configures optional graph features.
+type Option func(*options)
+
+// options contains optional graph settings.
+type options struct {
+	holidays   HolidayChecker
+	format     GraphFormat
+	watermark  string
+	typical    []databaser.Event
+	series     []Series
+	maxPoints  int
+	capacity   float64
+	showPoints bool
+	expected   bool
+	band       bool
+	compact    bool
+	overlap    bool
+}
+
+// WithPoints enables point markers at each real event.
+// Markers are skipped automatically for dense data.
+func WithPoints(enabled bool) Option {
+	return func(o *options) {
+		o.showPoints = enabled
+	}
+}
+
 // dtFormatMap maps time format constants to their corresponding layout strings.
 // Full format "2006-01-02T15:04:05Z07:00".
 //
@@ -81,8 +142,87 @@ func getDateFormat(events []time.Time) string {
 	}
 }
 
-// Graph generates a graph from the provided events and returns a new image like byte slice.
-func Graph(events, prediction []databaser.Event, location *time.Location) ([]byte, error) {
+// WithTypical adds a faint series with typical load values for the historical period.
+func WithTypical(typical []databaser.Event) Option {
+	return func(o *options) {
+		o.typical = typical
+	}
+}
+
+// WithExpected adds a highlighted series with expected load values over the historical period,
+// it's used instead of WithTypical to compare real events with the model.
+func WithExpected(expected []databaser.Event) Option {
+	return func(o *options) {
+		o.typical = expected
+		o.expected = true
+	}
+}
+
+// WithCapacity adds a horizontal reference line at the capacity load level in percents
+// over the time range of events and predictions. Zero or negative value means no line, it's the default.
+func WithCapacity(capacity float64) Option {
+	return func(o *options) {
+		o.capacity = capacity
+	}
+}
+
+// WithConfidenceBand enables a shaded band around the prediction, it is wider for less confident hours.
+// The band is drawn if there are at least two prediction points.
+func WithConfidenceBand(enabled bool) Option {
+	return func(o *options) {
+		o.band = enabled
+	}
+}
+
+// WithCompact reduces the image padding and omits the time axis name, so the plot area is larger on small screens.
+// The image size is not changed.
+func WithCompact(enabled bool) Option {
+	return func(o *options) {
+		o.compact = enabled
+	}
+}
+
+// WithWatermark adds a faint text watermark to the bottom-right corner of the image.
+// The rendered image is decoded to draw the text, so it takes an additional re-encoding step.
+func WithWatermark(text string) Option {
+	return func(o *options) {
+		o.watermark = text
+	}
+}
+
+// WithKeepOverlap keeps prediction points that are earlier than the last event.
+// By default, they are trimmed, so the prediction curve continues the events one.
+func WithKeepOverlap(enabled bool) Option {
+	return func(o *options) {
+		o.overlap = enabled
+	}
+}
+
+// trimOverlap returns prediction points that are not earlier than the last event.
+func trimOverlap(events, prediction []databaser.Event) []databaser.Event {
+	n := len(events)
+	if n == 0 {
+		return prediction
+	}
+
+	last := events[n-1].Timestamp
+	i := slices.IndexFunc(prediction, func(e databaser.Event) bool {
+		return !e.Timestamp.Before(last)
+	})
+	if i < 0 {
+		return nil
+	}
+	return prediction[i:]
+}
+
+// graphSeries returns chart series for the events and options, time values of the events and the max load value.
+func graphSeries(events, prediction []databaser.Event, o *options) ([]chart.Series, []time.Time, float64) {
+	if !o.overlap {
+		prediction = trimOverlap(events, prediction)
+	}
+	events, prediction = Downsample(events, o.maxPoints), Downsample(prediction, o.maxPoints)
+	typical := Downsample(o.typical, o.maxPoints)
+
 	var (
 		n  = len(events)
 		np = len(prediction)
@@ -93,10 +233,6 @@ func Graph(events, prediction []databaser.Event, location *time.Location) ([]byt
 		pys = make([]float64, 0, np)
 	)
 
-	if n < 1 {
-		return nil, errors.New("graph called with no events")
-	}
-
 	maxY := 0.0
 	for _, event := range events {
 		load := event.FloatLoad()
@@ -113,7 +249,7 @@ func Graph(events, prediction []databaser.Event, location *time.Location) ([]byt
 	}
 
 	mainSeries := chart.TimeSeries{
-		Name:    "Load",
+		Name:    loadSeriesName,
 		XValues: xs,
 		YValues: ys,
 		Style: chart.Style{
@@ -123,7 +259,71 @@ func Graph(events, prediction []databaser.Event, location *time.Location) ([]byt
 	}
 	series := []chart.Series{mainSeries}
 
+	if n > 1 && hasLoadRange(events) {
+		band := newRangeBand(events)
+		maxY = max(maxY, slices.Max(band.upper))
+		// the average load line is drawn over the band
+		series = append([]chart.Series{band}, series...)
+	}
+
+	if len(o.series) > 0 {
+		extra, extraMaxY := extraSeries(o.series, o.maxPoints)
+		maxY = max(maxY, extraMaxY)
+		series = append(series, extra...)
+	}
+
+	if nt := len(typical); nt > 1 {
+		txs := make([]time.Time, 0, nt)
+		tys := make([]float64, 0, nt)
+
+		for _, event := range typical {
+			txs = append(txs, event.Timestamp)
+			tys = append(tys, event.Predict)
+			maxY = max(maxY, event.Predict)
+		}
+
+		typicalSeries := chart.TimeSeries{
+			Name:    "Typical",
+			XValues: txs,
+			YValues: tys,
+			Style: chart.Style{
+				StrokeColor: chart.ColorLightGray,
+				StrokeWidth: 2.0,
+			},
+		}
+
+		if o.expected {
+			typicalSeries.Name = "Expected"
+			typicalSeries.Style = chart.Style{StrokeColor: chart.ColorOrange, StrokeWidth: 3.0}
+			series = append(series, typicalSeries)
+		} else {
+			// draw under the main series
+			series = append([]chart.Series{typicalSeries}, series...)
+		}
+	}
+
+	if o.showPoints && n <= maxPointMarkers {
+		pointsSeries := chart.TimeSeries{
+			Name:    "Points",
+			XValues: xs,
+			YValues: ys,
+			Style: chart.Style{
+				StrokeWidth: chart.Disabled,
+				DotWidth:    3.0,
+				DotColor:    chart.ColorBlue,
+			},
+		}
+		series = append(series, pointsSeries)
+	}
+
 	if np > 1 {
+		if o.band {
+			band := newBandSeries(prediction)
+			maxY = max(maxY, slices.Max(band.upper))
+			// draw under all other series
+			series = append([]chart.Series{band}, series...)
+		}
+
 		predictionSeries := chart.TimeSeries{
 			Name:    "Prediction",
 			XValues: pxs,
@@ -137,8 +337,78 @@ func Graph(events, prediction []databaser.Event, location *time.Location) ([]byt
 		series = append(series, predictionSeries)
 	}
 
+	if o.capacity > 0 && n > 0 {
+		first, last := xs[0], xs[n-1]
+		if np > 0 {
+			first, last = minTime(first, pxs[0]), maxTime(last, pxs[np-1])
+		}
+
+		capacitySeries := chart.TimeSeries{
+			Name:    "Capacity",
+			XValues: []time.Time{first, last},
+			YValues: []float64{o.capacity, o.capacity},
+			Style: chart.Style{
+				StrokeColor:     chart.ColorRed.WithAlpha(160),
+				StrokeWidth:     2.0,
+				StrokeDashArray: []float64{8.0, 4.0},
+			},
+		}
+		maxY = max(maxY, o.capacity)
+		// draw under all other series
+		series = append([]chart.Series{capacitySeries}, series...)
+	}
+
+	return series, xs, maxY
+}
+
+// minTime returns the earlier of two times.
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// maxTime returns the later of two times.
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// Graph generates a PNG graph from the provided events and returns a new image like byte slice.
+func Graph(events, prediction []databaser.Event, location *time.Location, opts ...Option) ([]byte, error) {
+	return GraphWithFormat(events, prediction, location, FormatPNG, opts...)
+}
+
+// GraphWithFormat generates a graph from the provided events in the image format and returns a new image like byte slice.
+// WebP is lossless, it is smaller than PNG, but requires an additional re-encoding step.
+// SVG is a vector image, it stays sharp when it is scaled.
+func GraphWithFormat(
+	events, prediction []databaser.Event, location *time.Location, format GraphFormat, opts ...Option,
+) ([]byte, error) {
+	o := options{format: format}
+
+	if len(events) < 1 {
+		return nil, errors.New("graph called with no events")
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	series, xs, maxY := graphSeries(events, prediction, &o)
+	if markers := holidayMarkers(o.holidays, xs[0], xs[len(xs)-1], location); len(markers) > 0 {
+		// lines are drawn under all other series, labels are drawn over them
+		lines, labels := holidaySeries(markers, maxY+10.0)
+		series = append(lines, series...)
+		if labels != nil {
+			series = append(series, labels)
+		}
+	}
 	layout := getDateFormat(xs)
-	slog.Debug("created time series", "points", n, "dateFormat", layout)
+	slog.Debug("created time series", "points", len(events), "dateFormat", layout)
 
 	graph := chart.Chart{
 		XAxis: chart.XAxis{
@@ -181,6 +451,15 @@ func Graph(events, prediction []databaser.Event, location *time.Location) ([]byt
 		},
 		Series: series,
 	}
+	if len(o.series) > 0 {
+		graph.Elements = append(graph.Elements, legendElement(series, o.series))
+	}
+	if o.compact {
+		graph.Background = chart.Style{
+			Padding: chart.Box{Top: compactPadding, Left: compactPadding, Right: compactPadding, Bottom: compactPadding},
+		}
+		graph.XAxis.Name = ""
+	}
 
 	buf, ok := bufferPool.Get().(*bytes.Buffer)
 	if !ok {
@@ -189,14 +468,97 @@ func Graph(events, prediction []databaser.Event, location *time.Location) ([]byt
 	buf.Reset()
 	defer bufferPool.Put(buf)
 
-	err := graph.Render(chart.PNG, buf)
+	provider := chart.PNG
+	if o.format == FormatSVG {
+		provider = chart.SVG
+		if o.watermark != "" {
+			// a vector image can't be decoded, so the watermark is a chart element
+			graph.Elements = append(graph.Elements, watermarkElement(o.watermark, graph.GetWidth(), graph.GetHeight()))
+		}
+	}
+
+	err := graph.Render(provider, buf)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRender, err)
+	}
+
+	if o.format == FormatSVG || o.format != FormatWebP && o.watermark == "" {
+		// copy bytes to avoid data corruption when buffer is reused from pool
+		result := make([]byte, buf.Len())
+		copy(result, buf.Bytes())
+
+		return result, nil
+	}
+
+	img, err := png.Decode(buf)
+	if err != nil {
+		return nil, fmt.Errorf("decode png: %w", err)
+	}
+
+	if o.watermark != "" {
+		if img, err = drawWatermark(img, o.watermark); err != nil {
+			return nil, err
+		}
+	}
+
+	return encodeImage(img, o.format)
+}
+
+// drawWatermark returns a copy of the image with the faint text in the bottom-right corner.
+func drawWatermark(img image.Image, text string) (image.Image, error) {
+	ttf, err := chart.GetDefaultFont()
 	if err != nil {
-		return nil, fmt.Errorf("render graph: %w", err)
+		return nil, fmt.Errorf("get watermark font: %w", err)
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(bounds)
+	draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+
+	face := truetype.NewFace(ttf, &truetype.Options{Size: watermarkFontSize})
+	defer func() {
+		if closeErr := face.Close(); closeErr != nil {
+			slog.Error("failed to close watermark font face", "error", closeErr)
+		}
+	}()
+
+	drawer := &font.Drawer{
+		Dst:  rgba,
+		Src:  image.NewUniform(color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0x60}),
+		Face: face,
+	}
+	width := drawer.MeasureString(text).Ceil()
+	drawer.Dot = fixed.P(bounds.Max.X-width-watermarkMargin, bounds.Max.Y-watermarkMargin)
+	drawer.DrawString(text)
+
+	return rgba, nil
+}
+
+// watermarkElement returns a chart element drawing the faint text in the bottom-right corner of the image.
+func watermarkElement(text string, width, height int) chart.Renderable {
+	return func(r chart.Renderer, _ chart.Box, defaults chart.Style) {
+		r.SetFont(defaults.Font)
+		r.SetFontSize(watermarkFontSize)
+		r.SetFontColor(drawing.Color{R: 0x80, G: 0x80, B: 0x80, A: 0x60})
+
+		box := r.MeasureText(text)
+		r.Text(text, width-box.Width()-watermarkMargin, height-watermarkMargin)
 	}
+}
 
-	// copy bytes to avoid data corruption when buffer is reused from pool
-	result := make([]byte, buf.Len())
-	copy(result, buf.Bytes())
+// encodeImage encodes the image to the given format, PNG is used by default.
+func encodeImage(img image.Image, format GraphFormat) ([]byte, error) {
+	result := new(bytes.Buffer)
 
-	return result, nil
+	if format == FormatWebP {
+		if err := nativewebp.Encode(result, img, nil); err != nil {
+			return nil, fmt.Errorf("encode webp: %w", err)
+		}
+		return result.Bytes(), nil
+	}
+
+	if err := png.Encode(result, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return result.Bytes(), nil
 }
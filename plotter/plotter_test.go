@@ -2,10 +2,15 @@ package plotter
 
 import (
 	"bytes"
+	"image/png"
+	"math"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/wcharczuk/go-chart/v2"
+
 	"github.com/z0rr0/ggp/databaser"
 )
 
@@ -504,6 +509,824 @@ func TestGraph_WithPredictionsVariousLengths(t *testing.T) {
 	}
 }
 
+func TestGraph_WithPoints(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{name: "sparse data", count: 10},
+		{name: "dense data skips markers", count: maxPointMarkers + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := make([]databaser.Event, tt.count)
+			for i := range events {
+				events[i] = databaser.Event{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Load: uint8(i % 100)}
+			}
+
+			result, err := Graph(events, nil, time.UTC, WithPoints(true))
+			if err != nil {
+				t.Fatalf("Graph() error = %v", err)
+			}
+			if !bytes.HasPrefix(result, []byte{0x89, 'P', 'N', 'G'}) {
+				t.Error("Graph() result is not a valid PNG")
+			}
+		})
+	}
+}
+
+func TestGraph_WithTypical(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	events := make([]databaser.Event, 24)
+	typical := make([]databaser.Event, 24)
+
+	for i := range events {
+		ts := baseTime.Add(time.Duration(i) * time.Hour)
+		events[i] = databaser.Event{Timestamp: ts, Load: uint8(i * 3)}
+		typical[i] = databaser.Event{Timestamp: ts, Predict: float64(i * 2)}
+	}
+
+	result, err := Graph(events, nil, time.UTC, WithTypical(typical), WithPoints(true))
+	if err != nil {
+		t.Fatalf("Graph() error = %v", err)
+	}
+	if !bytes.HasPrefix(result, []byte{0x89, 'P', 'N', 'G'}) {
+		t.Error("Graph() result is not a valid PNG")
+	}
+}
+
+func TestGraph_WithConfidenceBand(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	events := []databaser.Event{
+		{Timestamp: baseTime, Load: 30},
+		{Timestamp: baseTime.Add(time.Hour), Load: 50},
+	}
+	prediction := []databaser.Event{
+		{Timestamp: baseTime.Add(time.Hour), Predict: 50, Confidence: 0.9},
+		{Timestamp: baseTime.Add(2 * time.Hour), Predict: 60, Confidence: 0.5},
+		{Timestamp: baseTime.Add(3 * time.Hour), Predict: 40, Confidence: 0.2},
+	}
+
+	plain, err := GraphWithFormat(events, prediction, time.UTC, FormatSVG)
+	if err != nil {
+		t.Fatalf("Graph() error = %v", err)
+	}
+	banded, err := GraphWithFormat(events, prediction, time.UTC, FormatSVG, WithConfidenceBand(true))
+	if err != nil {
+		t.Fatalf("Graph() with band error = %v", err)
+	}
+
+	const bandFill = "fill:rgba(255,0,0,0.2)"
+	if bytes.Contains(plain, []byte(bandFill)) {
+		t.Error("band is drawn without the option")
+	}
+	if !bytes.Contains(banded, []byte(bandFill)) {
+		t.Error("band is not drawn")
+	}
+
+	// the band is skipped for a single prediction point
+	single, err := GraphWithFormat(events, prediction[:1], time.UTC, FormatSVG, WithConfidenceBand(true))
+	if err != nil {
+		t.Fatalf("Graph() with single prediction error = %v", err)
+	}
+	if bytes.Contains(single, []byte(bandFill)) {
+		t.Error("band is drawn for a single prediction point")
+	}
+
+	pngData, err := Graph(events, prediction, time.UTC, WithConfidenceBand(true))
+	if err != nil {
+		t.Fatalf("Graph() png with band error = %v", err)
+	}
+	if !bytes.HasPrefix(pngData, []byte{0x89, 'P', 'N', 'G'}) {
+		t.Error("Graph() result is not a valid PNG")
+	}
+}
+
+func TestGraph_TrimOverlap(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	events := []databaser.Event{
+		{Timestamp: baseTime, Load: 30},
+		{Timestamp: baseTime.Add(time.Hour), Load: 40},
+		{Timestamp: baseTime.Add(2 * time.Hour), Load: 50},
+	}
+	prediction := []databaser.Event{
+		{Timestamp: baseTime.Add(time.Hour), Predict: 45},
+		{Timestamp: baseTime.Add(90 * time.Minute), Predict: 48},
+		{Timestamp: baseTime.Add(2 * time.Hour), Predict: 50},
+		{Timestamp: baseTime.Add(3 * time.Hour), Predict: 60},
+	}
+
+	predictionSeries := func(series []chart.Series) chart.TimeSeries {
+		t.Helper()
+		for _, s := range series {
+			if ts, ok := s.(chart.TimeSeries); ok && ts.Name == "Prediction" {
+				return ts
+			}
+		}
+		t.Fatal("prediction series not found")
+		return chart.TimeSeries{}
+	}
+
+	series, _, _ := graphSeries(events, prediction, &options{})
+	trimmed := predictionSeries(series)
+	if n := len(trimmed.XValues); n != 2 {
+		t.Fatalf("trimmed prediction has %d points, want 2", n)
+	}
+	last := events[len(events)-1]
+	if first := trimmed.XValues[0]; !first.Equal(last.Timestamp) {
+		t.Errorf("first prediction point = %v, want %v", first, last.Timestamp)
+	}
+	if first := trimmed.YValues[0]; first != last.FloatLoad() {
+		t.Errorf("first prediction value = %v, want %v to continue the load curve", first, last.FloatLoad())
+	}
+
+	series, _, _ = graphSeries(events, prediction, &options{overlap: true})
+	if n := len(predictionSeries(series).XValues); n != len(prediction) {
+		t.Errorf("kept prediction has %d points, want %d", n, len(prediction))
+	}
+
+	// prediction completely before the last event is dropped
+	series, _, _ = graphSeries(events, prediction[:2], &options{})
+	for _, s := range series {
+		if ts, ok := s.(chart.TimeSeries); ok && ts.Name == "Prediction" {
+			t.Errorf("prediction series is drawn with %d points", len(ts.XValues))
+		}
+	}
+
+	if _, err := Graph(events, prediction, time.UTC, WithKeepOverlap(true)); err != nil {
+		t.Errorf("Graph() with overlap error = %v", err)
+	}
+}
+
+func TestGraph_LoadRange(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	raw := make([]databaser.Event, 6)
+	aggregated := make([]databaser.Event, 6)
+	for i := range raw {
+		ts := baseTime.Add(time.Duration(i) * time.Hour)
+		raw[i] = databaser.Event{Timestamp: ts, Load: uint8(40 + i)}
+		aggregated[i] = databaser.Event{Timestamp: ts, Load: uint8(40 + i), MinLoad: uint8(20 + i), MaxLoad: uint8(80 + i)}
+	}
+
+	names := func(series []chart.Series) []string {
+		result := make([]string, len(series))
+		for i, s := range series {
+			result[i] = s.GetName()
+		}
+		return result
+	}
+
+	series, _, maxY := graphSeries(raw, nil, &options{})
+	if got := names(series); !slices.Equal(got, []string{"Load"}) {
+		t.Errorf("raw series = %v, want only load", got)
+	}
+	if maxY != 45 {
+		t.Errorf("raw maxY = %v, want 45", maxY)
+	}
+
+	series, _, maxY = graphSeries(aggregated, nil, &options{})
+	if got := names(series); !slices.Equal(got, []string{"Range", "Load"}) {
+		t.Errorf("aggregated series = %v, want range under load", got)
+	}
+	if maxY != 85 {
+		t.Errorf("aggregated maxY = %v, want 85", maxY)
+	}
+
+	// downsampled buckets cover the range of all their events
+	series, _, _ = graphSeries(aggregated, nil, &options{maxPoints: 3})
+	band, ok := series[0].(*bandSeries)
+	if !ok {
+		t.Fatalf("first series is %T, want band", series[0])
+	}
+	if band.lower[0] != 20 || band.upper[0] != 81 {
+		t.Errorf("first bucket range = %v-%v, want 20-81", band.lower[0], band.upper[0])
+	}
+
+	pngData, err := Graph(aggregated, nil, time.UTC)
+	if err != nil {
+		t.Fatalf("Graph() error = %v", err)
+	}
+	if !bytes.HasPrefix(pngData, []byte{0x89, 'P', 'N', 'G'}) {
+		t.Error("Graph() result is not a valid PNG")
+	}
+}
+
+func TestNewBandSeries(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	prediction := []databaser.Event{
+		{Timestamp: baseTime, Predict: 50, Confidence: 1},
+		{Timestamp: baseTime.Add(time.Hour), Predict: 50, Confidence: 0.5},
+		{Timestamp: baseTime.Add(2 * time.Hour), Predict: 10, Confidence: 0},
+		{Timestamp: baseTime.Add(3 * time.Hour), Predict: 90, Confidence: 1.5},
+	}
+
+	band := newBandSeries(prediction)
+	if err := band.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	wantLower := []float64{50, 35, 0, 90}
+	wantUpper := []float64{50, 65, 40, 90}
+	for i := range prediction {
+		if math.Abs(band.lower[i]-wantLower[i]) > 1e-9 || math.Abs(band.upper[i]-wantUpper[i]) > 1e-9 {
+			t.Errorf("band[%d] = [%v, %v], want [%v, %v]", i, band.lower[i], band.upper[i], wantLower[i], wantUpper[i])
+		}
+	}
+
+	if err := newBandSeries(prediction[:1]).Validate(); err == nil {
+		t.Error("expected validation error for a single point")
+	}
+}
+
+func TestGraph_WithExpected(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	events := make([]databaser.Event, 24)
+	expected := make([]databaser.Event, 24)
+
+	for i := range events {
+		ts := baseTime.Add(time.Duration(i) * time.Hour)
+		events[i] = databaser.Event{Timestamp: ts, Load: uint8(i * 3)}
+		expected[i] = databaser.Event{Timestamp: ts, Predict: float64(i * 4)}
+	}
+
+	tests := []struct {
+		name   string
+		option Option
+		want   []string
+	}{
+		{name: "typical under load", option: WithTypical(expected), want: []string{"Typical", "Load"}},
+		{name: "expected over load", option: WithExpected(expected), want: []string{"Load", "Expected"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var o options
+			tc.option(&o)
+
+			series, _, maxY := graphSeries(events, nil, &o)
+			names := make([]string, len(series))
+			for i, s := range series {
+				names[i] = s.GetName()
+			}
+
+			if !slices.Equal(names, tc.want) {
+				t.Errorf("series = %v, want %v", names, tc.want)
+			}
+			if maxY != 92 {
+				t.Errorf("maxY = %v, want 92", maxY)
+			}
+
+			result, err := Graph(events, nil, time.UTC, tc.option)
+			if err != nil {
+				t.Fatalf("Graph() error = %v", err)
+			}
+			if !bytes.HasPrefix(result, []byte{0x89, 'P', 'N', 'G'}) {
+				t.Error("Graph() result is not a valid PNG")
+			}
+		})
+	}
+}
+
+func TestGraphWithFormat_Formats(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	events := []databaser.Event{
+		{Timestamp: baseTime, Load: 30},
+		{Timestamp: baseTime.Add(time.Hour), Load: 50},
+		{Timestamp: baseTime.Add(time.Hour * 2), Load: 70},
+	}
+
+	tests := []struct {
+		name   string
+		format GraphFormat
+		check  func([]byte) bool
+	}{
+		{
+			name:   "png",
+			format: FormatPNG,
+			check: func(data []byte) bool {
+				return bytes.HasPrefix(data, []byte{0x89, 'P', 'N', 'G'})
+			},
+		},
+		{
+			name:   "webp",
+			format: FormatWebP,
+			check: func(data []byte) bool {
+				return len(data) > 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP"))
+			},
+		},
+		{
+			name:   "svg",
+			format: FormatSVG,
+			check: func(data []byte) bool {
+				return bytes.HasPrefix(data, []byte("<svg")) && bytes.HasSuffix(bytes.TrimSpace(data), []byte("</svg>"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := GraphWithFormat(events, nil, time.UTC, tt.format)
+			if err != nil {
+				t.Fatalf("Graph() error = %v", err)
+			}
+			if !tt.check(result) {
+				t.Errorf("Graph() result has invalid %s magic bytes: %x", tt.format, result[:min(len(result), 12)])
+			}
+		})
+	}
+}
+
+func TestGraph_WithWatermark(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	events := []databaser.Event{
+		{Timestamp: baseTime, Load: 30},
+		{Timestamp: baseTime.Add(time.Hour), Load: 50},
+		{Timestamp: baseTime.Add(time.Hour * 2), Load: 70},
+	}
+
+	plain, err := Graph(events, nil, time.UTC)
+	if err != nil {
+		t.Fatalf("Graph() error = %v", err)
+	}
+
+	for _, format := range []GraphFormat{FormatPNG, FormatWebP} {
+		t.Run(string(format), func(t *testing.T) {
+			result, err := GraphWithFormat(events, nil, time.UTC, format, WithWatermark("GGP club"))
+			if err != nil {
+				t.Fatalf("Graph() error = %v", err)
+			}
+
+			if format == FormatWebP {
+				if len(result) < 12 || !bytes.Equal(result[8:12], []byte("WEBP")) {
+					t.Errorf("Graph() result has invalid webp magic bytes: %x", result[:min(len(result), 12)])
+				}
+				return
+			}
+
+			img, err := png.Decode(bytes.NewReader(result))
+			if err != nil {
+				t.Fatalf("watermarked image is not a valid png: %v", err)
+			}
+
+			plainImg, err := png.Decode(bytes.NewReader(plain))
+			if err != nil {
+				t.Fatalf("plain image is not a valid png: %v", err)
+			}
+
+			if img.Bounds() != plainImg.Bounds() {
+				t.Errorf("image bounds = %v, want %v", img.Bounds(), plainImg.Bounds())
+			}
+
+			// the watermark changes pixels in the bottom-right corner only
+			var changed bool
+			b := img.Bounds()
+			for y := b.Max.Y - 3*watermarkMargin; y < b.Max.Y && !changed; y++ {
+				for x := b.Max.X / 2; x < b.Max.X; x++ {
+					r1, g1, b1, _ := img.At(x, y).RGBA()
+					r2, g2, b2, _ := plainImg.At(x, y).RGBA()
+					if r1 != r2 || g1 != g2 || b1 != b2 {
+						changed = true
+						break
+					}
+				}
+			}
+			if !changed {
+				t.Error("watermark was not drawn in the bottom-right corner")
+			}
+		})
+	}
+}
+
+func TestGraph_WithCompact(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	events := []databaser.Event{
+		{Timestamp: baseTime, Load: 30},
+		{Timestamp: baseTime.Add(time.Hour), Load: 50},
+		{Timestamp: baseTime.Add(time.Hour * 2), Load: 70},
+	}
+
+	// blankRows returns the number of image rows without any non-white pixels, they are the graph margins
+	blankRows := func(data []byte) int {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("image is not a valid png: %v", err)
+		}
+
+		b := img.Bounds()
+		if b.Dx() != chart.DefaultChartWidth || b.Dy() != chart.DefaultChartHeight {
+			t.Errorf("image size = %dx%d, want %dx%d", b.Dx(), b.Dy(), chart.DefaultChartWidth, chart.DefaultChartHeight)
+		}
+
+		var count int
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			blank := true
+			for x := b.Min.X; x < b.Max.X && blank; x++ {
+				r, g, bl, _ := img.At(x, y).RGBA()
+				blank = r == 0xffff && g == 0xffff && bl == 0xffff
+			}
+			if blank {
+				count++
+			}
+		}
+		return count
+	}
+
+	plain, err := Graph(events, nil, time.UTC, WithCompact(false))
+	if err != nil {
+		t.Fatalf("Graph() error = %v", err)
+	}
+	compact, err := Graph(events, nil, time.UTC, WithCompact(true))
+	if err != nil {
+		t.Fatalf("Graph() compact error = %v", err)
+	}
+
+	plainBlank, compactBlank := blankRows(plain), blankRows(compact)
+	if compactBlank >= plainBlank {
+		t.Errorf("compact graph has %d blank rows, want less than default %d", compactBlank, plainBlank)
+	}
+}
+
+func TestGraph_SVGWatermark(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	events := []databaser.Event{
+		{Timestamp: baseTime, Load: 30},
+		{Timestamp: baseTime.Add(time.Hour), Load: 50},
+	}
+
+	result, err := GraphWithFormat(events, nil, time.UTC, FormatSVG, WithWatermark("GGP club"))
+	if err != nil {
+		t.Fatalf("Graph() error = %v", err)
+	}
+	if !bytes.Contains(result, []byte(">GGP club</text>")) {
+		t.Error("watermark text is not found in svg")
+	}
+}
+
+// mockHolidays is a holiday checker of fixed dates with titles.
+type mockHolidays map[string]string
+
+func (m mockHolidays) IsHoliday(t time.Time) bool {
+	_, ok := m[t.Format(time.DateOnly)]
+	return ok
+}
+
+func (m mockHolidays) HolidayTitle(t time.Time) string {
+	return m[t.Format(time.DateOnly)]
+}
+
+func TestHolidayMarkers(t *testing.T) {
+	holidays := mockHolidays{
+		"2025-05-01": "Labour Day",
+		"2025-05-09": "Victory Day",
+		"2025-05-12": "",
+		"2025-06-12": "Russia Day",
+	}
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		first    time.Time
+		last     time.Time
+		location *time.Location
+		checker  HolidayChecker
+		name     string
+		want     []string
+	}{
+		{
+			name:     "no checker",
+			first:    time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+			last:     time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC),
+			location: time.UTC,
+		},
+		{
+			name:     "days within range",
+			first:    time.Date(2025, 4, 30, 12, 0, 0, 0, time.UTC),
+			last:     time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC),
+			location: time.UTC,
+			checker:  holidays,
+			want:     []string{"2025-05-01T00:00:00Z", "2025-05-09T00:00:00Z", "2025-05-12T00:00:00Z"},
+		},
+		{
+			name:     "starts before and after range are clipped",
+			first:    time.Date(2025, 5, 1, 6, 0, 0, 0, time.UTC),
+			last:     time.Date(2025, 5, 8, 23, 59, 0, 0, time.UTC),
+			location: time.UTC,
+			checker:  holidays,
+		},
+		{
+			name:     "local day start",
+			first:    time.Date(2025, 5, 8, 20, 0, 0, 0, time.UTC),
+			last:     time.Date(2025, 5, 9, 1, 0, 0, 0, time.UTC),
+			location: moscow,
+			checker:  holidays,
+			want:     []string{"2025-05-09T00:00:00+03:00"},
+		},
+		{
+			name:     "reversed range",
+			first:    time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC),
+			last:     time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+			location: time.UTC,
+			checker:  holidays,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			markers := holidayMarkers(tt.checker, tt.first, tt.last, tt.location)
+
+			got := make([]string, 0, len(markers))
+			for _, marker := range markers {
+				got = append(got, marker.start.Format(time.RFC3339))
+				if marker.title != holidays.HolidayTitle(marker.start) {
+					t.Errorf("marker %s title = %q", marker.start, marker.title)
+				}
+			}
+			if len(got) != len(tt.want) || !slices.Equal(got, tt.want) {
+				t.Errorf("holidayMarkers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraph_WithHolidays(t *testing.T) {
+	baseTime := time.Date(2025, 5, 8, 12, 0, 0, 0, time.UTC)
+	events := make([]databaser.Event, 0, 48)
+	for i := range cap(events) {
+		events = append(events, databaser.Event{Timestamp: baseTime.Add(time.Duration(i) * time.Hour), Load: uint8(20 + i%40)})
+	}
+	holidays := mockHolidays{"2025-05-09": "Victory Day", "2025-05-11": "Out of range"}
+
+	plain, err := GraphWithFormat(events, nil, time.UTC, FormatSVG)
+	if err != nil {
+		t.Fatalf("Graph() error = %v", err)
+	}
+	if bytes.Contains(plain, []byte("Victory Day")) {
+		t.Error("graph without checker should not contain holiday labels")
+	}
+
+	result, err := GraphWithFormat(events, nil, time.UTC, FormatSVG, WithHolidays(holidays))
+	if err != nil {
+		t.Fatalf("Graph() error = %v", err)
+	}
+	if !bytes.Contains(result, []byte("Victory Day")) {
+		t.Error("holiday label is not found in svg")
+	}
+	if bytes.Contains(result, []byte("Out of range")) {
+		t.Error("holiday out of the events range should not be drawn")
+	}
+
+	if _, err = Graph(events, nil, time.UTC, WithHolidays(holidays)); err != nil {
+		t.Fatalf("Graph() png error = %v", err)
+	}
+}
+
+func TestGraph_WithCapacity(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	events := []databaser.Event{
+		{Timestamp: baseTime, Load: 30},
+		{Timestamp: baseTime.Add(time.Hour), Load: 50},
+	}
+	prediction := []databaser.Event{
+		{Timestamp: baseTime.Add(2 * time.Hour), Predict: 40},
+		{Timestamp: baseTime.Add(3 * time.Hour), Predict: 45},
+	}
+
+	tests := []struct {
+		name       string
+		capacity   float64
+		prediction []databaser.Event
+		wantLine   bool
+		wantLast   time.Time
+		wantMaxY   float64
+	}{
+		{name: "disabled", prediction: prediction, wantMaxY: 50},
+		{name: "with prediction", capacity: 80, prediction: prediction, wantLine: true, wantLast: baseTime.Add(3 * time.Hour), wantMaxY: 80},
+		{name: "events only", capacity: 80, wantLine: true, wantLast: baseTime.Add(time.Hour), wantMaxY: 80},
+		{name: "below load", capacity: 20, wantLine: true, wantLast: baseTime.Add(time.Hour), wantMaxY: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, _, maxY := graphSeries(events, tt.prediction, &options{capacity: tt.capacity})
+			if maxY != tt.wantMaxY {
+				t.Errorf("maxY = %v, want %v", maxY, tt.wantMaxY)
+			}
+
+			var line *chart.TimeSeries
+			for _, s := range series {
+				if ts, ok := s.(chart.TimeSeries); ok && ts.Name == "Capacity" {
+					line = &ts
+				}
+			}
+			if (line != nil) != tt.wantLine {
+				t.Fatalf("capacity line found = %v, want %v", line != nil, tt.wantLine)
+			}
+			if line != nil {
+				if !line.XValues[0].Equal(baseTime) || !line.XValues[1].Equal(tt.wantLast) {
+					t.Errorf("capacity line x = %v, want %v - %v", line.XValues, baseTime, tt.wantLast)
+				}
+				if line.YValues[0] != tt.capacity || line.YValues[1] != tt.capacity {
+					t.Errorf("capacity line y = %v, want %v", line.YValues, tt.capacity)
+				}
+			}
+
+			for _, format := range []GraphFormat{FormatPNG, FormatSVG} {
+				if _, err := GraphWithFormat(events, tt.prediction, time.UTC, format, WithCapacity(tt.capacity)); err != nil {
+					t.Errorf("Graph(%v) error = %v", format, err)
+				}
+			}
+		})
+	}
+}
+
+func TestGraph_WithSeries(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	events := []databaser.Event{
+		{Timestamp: baseTime, Load: 30},
+		{Timestamp: baseTime.Add(time.Hour), Load: 50},
+	}
+	previous := Series{
+		Name:   "Previous",
+		Points: []databaser.Event{{Timestamp: baseTime, Load: 20}, {Timestamp: baseTime.Add(time.Hour), Load: 70}},
+	}
+	styled := Series{
+		Name:   "Styled",
+		Points: []databaser.Event{{Timestamp: baseTime, Load: 10}, {Timestamp: baseTime.Add(time.Hour), Load: 15}},
+		Style:  chart.Style{StrokeColor: chart.ColorBlack, StrokeWidth: 1.0},
+	}
+	short := Series{Name: "Short", Points: []databaser.Event{{Timestamp: baseTime, Load: 90}}}
+
+	series, _, maxY := graphSeries(events, nil, &options{series: []Series{previous, styled, short}})
+	if maxY != 70 {
+		t.Errorf("maxY = %v, want 70", maxY)
+	}
+
+	found := make(map[string]chart.TimeSeries)
+	for _, s := range series {
+		if ts, ok := s.(chart.TimeSeries); ok {
+			found[ts.Name] = ts
+		}
+	}
+	if _, ok := found["Short"]; ok {
+		t.Error("series with a single point should be skipped")
+	}
+	if ts, ok := found["Previous"]; !ok || len(ts.YValues) != 2 || ts.YValues[1] != 70 {
+		t.Errorf("previous series = %+v, want loads of its points", ts)
+	} else if ts.Style.StrokeColor != seriesColors[0] || len(ts.Style.StrokeDashArray) == 0 {
+		t.Errorf("previous series style = %+v, want a dashed line of the first palette color", ts.Style)
+	}
+	if ts := found["Styled"]; ts.Style.StrokeColor != chart.ColorBlack {
+		t.Errorf("styled series color = %v, want the given one", ts.Style.StrokeColor)
+	}
+
+	for _, format := range []GraphFormat{FormatPNG, FormatSVG} {
+		if _, err := GraphWithFormat(events, nil, time.UTC, format, WithSeries(previous, styled)); err != nil {
+			t.Errorf("Graph(%v) error = %v", format, err)
+		}
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	events := make([]databaser.Event, 10)
+	for i := range events {
+		events[i] = databaser.Event{Timestamp: start.Add(time.Duration(i) * time.Minute), Load: uint8(i * 10)}
+	}
+
+	if got := Downsample(events, 10); len(got) != 10 {
+		t.Errorf("events count = %d, want 10 without downsampling", len(got))
+	}
+
+	got := Downsample(events, 4)
+	if n := len(got); n != 4 {
+		t.Fatalf("events count = %d, want 4", n)
+	}
+
+	wantLoads := []uint8{10, 40, 70, 90}
+	for i, e := range got {
+		if e.Load != wantLoads[i] {
+			t.Errorf("event %d load = %d, want %d", i, e.Load, wantLoads[i])
+		}
+	}
+
+	if !got[0].Timestamp.Equal(start) {
+		t.Errorf("first timestamp = %v, want %v", got[0].Timestamp, start)
+	}
+	if last := events[len(events)-1].Timestamp; !got[3].Timestamp.Equal(last) {
+		t.Errorf("last timestamp = %v, want %v", got[3].Timestamp, last)
+	}
+	if want := start.Add(4 * time.Minute); !got[1].Timestamp.Equal(want) {
+		t.Errorf("bucket timestamp = %v, want %v", got[1].Timestamp, want)
+	}
+}
+
+func TestDownsample_Predictions(t *testing.T) {
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	events := make([]databaser.Event, 6)
+	for i := range events {
+		events[i] = databaser.Event{
+			Timestamp:  start.Add(time.Duration(i) * time.Hour),
+			Predict:    float64(i * 10),
+			Confidence: float64(i) / 10,
+		}
+	}
+
+	got := Downsample(events, 3)
+	if n := len(got); n != 3 {
+		t.Fatalf("events count = %d, want 3", n)
+	}
+
+	wantPredict := []float64{5, 25, 45}
+	wantConfidence := []float64{0.05, 0.25, 0.45}
+	for i, e := range got {
+		if math.Abs(e.Predict-wantPredict[i]) > 1e-9 || math.Abs(e.Confidence-wantConfidence[i]) > 1e-9 {
+			t.Errorf("event %d = %v/%v, want %v/%v", i, e.Predict, e.Confidence, wantPredict[i], wantConfidence[i])
+		}
+	}
+
+	for _, maxPoints := range []int{0, 1, -1} {
+		if got = Downsample(events, maxPoints); len(got) != len(events) {
+			t.Errorf("maxPoints %d: events count = %d, want %d without limit", maxPoints, len(got), len(events))
+		}
+	}
+}
+
+func TestGraph_WithMaxPoints(t *testing.T) {
+	const (
+		total     = 5000
+		maxPoints = 100
+	)
+	baseTime := time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	events := make([]databaser.Event, total)
+	prediction := make([]databaser.Event, total)
+	for i := range total {
+		ts := baseTime.Add(time.Duration(i) * time.Minute)
+		events[i] = databaser.Event{Timestamp: ts, Load: uint8(i % 100)}
+		prediction[i] = databaser.Event{Timestamp: ts.Add(total * time.Minute), Predict: float64(i % 100)}
+	}
+
+	tests := []struct {
+		name      string
+		maxPoints int
+		want      int
+	}{
+		{name: "unlimited", maxPoints: 0, want: total},
+		{name: "limited", maxPoints: maxPoints, want: maxPoints},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options{maxPoints: tt.maxPoints}
+			series, xs, _ := graphSeries(events, prediction, &o)
+
+			if len(xs) != tt.want {
+				t.Errorf("load points = %d, want %d", len(xs), tt.want)
+			}
+			for _, s := range series {
+				ts, ok := s.(chart.TimeSeries)
+				if ok && ts.Name == "Prediction" && ts.Len() != tt.want {
+					t.Errorf("prediction points = %d, want %d", ts.Len(), tt.want)
+				}
+			}
+
+			if _, err := Graph(events, prediction, time.UTC, WithMaxPoints(tt.maxPoints)); err != nil {
+				t.Fatalf("Graph() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestGraphWithFormat(t *testing.T) {
+	baseTime := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	events := []databaser.Event{
+		{Timestamp: baseTime, Load: 30},
+		{Timestamp: baseTime.Add(time.Hour), Load: 50},
+	}
+
+	pngData, err := GraphWithFormat(events, nil, time.UTC, FormatPNG)
+	if err != nil {
+		t.Fatalf("GraphWithFormat() error = %v", err)
+	}
+	defaultData, err := Graph(events, nil, time.UTC)
+	if err != nil {
+		t.Fatalf("Graph() error = %v", err)
+	}
+	if !bytes.Equal(pngData, defaultData) {
+		t.Error("Graph() result differs from GraphWithFormat() with png format")
+	}
+
+	svgData, err := GraphWithFormat(events, nil, time.UTC, FormatSVG)
+	if err != nil {
+		t.Fatalf("GraphWithFormat() error = %v", err)
+	}
+	if !bytes.HasPrefix(svgData, []byte("<svg")) {
+		t.Errorf("GraphWithFormat() result is not svg: %q", svgData[:min(len(svgData), 20)])
+	}
+}
+
 func TestDtFormatMap_AllFormatsExist(t *testing.T) {
 	expectedFormats := []int{
 		dtFormatSecond,
@@ -567,3 +1390,34 @@ func BenchmarkGetDateFormat(b *testing.B) {
 		getDateFormat(events)
 	}
 }
+
+func TestSparkline(t *testing.T) {
+	loads := func(values ...uint8) []databaser.Event {
+		events := make([]databaser.Event, len(values))
+		for i, v := range values {
+			events[i] = databaser.Event{Load: v}
+		}
+		return events
+	}
+
+	tests := []struct {
+		name   string
+		events []databaser.Event
+		width  int
+		want   string
+	}{
+		{name: "empty", width: 10},
+		{name: "zero width", events: loads(50), want: ""},
+		{name: "every event", events: loads(0, 15, 50, 100), width: 10, want: "▁▂▄█"},
+		{name: "averaged", events: loads(0, 100, 100, 100, 20, 30), width: 3, want: "▄█▂"},
+		{name: "out of range load", events: loads(200), width: 1, want: "█"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sparkline(tt.events, tt.width); got != tt.want {
+				t.Errorf("Sparkline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
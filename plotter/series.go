@@ -0,0 +1,88 @@
+package plotter
+
+import (
+	"slices"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// loadSeriesName is the name of the main series of real events.
+const loadSeriesName = "Load"
+
+// seriesColors are stroke colors of additional series without a style, they are used in order.
+//
+//nolint:gochecknoglobals // package-level lookup table
+var seriesColors = []drawing.Color{chart.ColorOrange, chart.ColorGreen, chart.ColorCyan, chart.ColorYellow}
+
+// Series is an additional named line of real loads, e.g. loads of another period aligned with the events.
+// A zero Style is replaced by a dashed line of the next color of the palette.
+type Series struct {
+	Name   string
+	Points []databaser.Event
+	Style  chart.Style
+}
+
+// WithSeries adds named series drawn over the events, the graph gets a legend of the events and these series.
+// Points should be in the time range of the events, series with less than two points are skipped.
+func WithSeries(series ...Series) Option {
+	return func(o *options) {
+		o.series = append(o.series, series...)
+	}
+}
+
+// extraSeries returns chart series of the additional series and the max load value of them.
+func extraSeries(series []Series, maxPoints int) ([]chart.Series, float64) {
+	var (
+		result = make([]chart.Series, 0, len(series))
+		maxY   = 0.0
+	)
+
+	for i, s := range series {
+		points := Downsample(s.Points, maxPoints)
+		n := len(points)
+		if n < 2 {
+			continue
+		}
+
+		xs := make([]time.Time, 0, n)
+		ys := make([]float64, 0, n)
+		for _, event := range points {
+			load := event.FloatLoad()
+			xs = append(xs, event.Timestamp)
+			ys = append(ys, load)
+			maxY = max(maxY, load)
+		}
+
+		style := s.Style
+		if style.IsZero() {
+			style = chart.Style{
+				StrokeColor:     seriesColors[i%len(seriesColors)],
+				StrokeWidth:     3.0,
+				StrokeDashArray: []float64{6.0, 4.0},
+			}
+		}
+
+		result = append(result, chart.TimeSeries{Name: s.Name, XValues: xs, YValues: ys, Style: style})
+	}
+
+	return result, maxY
+}
+
+// legendElement returns a legend of the main series and the additional ones,
+// other series like holidays or capacity are explained by the caption and not listed.
+func legendElement(series []chart.Series, extra []Series) chart.Renderable {
+	legend := &chart.Chart{}
+
+	for _, s := range series {
+		name := s.GetName()
+		if name == loadSeriesName || slices.ContainsFunc(extra, func(e Series) bool { return e.Name == name }) {
+			legend.Series = append(legend.Series, s)
+		}
+	}
+
+	return chart.Legend(legend)
+}
@@ -0,0 +1,37 @@
+package plotter
+
+import (
+	"strings"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// sparkLevels are the bars of a text sparkline from the lowest to the highest load.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█") //nolint:gochecknoglobals
+
+// Sparkline returns a text line of bars with the load of the events, it doesn't need image rendering.
+// Events are averaged by width groups, the bars have absolute 0..100% scale.
+func Sparkline(events []databaser.Event, width int) string {
+	n := len(events)
+	if n == 0 || width < 1 {
+		return ""
+	}
+	width = min(width, n)
+
+	var sb strings.Builder
+	sb.Grow(width * len(string(sparkLevels[0])))
+
+	for i := range width {
+		start, end := i*n/width, (i+1)*n/width
+		var sum int
+
+		for _, event := range events[start:end] {
+			sum += int(min(event.Load, 100))
+		}
+
+		level := sum * (len(sparkLevels) - 1) / ((end - start) * 100)
+		sb.WriteRune(sparkLevels[level])
+	}
+
+	return sb.String()
+}
@@ -24,12 +24,31 @@ const (
 	Holiday // predefined holiday
 )
 
+// WeekDays returns day types of a week ordered from the given first weekday, holidays are not included.
+func WeekDays(first time.Weekday) []DayType {
+	const daysInWeek = 7
+	days := make([]DayType, 0, daysInWeek)
+
+	for i := range daysInWeek {
+		// #nosec G115 -- the result is 0-6, always fits in uint8
+		days = append(days, DayType((int(first)+i)%daysInWeek))
+	}
+
+	return days
+}
+
 // HolidayChecker checks if a given date is a holiday and retrieves the holiday title.
 type HolidayChecker interface {
 	IsHoliday(t time.Time) bool
 	HolidayTitle(t time.Time) string
 }
 
+// HolidayRange is implemented by holiday checkers which know the end of their loaded holidays.
+// Dates after it can be holidays, which are not loaded yet.
+type HolidayRange interface {
+	HolidaysUntil() time.Time
+}
+
 // monthDay represents a month and day combination.
 type monthDay struct {
 	month uint8
@@ -39,6 +58,7 @@ type monthDay struct {
 // RussianHolidayChecker implements HolidayChecker for Russian holidays.
 type RussianHolidayChecker struct {
 	fixedHolidays map[monthDay]string
+	until         time.Time
 }
 
 // NewRussianHolidayChecker creates a new RussianHolidayChecker with holidays loaded from the database.
@@ -57,7 +77,13 @@ func NewRussianHolidayChecker(ctx context.Context, db *databaser.DB, location *t
 		fixedHolidays[monthDay{month: uint8(m), day: uint8(d)}] = h.Title
 	}
 
-	return &RussianHolidayChecker{fixedHolidays: fixedHolidays}, nil
+	until := time.Date(year+1, time.January, 1, 0, 0, 0, 0, location)
+	return &RussianHolidayChecker{fixedHolidays: fixedHolidays, until: until}, nil
+}
+
+// HolidaysUntil returns the start of the year after the loaded holidays.
+func (c *RussianHolidayChecker) HolidaysUntil() time.Time {
+	return c.until
 }
 
 // IsHoliday checks if the given date is a holiday.
@@ -34,6 +34,39 @@ func TestDayType(t *testing.T) {
 	}
 }
 
+func TestWeekDays(t *testing.T) {
+	tests := []struct {
+		name  string
+		first time.Weekday
+		want  []DayType
+	}{
+		{
+			name:  "monday first",
+			first: time.Monday,
+			want:  []DayType{Monday, Tuesday, Wednesday, Thursday, Friday, Saturday, Sunday},
+		},
+		{
+			name:  "sunday first",
+			first: time.Sunday,
+			want:  []DayType{Sunday, Monday, Tuesday, Wednesday, Thursday, Friday, Saturday},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WeekDays(tt.first)
+			if len(got) != len(tt.want) {
+				t.Fatalf("WeekDays() length = %d, want %d", len(got), len(tt.want))
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("WeekDays()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
 func TestNewRussianHolidayChecker(t *testing.T) {
 	ctx := context.Background()
 	db, err := databaser.New(ctx, ":memory:", 1)
@@ -59,6 +92,11 @@ func TestNewRussianHolidayChecker(t *testing.T) {
 	if checker.fixedHolidays == nil {
 		t.Error("fixedHolidays map not initialized")
 	}
+
+	want := time.Date(time.Now().In(location).Year()+1, time.January, 1, 0, 0, 0, 0, location)
+	if until := checker.HolidaysUntil(); !until.Equal(want) {
+		t.Errorf("HolidaysUntil() = %v, want %v", until, want)
+	}
 }
 
 func TestNewRussianHolidayChecker_WithHolidays(t *testing.T) {
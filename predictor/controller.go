@@ -2,21 +2,39 @@ package predictor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"time"
 
 	"github.com/z0rr0/ggp/config"
 	"github.com/z0rr0/ggp/databaser"
+	"github.com/z0rr0/ggp/metrics"
+)
+
+const (
+	// defaultLoadRetries is the number of retries of the initial events loading if it is not set.
+	defaultLoadRetries = 2
+	// loadRetryDelay is the delay before the first retry of the initial events loading, it's doubled for next ones.
+	loadRetryDelay = time.Second
 )
 
 // Controller manages the predictor and handles incoming events.
 type Controller struct {
-	predictor *Predictor
-	eventCh   <-chan databaser.Event
-	Hours     uint8
-	loadSize  int
-	timeout   time.Duration
+	predictor  *Predictor
+	db         *databaser.DB
+	eventCh    <-chan databaser.Event
+	metrics    *metrics.Metrics
+	Hours      uint8
+	loadSize   int
+	smooth     int           // moving average window size for predictions, 0 or 1 disables smoothing
+	refresh    time.Duration // period to rebuild the predictor from the database, 0 disables refreshing
+	timeout    time.Duration
+	retries    int           // retries of the initial events loading
+	retryDelay time.Duration // delay before the first retry of the initial events loading
+	warmup     uint64        // learned events required to show predictions, 0 disables the warmup
+	accuracy   bool          // store next hour predictions and compare them with real events
 }
 
 // Run initializes and returns a new Controller with the predictor and event channel.
@@ -26,16 +44,52 @@ func Run(ctx context.Context, db *databaser.DB, eventCh <-chan databaser.Event,
 		return nil, fmt.Errorf("NewRussianHolidayChecker: %w", err)
 	}
 
+	p := New(holidayChecker)
+	p.scaleConfidence = cfg.Predictor.ScaleConfidence
+	p.maxRecentAge = cfg.Predictor.RecentMaxAge
+	if cfg.Predictor.RecentCount > 0 {
+		p.maxRecentCount = cfg.Predictor.RecentCount
+	}
+	if cfg.Predictor.DecayLambda > 0 {
+		p.decayLambda = cfg.Predictor.DecayLambda
+	}
+	if cfg.Predictor.MinWeight > 0 {
+		p.minWeight = cfg.Predictor.MinWeight
+	}
+	if cfg.Predictor.ResetWeight > 0 {
+		p.resetWeight = cfg.Predictor.ResetWeight
+	}
+	if cfg.Predictor.ConfidenceThreshold > 0 {
+		p.confidenceThreshold = cfg.Predictor.ConfidenceThreshold
+	}
+	if cfg.Predictor.HolidayMargin > 0 {
+		p.holidayMargin = time.Duration(cfg.Predictor.HolidayMargin) * 24 * time.Hour
+	}
+	if cfg.Predictor.GlobalBlend {
+		p.globalBlendWeight = cfg.Predictor.GlobalBlendWeight
+	}
+	p.anomalyThreshold = cfg.Predictor.AnomalyThreshold
+
 	controller := &Controller{
-		predictor: New(holidayChecker),
-		eventCh:   eventCh,
-		Hours:     cfg.Predictor.Hours,
-		loadSize:  cfg.Predictor.LoadSize,
-		timeout:   cfg.Predictor.Timeout,
+		predictor:  p,
+		db:         db,
+		eventCh:    eventCh,
+		Hours:      cfg.Predictor.Hours,
+		loadSize:   cfg.Predictor.LoadSize,
+		smooth:     cfg.Predictor.SmoothWindow,
+		refresh:    cfg.Predictor.Refresh,
+		timeout:    cfg.Predictor.Timeout,
+		retries:    defaultLoadRetries,
+		retryDelay: loadRetryDelay,
+		warmup:     cfg.Predictor.WarmupEvents,
+		accuracy:   cfg.Predictor.TrackAccuracy,
+	}
+	if cfg.Predictor.LoadRetries > 0 {
+		controller.retries = cfg.Predictor.LoadRetries
 	}
 
 	// load events from the database
-	if err = controller.LoadEvents(ctx, db); err != nil {
+	if err = controller.loadEventsRetry(ctx, db); err != nil {
 		return nil, fmt.Errorf("LoadEvents: %w", err)
 	}
 
@@ -43,36 +97,163 @@ func Run(ctx context.Context, db *databaser.DB, eventCh <-chan databaser.Event,
 }
 
 // Run starts the controller to listen for events and process them.
+// After the context cancellation the controller keeps reading events until the event channel is closed,
+// so events fetched right before the shutdown are counted. The returned channel is closed after that.
 func (c *Controller) Run(ctx context.Context) <-chan struct{} {
 	doneCh := make(chan struct{})
-	if c.eventCh == nil {
+	if c.eventCh == nil && c.refresh <= 0 {
 		slog.InfoContext(ctx, "no event channel provided, predictor controller will not run")
 		close(doneCh)
 		return doneCh
 	}
 
 	go func() {
+		var refreshCh <-chan time.Time
 		defer close(doneCh)
+
+		if c.refresh > 0 {
+			ticker := time.NewTicker(c.refresh)
+			defer ticker.Stop()
+			refreshCh = ticker.C
+		}
+
 		for {
 			select {
 			case <-ctx.Done():
+				c.drain(ctx)
 				slog.InfoContext(ctx, "stopping predictor controller")
 				return
+			case <-refreshCh:
+				// events are not handled during refreshing, they will be read from the channel after it
+				if err := c.Refresh(ctx); err != nil {
+					slog.ErrorContext(ctx, "predictor refresh failed", "error", err)
+				}
 			case event, ok := <-c.eventCh:
 				if !ok {
 					slog.InfoContext(ctx, "event channel closed, stopping predictor controller")
 					return
 				}
 				slog.DebugContext(ctx, "predictor received event", "event", event)
-				c.predictor.AddEvent(event)
+				c.addEvent(ctx, event)
 			}
 		}
 	}()
 	return doneCh
 }
 
+// drain adds remaining events to the predictor until the event channel is closed, the fetcher closes it on stop.
+func (c *Controller) drain(ctx context.Context) {
+	if c.eventCh == nil {
+		return
+	}
+
+	var n int
+	for event := range c.eventCh {
+		c.addEvent(ctx, event)
+		n++
+	}
+
+	slog.InfoContext(ctx, "predictor drained events", "count", n)
+}
+
+// SetMetrics sets metrics of predicted and actual loads, it must be called before Run.
+func (c *Controller) SetMetrics(m *metrics.Metrics) {
+	c.metrics = m
+}
+
+// addEvent adds the event to the predictor, the typical load expected before it is exported to metrics.
+// Anomalous events are logged, but still added, the predictor can't tell a wrong reading from a real change.
+func (c *Controller) addEvent(ctx context.Context, event databaser.Event) {
+	if c.metrics != nil {
+		c.metrics.ObservePrediction(c.predictor.GetTypicalLoad(event.Timestamp), event.Load)
+	}
+	if anomalous, z := c.predictor.IsAnomalous(event); anomalous {
+		slog.WarnContext(ctx, "anomalous load", "event", &event, "z_score", z)
+	}
+	c.predictor.AddEvent(event)
+
+	if c.accuracy {
+		c.trackAccuracy(ctx, event)
+	}
+}
+
+// trackAccuracy compares the stored prediction of the event hour with the event load
+// and stores the prediction of the next hour. Errors are only logged, they don't stop events handling.
+func (c *Controller) trackAccuracy(ctx context.Context, event databaser.Event) {
+	// events are drained after the context cancellation, so their predictions are tracked too
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	defer cancel()
+
+	if _, err := c.db.ReconcilePrediction(ctx, event); err != nil {
+		slog.ErrorContext(ctx, "failed to reconcile prediction", "error", err)
+	}
+
+	p := c.predictor.Predict(1)
+	prediction := databaser.Prediction{Target: p.TargetTime, Load: p.Load, Confidence: p.Confidence}
+	if err := c.db.SavePrediction(ctx, prediction); err != nil {
+		slog.ErrorContext(ctx, "failed to save prediction", "error", err)
+	}
+}
+
 // LoadEvents loads historical events from the database into the predictor.
 func (c *Controller) LoadEvents(ctx context.Context, db *databaser.DB) error {
+	if err := c.loadEventsInto(ctx, db, c.predictor); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "predictor loaded events")
+	return nil
+}
+
+// loadEventsRetry loads historical events like LoadEvents, failed loading is retried with exponential backoff,
+// e.g. if the database is busy by the first fetcher write. Retries rebuild the predictor from scratch,
+// so partially loaded events are not counted twice.
+func (c *Controller) loadEventsRetry(ctx context.Context, db *databaser.DB) error {
+	err := c.LoadEvents(ctx, db)
+	delay := c.retryDelay
+
+	for attempt := 1; err != nil && attempt <= c.retries; attempt++ {
+		slog.WarnContext(ctx, "predictor events loading failed", "attempt", attempt, "delay", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+
+		p := c.predictor.emptyCopy()
+		if err = c.loadEventsInto(ctx, db, p); err == nil {
+			c.predictor.replace(p)
+			slog.InfoContext(ctx, "predictor loaded events", "attempt", attempt)
+		}
+	}
+
+	return err
+}
+
+// Refresh rebuilds the predictor from all database events and replaces its statistics,
+// so events missed by the predictor, e.g. during a bot downtime, are taken into account.
+func (c *Controller) Refresh(ctx context.Context) error {
+	p := c.predictor.emptyCopy()
+
+	if err := c.loadEventsInto(ctx, c.db, p); err != nil {
+		return err
+	}
+
+	c.predictor.replace(p)
+	slog.InfoContext(ctx, "predictor refreshed")
+	return nil
+}
+
+// Reset drops all statistics of the predictor, e.g. after removing all events from the database.
+func (c *Controller) Reset(ctx context.Context) {
+	c.predictor.replace(c.predictor.emptyCopy())
+	slog.InfoContext(ctx, "predictor reset")
+}
+
+// loadEventsInto loads all events from the database into the given predictor by batches.
+func (c *Controller) loadEventsInto(ctx context.Context, db *databaser.DB, p *Predictor) error {
 	var n, offset int
 
 	for {
@@ -84,17 +265,17 @@ func (c *Controller) LoadEvents(ctx context.Context, db *databaser.DB) error {
 			break
 		}
 		slog.DebugContext(ctx, "got events", "events", n)
-		c.predictor.AddEvents(events)
+		p.AddEvents(events)
 
 		offset += n
 		slog.DebugContext(ctx, "add events", "offset", offset)
 	}
 
-	slog.InfoContext(ctx, "predictor loaded events")
 	return nil
 }
 
 // PredictLoad generates load predictions for the configured number of hours.
+// The first event is the current typical load, events have the confidence of their predictions.
 func (c *Controller) PredictLoad(hours uint8) []databaser.Event {
 	now := time.Now().UTC()
 	predictions := c.predictor.PredictRange(hours)
@@ -102,12 +283,104 @@ func (c *Controller) PredictLoad(hours uint8) []databaser.Event {
 
 	events = append(events, databaser.Event{Timestamp: now, Predict: c.predictor.GetTypicalLoad(now)})
 	for _, p := range predictions {
-		events = append(events, databaser.Event{Timestamp: p.TargetTime, Predict: p.Load})
+		events = append(events, databaser.Event{Timestamp: p.TargetTime, Predict: p.Load, Confidence: p.Confidence})
+	}
+	if len(predictions) > 0 {
+		// the current typical load is as confident as the nearest prediction
+		events[0].Confidence = predictions[0].Confidence
+	}
+
+	if c.smooth > 1 {
+		smoothPredictions(events, c.smooth)
 	}
 
 	return events
 }
 
+// smoothPredictions replaces predicted values by the centered moving average with the given window size.
+// The window is shrunk at the edges, so the first and the last values are averaged with available neighbors only.
+func smoothPredictions(events []databaser.Event, window int) {
+	radius := window / 2
+	values := make([]float64, len(events))
+
+	for i := range events {
+		values[i] = events[i].Predict
+	}
+
+	for i := range events {
+		var sum float64
+		start, end := max(0, i-radius), min(len(values)-1, i+radius)
+
+		for j := start; j <= end; j++ {
+			sum += values[j]
+		}
+		events[i].Predict = sum / float64(end-start+1)
+	}
+}
+
+// Confidence returns the average displayed confidence of predictions for the given number of hours.
+func (c *Controller) Confidence(hours uint8) float64 {
+	predictions := c.predictor.PredictRange(hours)
+	if len(predictions) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, p := range predictions {
+		sum += p.DisplayConfidence
+	}
+
+	return sum / float64(len(predictions))
+}
+
+// TypicalLoad returns the typical load for timestamps of the given events.
+func (c *Controller) TypicalLoad(events []databaser.Event) []databaser.Event {
+	typical := make([]databaser.Event, len(events))
+
+	for i, e := range events {
+		typical[i] = databaser.Event{Timestamp: e.Timestamp, Predict: c.predictor.GetTypicalLoad(e.Timestamp)}
+	}
+
+	return typical
+}
+
+// Ready reports whether the predictor has learned enough events to show predictions.
+// Before that predictions are mostly the average load fallback, so they shouldn't look like a real forecast.
+func (c *Controller) Ready() bool {
+	return c.warmup == 0 || c.predictor.EventsCount() >= c.warmup
+}
+
+// HolidayChecker returns the holiday checker of the predictor.
+func (c *Controller) HolidayChecker() HolidayChecker {
+	return c.predictor.holidayChecker
+}
+
+// SetScaleConfidence enables or disables scaling of displayed prediction confidence by the events count.
+func (c *Controller) SetScaleConfidence(scale bool) {
+	c.predictor.mu.Lock()
+	defer c.predictor.mu.Unlock()
+	c.predictor.scaleConfidence = scale
+}
+
+// ScaleConfidence returns true if displayed prediction confidence is scaled by the events count.
+func (c *Controller) ScaleConfidence() bool {
+	c.predictor.mu.RLock()
+	defer c.predictor.mu.RUnlock()
+	return c.predictor.scaleConfidence
+}
+
+// SetAnomalyThreshold sets the z-score of loads logged as anomalous, 0 disables anomaly detection.
+func (c *Controller) SetAnomalyThreshold(threshold float64) {
+	c.predictor.mu.Lock()
+	defer c.predictor.mu.Unlock()
+	c.predictor.anomalyThreshold = threshold
+}
+
+// ExportCSV writes the predictor's learned statistics to w in CSV format, weekdays are ordered from the first one.
+func (c *Controller) ExportCSV(w io.Writer, first time.Weekday) error {
+	return c.predictor.ExportCSV(w, first)
+}
+
 // loadEventsBatch loads a batch of events from the database starting from the given offset.
 func (c *Controller) loadEventsBatch(ctx context.Context, db *databaser.DB, offset int) ([]databaser.Event, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.timeout)
@@ -2,11 +2,14 @@ package predictor
 
 import (
 	"context"
+	"errors"
+	"math"
 	"testing"
 	"time"
 
 	"github.com/z0rr0/ggp/config"
 	"github.com/z0rr0/ggp/databaser"
+	"github.com/z0rr0/ggp/metrics"
 )
 
 func setupTestDB(t *testing.T, ctx context.Context) *databaser.DB {
@@ -59,6 +62,56 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRun_PredictorSettings(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t, ctx)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("failed to close database: %v", err)
+		}
+	}()
+
+	defaults := New(newMockHolidayChecker())
+	tests := []struct {
+		name      string
+		predictor config.Predictor
+		want      [4]float64 // decay lambda, min weight, reset weight, confidence threshold
+		margin    time.Duration
+	}{
+		{
+			name:   "defaults",
+			want:   [4]float64{defaults.decayLambda, defaults.minWeight, defaults.resetWeight, defaults.confidenceThreshold},
+			margin: defaults.holidayMargin,
+		},
+		{
+			name:      "configured",
+			predictor: config.Predictor{DecayLambda: 0.05, MinWeight: 2, ResetWeight: 1e-3, ConfidenceThreshold: 40, HolidayMargin: 14},
+			want:      [4]float64{0.05, 2, 1e-3, 40},
+			margin:    14 * 24 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Base: config.Base{TimeLocation: time.UTC}, Predictor: tt.predictor}
+			cfg.Predictor.Hours, cfg.Predictor.LoadSize, cfg.Predictor.Timeout = 24, 100, 3*time.Second
+
+			controller, err := Run(ctx, db, nil, cfg)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			p := controller.predictor
+			if got := [4]float64{p.decayLambda, p.minWeight, p.resetWeight, p.confidenceThreshold}; got != tt.want {
+				t.Errorf("decay lambda, min weight, reset weight, confidence threshold = %v, want %v", got, tt.want)
+			}
+			if p.holidayMargin != tt.margin {
+				t.Errorf("holiday margin = %v, want %v", p.holidayMargin, tt.margin)
+			}
+		})
+	}
+}
+
 func TestController_Run(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -127,6 +180,15 @@ func TestController_Run_ContextCancellation(t *testing.T) {
 
 	cancel()
 
+	// the controller waits for closing of the event channel
+	select {
+	case <-doneCh:
+		t.Fatal("controller stopped before the event channel is closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(eventCh)
+
 	select {
 	case <-doneCh:
 	case <-time.After(100 * time.Millisecond):
@@ -134,6 +196,85 @@ func TestController_Run_ContextCancellation(t *testing.T) {
 	}
 }
 
+func TestController_Run_DrainOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	eventCh := make(chan databaser.Event, 3)
+
+	controller := &Controller{
+		predictor: New(newMockHolidayChecker()),
+		eventCh:   eventCh,
+		Hours:     24,
+		loadSize:  100,
+		timeout:   3 * time.Second,
+	}
+
+	// events are buffered in the channel when the context is canceled
+	cancel()
+	baseTime := time.Now().UTC().Truncate(time.Second)
+	for i := range 3 {
+		eventCh <- databaser.Event{Timestamp: baseTime.Add(time.Duration(i-3) * time.Minute), Load: 50}
+	}
+
+	doneCh := controller.Run(ctx)
+
+	// a pending fetch sends its event after the cancellation and then closes the channel
+	go func() {
+		eventCh <- databaser.Event{Timestamp: baseTime, Load: 60}
+		close(eventCh)
+	}()
+
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatal("controller did not stop after the event channel is closed")
+	}
+
+	var count uint64
+	for d := range dayTypesCount {
+		for h := range hoursInDay {
+			count += controller.predictor.stats[d][h].Count
+		}
+	}
+	if count != 4 {
+		t.Errorf("stats events count = %d, want 4", count)
+	}
+}
+
+func TestController_Run_Metrics(t *testing.T) {
+	eventCh := make(chan databaser.Event, 1)
+	m := metrics.New()
+
+	controller := &Controller{
+		predictor: New(newMockHolidayChecker()),
+		eventCh:   eventCh,
+		Hours:     24,
+		loadSize:  100,
+		timeout:   3 * time.Second,
+	}
+	controller.SetMetrics(m)
+
+	event := databaser.Event{Timestamp: time.Now().UTC().Truncate(time.Second), Load: 60}
+	// the prediction is taken before the event is added
+	want := controller.predictor.GetTypicalLoad(event.Timestamp)
+
+	eventCh <- event
+	close(eventCh)
+
+	select {
+	case <-controller.Run(context.Background()):
+	case <-time.After(time.Second):
+		t.Fatal("controller did not stop after the event channel is closed")
+	}
+
+	predicted, actual := m.Prediction()
+	if predicted != want {
+		t.Errorf("predicted load = %v, want %v", predicted, want)
+	}
+	if actual != 60 {
+		t.Errorf("actual load = %v, want 60", actual)
+	}
+}
+
 func TestController_LoadEvents(t *testing.T) {
 	ctx := context.Background()
 	db := setupTestDB(t, ctx)
@@ -172,6 +313,269 @@ func TestController_LoadEvents(t *testing.T) {
 	}
 }
 
+func TestController_LoadEventsRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries int
+		wantErr bool
+	}{
+		{name: "success on retry", retries: 2},
+		{name: "no retries", retries: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := setupTestDB(t, ctx)
+			defer func() {
+				if err := db.Close(); err != nil {
+					t.Errorf("failed to close database: %v", err)
+				}
+			}()
+
+			baseTime := time.Now().UTC().Truncate(time.Second)
+			events := []databaser.Event{
+				{Timestamp: baseTime.Add(-3 * time.Hour), Load: 40},
+				{Timestamp: baseTime.Add(-2 * time.Hour), Load: 50},
+				{Timestamp: baseTime.Add(-1 * time.Hour), Load: 60},
+			}
+			if err := db.SaveManyEvents(ctx, events); err != nil {
+				t.Fatalf("failed to save events: %v", err)
+			}
+
+			// the only database connection is busy by a transaction, so the first loading fails by timeout
+			tx, err := db.BeginTxx(ctx, nil)
+			if err != nil {
+				t.Fatalf("failed to begin transaction: %v", err)
+			}
+			releaseCh := make(chan struct{})
+			go func() {
+				defer close(releaseCh)
+				time.Sleep(100 * time.Millisecond)
+				if rbErr := tx.Rollback(); rbErr != nil {
+					t.Errorf("failed to rollback transaction: %v", rbErr)
+				}
+			}()
+
+			controller := &Controller{
+				predictor:  New(newMockHolidayChecker()),
+				Hours:      24,
+				loadSize:   2,
+				timeout:    50 * time.Millisecond,
+				retries:    tt.retries,
+				retryDelay: 100 * time.Millisecond,
+			}
+
+			err = controller.loadEventsRetry(ctx, db)
+			<-releaseCh
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadEventsRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var count uint64
+			for d := range dayTypesCount {
+				for h := range hoursInDay {
+					count += controller.predictor.stats[d][h].Count
+				}
+			}
+			if count != uint64(len(events)) {
+				t.Errorf("stats events count = %d, want %d", count, len(events))
+			}
+		})
+	}
+}
+
+func TestController_LoadEventsRetry_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	db := setupTestDB(t, context.Background())
+
+	// a closed database fails every loading
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close database: %v", err)
+	}
+
+	controller := &Controller{
+		predictor:  New(newMockHolidayChecker()),
+		loadSize:   100,
+		timeout:    time.Second,
+		retries:    5,
+		retryDelay: time.Hour,
+	}
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+	err := controller.loadEventsRetry(ctx, db)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("loadEventsRetry() error = %v, want context canceled", err)
+	}
+}
+
+func TestController_Run_Refresh(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	db := setupTestDB(t, ctx)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("failed to close database: %v", err)
+		}
+	}()
+
+	baseTime := time.Now().UTC().Truncate(time.Second)
+	if err := db.SaveEvent(ctx, databaser.Event{Timestamp: baseTime.Add(-3 * time.Hour), Load: 40}); err != nil {
+		t.Fatalf("failed to save event: %v", err)
+	}
+
+	controller := &Controller{
+		predictor: New(newMockHolidayChecker()),
+		db:        db,
+		Hours:     24,
+		loadSize:  100,
+		refresh:   20 * time.Millisecond,
+		timeout:   3 * time.Second,
+	}
+	if err := controller.LoadEvents(ctx, db); err != nil {
+		t.Fatalf("LoadEvents() error = %v", err)
+	}
+
+	// events are added to the database directly, the predictor doesn't receive them by the channel
+	missed := []databaser.Event{
+		{Timestamp: baseTime.Add(-2 * time.Hour), Load: 50},
+		{Timestamp: baseTime.Add(-1 * time.Hour), Load: 60},
+	}
+	if err := db.SaveManyEvents(ctx, missed); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	doneCh := controller.Run(ctx)
+	deadline := time.After(2 * time.Second)
+
+	for refreshed := false; !refreshed; {
+		select {
+		case <-deadline:
+			t.Fatal("predictor was not refreshed")
+		case <-time.After(10 * time.Millisecond):
+			controller.predictor.mu.RLock()
+			refreshed = len(controller.predictor.recentEvents) == 3
+			controller.predictor.mu.RUnlock()
+		}
+	}
+
+	cancel()
+	<-doneCh
+
+	// statistics are rebuilt, so previously loaded events are not counted twice
+	var count uint64
+	for d := range dayTypesCount {
+		for h := range hoursInDay {
+			count += controller.predictor.stats[d][h].Count
+		}
+	}
+	if count != 3 {
+		t.Errorf("stats events count = %d, want 3", count)
+	}
+}
+
+func TestController_Reset(t *testing.T) {
+	controller := &Controller{predictor: New(newMockHolidayChecker())}
+	controller.predictor.globalBlendWeight = 0.3
+
+	baseTime := time.Now().UTC().Truncate(time.Hour)
+	controller.predictor.AddEvents([]databaser.Event{
+		{Timestamp: baseTime.Add(-2 * time.Hour), Load: 50},
+		{Timestamp: baseTime.Add(-1 * time.Hour), Load: 60},
+	})
+
+	controller.Reset(context.Background())
+
+	p := controller.predictor
+	if n := len(p.recentEvents); n != 0 {
+		t.Errorf("recent events = %d, want 0", n)
+	}
+	for d := range dayTypesCount {
+		for h := range hoursInDay {
+			if count := p.stats[d][h].Count; count != 0 {
+				t.Fatalf("stats[%d][%d] count = %d, want 0", d, h, count)
+			}
+		}
+	}
+	if p.globalBlendWeight != 0.3 {
+		t.Errorf("global blend weight = %v, want settings to be kept", p.globalBlendWeight)
+	}
+}
+
+func TestController_Run_TrackAccuracy(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t, ctx)
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("failed to close database: %v", err)
+		}
+	}()
+
+	eventCh := make(chan databaser.Event, 2)
+	controller := &Controller{
+		predictor: New(newMockHolidayChecker()),
+		db:        db,
+		eventCh:   eventCh,
+		Hours:     24,
+		loadSize:  100,
+		timeout:   3 * time.Second,
+		accuracy:  true,
+	}
+
+	// the first event stores the prediction of the next hour, the second one reconciles it
+	now := time.Now().UTC()
+	eventCh <- databaser.Event{Timestamp: now, Load: 60}
+	eventCh <- databaser.Event{Timestamp: now.Add(time.Hour), Load: 40}
+	close(eventCh)
+
+	select {
+	case <-controller.Run(ctx):
+	case <-time.After(time.Second):
+		t.Fatal("controller did not stop after the event channel is closed")
+	}
+
+	_, n, err := db.PredictionAccuracy(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("PredictionAccuracy() error = %v", err)
+	}
+	if n != 1 {
+		t.Errorf("reconciled predictions = %d, want 1", n)
+	}
+}
+
+func TestController_Ready(t *testing.T) {
+	tests := []struct {
+		name   string
+		warmup uint64
+		events int
+		want   bool
+	}{
+		{name: "no warmup", want: true},
+		{name: "no events", warmup: 3},
+		{name: "below threshold", warmup: 3, events: 2},
+		{name: "threshold", warmup: 3, events: 3, want: true},
+		{name: "above threshold", warmup: 3, events: 5, want: true},
+	}
+
+	baseTime := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller := &Controller{predictor: New(newMockHolidayChecker()), warmup: tt.warmup}
+			for i := range tt.events {
+				controller.predictor.AddEvent(databaser.Event{Timestamp: baseTime.Add(time.Duration(i) * time.Hour), Load: 50})
+			}
+
+			if got := controller.Ready(); got != tt.want {
+				t.Errorf("Ready() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestController_LoadEvents_Empty(t *testing.T) {
 	ctx := context.Background()
 	db := setupTestDB(t, ctx)
@@ -240,6 +644,83 @@ func TestController_PredictLoad(t *testing.T) {
 				if events[i].Predict < 0 || events[i].Predict > 100 {
 					t.Errorf("prediction[%d] = %v, want 0-100", i, events[i].Predict)
 				}
+				if events[i].Confidence <= 0 || events[i].Confidence > 1 {
+					t.Errorf("prediction[%d] confidence = %v, want (0, 1]", i, events[i].Confidence)
+				}
+			}
+
+			if events[0].Confidence != events[1].Confidence {
+				t.Errorf("current load confidence = %v, want %v", events[0].Confidence, events[1].Confidence)
+			}
+		})
+	}
+}
+
+func TestController_PredictLoad_Smooth(t *testing.T) {
+	controller := &Controller{
+		predictor: New(newMockHolidayChecker()),
+		Hours:     12,
+		loadSize:  100,
+		timeout:   3 * time.Second,
+	}
+
+	// alternating load by hours a week ago makes a jagged prediction
+	baseTime := time.Now().UTC().Truncate(time.Hour).Add(-7 * 24 * time.Hour)
+	for i := range 24 {
+		ts := baseTime.Add(time.Duration(i) * time.Hour)
+		var load uint8 = 20
+		if ts.Hour()%2 == 0 {
+			load = 80
+		}
+		controller.predictor.AddEvent(databaser.Event{Timestamp: ts, Load: load})
+	}
+
+	raw := controller.PredictLoad(12)
+	controller.smooth = 3
+	smoothed := controller.PredictLoad(12)
+
+	if len(raw) != len(smoothed) {
+		t.Fatalf("got %d smoothed events, want %d", len(smoothed), len(raw))
+	}
+
+	variation := func(events []databaser.Event) float64 {
+		var sum float64
+		for i := 1; i < len(events); i++ {
+			sum += math.Abs(events[i].Predict - events[i-1].Predict)
+		}
+		return sum
+	}
+
+	if rv, sv := variation(raw), variation(smoothed); sv >= rv {
+		t.Errorf("smoothed variation = %v, want less than raw %v", sv, rv)
+	}
+}
+
+func TestSmoothPredictions(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		window int
+		want   []float64
+	}{
+		{name: "window 3", values: []float64{10, 40, 10, 40}, window: 3, want: []float64{25, 20, 30, 25}},
+		{name: "window 5", values: []float64{0, 10, 20, 30, 40}, window: 5, want: []float64{10, 15, 20, 25, 30}},
+		{name: "single value", values: []float64{42}, window: 3, want: []float64{42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := make([]databaser.Event, len(tt.values))
+			for i, v := range tt.values {
+				events[i].Predict = v
+			}
+
+			smoothPredictions(events, tt.window)
+
+			for i := range tt.want {
+				if math.Abs(events[i].Predict-tt.want[i]) > 1e-9 {
+					t.Errorf("events[%d].Predict = %v, want %v", i, events[i].Predict, tt.want[i])
+				}
 			}
 		})
 	}
@@ -268,3 +749,35 @@ func TestController_PredictLoad_CurrentTime(t *testing.T) {
 		t.Errorf("second event timestamp %v is in the past", events[1].Timestamp)
 	}
 }
+
+func TestController_TypicalLoad(t *testing.T) {
+	controller := &Controller{
+		predictor: New(newMockHolidayChecker()),
+		Hours:     1,
+		loadSize:  100,
+		timeout:   3 * time.Second,
+	}
+
+	baseTime := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC) // Monday
+	controller.predictor.AddEvent(databaser.Event{Timestamp: baseTime, Load: 60})
+
+	events := []databaser.Event{
+		{Timestamp: baseTime.Add(time.Minute), Load: 10},
+		{Timestamp: baseTime.Add(time.Hour), Load: 20},
+	}
+	typical := controller.TypicalLoad(events)
+
+	if len(typical) != len(events) {
+		t.Fatalf("TypicalLoad() returned %d events, want %d", len(typical), len(events))
+	}
+
+	for i := range events {
+		if !typical[i].Timestamp.Equal(events[i].Timestamp) {
+			t.Errorf("typical[%d].Timestamp = %v, want %v", i, typical[i].Timestamp, events[i].Timestamp)
+		}
+	}
+
+	if typical[0].Predict != 60 {
+		t.Errorf("typical[0].Predict = %v, want 60", typical[0].Predict)
+	}
+}
@@ -2,8 +2,12 @@
 package predictor
 
 import (
+	"encoding/csv"
 	"fmt"
+	"io"
+	"log/slog"
 	"math"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,23 +20,30 @@ const (
 	hoursInDay    = 24 // 0..23
 
 	averageLoad = 25.0 // not 50, 25 is more realistic for an average load
+
+	confidenceCountScale = 30.0 // 30 events -> ~0.63, 90 events -> ~0.95 of the displayed confidence
+
+	minAnomalyWeight = 5.0 // min weight of hourly stats to detect anomalies, fewer events give no stable deviation
+	minAnomalyStdDev = 1.0 // min standard deviation in load percents, so a stuck load doesn't make every change anomalous
 )
 
 // HourlyStats is a storage for hourly statistics.
 type HourlyStats struct {
 	LastUpdate  time.Time // last update time
 	WeightedSum float64   // Sum(load × weight)
+	SumSquares  float64   // Sum(load² × weight)
 	TotalWeight float64   // Sum(weight)
 	Count       uint64    // total events counted
 }
 
 // Prediction represents a load prediction for a specific hour.
 type Prediction struct {
-	TargetTime time.Time
-	Hour       int
-	Load       float64
-	Confidence float64 // prediction confidence [0.0..1.0]
-	IsHoliday  bool
+	TargetTime        time.Time
+	Hour              int
+	Load              float64
+	Confidence        float64 // prediction confidence [0.0..1.0]
+	DisplayConfidence float64 // confidence to show users, it can be scaled by the events count
+	IsHoliday         bool
 }
 
 // Predictor holds the statistics and provides methods to update and retrieve predictions.
@@ -42,9 +53,16 @@ type Predictor struct {
 	recentEvents        []databaser.Event
 	decayLambda         float64
 	minWeight           float64
+	resetWeight         float64
 	confidenceThreshold float64
+	globalBlendWeight   float64       // max share of the global baseline in predictions, 0 disables global blending
+	anomalyThreshold    float64       // z-score of anomalous events, 0 disables anomaly detection
+	maxRecentAge        time.Duration // max age of recent events relative to the newest one, 0 disables age eviction
+	holidayMargin       time.Duration // events closer to the end of loaded holidays produce a warning
+	holidayWarned       time.Time     // end of loaded holidays, which the warning was logged for
 	maxRecentCount      int
 	mu                  sync.RWMutex
+	scaleConfidence     bool
 }
 
 // New creates a new Predictor instance with the provided HolidayChecker.
@@ -53,8 +71,10 @@ func New(holidayChecker HolidayChecker) *Predictor {
 		holidayChecker:      holidayChecker,
 		decayLambda:         0.1,  // exp(-0.1*7) ~= 0.5
 		minWeight:           0.5,  // minimum weight for prediction confidence
+		resetWeight:         1e-6, // exp(-0.1*138) ~= 1e-6, stats older than ~4.5 months are reset
 		maxRecentCount:      40,   // ~ last hour 3600 / 90 = 40
 		confidenceThreshold: 20.0, // weight threshold for max confidence
+		holidayMargin:       7 * 24 * time.Hour,
 	}
 
 	// initialize the statistics array
@@ -67,6 +87,39 @@ func New(holidayChecker HolidayChecker) *Predictor {
 	return p
 }
 
+// emptyCopy returns a new predictor with the same settings, but without statistics and recent events.
+func (p *Predictor) emptyCopy() *Predictor {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	q := New(p.holidayChecker)
+	q.decayLambda = p.decayLambda
+	q.minWeight = p.minWeight
+	q.resetWeight = p.resetWeight
+	q.confidenceThreshold = p.confidenceThreshold
+	q.globalBlendWeight = p.globalBlendWeight
+	q.anomalyThreshold = p.anomalyThreshold
+	q.maxRecentAge = p.maxRecentAge
+	q.holidayMargin = p.holidayMargin
+	q.maxRecentCount = p.maxRecentCount
+	q.scaleConfidence = p.scaleConfidence
+
+	return q
+}
+
+// replace replaces statistics and recent events by ones of the other predictor,
+// the other predictor must not be used after that.
+func (p *Predictor) replace(other *Predictor) {
+	other.mu.RLock()
+	defer other.mu.RUnlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stats = other.stats
+	p.recentEvents = other.recentEvents
+}
+
 // AddEvent adds a new event to the predictor and updates the statistics.
 func (p *Predictor) AddEvent(event databaser.Event) {
 	p.mu.Lock()
@@ -97,24 +150,14 @@ func (p *Predictor) Predict(hoursAhead uint8) Prediction {
 	hour := targetTime.Hour()
 	stats := p.stats[dayType][hour] // day-hour stats
 	basePrediction = p.predictWithBlending(targetTime, hour)
+	confidence = p.hourConfidence(dayType, hour)
 
-	switch {
-	case stats.TotalWeight >= p.minWeight:
-		confidence = p.calculateConfidence(stats, dayType)
-	case dayType == Holiday:
-		sundayStats := p.stats[Sunday][hour]
-		if sundayStats.TotalWeight >= p.minWeight {
-			confidence = 0.5
-		} else {
-			confidence = 0.3
-		}
-	default:
+	if stats.TotalWeight < p.minWeight && dayType != Holiday {
 		basePrediction = p.fallbackPrediction(int(dayType))
-		confidence = 0.3
 	}
 
 	// trend correction for short-term predictions
-	if hoursAhead <= 3 && len(p.recentEvents) >= 20 {
+	if hoursAhead <= 3 && len(p.freshRecentEvents(now)) >= 20 {
 		trend := p.calculateTrend()
 		trendWeight := 0.3 / float64(hoursAhead)
 		basePrediction += trend * trendWeight * float64(hoursAhead)
@@ -123,11 +166,12 @@ func (p *Predictor) Predict(hoursAhead uint8) Prediction {
 	basePrediction = max(0.0, min(100.0, basePrediction))
 
 	return Prediction{
-		TargetTime: targetTime,
-		Hour:       hour,
-		Load:       basePrediction,
-		Confidence: confidence,
-		IsHoliday:  dayType == Holiday,
+		TargetTime:        targetTime,
+		Hour:              hour,
+		Load:              basePrediction,
+		Confidence:        confidence,
+		DisplayConfidence: p.displayConfidence(confidence, stats.Count),
+		IsHoliday:         dayType == Holiday,
 	}
 }
 
@@ -151,14 +195,61 @@ func (p *Predictor) String() string {
 	for i := range dayTypesCount {
 		for j := range hoursInDay {
 			stats := p.stats[i][j]
-			s.WriteString(fmt.Sprintf("DayType %d Hour %02d: Count=%d WeightedSum=%.2f TotalWeight=%.2f LastUpdate=%s\n",
-				i, j, stats.Count, stats.WeightedSum, stats.TotalWeight, stats.LastUpdate.Format(time.RFC3339)))
+			s.WriteString(fmt.Sprintf("DayType %d Hour %02d: Count=%d WeightedSum=%.2f SumSquares=%.2f TotalWeight=%.2f LastUpdate=%s\n",
+				i, j, stats.Count, stats.WeightedSum, stats.SumSquares, stats.TotalWeight, stats.LastUpdate.Format(time.RFC3339)))
 		}
 	}
 
 	return s.String()
 }
 
+// ExportCSV writes the learned statistics for all day types and hours to w in CSV format.
+// Weekdays are ordered from the first weekday, holidays are the last. The average column is empty for hours without data.
+func (p *Predictor) ExportCSV(w io.Writer, first time.Weekday) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"day_type", "hour", "count", "weighted_sum", "total_weight", "average", "last_update"})
+	if err != nil {
+		return fmt.Errorf("write csv header: %w", err)
+	}
+
+	for _, dayType := range append(WeekDays(first), Holiday) {
+		for j := range hoursInDay {
+			var average, lastUpdate string
+			stats := p.stats[dayType][j]
+
+			if stats.TotalWeight > 0 {
+				average = strconv.FormatFloat(stats.WeightedSum/stats.TotalWeight, 'f', 2, 64)
+			}
+			if !stats.LastUpdate.IsZero() {
+				lastUpdate = stats.LastUpdate.Format(time.RFC3339)
+			}
+
+			err = cw.Write([]string{
+				strconv.Itoa(int(dayType)),
+				strconv.Itoa(j),
+				strconv.FormatUint(stats.Count, 10),
+				strconv.FormatFloat(stats.WeightedSum, 'f', 4, 64),
+				strconv.FormatFloat(stats.TotalWeight, 'f', 4, 64),
+				average,
+				lastUpdate,
+			})
+			if err != nil {
+				return fmt.Errorf("write csv row: %w", err)
+			}
+		}
+	}
+
+	cw.Flush()
+	if err = cw.Error(); err != nil {
+		return fmt.Errorf("flush csv: %w", err)
+	}
+
+	return nil
+}
+
 // GetTypicalLoad returns the typical load for the given time based on historical data.
 func (p *Predictor) GetTypicalLoad(t time.Time) float64 {
 	p.mu.RLock()
@@ -175,8 +266,53 @@ func (p *Predictor) GetTypicalLoad(t time.Time) float64 {
 	return p.fallbackPrediction(int(dayType))
 }
 
+// ConfidenceAt returns the prediction confidence for the day type and hour of the given time.
+func (p *Predictor) ConfidenceAt(t time.Time) float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.hourConfidence(p.getDayType(t), t.Hour())
+}
+
+// EventsCount returns the number of events learned by the predictor for all day types and hours.
+func (p *Predictor) EventsCount() uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var count uint64
+	for i := range dayTypesCount {
+		for j := range hoursInDay {
+			count += p.stats[i][j].Count
+		}
+	}
+
+	return count
+}
+
+// IsAnomalous compares the event load with the weighted average and standard deviation of its day type and hour.
+// It returns the z-score of the load and whether it exceeds the anomaly threshold.
+// The z-score is zero if there are not enough events for the hour, the event isn't anomalous then.
+func (p *Predictor) IsAnomalous(event databaser.Event) (bool, float64) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := p.stats[p.getDayType(event.Timestamp)][event.Timestamp.Hour()]
+	if stats.TotalWeight < minAnomalyWeight {
+		return false, 0
+	}
+
+	// the decay scales all sums by the same factor, so the mean and variance don't depend on it
+	mean := stats.WeightedSum / stats.TotalWeight
+	variance := max(0, stats.SumSquares/stats.TotalWeight-mean*mean)
+	z := math.Abs(event.FloatLoad()-mean) / max(math.Sqrt(variance), minAnomalyStdDev)
+
+	return p.anomalyThreshold > 0 && z > p.anomalyThreshold, z
+}
+
 // addEvent adds a new event to the predictor and updates the statistics, should be called with lock held.
 func (p *Predictor) addEvent(event databaser.Event) {
+	p.checkHolidayRange(event.Timestamp)
+
 	dayType := p.getDayType(event.Timestamp)
 	hour := event.Timestamp.Hour()
 	stats := p.stats[dayType][hour]
@@ -186,22 +322,82 @@ func (p *Predictor) addEvent(event databaser.Event) {
 		if daysSinceUpdate > 0 {
 			decayFactor := math.Exp(-p.decayLambda * daysSinceUpdate)
 			stats.WeightedSum *= decayFactor
+			stats.SumSquares *= decayFactor
 			stats.TotalWeight *= decayFactor
 		}
 	}
 
-	stats.WeightedSum += event.FloatLoad()
+	if stats.TotalWeight < p.resetWeight {
+		// fully decayed stats carry no information, but tiny values lose precision, so start from scratch
+		stats.WeightedSum = 0
+		stats.SumSquares = 0
+		stats.TotalWeight = 0
+	}
+
+	load := event.FloatLoad()
+	stats.WeightedSum += load
+	stats.SumSquares += load * load
 	stats.TotalWeight += 1.0
 	stats.Count++
 	stats.LastUpdate = event.Timestamp
 
 	p.recentEvents = append(p.recentEvents, event)
-	if len(p.recentEvents) > p.maxRecentCount {
-		copy(p.recentEvents, p.recentEvents[1:])
-		p.recentEvents = p.recentEvents[:p.maxRecentCount]
+	p.evictRecentEvents(event.Timestamp)
+}
+
+// checkHolidayRange logs a warning once if the time is close to the end of loaded holidays or after it,
+// so holidays should be reloaded, otherwise new holidays are learned as usual days.
+// It should be called with lock held.
+func (p *Predictor) checkHolidayRange(t time.Time) {
+	holidayRange, ok := p.holidayChecker.(HolidayRange)
+	if !ok {
+		return
+	}
+
+	until := holidayRange.HolidaysUntil()
+	if until.IsZero() || until.Equal(p.holidayWarned) || t.Before(until.Add(-p.holidayMargin)) {
+		return
+	}
+
+	p.holidayWarned = until
+	slog.Warn("event is near the end of loaded holidays, reload them", "event", t, "until", until)
+}
+
+// evictRecentEvents removes the oldest recent events exceeding the count limit
+// or older than the max age relative to the newest timestamp, should be called with lock held.
+func (p *Predictor) evictRecentEvents(newest time.Time) {
+	n := len(p.recentEvents)
+	drop := max(0, n-p.maxRecentCount)
+
+	if p.maxRecentAge > 0 {
+		threshold := newest.Add(-p.maxRecentAge)
+		for drop < n && p.recentEvents[drop].Timestamp.Before(threshold) {
+			drop++
+		}
+	}
+
+	if drop > 0 {
+		// copy to the start, so the backing array doesn't keep growing
+		copy(p.recentEvents, p.recentEvents[drop:])
+		p.recentEvents = p.recentEvents[:n-drop]
 	}
 }
 
+// freshRecentEvents returns recent events not older than the max age relative to now, should be called with lock held.
+func (p *Predictor) freshRecentEvents(now time.Time) []databaser.Event {
+	if p.maxRecentAge <= 0 {
+		return p.recentEvents
+	}
+
+	threshold := now.Add(-p.maxRecentAge)
+	i := 0
+	for i < len(p.recentEvents) && p.recentEvents[i].Timestamp.Before(threshold) {
+		i++
+	}
+
+	return p.recentEvents[i:]
+}
+
 // getDayType determines the DayType for the given time.
 func (p *Predictor) getDayType(t time.Time) DayType {
 	if p.holidayChecker != nil && p.holidayChecker.IsHoliday(t) {
@@ -212,15 +408,17 @@ func (p *Predictor) getDayType(t time.Time) DayType {
 }
 
 // calculateTrend calculates the trend of recent events using linear regression.
+// Events older than the max recent age are ignored, so the trend isn't based on stale data if there are no new events.
 func (p *Predictor) calculateTrend() float64 {
-	n := len(p.recentEvents)
+	events := p.freshRecentEvents(time.Now().UTC())
+	n := len(events)
 	if n < 3 {
 		return 0
 	}
 
 	// linear regression to find the trend = (last - first) / counted
-	first := p.recentEvents[0]
-	last := p.recentEvents[n-1]
+	first := events[0]
+	last := events[n-1]
 	hoursDiff := last.Timestamp.Sub(first.Timestamp).Hours()
 
 	if hoursDiff < 0.1 {
@@ -242,6 +440,31 @@ func (p *Predictor) fallbackPrediction(dayOfWeek int) float64 {
 		}
 	}
 
+	if weight > 0 {
+		return p.blendGlobal(sum/weight, weight)
+	}
+
+	if p.globalBlendWeight > 0 {
+		return p.globalBaseline()
+	}
+
+	return averageLoad
+}
+
+// globalBaseline returns the average load across all learned hours, should be called with lock held.
+func (p *Predictor) globalBaseline() float64 {
+	var sum, weight float64
+
+	for d := range dayTypesCount {
+		for h := range hoursInDay {
+			stats := p.stats[d][h]
+			if stats.TotalWeight > 0 {
+				sum += stats.WeightedSum
+				weight += stats.TotalWeight
+			}
+		}
+	}
+
 	if weight > 0 {
 		return sum / weight
 	}
@@ -249,7 +472,37 @@ func (p *Predictor) fallbackPrediction(dayOfWeek int) float64 {
 	return averageLoad
 }
 
+// blendGlobal mixes the value with the global baseline if global blending is enabled.
+// The less weight the value has, the bigger share of the baseline is used.
+func (p *Predictor) blendGlobal(value, weight float64) float64 {
+	if p.globalBlendWeight <= 0 {
+		return value
+	}
+
+	share := p.globalBlendWeight * (1 - math.Min(1.0, weight/p.confidenceThreshold))
+	return value*(1-share) + p.globalBaseline()*share
+}
+
+// hourConfidence returns the confidence for the day type and hour stats, should be called with lock held.
+// If there is not enough data, the confidence of the fallback prediction is returned.
+func (p *Predictor) hourConfidence(dayType DayType, hour int) float64 {
+	stats := p.stats[dayType][hour]
+
+	switch {
+	case stats.TotalWeight >= p.minWeight:
+		return p.calculateConfidence(stats, dayType)
+	case dayType == Holiday && p.stats[Sunday][hour].TotalWeight >= p.minWeight:
+		return 0.5
+	default:
+		return 0.3
+	}
+}
+
 func (p *Predictor) calculateConfidence(stats *HourlyStats, dayType DayType) float64 {
+	if !(stats.TotalWeight >= p.resetWeight) { // also handles NaN
+		return 0
+	}
+
 	// base confidence based on total weight
 	base := math.Min(1.0, stats.TotalWeight/p.confidenceThreshold)
 
@@ -260,7 +513,8 @@ func (p *Predictor) calculateConfidence(stats *HourlyStats, dayType DayType) flo
 
 	// penalty for stale data
 	if !stats.LastUpdate.IsZero() {
-		daysSince := time.Since(stats.LastUpdate).Hours() / 24
+		// updates from the future are treated as fresh
+		daysSince := max(0, time.Since(stats.LastUpdate).Hours()/24)
 		freshness := math.Exp(-0.05 * daysSince) // 2 weeks -> ~0.37
 		base *= freshness
 	}
@@ -268,6 +522,16 @@ func (p *Predictor) calculateConfidence(stats *HourlyStats, dayType DayType) flo
 	return base
 }
 
+// displayConfidence returns the confidence to show users.
+// If scaling is enabled, it is reduced for a small number of events, so high values are not shown too early.
+func (p *Predictor) displayConfidence(confidence float64, count uint64) float64 {
+	if !p.scaleConfidence {
+		return confidence
+	}
+
+	return confidence * (1 - math.Exp(-float64(count)/confidenceCountScale))
+}
+
 func (p *Predictor) getWeightedAverage(dayType DayType, hour int) float64 {
 	stats := p.stats[dayType][hour]
 	if stats.TotalWeight < 0.1 {
@@ -283,7 +547,7 @@ func (p *Predictor) predictWithBlending(targetTime time.Time, hour int) float64
 	if !isHoliday {
 		// #nosec G115 -- Weekday() returns 0-6, always fits in uint8
 		dayType := DayType(targetTime.Weekday())
-		return p.getWeightedAverage(dayType, hour)
+		return p.blendGlobal(p.getWeightedAverage(dayType, hour), p.stats[dayType][hour].TotalWeight)
 	}
 
 	// holiday — blend holiday and Sunday stats
@@ -1,7 +1,12 @@
 package predictor
 
 import (
+	"bytes"
+	"encoding/csv"
+	"log/slog"
 	"math"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -145,6 +150,85 @@ func TestAddEvent(t *testing.T) {
 	}
 }
 
+func TestAddEvent_SumSquaresDecay(t *testing.T) {
+	p := New(newMockHolidayChecker())
+
+	// the same weekday and hour a week later, so the first event is decayed by exp(-0.7)
+	baseTime := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	p.AddEvent(databaser.Event{Timestamp: baseTime, Load: 10})
+	p.AddEvent(databaser.Event{Timestamp: baseTime.AddDate(0, 0, 7), Load: 20})
+
+	decay := math.Exp(-0.7)
+	stats := p.stats[DayType(time.Monday)][10]
+
+	if want := 10*decay + 20; math.Abs(stats.WeightedSum-want) > 1e-9 {
+		t.Errorf("WeightedSum = %v, want %v", stats.WeightedSum, want)
+	}
+	if want := 100*decay + 400; math.Abs(stats.SumSquares-want) > 1e-9 {
+		t.Errorf("SumSquares = %v, want %v", stats.SumSquares, want)
+	}
+	if want := decay + 1; math.Abs(stats.TotalWeight-want) > 1e-9 {
+		t.Errorf("TotalWeight = %v, want %v", stats.TotalWeight, want)
+	}
+
+	// fully decayed stats are reset together
+	p.AddEvent(databaser.Event{Timestamp: baseTime.AddDate(1, 0, 0), Load: 30})
+	stats = p.stats[p.getDayType(baseTime.AddDate(1, 0, 0))][10]
+	if stats.SumSquares != 900 || stats.TotalWeight != 1 {
+		t.Errorf("stats after reset = %v/%v, want 900/1", stats.SumSquares, stats.TotalWeight)
+	}
+}
+
+func TestIsAnomalous(t *testing.T) {
+	// Mondays at 10:00 with loads 40, 50, 60 repeated, mean 50 and standard deviation ~8.16
+	baseTime := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	history := make([]databaser.Event, 0, 9)
+	for i := range 9 {
+		history = append(history, databaser.Event{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Load: uint8(40 + 10*(i%3))})
+	}
+	stuck := make([]databaser.Event, 0, 9)
+	for i := range 9 {
+		stuck = append(stuck, databaser.Event{Timestamp: baseTime.Add(time.Duration(i) * time.Minute), Load: 30})
+	}
+	stdDev := math.Sqrt(200.0 / 3)
+
+	tests := []struct {
+		name          string
+		history       []databaser.Event
+		load          uint8
+		threshold     float64
+		wantAnomalous bool
+		wantZ         float64
+	}{
+		{name: "no history", load: 90, threshold: 3},
+		{name: "few events", history: history[:3], load: 90, threshold: 3},
+		{name: "usual load", history: history, load: 55, threshold: 3, wantZ: 5 / stdDev},
+		{name: "spike", history: history, load: 100, threshold: 3, wantAnomalous: true, wantZ: 50 / stdDev},
+		{name: "drop", history: history, load: 0, threshold: 3, wantAnomalous: true, wantZ: 50 / stdDev},
+		{name: "disabled", history: history, load: 100, wantZ: 50 / stdDev},
+		{name: "stuck history", history: stuck, load: 35, threshold: 3, wantAnomalous: true, wantZ: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(newMockHolidayChecker())
+			p.anomalyThreshold = tt.threshold
+			p.AddEvents(tt.history)
+
+			event := databaser.Event{Timestamp: baseTime.Add(30 * time.Minute), Load: tt.load}
+			anomalous, z := p.IsAnomalous(event)
+
+			if anomalous != tt.wantAnomalous {
+				t.Errorf("IsAnomalous() = %v, want %v", anomalous, tt.wantAnomalous)
+			}
+			// events minutes apart are decayed a bit, so the weights are almost equal
+			if math.Abs(z-tt.wantZ) > 1e-3 {
+				t.Errorf("z-score = %v, want %v", z, tt.wantZ)
+			}
+		})
+	}
+}
+
 func TestAddEvent_RecentEventsLimit(t *testing.T) {
 	p := New(newMockHolidayChecker())
 	p.maxRecentCount = 10
@@ -171,6 +255,95 @@ func TestAddEvent_RecentEventsLimit(t *testing.T) {
 	}
 }
 
+func TestAddEvent_RecentEventsAge(t *testing.T) {
+	p := New(newMockHolidayChecker())
+	p.maxRecentAge = time.Hour
+
+	baseTime := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	// old events every 10 minutes during 2 hours, then a gap and fresh events every minute
+	for i := range 12 {
+		p.AddEvent(databaser.Event{Timestamp: baseTime.Add(time.Duration(i) * 10 * time.Minute), Load: 10})
+	}
+	freshStart := baseTime.Add(3 * time.Hour)
+	for i := range 5 {
+		p.AddEvent(databaser.Event{Timestamp: freshStart.Add(time.Duration(i) * time.Minute), Load: 50})
+	}
+
+	if n := len(p.recentEvents); n != 5 {
+		t.Fatalf("recentEvents length = %d, want 5", n)
+	}
+
+	for _, e := range p.recentEvents {
+		if e.Load != 50 {
+			t.Errorf("old event %v was not evicted", e.Timestamp)
+		}
+	}
+
+	// a new event moves the window, events older than 1 hour before it are evicted
+	p.AddEvent(databaser.Event{Timestamp: freshStart.Add(time.Hour + 3*time.Minute), Load: 60})
+
+	if n := len(p.recentEvents); n != 3 {
+		t.Fatalf("recentEvents length = %d, want 3", n)
+	}
+
+	if first := p.recentEvents[0].Timestamp; !first.Equal(freshStart.Add(3 * time.Minute)) {
+		t.Errorf("first event timestamp = %v, want %v", first, freshStart.Add(3*time.Minute))
+	}
+}
+
+func TestCalculateTrend_IgnoresStaleEvents(t *testing.T) {
+	now := time.Now().UTC()
+	p := New(newMockHolidayChecker())
+	p.maxRecentAge = time.Hour
+	p.recentEvents = []databaser.Event{
+		{Timestamp: now.Add(-5 * time.Hour), Load: 10},
+		{Timestamp: now.Add(-4 * time.Hour), Load: 20},
+		{Timestamp: now.Add(-3 * time.Hour), Load: 30},
+	}
+
+	if got := p.calculateTrend(); got != 0 {
+		t.Errorf("calculateTrend() = %v, want 0 for stale events", got)
+	}
+
+	p.maxRecentAge = 0
+	if got := p.calculateTrend(); math.Abs(got-10) > 0.01 {
+		t.Errorf("calculateTrend() = %v, want 10 without age limit", got)
+	}
+}
+
+func TestAddEvent_LongGaps(t *testing.T) {
+	p := New(newMockHolidayChecker())
+	baseTime := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC) // Monday
+
+	// the same hour receives events rarely, with 20 weeks gaps
+	var event databaser.Event
+	for i := range 50 {
+		event = databaser.Event{
+			Timestamp: baseTime.Add(time.Duration(i) * 20 * 7 * 24 * time.Hour),
+			Load:      uint8(10 + i),
+		}
+		p.AddEvent(event)
+	}
+
+	stats := p.stats[DayType(time.Monday)][10]
+	if stats.Count != 50 {
+		t.Errorf("Count = %d, want 50", stats.Count)
+	}
+
+	if stats.TotalWeight != 1.0 {
+		t.Errorf("TotalWeight = %v, want 1.0 after reset", stats.TotalWeight)
+	}
+
+	avg := stats.WeightedSum / stats.TotalWeight
+	if math.IsNaN(avg) || math.Abs(avg-event.FloatLoad()) > 0.001 {
+		t.Errorf("average = %v, want %v", avg, event.FloatLoad())
+	}
+
+	if got := p.getWeightedAverage(DayType(time.Monday), 10); math.Abs(got-event.FloatLoad()) > 0.001 {
+		t.Errorf("getWeightedAverage() = %v, want %v", got, event.FloatLoad())
+	}
+}
+
 func TestPredict(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -318,6 +491,94 @@ func TestGetTypicalLoad(t *testing.T) {
 	}
 }
 
+func TestConfidenceAt(t *testing.T) {
+	now := time.Now().UTC()
+	monday := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	holiday := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	p := New(newMockHolidayChecker("2025-01-01"))
+	p.stats[DayType(time.Monday)][8] = &HourlyStats{TotalWeight: 40.0, Count: 100, LastUpdate: now}
+	p.stats[DayType(time.Monday)][10] = &HourlyStats{TotalWeight: 10.0, Count: 20, LastUpdate: now}
+	p.stats[DayType(time.Monday)][12] = &HourlyStats{TotalWeight: 0.2, Count: 1, LastUpdate: now}
+	p.stats[Holiday][8] = &HourlyStats{TotalWeight: 40.0, Count: 100, LastUpdate: now}
+	p.stats[Sunday][10] = &HourlyStats{TotalWeight: 10.0, Count: 20, LastUpdate: now}
+
+	tests := []struct {
+		name string
+		time time.Time
+		want float64
+	}{
+		{name: "enough data", time: monday.Add(8*time.Hour + 30*time.Minute), want: 1.0},
+		{name: "partial data", time: monday.Add(10 * time.Hour), want: 0.5},
+		{name: "too little data", time: monday.Add(12 * time.Hour), want: 0.3},
+		{name: "no data", time: monday.Add(20 * time.Hour), want: 0.3},
+		{name: "holiday with data", time: holiday.Add(8 * time.Hour), want: 0.7},
+		{name: "holiday with sunday data", time: holiday.Add(10 * time.Hour), want: 0.5},
+		{name: "holiday without data", time: holiday.Add(20 * time.Hour), want: 0.3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.ConfidenceAt(tt.time); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("ConfidenceAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// mockRangeChecker is a holiday checker with the end of loaded holidays.
+type mockRangeChecker struct {
+	*mockHolidayChecker
+	until time.Time
+}
+
+func (m *mockRangeChecker) HolidaysUntil() time.Time {
+	return m.until
+}
+
+func TestAddEvent_HolidayRangeWarning(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	const warning = "event is near the end of loaded holidays"
+	checker := &mockRangeChecker{
+		mockHolidayChecker: newMockHolidayChecker("2025-01-01"),
+		until:              time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	p := New(checker)
+
+	p.AddEvent(databaser.Event{Timestamp: time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC), Load: 50})
+	if strings.Contains(buf.String(), warning) {
+		t.Fatalf("unexpected warning far from the end of loaded holidays: %s", buf.String())
+	}
+
+	// the next year holiday isn't loaded, so it's learned as a usual day
+	unloaded := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	p.AddEvent(databaser.Event{Timestamp: unloaded, Load: 10})
+	if dayType := p.getDayType(unloaded); dayType != DayType(time.Thursday) {
+		t.Errorf("day type of unloaded holiday = %v, want Thursday", dayType)
+	}
+	if n := strings.Count(buf.String(), warning); n != 1 {
+		t.Errorf("warnings count = %d, want 1: %s", n, buf.String())
+	}
+
+	// the warning is logged once for the loaded range
+	p.AddEvent(databaser.Event{Timestamp: unloaded.Add(time.Hour), Load: 10})
+	if n := strings.Count(buf.String(), warning); n != 1 {
+		t.Errorf("warnings count after repeated event = %d, want 1", n)
+	}
+
+	// the margin warns before the end of loaded holidays
+	buf.Reset()
+	p = New(checker)
+	p.AddEvent(databaser.Event{Timestamp: checker.until.Add(-p.holidayMargin / 2), Load: 50})
+	if !strings.Contains(buf.String(), warning) {
+		t.Error("no warning within the margin before the end of loaded holidays")
+	}
+}
+
 func TestGetDayType(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -516,6 +777,36 @@ func TestCalculateConfidence(t *testing.T) {
 			wantMin: 0.1,
 			wantMax: 0.5,
 		},
+		{
+			name: "decayed weight",
+			stats: &HourlyStats{
+				TotalWeight: 1e-300,
+				LastUpdate:  time.Now().UTC(),
+			},
+			dayType: DayType(time.Monday),
+			wantMin: 0.0,
+			wantMax: 0.0,
+		},
+		{
+			name: "NaN weight",
+			stats: &HourlyStats{
+				TotalWeight: math.NaN(),
+				LastUpdate:  time.Now().UTC(),
+			},
+			dayType: DayType(time.Monday),
+			wantMin: 0.0,
+			wantMax: 0.0,
+		},
+		{
+			name: "future update",
+			stats: &HourlyStats{
+				TotalWeight: 20.0,
+				LastUpdate:  time.Now().UTC().Add(48 * time.Hour),
+			},
+			dayType: DayType(time.Monday),
+			wantMin: 1.0,
+			wantMax: 1.0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -534,6 +825,57 @@ func TestCalculateConfidence(t *testing.T) {
 	}
 }
 
+func TestDisplayConfidence(t *testing.T) {
+	p := New(newMockHolidayChecker())
+
+	if got := p.displayConfidence(0.9, 1); got != 0.9 {
+		t.Errorf("displayConfidence() without scaling = %v, want 0.9", got)
+	}
+
+	p.scaleConfidence = true
+	prev := 0.0
+
+	for _, count := range []uint64{0, 1, 5, 10, 30, 100, 1000} {
+		got := p.displayConfidence(0.9, count)
+
+		if count > 0 && got <= prev {
+			t.Errorf("displayConfidence(count=%d) = %v, want > %v", count, got, prev)
+		}
+		if got < 0.0 || got > 0.9 {
+			t.Errorf("displayConfidence(count=%d) = %v, want between 0.0 and 0.9", count, got)
+		}
+		prev = got
+	}
+
+	if got := p.displayConfidence(0.9, 5); got > 0.3 {
+		t.Errorf("displayConfidence(count=5) = %v, want <= 0.3", got)
+	}
+}
+
+func TestPredict_DisplayConfidence(t *testing.T) {
+	p := New(newMockHolidayChecker())
+	p.scaleConfidence = true
+
+	// predictions with the same hour and day type
+	target := time.Now().UTC().Add(time.Hour)
+	prev := 0.0
+
+	for i := range 3 {
+		for range 10 {
+			p.AddEvent(databaser.Event{Timestamp: target.Add(-7 * 24 * time.Hour), Load: 50})
+		}
+
+		prediction := p.Predict(1)
+		if prediction.DisplayConfidence > prediction.Confidence {
+			t.Errorf("DisplayConfidence = %v, want <= Confidence %v", prediction.DisplayConfidence, prediction.Confidence)
+		}
+		if i > 0 && prediction.DisplayConfidence <= prev {
+			t.Errorf("DisplayConfidence = %v, want > %v", prediction.DisplayConfidence, prev)
+		}
+		prev = prediction.DisplayConfidence
+	}
+}
+
 func TestGetWeightedAverage(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -631,6 +973,62 @@ func TestPredictWithBlending(t *testing.T) {
 	}
 }
 
+func TestPredictWithBlending_Global(t *testing.T) {
+	// Monday 10:00 has a single event, other hours have a lot of data with a lower load
+	events := []databaser.Event{{Timestamp: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC), Load: 80}}
+	for i := range 100 {
+		events = append(events, databaser.Event{
+			Timestamp: time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Second),
+			Load:      20,
+		})
+	}
+	targetTime := time.Date(2025, 1, 13, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		blendWeight float64
+		wantMin     float64
+		wantMax     float64
+	}{
+		{name: "without global blending", blendWeight: 0, wantMin: 80, wantMax: 80},
+		{name: "with global blending", blendWeight: 0.5, wantMin: 50, wantMax: 60},
+		{name: "with full global blending", blendWeight: 1, wantMin: 20, wantMax: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(newMockHolidayChecker())
+			p.globalBlendWeight = tt.blendWeight
+			p.AddEvents(events)
+
+			got := p.predictWithBlending(targetTime, targetTime.Hour())
+
+			if got < tt.wantMin || got > tt.wantMax {
+				t.Errorf("predictWithBlending() = %v, want between %v and %v", got, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestFallbackPrediction_Global(t *testing.T) {
+	events := []databaser.Event{
+		{Timestamp: time.Date(2025, 1, 7, 12, 0, 0, 0, time.UTC), Load: 40}, // Tuesday
+		{Timestamp: time.Date(2025, 1, 7, 13, 0, 0, 0, time.UTC), Load: 60}, // Tuesday
+	}
+
+	p := New(newMockHolidayChecker())
+	p.AddEvents(events)
+
+	if got := p.fallbackPrediction(int(time.Monday)); got != averageLoad {
+		t.Errorf("fallbackPrediction() without global blending = %v, want %v", got, averageLoad)
+	}
+
+	p.globalBlendWeight = 0.5
+	if got := p.fallbackPrediction(int(time.Monday)); math.Abs(got-50) > 0.01 {
+		t.Errorf("fallbackPrediction() with global blending = %v, want 50", got)
+	}
+}
+
 func TestConcurrentAccess(t *testing.T) {
 	p := New(newMockHolidayChecker())
 	done := make(chan bool)
@@ -682,6 +1080,83 @@ func TestString(t *testing.T) {
 	}
 }
 
+func TestExportCSV(t *testing.T) {
+	p := New(newMockHolidayChecker())
+	p.AddEvent(databaser.Event{Timestamp: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC), Load: 50}) // Monday
+
+	var buf bytes.Buffer
+	if err := p.ExportCSV(&buf, time.Sunday); err != nil {
+		t.Fatalf("ExportCSV() error = %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read csv: %v", err)
+	}
+
+	if n := len(records); n != dayTypesCount*hoursInDay+1 {
+		t.Fatalf("got %d rows, want %d", n, dayTypesCount*hoursInDay+1)
+	}
+
+	for i, record := range records {
+		if len(record) != 7 {
+			t.Errorf("row %d has %d columns, want 7", i, len(record))
+		}
+	}
+
+	if records[0][0] != "day_type" || records[0][5] != "average" {
+		t.Errorf("unexpected header: %v", records[0])
+	}
+
+	// header + Sunday hours + Monday 10:00
+	monday := records[1+int(Monday)*hoursInDay+10]
+	want := []string{"1", "10", "1", "50.0000", "1.0000", "50.00", "2025-01-06T10:00:00Z"}
+	for i := range want {
+		if monday[i] != want[i] {
+			t.Errorf("monday row column %d = %q, want %q", i, monday[i], want[i])
+		}
+	}
+
+	if empty := records[1]; empty[2] != "0" || empty[5] != "" || empty[6] != "" {
+		t.Errorf("unexpected empty hour row: %v", empty)
+	}
+}
+
+func TestExportCSV_FirstWeekday(t *testing.T) {
+	p := New(newMockHolidayChecker())
+
+	tests := []struct {
+		first time.Weekday
+		want  []string
+	}{
+		{first: time.Monday, want: []string{"1", "2", "3", "4", "5", "6", "0", "7"}},
+		{first: time.Sunday, want: []string{"0", "1", "2", "3", "4", "5", "6", "7"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.first.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := p.ExportCSV(&buf, tt.first); err != nil {
+				t.Fatalf("ExportCSV() error = %v", err)
+			}
+
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatalf("failed to read csv: %v", err)
+			}
+
+			// the first hour row of every day type after the header
+			got := make([]string, 0, dayTypesCount)
+			for i := 1; i < len(records); i += hoursInDay {
+				got = append(got, records[i][0])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("day types = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func BenchmarkAddEvent(b *testing.B) {
 	p := New(newMockHolidayChecker())
 	baseTime := time.Now().UTC()
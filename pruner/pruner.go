@@ -0,0 +1,101 @@
+// Package pruner provides functionality to periodically roll up completed days into daily statistics
+// and to delete old events, keeping their daily aggregates for long-term statistics.
+package pruner
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// rollupDays is the number of last completed days to roll up, more than one to cover missed runs.
+const rollupDays = 2
+
+// Pruner holds the configuration for the events rollup and pruning.
+type Pruner struct {
+	Db           *databaser.DB
+	Location     *time.Location
+	RawTTL       time.Duration // raw events older than it are aggregated and deleted, zero value keeps them forever
+	AggregateTTL time.Duration // daily statistics older than it are deleted, zero value keeps them forever
+	Timeout      time.Duration
+	QueryTimeout time.Duration
+}
+
+// Run begins the periodic pruning process.
+func (p *Pruner) Run(ctx context.Context) <-chan struct{} {
+	if err := p.Prune(ctx); err != nil {
+		slog.Error("pruner error", "error", err)
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(p.Timeout)
+		defer ticker.Stop()
+		slog.Info("pruner starting", "period", p.Timeout, "raw_ttl", p.RawTTL, "aggregate_ttl", p.AggregateTTL)
+
+		for {
+			select {
+			case <-ctx.Done():
+				slog.Info("stopping pruner")
+				close(doneCh)
+				return
+			case <-ticker.C:
+				slog.Info("wake up pruner")
+				if err := p.Prune(ctx); err != nil {
+					slog.Error("pruner error", "error", err)
+				}
+			}
+		}
+	}()
+
+	return doneCh
+}
+
+// Prune rolls up the last completed days, aggregates and deletes old raw events,
+// then deletes old daily statistics.
+func (p *Pruner) Prune(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.QueryTimeout)
+	defer cancel()
+
+	now := time.Now()
+	if err := p.Rollup(ctx, now); err != nil {
+		return err
+	}
+
+	var events int64
+	if p.RawTTL > 0 {
+		var err error
+		events, err = p.Db.PruneEvents(ctx, now.Add(-p.RawTTL), p.Location)
+		if err != nil {
+			return fmt.Errorf("prune raw events: %w", err)
+		}
+	}
+
+	var stats int64
+	if p.AggregateTTL > 0 {
+		var err error
+		stats, err = p.Db.PruneDailyStats(ctx, now.Add(-p.AggregateTTL), p.Location)
+		if err != nil {
+			return fmt.Errorf("prune daily stats: %w", err)
+		}
+	}
+
+	slog.InfoContext(ctx, "pruner finished", "events", events, "daily_stats", stats)
+	return nil
+}
+
+// Rollup saves daily statistics for the completed days before now.
+func (p *Pruner) Rollup(ctx context.Context, now time.Time) error {
+	today := now.In(p.Location)
+
+	for i := rollupDays; i > 0; i-- {
+		if err := p.Db.RollupDay(ctx, today.AddDate(0, 0, -i), p.Location); err != nil {
+			return fmt.Errorf("rollup: %w", err)
+		}
+	}
+
+	return nil
+}
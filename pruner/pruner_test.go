@@ -0,0 +1,178 @@
+package pruner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func newTestDB(t *testing.T) *databaser.DB {
+	t.Helper()
+	db, err := databaser.New(context.Background(), ":memory:", 1)
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("failed to close test database: %v", err)
+		}
+	})
+	return db
+}
+
+func TestPruner_Prune(t *testing.T) {
+	tests := []struct {
+		name         string
+		aggregateTTL time.Duration
+		wantStats    int
+	}{
+		{name: "keep aggregates forever", wantStats: 2},
+		{name: "prune old aggregates", aggregateTTL: 15 * 24 * time.Hour, wantStats: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			ctx := context.Background()
+			today := time.Now().UTC().Truncate(24 * time.Hour)
+
+			events := []databaser.Event{
+				{Timestamp: today.Add(-20 * 24 * time.Hour), Load: 10},
+				{Timestamp: today.Add(-20*24*time.Hour + time.Hour), Load: 30},
+				{Timestamp: today.Add(-10 * 24 * time.Hour), Load: 50},
+				{Timestamp: today.Add(time.Minute), Load: 70},
+			}
+			if err := db.SaveManyEvents(ctx, events); err != nil {
+				t.Fatalf("failed to save events: %v", err)
+			}
+
+			p := &Pruner{
+				Db:           db,
+				Location:     time.UTC,
+				RawTTL:       7 * 24 * time.Hour,
+				AggregateTTL: tt.aggregateTTL,
+				Timeout:      time.Hour,
+				QueryTimeout: 5 * time.Second,
+			}
+
+			if err := p.Prune(ctx); err != nil {
+				t.Fatalf("Prune() error = %v", err)
+			}
+
+			raw, err := db.GetAllEvents(ctx, 100, 0)
+			if err != nil {
+				t.Fatalf("GetAllEvents() error = %v", err)
+			}
+			if len(raw) != 1 || raw[0].Load != 70 {
+				t.Errorf("unexpected raw events after pruning: %v", raw)
+			}
+
+			stats, err := db.GetDailyStats(ctx, today.Add(-30*24*time.Hour), today)
+			if err != nil {
+				t.Fatalf("GetDailyStats() error = %v", err)
+			}
+			if len(stats) != tt.wantStats {
+				t.Fatalf("got %d daily stats, want %d", len(stats), tt.wantStats)
+			}
+
+			last := stats[len(stats)-1]
+			if last.Count != 1 || last.Avg != 50 {
+				t.Errorf("unexpected last daily stats: %v", last.LogValue())
+			}
+		})
+	}
+}
+
+func TestPruner_Prune_KeepForever(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	events := []databaser.Event{
+		{Timestamp: today.Add(-400 * 24 * time.Hour), Load: 10},
+		{Timestamp: today.Add(-20 * 24 * time.Hour), Load: 30},
+		{Timestamp: today.Add(time.Minute), Load: 50},
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	// zero TTLs keep all data
+	p := &Pruner{Db: db, Location: time.UTC, Timeout: time.Hour, QueryTimeout: 5 * time.Second}
+	if err := p.Prune(ctx); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+
+	raw, err := db.GetAllEvents(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+	if len(raw) != len(events) {
+		t.Errorf("got %d raw events after pruning, want %d", len(raw), len(events))
+	}
+}
+
+func TestPruner_Rollup(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 17, 12, 0, 0, 0, time.UTC)
+
+	events := []databaser.Event{
+		{Timestamp: time.Date(2024, 1, 14, 10, 0, 0, 0, time.UTC), Load: 10}, // too old for rollup
+		{Timestamp: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC), Load: 20},
+		{Timestamp: time.Date(2024, 1, 16, 10, 0, 0, 0, time.UTC), Load: 30},
+		{Timestamp: time.Date(2024, 1, 17, 10, 0, 0, 0, time.UTC), Load: 40}, // today is not completed
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	p := &Pruner{Db: db, Location: time.UTC}
+	if err := p.Rollup(ctx, now); err != nil {
+		t.Fatalf("Rollup() error = %v", err)
+	}
+
+	stats, err := db.GetDailyStats(ctx, now.AddDate(0, 0, -7), now)
+	if err != nil {
+		t.Fatalf("GetDailyStats() error = %v", err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("got %d daily stats, want 2", len(stats))
+	}
+	if stats[0].Day.String() != "2024-01-15" || stats[1].Day.String() != "2024-01-16" {
+		t.Errorf("unexpected rolled up days: %s, %s", stats[0].Day, stats[1].Day)
+	}
+
+	// raw events are kept
+	raw, err := db.GetAllEvents(ctx, 100, 0)
+	if err != nil {
+		t.Fatalf("GetAllEvents() error = %v", err)
+	}
+	if len(raw) != len(events) {
+		t.Errorf("got %d raw events, want %d", len(raw), len(events))
+	}
+}
+
+func TestPruner_Run(t *testing.T) {
+	db := newTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Pruner{
+		Db:           db,
+		Location:     time.UTC,
+		RawTTL:       24 * time.Hour,
+		Timeout:      time.Hour,
+		QueryTimeout: 5 * time.Second,
+	}
+
+	doneCh := p.Run(ctx)
+	cancel()
+
+	select {
+	case <-doneCh:
+	case <-time.After(time.Second):
+		t.Fatal("pruner did not stop after context cancellation")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/z0rr0/ggp/config"
+	"github.com/z0rr0/ggp/fetcher"
+	"github.com/z0rr0/ggp/holidayer"
+	"github.com/z0rr0/ggp/predictor"
+	"github.com/z0rr0/ggp/watcher"
+)
+
+// periodSetter is a periodic component which period can be changed while it's running.
+type periodSetter interface {
+	SetPeriod(period time.Duration)
+}
+
+// predictorSetter is a predictor which display settings can be changed while it's running.
+type predictorSetter interface {
+	SetScaleConfidence(scale bool)
+	SetAnomalyThreshold(threshold float64)
+}
+
+// reloader re-reads the config file on SIGHUP and applies hot-reloadable fields to running components.
+// Nil components are inactive and skipped, so nil pointers must not be assigned to them.
+type reloader struct {
+	cfg       *config.Config // initial config, it's used to report not reloadable changes
+	admins    *watcher.AdminSet
+	fetcher   periodSetter
+	holidayer periodSetter
+	predictor predictorSetter
+	path      string
+}
+
+// newReloader creates a reloader of the config file, nil components are skipped.
+func newReloader(
+	path string, cfg *config.Config, admins *watcher.AdminSet,
+	fetchWorker *fetcher.Fetcher, holidayerWorker *holidayer.HolidayParams, pc *predictor.Controller,
+) *reloader {
+	r := &reloader{path: path, cfg: cfg, admins: admins}
+
+	if fetchWorker != nil {
+		r.fetcher = fetchWorker
+	}
+	if holidayerWorker != nil {
+		r.holidayer = holidayerWorker
+	}
+	if pc != nil {
+		r.predictor = pc
+	}
+
+	return r
+}
+
+// Run handles SIGHUP signals until the context is done.
+func (r *reloader) Run(ctx context.Context) {
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupCh:
+			slog.Info("reloading config", "path", r.path)
+			if err := r.reload(); err != nil {
+				slog.Error("failed to reload config", "error", err)
+			}
+		}
+	}
+}
+
+// reload reads the config file and applies it.
+func (r *reloader) reload() error {
+	cfg, err := config.Load(r.path)
+	if err != nil {
+		return err
+	}
+
+	r.apply(cfg)
+	return nil
+}
+
+// apply updates components by hot-reloadable fields of the new config.
+// It returns changed fields which require restart, they are ignored.
+func (r *reloader) apply(cfg *config.Config) []string {
+	ignored := r.cfg.NotReloadable(cfg)
+	for _, field := range ignored {
+		slog.Warn("config field change requires restart, it is ignored", "field", field)
+	}
+
+	r.admins.Set(cfg.Base.AdminIDs)
+
+	if r.fetcher != nil && cfg.Fetcher.Active {
+		r.fetcher.SetPeriod(cfg.Fetcher.Timeout)
+	}
+
+	if r.holidayer != nil && cfg.Holidayer.Active {
+		r.holidayer.SetPeriod(cfg.Holidayer.Timeout)
+	}
+
+	if r.predictor != nil {
+		r.predictor.SetScaleConfidence(cfg.Predictor.ScaleConfidence)
+		r.predictor.SetAnomalyThreshold(cfg.Predictor.AnomalyThreshold)
+	}
+
+	slog.Info("config reloaded", "admins", len(cfg.Base.AdminIDs))
+	return ignored
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/z0rr0/ggp/config"
+	"github.com/z0rr0/ggp/watcher"
+)
+
+type mockPeriodSetter struct {
+	periods []time.Duration
+}
+
+func (m *mockPeriodSetter) SetPeriod(period time.Duration) {
+	m.periods = append(m.periods, period)
+}
+
+type mockPredictorSetter struct {
+	threshold float64
+	scale     bool
+}
+
+func (m *mockPredictorSetter) SetScaleConfidence(scale bool) {
+	m.scale = scale
+}
+
+func (m *mockPredictorSetter) SetAnomalyThreshold(threshold float64) {
+	m.threshold = threshold
+}
+
+func newReloadConfig(admins ...int64) *config.Config {
+	adminIDs := make(map[int64]struct{}, len(admins))
+	for _, id := range admins {
+		adminIDs[id] = struct{}{}
+	}
+
+	return &config.Config{
+		Base:      config.Base{Admins: admins, AdminIDs: adminIDs},
+		Database:  config.Database{Path: "test.db"},
+		Fetcher:   config.Fetcher{Active: true, Period: 60, Timeout: time.Minute},
+		Holidayer: config.Holidayer{Active: true, Period: 3600, Timeout: time.Hour},
+		Telegram:  config.Telegram{Active: true, Token: "bot-token"},
+	}
+}
+
+func TestReloader_Apply(t *testing.T) {
+	cfg := newReloadConfig(1)
+	fetchSetter, holidaySetter, predictorSetter := &mockPeriodSetter{}, &mockPeriodSetter{}, &mockPredictorSetter{}
+	r := &reloader{
+		cfg:       cfg,
+		admins:    watcher.NewAdminSet(cfg.Base.AdminIDs),
+		fetcher:   fetchSetter,
+		holidayer: holidaySetter,
+		predictor: predictorSetter,
+	}
+
+	newCfg := newReloadConfig(2, 3)
+	newCfg.Fetcher.Period, newCfg.Fetcher.Timeout = 30, 30*time.Second
+	newCfg.Holidayer.Period, newCfg.Holidayer.Timeout = 7200, 2*time.Hour
+	newCfg.Predictor.ScaleConfidence, newCfg.Predictor.AnomalyThreshold = true, 3.5
+
+	if ignored := r.apply(newCfg); len(ignored) != 0 {
+		t.Errorf("apply() ignored = %v, want none", ignored)
+	}
+
+	if got := r.admins.IDs(); !slices.Equal(got, []int64{2, 3}) {
+		t.Errorf("admins = %v, want [2 3]", got)
+	}
+	if r.admins.Contains(1) {
+		t.Error("removed admin is still contained")
+	}
+	if !slices.Equal(fetchSetter.periods, []time.Duration{30 * time.Second}) {
+		t.Errorf("fetcher periods = %v, want [30s]", fetchSetter.periods)
+	}
+	if !slices.Equal(holidaySetter.periods, []time.Duration{2 * time.Hour}) {
+		t.Errorf("holidayer periods = %v, want [2h]", holidaySetter.periods)
+	}
+	if !predictorSetter.scale || predictorSetter.threshold != 3.5 {
+		t.Errorf("predictor settings = %+v, want scale and threshold 3.5", predictorSetter)
+	}
+}
+
+func TestReloader_ApplyNotReloadable(t *testing.T) {
+	cfg := newReloadConfig(1)
+	fetchSetter := &mockPeriodSetter{}
+	r := &reloader{cfg: cfg, admins: watcher.NewAdminSet(cfg.Base.AdminIDs), fetcher: fetchSetter}
+
+	newCfg := newReloadConfig(1, 2)
+	newCfg.Database.Path = "other.db"
+	newCfg.Telegram.Token = "other-token"
+	newCfg.Fetcher.Active = false
+
+	want := []string{"database.path", "fetcher.active", "telegram.token"}
+	if ignored := r.apply(newCfg); !slices.Equal(ignored, want) {
+		t.Errorf("apply() ignored = %v, want %v", ignored, want)
+	}
+
+	// reloadable fields are applied, inactive components are not changed
+	if got := r.admins.IDs(); !slices.Equal(got, []int64{1, 2}) {
+		t.Errorf("admins = %v, want [1 2]", got)
+	}
+	if len(fetchSetter.periods) != 0 {
+		t.Errorf("fetcher periods = %v, want none", fetchSetter.periods)
+	}
+	if cfg.Database.Path != "test.db" || cfg.Telegram.Token != "bot-token" {
+		t.Errorf("initial config is changed: %+v", cfg.Database)
+	}
+}
+
+func TestNewReloader_NilComponents(t *testing.T) {
+	cfg := newReloadConfig(1)
+	r := newReloader("config.toml", cfg, watcher.NewAdminSet(cfg.Base.AdminIDs), nil, nil, nil)
+
+	if r.fetcher != nil || r.holidayer != nil || r.predictor != nil {
+		t.Fatalf("nil components are set: %+v", r)
+	}
+
+	// inactive components are skipped
+	r.apply(newReloadConfig(2))
+}
+
+func TestReloader_ApplySections(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(c *config.Config)
+		applied  func(r *reloader) bool // nil if the field is reported as ignored
+		reported string
+	}{
+		{
+			name:    "base admins",
+			change:  func(c *config.Config) { c.Base.AdminIDs = map[int64]struct{}{5: {}}; c.Base.Admins = []int64{5} },
+			applied: func(r *reloader) bool { return r.admins.Contains(5) },
+		},
+		{name: "base week start", change: func(c *config.Config) { c.Base.WeekStart = "sunday" }, reported: "base.week_start"},
+		{name: "database busy timeout", change: func(c *config.Config) { c.Database.BusyTimeout = 100 }, reported: "database.busy_timeout"},
+		{
+			name:    "fetcher period",
+			change:  func(c *config.Config) { c.Fetcher.Period, c.Fetcher.Timeout = 30, 30*time.Second },
+			applied: func(r *reloader) bool { return slices.Contains(r.fetcher.(*mockPeriodSetter).periods, 30*time.Second) },
+		},
+		{name: "fetcher auth", change: func(c *config.Config) { c.Fetcher.Password = "secret" }, reported: "fetcher.password"},
+		{
+			name:    "holidayer period",
+			change:  func(c *config.Config) { c.Holidayer.Period, c.Holidayer.Timeout = 60, time.Minute },
+			applied: func(r *reloader) bool { return slices.Contains(r.holidayer.(*mockPeriodSetter).periods, time.Minute) },
+		},
+		{
+			name:    "predictor anomaly threshold",
+			change:  func(c *config.Config) { c.Predictor.AnomalyThreshold = 2 },
+			applied: func(r *reloader) bool { return r.predictor.(*mockPredictorSetter).threshold == 2 },
+		},
+		{name: "predictor decay", change: func(c *config.Config) { c.Predictor.DecayLambda = 0.2 }, reported: "predictor.decay_lambda"},
+		{name: "alerter high", change: func(c *config.Config) { c.Alerter.High = 90 }, reported: "alerter.high"},
+		{name: "subscriptions period", change: func(c *config.Config) { c.Subscriptions.Period = 60 }, reported: "subscriptions.period"},
+		{name: "retention raw days", change: func(c *config.Config) { c.Retention.RawDays = 30 }, reported: "retention.raw_days"},
+		{name: "cache size", change: func(c *config.Config) { c.Cache.Size = 10 }, reported: "cache.size"},
+		{name: "telegram points", change: func(c *config.Config) { c.Telegram.ShowPoints = true }, reported: "telegram.show_points"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newReloadConfig(1)
+			r := &reloader{
+				cfg:       cfg,
+				admins:    watcher.NewAdminSet(cfg.Base.AdminIDs),
+				fetcher:   &mockPeriodSetter{},
+				holidayer: &mockPeriodSetter{},
+				predictor: &mockPredictorSetter{},
+			}
+
+			newCfg := newReloadConfig(1)
+			tt.change(newCfg)
+			ignored := r.apply(newCfg)
+
+			if tt.applied != nil {
+				if len(ignored) != 0 || !tt.applied(r) {
+					t.Errorf("field is not applied, ignored = %v", ignored)
+				}
+				return
+			}
+			if !slices.Equal(ignored, []string{tt.reported}) {
+				t.Errorf("apply() ignored = %v, want [%s]", ignored, tt.reported)
+			}
+		})
+	}
+}
@@ -1,22 +1,76 @@
 package watcher
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
 	"log/slog"
+	"math"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/databaser"
+	"github.com/z0rr0/ggp/fetcher"
+	"github.com/z0rr0/ggp/plotter"
 )
 
 // Admin bot command constants.
 const (
-	CmdUsers   = "users"
-	CmdApprove = "approve"
-	CmdReject  = "reject"
+	CmdUsers        = "users"
+	CmdApprove      = "approve"
+	CmdApproveAll   = "approveall"
+	CmdReject       = "reject"
+	CmdIntegrity    = "integrity"
+	CmdMaintenance  = "maintenance"
+	CmdAdmins       = "admins"
+	CmdExportModel  = "exportmodel"
+	CmdCollapse     = "collapse"
+	CmdUser         = "user"
+	CmdPing         = "ping"
+	CmdRaw          = "raw"
+	CmdInsert       = "insert"
+	CmdReview       = "review"
+	CmdNextFetch    = "nextfetch"
+	CmdTime         = "time"
+	CmdExplain      = "explain"
+	CmdBackup       = "backup"
+	CmdExport       = "export"
+	CmdStats        = "stats"
+	CmdExportUsers  = "exportusers"
+	CmdAvailability = "availability"
+	CmdWipeEvents   = "wipeevents"
+	CmdAddHoliday   = "addholiday"
+	CmdDelHoliday   = "delholiday"
 )
 
+// maxInsertLoad is the maximum load percent of a manually inserted event.
+const maxInsertLoad = 100
+
+// maxHolidayTitle is the maximum length of a manual holiday title in characters.
+const maxHolidayTitle = 255
+
+// defaultReviewPeriod is a period of the model review if it is not set.
+const defaultReviewPeriod = 24 * time.Hour
+
+// maxRawBody is the maximum number of runes of the response body sent by /raw command, it's less than the message limit.
+const maxRawBody = 3000
+
+// accuracyPeriod is a period of the prediction accuracy in the statistics.
+const accuracyPeriod = 7 * 24 * time.Hour
+
+// defaultAvailabilityPeriod is a period of the availability report if it is not set.
+const defaultAvailabilityPeriod = 30 * 24 * time.Hour
+
 // WrapHandleUsers wraps HandleUsers to match bot.HandlerFunc signature.
 func (h *BotHandler) WrapHandleUsers(ctx context.Context, b *bot.Bot, update *models.Update) {
 	h.HandleUsers(ctx, b, update)
@@ -27,12 +81,117 @@ func (h *BotHandler) WrapHandleApprove(ctx context.Context, b *bot.Bot, update *
 	h.HandleApprove(ctx, b, update)
 }
 
+// WrapHandleApproveAll wraps HandleApproveAll to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleApproveAll(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleApproveAll(ctx, b, update)
+}
+
 // WrapHandleReject wraps HandleReject to match bot.HandlerFunc signature.
 func (h *BotHandler) WrapHandleReject(ctx context.Context, b *bot.Bot, update *models.Update) {
 	h.HandleReject(ctx, b, update)
 }
 
-// HandleUsers returns users information.
+// WrapHandleIntegrity wraps HandleIntegrity to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleIntegrity(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleIntegrity(ctx, b, update)
+}
+
+// WrapHandleMaintenance wraps HandleMaintenance to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleMaintenance(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleMaintenance(ctx, b, update)
+}
+
+// WrapHandleAdmins wraps HandleAdmins to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleAdmins(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleAdmins(ctx, b, update)
+}
+
+// WrapHandleExportModel wraps HandleExportModel to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleExportModel(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleExportModel(ctx, b, update)
+}
+
+// WrapHandleExport wraps HandleExport to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleExport(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleExport(ctx, b, update)
+}
+
+// WrapHandleStats wraps HandleStats to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleStats(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleStats(ctx, b, update)
+}
+
+// WrapHandleExportUsers wraps HandleExportUsers to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleExportUsers(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleExportUsers(ctx, b, update)
+}
+
+// WrapHandleAvailability wraps HandleAvailability to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleAvailability(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleAvailability(ctx, b, update)
+}
+
+// WrapHandleCollapse wraps HandleCollapse to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleCollapse(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleCollapse(ctx, b, update)
+}
+
+// WrapHandleUser wraps HandleUser to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleUser(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleUser(ctx, b, update)
+}
+
+// WrapHandleInsert wraps HandleInsert to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleInsert(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleInsert(ctx, b, update)
+}
+
+// WrapHandleReview wraps HandleReview to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleReview(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleReview(ctx, b, update)
+}
+
+// WrapHandleNextFetch wraps HandleNextFetch to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleNextFetch(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleNextFetch(ctx, b, update)
+}
+
+// WrapHandleTime wraps HandleTime to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleTime(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleTime(ctx, b, update)
+}
+
+// WrapHandleExplain wraps HandleExplain to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleExplain(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleExplain(ctx, b, update)
+}
+
+// WrapHandleBackup wraps HandleBackup to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleBackup(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleBackup(ctx, b, update)
+}
+
+// WrapHandlePing wraps HandlePing to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandlePing(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandlePing(ctx, b, update)
+}
+
+// WrapHandleRaw wraps HandleRaw to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleRaw(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleRaw(ctx, b, update)
+}
+
+// WrapHandleAddHoliday wraps HandleAddHoliday to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleAddHoliday(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleAddHoliday(ctx, b, update)
+}
+
+// WrapHandleDelHoliday wraps HandleDelHoliday to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleDelHoliday(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleDelHoliday(ctx, b, update)
+}
+
+// HandleUsers returns users information, the optional argument filters users by status.
 func (h *BotHandler) HandleUsers(ctx context.Context, b BotAPI, update *models.Update) {
 	const (
 		approvedSymbol = "✅"
@@ -40,9 +199,27 @@ func (h *BotHandler) HandleUsers(ctx context.Context, b BotAPI, update *models.U
 		rejectedSymbol = "❌"
 	)
 
-	users, err := h.db.GetUsers(ctx)
+	getUsers := h.db.GetUsers
+	if args := strings.Fields(update.Message.Text); len(args) > 1 {
+		switch strings.ToLower(args[1]) {
+		case "pending":
+			getUsers = h.db.GetPendingUsers
+		case "approved":
+			getUsers = h.db.GetApprovedUsers
+		case "rejected":
+			getUsers = h.db.GetRejectedUsers
+		default:
+			sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Используйте: /users [pending|approved|rejected]")
+			return
+		}
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	users, err := getUsers(opCtx)
 	if err != nil {
-		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, "Не удалось получить список пользователей.")
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, operationErrorText(opCtx, LangRU, "Не удалось получить список пользователей."))
 		return
 	}
 
@@ -84,6 +261,60 @@ func (h *BotHandler) HandleUsers(ctx context.Context, b BotAPI, update *models.U
 	}
 }
 
+// HandleUser returns information about a user by its ID.
+func (h *BotHandler) HandleUser(ctx context.Context, b BotAPI, update *models.Update) {
+	args := strings.Fields(update.Message.Text)
+	if len(args) < 2 {
+		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Используйте: /user <user_id>")
+		return
+	}
+
+	userID, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, "Неверный формат user_id.")
+		return
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	user, err := h.db.GetUser(opCtx, userID)
+	if err != nil {
+		if errors.Is(err, databaser.ErrUserNotFound) {
+			sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Пользователь не найден.")
+			return
+		}
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, operationErrorText(opCtx, LangRU, "Не удалось получить пользователя."))
+		return
+	}
+
+	var status string
+	switch {
+	case user.IsApproved():
+		status = "одобрен"
+	case user.IsPending():
+		status = "ожидает одобрения"
+	default:
+		status = "отклонён"
+	}
+
+	location := h.cfg.Base.TimeLocation
+	text := fmt.Sprintf(
+		"ID: %d\nСтатус: %s\nUsername: @%s\nИмя: %s\nФамилия: %s\nСоздан: %s\nОбновлён: %s",
+		user.ID, status, user.Username, user.FirstName, user.LastName,
+		user.Created.In(location).Format(dateTimeFormat), user.Updated.In(location).Format(dateTimeFormat),
+	)
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleUser", "error", err)
+	}
+}
+
 // HandleApprove approves a user by its ID.
 func (h *BotHandler) HandleApprove(ctx context.Context, b BotAPI, update *models.Update) { //nolint:dupl
 	args := strings.Fields(update.Message.Text)
@@ -114,9 +345,40 @@ func (h *BotHandler) HandleApprove(ctx context.Context, b BotAPI, update *models
 		return
 	}
 
-	// notify users about approval
 	slog.InfoContext(ctx, "approved user", "user_id", userID)
+	notifyApproved(ctx, b, userID)
+}
+
+// HandleApproveAll approves all pending users and notifies them.
+func (h *BotHandler) HandleApproveAll(ctx context.Context, b BotAPI, update *models.Update) {
+	userIDs, err := h.db.ApproveAllPending(ctx)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, "Не удалось одобрить пользователей.")
+		return
+	}
+
+	text := fmt.Sprintf("Одобрено пользователей: %d.", len(userIDs))
+	if len(userIDs) == 0 {
+		text = "Нет ожидающих пользователей."
+	}
+
 	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleApproveAll", "error", err)
+	}
+
+	slog.InfoContext(ctx, "approved all pending users", "count", len(userIDs))
+	for _, userID := range userIDs {
+		notifyApproved(ctx, b, userID)
+	}
+}
+
+// notifyApproved notifies the user about approval.
+func notifyApproved(ctx context.Context, b BotAPI, userID int64) {
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: userID,
 		Text:   "Ваш запрос одобрен администратором. Бот активен.",
 	})
@@ -165,3 +427,898 @@ func (h *BotHandler) HandleReject(ctx context.Context, b BotAPI, update *models.
 		slog.ErrorContext(ctx, "notify rejected user", "user_id", userID, "error", err)
 	}
 }
+
+// HandleIntegrity runs the database integrity check and reports its result.
+func (h *BotHandler) HandleIntegrity(ctx context.Context, b BotAPI, update *models.Update) {
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	ok, err := h.db.IntegrityCheck(opCtx)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, operationErrorText(opCtx, LangRU, "Не удалось проверить целостность базы данных."))
+		return
+	}
+
+	text := "Проверка целостности базы данных: ✅ ok"
+	if !ok {
+		slog.ErrorContext(ctx, "DATABASE IS CORRUPTED, restore it from a backup", "user_id", update.Message.From.ID)
+		text = "Проверка целостности базы данных: ❌ обнаружены ошибки. Восстановите базу из резервной копии."
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleIntegrity", "error", err)
+	}
+}
+
+// HandleMaintenance switches the maintenance mode on or off, without arguments it shows the current mode.
+func (h *BotHandler) HandleMaintenance(ctx context.Context, b BotAPI, update *models.Update) {
+	args := strings.Fields(update.Message.Text)
+	if len(args) > 1 {
+		switch strings.ToLower(args[1]) {
+		case "on":
+			h.maintenance.Store(true)
+		case "off":
+			h.maintenance.Store(false)
+		default:
+			sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Используйте: /maintenance on|off")
+			return
+		}
+		slog.InfoContext(ctx, "maintenance mode", "enabled", h.maintenance.Load(), "user_id", update.Message.From.ID)
+	}
+
+	text := "Режим обслуживания выключен."
+	if h.maintenance.Load() {
+		text = "Режим обслуживания включен, команды пользователей недоступны."
+	}
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleMaintenance", "error", err)
+	}
+}
+
+// HandleAdmins returns configured admins, enriched with user information if it exists.
+func (h *BotHandler) HandleAdmins(ctx context.Context, b BotAPI, update *models.Update) {
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	var sb strings.Builder
+	sb.WriteString("Администраторы:\n")
+
+	for _, adminID := range h.adminIDs.IDs() {
+		sb.WriteString("ID: ")
+		sb.WriteString(strconv.FormatInt(adminID, 10))
+
+		user, err := h.db.GetUser(opCtx, adminID)
+		switch {
+		case err == nil:
+			sb.WriteString(" @")
+			sb.WriteString(user.Username)
+			sb.WriteString(" ")
+			sb.WriteString(user.FirstName)
+			sb.WriteString(" ")
+			sb.WriteString(user.LastName)
+		case !errors.Is(err, databaser.ErrUserNotFound):
+			sendErrorMessage(ctx, err, b, update.Message.Chat.ID, operationErrorText(opCtx, LangRU, "Не удалось получить список администраторов."))
+			return
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   sb.String(),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleAdmins", "error", err)
+	}
+}
+
+// HandleExportModel sends the predictor's learned statistics as a CSV document.
+func (h *BotHandler) HandleExportModel(ctx context.Context, b BotAPI, update *models.Update) {
+	if h.pc == nil {
+		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Прогнозирование отключено.")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.pc.ExportCSV(&buf, h.cfg.Base.FirstWeekday); err != nil {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, "Не удалось экспортировать модель.")
+		return
+	}
+
+	_, err := b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   update.Message.Chat.ID,
+		Document: &models.InputFileUpload{Filename: "model.csv", Data: &buf},
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleExportModel", "error", err)
+	}
+}
+
+// HandleExport sends events of the main club as a CSV document in the format of the CSV import.
+// An optional period argument limits exported events, all events are exported without it.
+// Events are streamed to the upload without buffering them all in memory.
+func (h *BotHandler) HandleExport(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	var since time.Time
+	if _, value, ok := strings.Cut(strings.TrimSpace(update.Message.Text), " "); ok {
+		period, err := parsePeriod(value)
+		if err != nil {
+			sendErrorMessage(ctx, err, b, chatID, "Используйте: /export [период], например /export 30d.")
+			return
+		}
+		since = time.Now().Add(-period)
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	defer func() {
+		// stop the writer if the upload is failed before reading all data
+		if closeErr := pr.Close(); closeErr != nil {
+			slog.ErrorContext(ctx, "HandleExport close pipe", "error", closeErr)
+		}
+	}()
+
+	go func() {
+		count, err := writeEventsCSV(pw, h.db.GetAllEventsIter(opCtx, since), h.cfg.Base.TimeLocation)
+		if err == nil {
+			slog.InfoContext(ctx, "events exported", "count", count, "since", since)
+		}
+		// the reader gets EOF if err is nil
+		if closeErr := pw.CloseWithError(err); closeErr != nil {
+			slog.ErrorContext(ctx, "HandleExport close pipe writer", "error", closeErr)
+		}
+	}()
+
+	_, err := b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: "events.csv", Data: pr},
+	})
+
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось выгрузить события."))
+	}
+}
+
+// writeEventsCSV writes events with the header to w as CSV records with local time, it returns the number of events.
+func writeEventsCSV(w io.Writer, events iter.Seq2[databaser.Event, error], location *time.Location) (int, error) {
+	var count int
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write([]string{"time", "load"}); err != nil {
+		return 0, fmt.Errorf("write header: %w", err)
+	}
+
+	for event, err := range events {
+		if err != nil {
+			return count, err
+		}
+
+		record := []string{event.Timestamp.In(location).Format(time.DateTime), strconv.FormatUint(uint64(event.Load), 10)}
+		if err = csvWriter.Write(record); err != nil {
+			return count, fmt.Errorf("write event: %w", err)
+		}
+		count++
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return count, fmt.Errorf("flush events: %w", err)
+	}
+
+	return count, nil
+}
+
+// HandleExportUsers sends all users as a CSV document, timestamps are in RFC3339 UTC format.
+func (h *BotHandler) HandleExportUsers(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	users, err := h.db.GetUsers(opCtx)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось получить список пользователей."))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = writeUsersCSV(&buf, users); err != nil {
+		sendErrorMessage(ctx, err, b, chatID, "Не удалось выгрузить пользователей.")
+		return
+	}
+
+	_, err = b.SendDocument(ctx, &bot.SendDocumentParams{
+		ChatID:   chatID,
+		Document: &models.InputFileUpload{Filename: "users.csv", Data: &buf},
+	})
+
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, "Не удалось выгрузить пользователей.")
+		return
+	}
+
+	slog.InfoContext(ctx, "users exported", "count", len(users))
+}
+
+// writeUsersCSV writes users with the header to w as CSV records.
+func writeUsersCSV(w io.Writer, users []databaser.User) error {
+	csvWriter := csv.NewWriter(w)
+
+	header := []string{"id", "status", "username", "first_name", "last_name", "created", "updated"}
+	if err := csvWriter.Write(header); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	for _, user := range users {
+		record := []string{
+			strconv.FormatInt(user.ID, 10),
+			strconv.FormatUint(uint64(user.Status), 10),
+			user.Username,
+			user.FirstName,
+			user.LastName,
+			user.Created.UTC().Format(time.RFC3339),
+			user.Updated.UTC().Format(time.RFC3339),
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return fmt.Errorf("write user: %w", err)
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("flush users: %w", err)
+	}
+
+	return nil
+}
+
+// HandleStats reports data coverage: events count and time range, holidays of the current and next years
+// and users by status.
+func (h *BotHandler) HandleStats(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	location := h.cfg.Base.TimeLocation
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	count, err := h.db.CountEvents(opCtx)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось получить статистику событий."))
+		return
+	}
+
+	minTime, maxTime, err := h.db.EventTimeRange(opCtx)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось получить статистику событий."))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Статистика:\n")
+	fmt.Fprintf(&sb, "Событий: %d\n", count)
+	fmt.Fprintf(&sb, "Первое событие: %s\n", formatStatsTime(minTime, location))
+	fmt.Fprintf(&sb, "Последнее событие: %s\n", formatStatsTime(maxTime, location))
+
+	year := time.Now().In(location).Year()
+	for _, y := range []int{year, year + 1} {
+		holidays, holidaysErr := h.db.GetHolidays(opCtx, y, location)
+		if holidaysErr != nil {
+			sendErrorMessage(ctx, holidaysErr, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось получить праздничные дни."))
+			return
+		}
+		fmt.Fprintf(&sb, "Праздников в %d: %d\n", y, len(holidays))
+	}
+
+	users, err := h.db.CountUsersByStatus(opCtx)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось получить список пользователей."))
+		return
+	}
+	fmt.Fprintf(&sb,
+		"Пользователи: одобрено %d, ожидают %d, отклонено %d",
+		users[databaser.UserApproved], users[databaser.UserPending], users[databaser.UserRejected],
+	)
+
+	if h.cfg.Predictor.TrackAccuracy {
+		mae, n, accuracyErr := h.db.PredictionAccuracy(opCtx, accuracyPeriod)
+		if accuracyErr != nil {
+			sendErrorMessage(ctx, accuracyErr, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось получить точность прогноза."))
+			return
+		}
+		sb.WriteString(formatAccuracy(mae, n))
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   sb.String(),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleStats", "error", err)
+	}
+}
+
+// formatAccuracy returns the statistics line of the prediction mean absolute error over n predicted hours.
+func formatAccuracy(mae float64, n int) string {
+	period := formatPeriod(accuracyPeriod)
+	if n == 0 {
+		return fmt.Sprintf("\nОшибка прогноза за %s: нет данных", period)
+	}
+	return fmt.Sprintf("\nОшибка прогноза за %s: %.1f%% (часов: %d)", period, mae, n)
+}
+
+// formatStatsTime returns the local time in the date time format or a dash for the zero time.
+func formatStatsTime(t time.Time, location *time.Location) string {
+	if t.IsZero() {
+		return "—"
+	}
+	return t.In(location).Format(dateTimeFormat)
+}
+
+// availability is the data pipeline availability over a period.
+type availability struct {
+	expected int
+	stored   int
+	missed   int
+	percent  float64
+}
+
+// newAvailability returns the availability of stored events, the fetching is expected once per period
+// during the window. Extra events (e.g. inserted manually) don't make it greater than 100%.
+func newAvailability(stored int, window, period time.Duration) availability {
+	expected := max(int((window+period-1)/period), 1)
+	a := availability{expected: expected, stored: stored, missed: max(expected-stored, 0)}
+	a.percent = min(float64(stored)/float64(expected)*100, 100)
+	return a
+}
+
+// HandleAvailability reports the fraction of expected fetch intervals of the period which have stored events.
+// The period is limited by the first stored event, so the time before data collection started is not counted.
+func (h *BotHandler) HandleAvailability(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	if h.cfg.Fetcher.Timeout <= 0 {
+		sendErrorMessage(ctx, nil, b, chatID, "Опрос загрузки не настроен.")
+		return
+	}
+
+	duration := defaultAvailabilityPeriod
+	if _, value, ok := strings.Cut(strings.TrimSpace(update.Message.Text), " "); ok {
+		period, err := parsePeriod(value)
+		if err != nil {
+			sendErrorMessage(ctx, err, b, chatID, "Используйте: /availability <период>, например /availability 30d.")
+			return
+		}
+		duration = period
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	minTime, _, err := h.db.EventTimeRange(opCtx)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось получить статистику событий."))
+		return
+	}
+	if minTime.IsZero() {
+		sendErrorMessage(ctx, nil, b, chatID, "Нет сохраненных событий.")
+		return
+	}
+
+	now := time.Now().UTC()
+	start := now.Add(-duration)
+	if minTime.After(start) {
+		start = minTime
+	}
+
+	count, err := h.db.CountEventsSince(opCtx, start)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось получить статистику событий."))
+		return
+	}
+
+	a := newAvailability(count, now.Sub(start), h.cfg.Fetcher.Timeout)
+	text := fmt.Sprintf(
+		"Доступность за %s: %.1f%%\nС %s, период опроса %s\nОжидалось опросов: %d, сохранено: %d, пропущено: %d",
+		formatPeriod(duration), a.percent,
+		start.In(h.cfg.Base.TimeLocation).Format(dateTimeFormat), h.cfg.Fetcher.Timeout,
+		a.expected, a.stored, a.missed,
+	)
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: text})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleAvailability", "error", err)
+	}
+}
+
+// HandleCollapse removes intermediate events of flat load runs with the given tolerance.
+func (h *BotHandler) HandleCollapse(ctx context.Context, b BotAPI, update *models.Update) {
+	args := strings.Fields(update.Message.Text)
+	if len(args) < 2 {
+		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Используйте: /collapse <допуск>")
+		return
+	}
+
+	tolerance, err := strconv.ParseUint(args[1], 10, 8)
+	if err != nil || tolerance > 100 {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, "Неверный формат допуска, ожидается число от 0 до 100.")
+		return
+	}
+
+	// it's a one-shot maintenance operation, so it is not limited by the handler timeout
+	removed, err := h.db.CollapseFlat(ctx, uint8(tolerance))
+	if err != nil {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, "Не удалось удалить повторяющиеся события.")
+		return
+	}
+
+	slog.InfoContext(ctx, "collapsed flat events", "user_id", update.Message.From.ID, "removed", removed)
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("Удалено событий: %d.", removed),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleCollapse", "error", err)
+	}
+}
+
+// HandlePing makes a single request to the configured load URL and reports the result without saving it.
+func (h *BotHandler) HandlePing(ctx context.Context, b BotAPI, update *models.Update) {
+	if h.cfg.Fetcher.URL == "" {
+		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Адрес загрузки не настроен.")
+		return
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	result, err := h.probeFetcher().Probe(opCtx)
+
+	status := result.Status
+	if status == "" {
+		status = "нет ответа"
+	}
+
+	var sb strings.Builder
+	if err != nil {
+		slog.ErrorContext(ctx, "HandlePing", "error", err)
+		fmt.Fprintf(&sb, "Ошибка: %v\n", err)
+	} else {
+		fmt.Fprintf(&sb, "Загрузка: %d%%\n", result.Load)
+	}
+	fmt.Fprintf(&sb, "Статус: %s\n", status)
+	fmt.Fprintf(&sb, "Задержка: %s", result.Latency.Round(time.Millisecond))
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   sb.String(),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandlePing", "error", err)
+	}
+}
+
+// HandleRaw makes a single request to the configured load URL and sends the response body
+// with the load parsed from it, nothing is saved. Long bodies are truncated.
+func (h *BotHandler) HandleRaw(ctx context.Context, b BotAPI, update *models.Update) {
+	if h.cfg.Fetcher.URL == "" {
+		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Адрес загрузки не настроен.")
+		return
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	result, err := h.probeFetcher().Raw(opCtx)
+
+	status := result.Status
+	if status == "" {
+		status = "нет ответа"
+	}
+
+	var sb strings.Builder
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleRaw", "error", err)
+		fmt.Fprintf(&sb, "Ошибка: %v\n", err)
+	} else {
+		fmt.Fprintf(&sb, "Загрузка: %d%%\n", result.Load)
+	}
+	fmt.Fprintf(&sb, "Статус: %s\n", status)
+	fmt.Fprintf(&sb, "Ответ (%d байт):\n%s", len(result.Body), truncateRunes(string(result.Body), maxRawBody))
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   sb.String(),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleRaw", "error", err)
+	}
+}
+
+// truncateRunes returns s limited by n runes, the truncated string ends with an ellipsis.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
+
+// probeFetcher returns a fetcher of the configured load URL for single requests, it has no database.
+func (h *BotHandler) probeFetcher() *fetcher.Fetcher {
+	return &fetcher.Fetcher{
+		URL: h.cfg.Fetcher.URL,
+		Auth: fetcher.Auth{
+			Kind:     h.cfg.Fetcher.AuthType,
+			Header:   h.cfg.Fetcher.AuthHeader,
+			Token:    h.cfg.Fetcher.Token,
+			Username: h.cfg.Fetcher.Username,
+			Password: h.cfg.Fetcher.Password,
+		},
+		Client: h.cfg.Fetcher.Client.NewClient(),
+	}
+}
+
+// HandleInsert saves a load event at the given local time, an existing event with the same time is replaced.
+func (h *BotHandler) HandleInsert(ctx context.Context, b BotAPI, update *models.Update) {
+	const layout = "2006-01-02 15:04"
+
+	args := strings.Fields(update.Message.Text)
+	if len(args) != 4 {
+		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Используйте: /insert <ГГГГ-ММ-ДД> <ЧЧ:ММ> <загрузка>")
+		return
+	}
+
+	location := h.cfg.Base.TimeLocation
+	timestamp, err := time.ParseInLocation(layout, args[1]+" "+args[2], location)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, "Неверный формат времени, ожидается ГГГГ-ММ-ДД ЧЧ:ММ.")
+		return
+	}
+
+	load, err := strconv.ParseUint(args[3], 10, 8)
+	if err != nil || load > maxInsertLoad {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, fmt.Sprintf("Неверный формат загрузки, ожидается число от 0 до %d.", maxInsertLoad))
+		return
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	event := databaser.Event{Timestamp: timestamp.UTC(), Load: uint8(load)}
+	if err = h.db.UpsertEvent(opCtx, event); err != nil {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, operationErrorText(opCtx, LangRU, "Не удалось сохранить событие."))
+		return
+	}
+
+	slog.InfoContext(ctx, "event inserted", "user_id", update.Message.From.ID, "event", &event)
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("Событие сохранено: %s, загрузка %d%%.", timestamp.Format(dateTimeFormat), load),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleInsert", "error", err)
+	}
+}
+
+// HandleAddHoliday adds a manual holiday, e.g. a local closure day, it's kept on holidays fetching.
+// The title can be quoted, a holiday of the same day is replaced.
+func (h *BotHandler) HandleAddHoliday(ctx context.Context, b BotAPI, update *models.Update) {
+	const usage = `Используйте: /addholiday <ГГГГ-ММ-ДД> <название>, например /addholiday 2025-12-24 "Закрыто"`
+	chatID := update.Message.Chat.ID
+
+	_, args, _ := strings.Cut(strings.TrimSpace(update.Message.Text), " ")
+	value, title, _ := strings.Cut(strings.TrimSpace(args), " ")
+	title = strings.TrimSpace(strings.Trim(strings.TrimSpace(title), `"`))
+	if value == "" || title == "" {
+		sendErrorMessage(ctx, nil, b, chatID, usage)
+		return
+	}
+	if utf8.RuneCountInString(title) > maxHolidayTitle {
+		sendErrorMessage(ctx, nil, b, chatID, fmt.Sprintf("Название праздника длиннее %d символов.", maxHolidayTitle))
+		return
+	}
+
+	day, err := parseHolidayDay(value, h.cfg.Base.TimeLocation)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, "Неверный формат даты, ожидается ГГГГ-ММ-ДД.")
+		return
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	if err = h.db.AddHoliday(opCtx, databaser.Holiday{Day: day, Title: title}); err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось сохранить праздник."))
+		return
+	}
+
+	slog.InfoContext(ctx, "holiday added", "user_id", update.Message.From.ID, "day", day.String(), "title", title)
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("Праздник сохранен: %s, %s.", day.String(), title),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleAddHoliday", "error", err)
+	}
+}
+
+// HandleDelHoliday deletes a holiday of the day, fetched holidays are restored by the next holidays fetching.
+func (h *BotHandler) HandleDelHoliday(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+
+	args := strings.Fields(update.Message.Text)
+	if len(args) != 2 {
+		sendErrorMessage(ctx, nil, b, chatID, "Используйте: /delholiday <ГГГГ-ММ-ДД>")
+		return
+	}
+
+	day, err := parseHolidayDay(args[1], h.cfg.Base.TimeLocation)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, "Неверный формат даты, ожидается ГГГГ-ММ-ДД.")
+		return
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	err = h.db.DeleteHoliday(opCtx, day)
+	switch {
+	case errors.Is(err, databaser.ErrHolidayNotFound):
+		sendErrorMessage(ctx, nil, b, chatID, fmt.Sprintf("Праздник %s не найден.", day.String()))
+		return
+	case err != nil:
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось удалить праздник."))
+		return
+	}
+
+	slog.InfoContext(ctx, "holiday deleted", "user_id", update.Message.From.ID, "day", day.String())
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("Праздник удален: %s.", day.String()),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleDelHoliday", "error", err)
+	}
+}
+
+// parseHolidayDay parses a holiday date in the ГГГГ-ММ-ДД format.
+func parseHolidayDay(value string, location *time.Location) (*databaser.DateOnly, error) {
+	t, err := time.ParseInLocation(time.DateOnly, value, location)
+	if err != nil {
+		return nil, fmt.Errorf("parse holiday day: %w", err)
+	}
+
+	day := databaser.DateOnly(t)
+	return &day, nil
+}
+
+// HandleReview sends a graph of real events with the typical load expected by the model for the same timestamps.
+// The caption contains the mean absolute difference between them.
+func (h *BotHandler) HandleReview(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	if h.pc == nil {
+		sendErrorMessage(ctx, nil, b, chatID, "Прогнозирование отключено.")
+		return
+	}
+
+	duration := defaultReviewPeriod
+	if _, value, ok := strings.Cut(strings.TrimSpace(update.Message.Text), " "); ok {
+		period, err := parsePeriod(value)
+		if err != nil {
+			sendErrorMessage(ctx, err, b, chatID, "Используйте: /review <период>, например /review 24h или /review 2d.")
+			return
+		}
+		duration = period
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	events, err := h.db.GetEvents(opCtx, duration)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, localize(LangRU, msgEventsFailed)))
+		return
+	}
+
+	n := len(events)
+	if n < 2 {
+		sendErrorMessage(ctx, nil, b, chatID, localize(LangRU, msgTooFewEvents))
+		return
+	}
+
+	expected := h.pc.TypicalLoad(events)
+
+	imageData, err := h.graph(
+		events, nil, h.cfg.Base.TimeLocation, plotter.GraphFormat(h.cfg.Telegram.GraphFormat),
+		plotter.WithExpected(expected),
+		plotter.WithWatermark(h.cfg.Telegram.Watermark),
+		plotter.WithCompact(h.cfg.Telegram.CompactGraph),
+		plotter.WithMaxPoints(maxGraphPoints),
+		plotter.WithCapacity(float64(h.cfg.Base.Capacity)),
+	)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, localize(LangRU, msgGraphFailed))
+		return
+	}
+
+	caption := fmt.Sprintf(
+		"%s - %s\nСредняя ошибка: %.1f%%",
+		events[0].Timestamp.In(h.cfg.Base.TimeLocation).Format(dateTimeFormat),
+		events[n-1].Timestamp.In(h.cfg.Base.TimeLocation).Format(dateTimeFormat),
+		meanAbsError(events, expected),
+	)
+
+	if _, err = sendImage(ctx, b, chatID, imageData, "review."+h.cfg.Telegram.GraphFormat, caption); err != nil {
+		sendErrorMessage(ctx, err, b, chatID, localize(LangRU, msgGraphSendFailed))
+	}
+}
+
+// HandleNextFetch shows the fetching period and the time remaining until the next scheduled fetch.
+func (h *BotHandler) HandleNextFetch(ctx context.Context, b BotAPI, update *models.Update) {
+	var (
+		schedule fetcher.Schedule
+		ok       bool
+	)
+	if h.fetcher != nil {
+		schedule, ok = h.fetcher.NextFetch()
+	}
+
+	if !ok {
+		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Опрос загрузки не запущен.")
+		return
+	}
+
+	location := h.cfg.Base.TimeLocation
+	text := fmt.Sprintf(
+		"Период опроса: %s\nПоследний запуск: %s\nСледующий опрос: %s (через %s)",
+		schedule.Period,
+		schedule.Last.In(location).Format(dateTimeFormat),
+		schedule.Next().In(location).Format(dateTimeFormat),
+		schedule.Remaining(time.Now()).Round(time.Second),
+	)
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   text,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleNextFetch", "error", err)
+	}
+}
+
+// HandleTime shows the configured timezone, the current time in it and in UTC.
+func (h *BotHandler) HandleTime(ctx context.Context, b BotAPI, update *models.Update) {
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   timeText(time.Now(), h.cfg.Base.TimeLocation),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleTime", "error", err)
+	}
+}
+
+// timeText returns the timezone name with its offset and the time in the location and in UTC.
+func timeText(now time.Time, location *time.Location) string {
+	const layout = "02.01.2006 15:04:05"
+	local := now.In(location)
+
+	return fmt.Sprintf(
+		"Часовой пояс: %s (UTC%s)\nМестное время: %s\nUTC: %s",
+		location, local.Format("-07:00"), local.Format(layout), now.UTC().Format(layout),
+	)
+}
+
+// HandleExplain shows how a graph of the period is built by the default handler without rendering it.
+func (h *BotHandler) HandleExplain(ctx context.Context, b BotAPI, update *models.Update) {
+	const usage = "Используйте: /explain <период>, например /explain 18h или /explain 2d."
+
+	_, value, ok := strings.Cut(strings.TrimSpace(update.Message.Text), " ")
+	if !ok {
+		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, usage)
+		return
+	}
+
+	duration, err := parsePeriod(value)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, usage)
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   explainText(duration, h.cfg.Telegram.MaxPeriod, h.pc != nil),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleExplain", "error", err)
+	}
+}
+
+// explainText describes the graph of the period: its limited period, prediction hours and aggregation.
+func explainText(duration, maxPeriod time.Duration, prediction bool) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Запрошенный период: %s", formatPeriod(duration))
+
+	if maxPeriod > 0 && duration > maxPeriod {
+		duration = maxPeriod
+		fmt.Fprintf(&sb, "\nПериод ограничен до %s", formatPeriod(maxPeriod))
+	}
+
+	predictHours := calculatePredictHours(duration)
+	if prediction {
+		fmt.Fprintf(&sb, "\nЧасов прогноза: %d", predictHours)
+	} else {
+		fmt.Fprintf(&sb, "\nЧасов прогноза: %d, но прогнозирование отключено", predictHours)
+	}
+
+	if duration > hourlyGraphPeriod {
+		sb.WriteString("\nДанные усредняются по часам")
+	}
+
+	return sb.String()
+}
+
+// meanAbsError returns the mean absolute difference between loads of events and expected values.
+func meanAbsError(events, expected []databaser.Event) float64 {
+	n := min(len(events), len(expected))
+	if n == 0 {
+		return 0
+	}
+
+	var sum float64
+	for i := range n {
+		sum += math.Abs(events[i].FloatLoad() - expected[i].Predict)
+	}
+
+	return sum / float64(n)
+}
+
+// HandleBackup writes a database backup to the configured directory and replies with its size.
+func (h *BotHandler) HandleBackup(ctx context.Context, b BotAPI, update *models.Update) {
+	dir := h.cfg.Database.BackupDir
+	if dir == "" {
+		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Каталог резервных копий не настроен.")
+		return
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	backupPath, size, err := h.db.BackupToDir(opCtx, dir)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, operationErrorText(opCtx, LangRU, "Не удалось создать резервную копию."))
+		return
+	}
+	slog.InfoContext(ctx, "database backup created", "path", backupPath, "size", size, "user_id", update.Message.From.ID)
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: update.Message.Chat.ID,
+		Text:   fmt.Sprintf("Резервная копия создана: %s, размер: %d байт", filepath.Base(backupPath), size),
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleBackup", "error", err)
+	}
+}
@@ -1,15 +1,29 @@
 package watcher
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-telegram/bot/models"
+	"github.com/jmoiron/sqlx"
 
+	"github.com/z0rr0/ggp/config"
 	"github.com/z0rr0/ggp/databaser"
+	"github.com/z0rr0/ggp/fetcher"
+	"github.com/z0rr0/ggp/importer"
+	"github.com/z0rr0/ggp/predictor"
 )
 
 func seedUser(t *testing.T, db *databaser.DB, id int64, status uint8, username string) {
@@ -87,6 +101,52 @@ func TestHandleUsers(t *testing.T) {
 		})
 	}
 }
+func TestHandleUsers_Status(t *testing.T) {
+	db := newTestDB(t)
+	seedUser(t, db, 100, 0, "pending_user")
+	seedUser(t, db, 200, 1, "approved_user")
+	seedUser(t, db, 300, 2, "rejected_user")
+	handler := NewBotHandler(db, newTestConfig(456), nil)
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{text: "/users pending", want: []string{"@pending_user"}},
+		{text: "/users approved", want: []string{"@approved_user"}},
+		{text: "/users Rejected", want: []string{"@rejected_user"}},
+		{text: "/users", want: []string{"@pending_user", "@approved_user", "@rejected_user"}},
+		{text: "/users unknown", want: []string{"Используйте: /users [pending|approved|rejected]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			mBot := &mockBot{}
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: tt.text,
+				},
+			}
+
+			handler.HandleUsers(context.Background(), mBot, update)
+
+			for _, username := range []string{"@pending_user", "@approved_user", "@rejected_user"} {
+				want := slices.Contains(tt.want, username)
+				if got := strings.Contains(mBot.lastText, username); got != want {
+					t.Errorf("response contains %q = %v, want %v, got: %s", username, got, want, mBot.lastText)
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(mBot.lastText, want) {
+					t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+				}
+			}
+		})
+	}
+}
+
 func TestHandleUsers_DatabaseError(t *testing.T) {
 	db := newTestDB(t)
 	cfg := newTestConfig(456)
@@ -197,6 +257,48 @@ func TestHandleApprove(t *testing.T) {
 	}
 }
 
+func TestHandleApproveAll(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewBotHandler(db, newTestConfig(456), nil)
+	ctx := context.Background()
+	update := &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 456},
+			Text: "/approveall",
+		},
+	}
+
+	mBot := &mockBot{}
+	handler.HandleApproveAll(ctx, mBot, update)
+	if mBot.sendMessageCalls != 1 || !strings.Contains(mBot.lastText, "Нет ожидающих") {
+		t.Errorf("empty queue: SendMessage called %d times, last text %q", mBot.sendMessageCalls, mBot.lastText)
+	}
+
+	seedUser(t, db, 100, 0, "pending1")
+	seedUser(t, db, 200, 0, "pending2")
+	seedUser(t, db, 300, 2, "rejected")
+
+	mBot = &mockBot{}
+	handler.HandleApproveAll(ctx, mBot, update)
+	if mBot.sendMessageCalls != 3 { // report + 2 notifications
+		t.Errorf("SendMessage called %d times, want 3", mBot.sendMessageCalls)
+	}
+	if mBot.lastChatID != int64(200) || !strings.Contains(mBot.lastText, "одобрен") {
+		t.Errorf("last message to %v: %q, want approval notification to 200", mBot.lastChatID, mBot.lastText)
+	}
+
+	for id, want := range map[int64]bool{100: true, 200: true, 300: false} {
+		user, err := db.GetUser(ctx, id)
+		if err != nil {
+			t.Fatalf("GetUser(%d) error = %v", id, err)
+		}
+		if user.IsApproved() != want {
+			t.Errorf("user %d approved = %v, want %v", id, user.IsApproved(), want)
+		}
+	}
+}
+
 func TestHandleApprove_SendError(t *testing.T) {
 	db := newTestDB(t)
 	seedUser(t, db, 100, 0, "pending")
@@ -459,3 +561,1400 @@ func TestHandleApprove_WithExtraArgs(t *testing.T) {
 		t.Errorf("SendMessage called %d times, want 2", mBot.sendMessageCalls)
 	}
 }
+
+func TestHandleIntegrity(t *testing.T) {
+	db := newTestDB(t)
+	cfg := newTestConfig(456)
+	handler := NewBotHandler(db, cfg, nil)
+	mBot := &mockBot{}
+	ctx := context.Background()
+
+	update := &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 456},
+			Text: "/integrity",
+		},
+	}
+
+	handler.HandleIntegrity(ctx, mBot, update)
+
+	if mBot.sendMessageCalls != 1 {
+		t.Errorf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+	}
+	if !strings.Contains(mBot.lastText, "ok") {
+		t.Errorf("response should contain ok, got: %s", mBot.lastText)
+	}
+}
+
+func TestHandleIntegrity_DatabaseError(t *testing.T) {
+	db := newTestDB(t)
+	cfg := newTestConfig(456)
+	handler := NewBotHandler(db, cfg, nil)
+	mBot := &mockBot{}
+	ctx := context.Background()
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close db: %v", err)
+	}
+
+	update := &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 456},
+			Text: "/integrity",
+		},
+	}
+
+	handler.HandleIntegrity(ctx, mBot, update)
+
+	if !strings.Contains(mBot.lastText, "Не удалось проверить") {
+		t.Errorf("expected error message, got: %s", mBot.lastText)
+	}
+}
+
+func TestHandleMaintenance(t *testing.T) {
+	db := newTestDB(t)
+	cfg := newTestConfig(456)
+	handler := NewBotHandler(db, cfg, nil)
+	ctx := context.Background()
+
+	steps := []struct {
+		text        string
+		wantEnabled bool
+		wantText    string
+	}{
+		{text: "/maintenance", wantEnabled: false, wantText: "выключен"},
+		{text: "/maintenance on", wantEnabled: true, wantText: "включен"},
+		{text: "/maintenance", wantEnabled: true, wantText: "включен"},
+		{text: "/maintenance invalid", wantEnabled: true, wantText: "Используйте"},
+		{text: "/maintenance OFF", wantEnabled: false, wantText: "выключен"},
+	}
+
+	for _, step := range steps {
+		mBot := &mockBot{}
+		update := &models.Update{
+			Message: &models.Message{
+				Chat: models.Chat{ID: 123},
+				From: &models.User{ID: 456},
+				Text: step.text,
+			},
+		}
+
+		handler.HandleMaintenance(ctx, mBot, update)
+
+		if enabled := handler.maintenance.Load(); enabled != step.wantEnabled {
+			t.Errorf("%q: maintenance = %v, want %v", step.text, enabled, step.wantEnabled)
+		}
+		if !strings.Contains(mBot.lastText, step.wantText) {
+			t.Errorf("%q: response should contain %q, got: %s", step.text, step.wantText, mBot.lastText)
+		}
+	}
+}
+
+func TestHandleAdmins(t *testing.T) {
+	db := newTestDB(t)
+	cfg := newTestConfig(456, 789, 123)
+	handler := NewBotHandler(db, cfg, nil)
+	mBot := &mockBot{}
+	ctx := context.Background()
+
+	seedUser(t, db, 456, 1, "first_admin")
+	seedUser(t, db, 789, 1, "second_admin")
+
+	update := &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 456},
+			Text: "/admins",
+		},
+	}
+
+	handler.HandleAdmins(ctx, mBot, update)
+
+	if mBot.sendMessageCalls != 1 {
+		t.Fatalf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+	}
+
+	want := "Администраторы:\n" +
+		"ID: 123\n" +
+		"ID: 456 @first_admin First Last\n" +
+		"ID: 789 @second_admin First Last\n"
+	if mBot.lastText != want {
+		t.Errorf("unexpected response:\n%s\nwant:\n%s", mBot.lastText, want)
+	}
+}
+
+func TestHandleAdmins_DatabaseError(t *testing.T) {
+	db := newTestDB(t)
+	cfg := newTestConfig(456)
+	handler := NewBotHandler(db, cfg, nil)
+	mBot := &mockBot{}
+	ctx := context.Background()
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("failed to close db: %v", err)
+	}
+
+	update := &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 456},
+			Text: "/admins",
+		},
+	}
+
+	handler.HandleAdmins(ctx, mBot, update)
+
+	if !strings.Contains(mBot.lastText, "Не удалось получить список администраторов") {
+		t.Errorf("expected error message, got: %s", mBot.lastText)
+	}
+}
+
+func TestHandleExportModel(t *testing.T) {
+	tests := []struct {
+		name          string
+		withPredictor bool
+		wantDocCalls  int
+		wantMsgCalls  int
+	}{
+		{name: "export model", withPredictor: true, wantDocCalls: 1},
+		{name: "predictor disabled", withPredictor: false, wantMsgCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			cfg := newTestConfig(456)
+
+			var pc *predictor.Controller
+			if tt.withPredictor {
+				pc = newTestController(t, db)
+			}
+
+			handler := NewBotHandler(db, cfg, pc)
+			mBot := &mockBot{}
+			ctx := context.Background()
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: "/exportmodel",
+				},
+			}
+
+			handler.HandleExportModel(ctx, mBot, update)
+
+			if mBot.sendDocumentCalls != tt.wantDocCalls {
+				t.Errorf("SendDocument called %d times, want %d", mBot.sendDocumentCalls, tt.wantDocCalls)
+			}
+			if mBot.sendMessageCalls != tt.wantMsgCalls {
+				t.Errorf("SendMessage called %d times, want %d", mBot.sendMessageCalls, tt.wantMsgCalls)
+			}
+		})
+	}
+}
+
+func TestHandleCollapse(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantContains string
+		wantEvents   int
+	}{
+		{name: "missing tolerance", text: "/collapse", wantContains: "Используйте", wantEvents: 5},
+		{name: "invalid tolerance", text: "/collapse abc", wantContains: "Неверный формат", wantEvents: 5},
+		{name: "tolerance above 100", text: "/collapse 101", wantContains: "Неверный формат", wantEvents: 5},
+		{name: "collapse events", text: "/collapse 100", wantContains: "Удалено событий: 3.", wantEvents: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedEvents(t, db, 5)
+			cfg := newTestConfig(456)
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{}
+			ctx := context.Background()
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: tt.text,
+				},
+			}
+
+			handler.HandleCollapse(ctx, mBot, update)
+
+			if !strings.Contains(mBot.lastText, tt.wantContains) {
+				t.Errorf("response %q should contain %q", mBot.lastText, tt.wantContains)
+			}
+
+			events, err := db.GetAllEvents(ctx, 100, 0)
+			if err != nil {
+				t.Fatalf("failed to get events: %v", err)
+			}
+			if len(events) != tt.wantEvents {
+				t.Errorf("got %d events, want %d", len(events), tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestHandleUser(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantContains []string
+	}{
+		{name: "missing user id", text: "/user", wantContains: []string{"Используйте: /user <user_id>"}},
+		{name: "invalid user id", text: "/user abc", wantContains: []string{"Неверный формат user_id"}},
+		{name: "missing user", text: "/user 999", wantContains: []string{"Пользователь не найден."}},
+		{
+			name:         "existing user",
+			text:         "/user 100",
+			wantContains: []string{"ID: 100", "Статус: одобрен", "@approved_user", "Имя: First", "Фамилия: Last", "Создан: ", "Обновлён: "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedUser(t, db, 100, 1, "approved_user")
+			cfg := newTestConfig(456)
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{}
+			ctx := context.Background()
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: tt.text,
+				},
+			}
+
+			handler.HandleUser(ctx, mBot, update)
+
+			if mBot.sendMessageCalls != 1 {
+				t.Errorf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(mBot.lastText, want) {
+					t.Errorf("response %q should contain %q", mBot.lastText, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlePing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"id":1,"title":"Test","currentLoad":"42%"}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		url          string
+		token        string
+		wantContains []string
+	}{
+		{
+			name:         "success",
+			url:          server.URL,
+			token:        "test-token",
+			wantContains: []string{"Загрузка: 42%", "Статус: 200 OK", "Задержка: "},
+		},
+		{
+			name:         "unauthorized",
+			url:          server.URL,
+			token:        "wrong-token",
+			wantContains: []string{"Ошибка: ", "Статус: 401 Unauthorized"},
+		},
+		{
+			name:         "no url",
+			wantContains: []string{"Адрес загрузки не настроен."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			cfg := newTestConfig(456)
+			cfg.Fetcher.URL = tt.url
+			cfg.Fetcher.Token = tt.token
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: "/ping",
+				},
+			}
+
+			handler.HandlePing(context.Background(), mBot, update)
+
+			if mBot.sendMessageCalls != 1 {
+				t.Errorf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(mBot.lastText, want) {
+					t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+				}
+			}
+			if tt.token != "" && strings.Contains(mBot.lastText, tt.token) {
+				t.Errorf("response contains token: %s", mBot.lastText)
+			}
+
+			events, err := db.GetEvents(context.Background(), time.Hour)
+			if err != nil {
+				t.Fatalf("failed to get events: %v", err)
+			}
+			if len(events) != 0 {
+				t.Errorf("got %d saved events, want 0", len(events))
+			}
+		})
+	}
+}
+
+func TestHandleRaw(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body := `{"id":1,"title":"Test","currentLoad":"42%"}`
+		switch r.URL.Path {
+		case "/changed":
+			body = `{"id":1,"load":{"current":42}}`
+		case "/long":
+			body = `{"id":1,"currentLoad":"42%","title":"` + strings.Repeat("ж", 2*maxRawBody) + `"}`
+		case "/echo":
+			w.WriteHeader(http.StatusUnauthorized)
+			body = `{"error":"bad token ` + r.Header.Get("Authorization") + `"}`
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		url          string
+		wantContains []string
+	}{
+		{
+			name:         "success",
+			url:          server.URL,
+			wantContains: []string{"Загрузка: 42%", "Статус: 200 OK", `Ответ (43 байт):` + "\n" + `{"id":1,"title":"Test","currentLoad":"42%"}`},
+		},
+		{
+			name:         "changed format",
+			url:          server.URL + "/changed",
+			wantContains: []string{"Ошибка: currentLoad is not set", `{"id":1,"load":{"current":42}}`},
+		},
+		{
+			name:         "long body",
+			url:          server.URL + "/long",
+			wantContains: []string{"Загрузка: 42%", "жж…"},
+		},
+		{
+			name:         "auth echo",
+			url:          server.URL + "/echo",
+			wantContains: []string{"Статус: 401 Unauthorized", `{"error":"bad token Bearer ***"}`},
+		},
+		{
+			name:         "no url",
+			wantContains: []string{"Адрес загрузки не настроен."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(456)
+			cfg.Fetcher.URL = tt.url
+			cfg.Fetcher.Token = "test-token"
+			handler := NewBotHandler(newTestDB(t), cfg, nil)
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				Message: &models.Message{Chat: models.Chat{ID: 123}, From: &models.User{ID: 456}, Text: "/raw"},
+			}
+			handler.HandleRaw(context.Background(), mBot, update)
+
+			for _, want := range tt.wantContains {
+				if !strings.Contains(mBot.lastText, want) {
+					t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+				}
+			}
+			if strings.Contains(mBot.lastText, "test-token") {
+				t.Errorf("response contains token: %s", mBot.lastText)
+			}
+			if n := utf8.RuneCountInString(mBot.lastText); n > maxMessageLength {
+				t.Errorf("response length = %d runes, want at most %d", n, maxMessageLength)
+			}
+		})
+	}
+}
+
+func TestHandleInsert(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name      string
+		text      string
+		wantText  string
+		wantEvent *databaser.Event
+	}{
+		{
+			name:      "valid event",
+			text:      "/insert 2025-06-15 14:30 42",
+			wantText:  "Событие сохранено: 15.06.2025 14:30, загрузка 42%.",
+			wantEvent: &databaser.Event{Timestamp: time.Date(2025, 6, 15, 11, 30, 0, 0, time.UTC), Load: 42},
+		},
+		{
+			name:     "missing load",
+			text:     "/insert 2025-06-15 14:30",
+			wantText: "Используйте: /insert <ГГГГ-ММ-ДД> <ЧЧ:ММ> <загрузка>",
+		},
+		{
+			name:     "invalid date",
+			text:     "/insert 2025-13-15 14:30 42",
+			wantText: "Неверный формат времени, ожидается ГГГГ-ММ-ДД ЧЧ:ММ.",
+		},
+		{
+			name:     "invalid time",
+			text:     "/insert 2025-06-15 1430 42",
+			wantText: "Неверный формат времени, ожидается ГГГГ-ММ-ДД ЧЧ:ММ.",
+		},
+		{
+			name:     "load too big",
+			text:     "/insert 2025-06-15 14:30 101",
+			wantText: "Неверный формат загрузки, ожидается число от 0 до 100.",
+		},
+		{
+			name:     "negative load",
+			text:     "/insert 2025-06-15 14:30 -1",
+			wantText: "Неверный формат загрузки, ожидается число от 0 до 100.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			cfg := newTestConfig(456)
+			cfg.Base.TimeLocation = moscow
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{}
+			ctx := context.Background()
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: tt.text,
+				},
+			}
+
+			handler.HandleInsert(ctx, mBot, update)
+
+			if mBot.lastText != tt.wantText {
+				t.Errorf("got message %q, want %q", mBot.lastText, tt.wantText)
+			}
+
+			var events []databaser.Event
+			if err := db.SelectContext(ctx, &events, `SELECT timestamp, load FROM events;`); err != nil {
+				t.Fatalf("failed to select events: %v", err)
+			}
+
+			if tt.wantEvent == nil {
+				if len(events) != 0 {
+					t.Errorf("got %d events, want 0", len(events))
+				}
+				return
+			}
+
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+			if !events[0].Timestamp.Equal(tt.wantEvent.Timestamp) || events[0].Load != tt.wantEvent.Load {
+				t.Errorf("event = %v %d, want %v %d", events[0].Timestamp, events[0].Load, tt.wantEvent.Timestamp, tt.wantEvent.Load)
+			}
+		})
+	}
+}
+
+func TestHandleReview(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		events        int
+		noPredictor   bool
+		wantPhoto     bool
+		wantText      string
+		wantCaptionIn string
+	}{
+		{
+			name:          "default period",
+			text:          "/review",
+			events:        10,
+			wantPhoto:     true,
+			wantCaptionIn: "Средняя ошибка: ",
+		},
+		{
+			name:          "custom period",
+			text:          "/review 6h",
+			events:        10,
+			wantPhoto:     true,
+			wantCaptionIn: "Средняя ошибка: ",
+		},
+		{
+			name:        "prediction disabled",
+			text:        "/review",
+			events:      10,
+			noPredictor: true,
+			wantText:    "Прогнозирование отключено.",
+		},
+		{
+			name:     "invalid period",
+			text:     "/review yesterday",
+			events:   10,
+			wantText: "Используйте: /review <период>, например /review 24h или /review 2d.",
+		},
+		{
+			name:     "too few events",
+			text:     "/review 24h",
+			events:   1,
+			wantText: localize(LangRU, msgTooFewEvents),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedEvents(t, db, tt.events)
+
+			var pc *predictor.Controller
+			if !tt.noPredictor {
+				pc = newTestController(t, db)
+			}
+
+			handler := NewBotHandler(db, newTestConfig(456), pc)
+			mBot := &mockBot{}
+			update := &models.Update{
+				Message: &models.Message{
+					Text: tt.text,
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+				},
+			}
+
+			handler.HandleReview(context.Background(), mBot, update)
+
+			if tt.wantPhoto {
+				if mBot.sendPhotoCalls != 1 {
+					t.Fatalf("SendPhoto called %d times, want 1", mBot.sendPhotoCalls)
+				}
+				if !strings.Contains(mBot.lastCaption, tt.wantCaptionIn) {
+					t.Errorf("caption = %q, want to contain %q", mBot.lastCaption, tt.wantCaptionIn)
+				}
+				return
+			}
+
+			if mBot.sendPhotoCalls != 0 {
+				t.Errorf("SendPhoto called %d times, want 0", mBot.sendPhotoCalls)
+			}
+			if mBot.lastText != tt.wantText {
+				t.Errorf("text = %q, want %q", mBot.lastText, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestMeanAbsError(t *testing.T) {
+	events := []databaser.Event{{Load: 10}, {Load: 50}, {Load: 90}}
+	expected := []databaser.Event{{Predict: 20}, {Predict: 50}, {Predict: 70}}
+
+	if got := meanAbsError(events, expected); got != 10 {
+		t.Errorf("meanAbsError() = %v, want 10", got)
+	}
+	if got := meanAbsError(nil, expected); got != 0 {
+		t.Errorf("meanAbsError() with no events = %v, want 0", got)
+	}
+}
+
+// stubScheduler is a FetchScheduler with a fixed schedule.
+type stubScheduler struct {
+	schedule fetcher.Schedule
+	running  bool
+}
+
+func (s stubScheduler) NextFetch() (fetcher.Schedule, bool) {
+	return s.schedule, s.running
+}
+
+func TestHandleNextFetch(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	last := time.Now().Add(-2 * time.Minute)
+
+	tests := []struct {
+		name      string
+		scheduler FetchScheduler
+		want      []string
+	}{
+		{name: "disabled", want: []string{"не запущен"}},
+		{name: "stopped", scheduler: stubScheduler{}, want: []string{"не запущен"}},
+		{
+			name:      "running",
+			scheduler: stubScheduler{schedule: fetcher.Schedule{Last: last, Period: 5 * time.Minute}, running: true},
+			want:      []string{"Период опроса: 5m0s", "Следующий опрос:", "через 3m0s"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBotHandler(db, newTestConfig(456), nil)
+			if tt.scheduler != nil {
+				handler.SetFetcher(tt.scheduler)
+			}
+			mBot := &mockBot{}
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: "/nextfetch",
+				},
+			}
+
+			handler.HandleNextFetch(ctx, mBot, update)
+
+			for _, want := range tt.want {
+				if !strings.Contains(mBot.lastText, want) {
+					t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeText(t *testing.T) {
+	now := time.Date(2025, 6, 15, 21, 30, 5, 0, time.UTC)
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		location *time.Location
+		want     string
+	}{
+		{
+			name:     "utc",
+			location: time.UTC,
+			want:     "Часовой пояс: UTC (UTC+00:00)\nМестное время: 15.06.2025 21:30:05\nUTC: 15.06.2025 21:30:05",
+		},
+		{
+			name:     "next day",
+			location: moscow,
+			want:     "Часовой пояс: Europe/Moscow (UTC+03:00)\nМестное время: 16.06.2025 00:30:05\nUTC: 15.06.2025 21:30:05",
+		},
+		{
+			name:     "negative offset",
+			location: time.FixedZone("UTC-5", -5*3600),
+			want:     "Часовой пояс: UTC-5 (UTC-05:00)\nМестное время: 15.06.2025 16:30:05\nUTC: 15.06.2025 21:30:05",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeText(now, tt.location); got != tt.want {
+				t.Errorf("timeText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleTime(t *testing.T) {
+	handler := NewBotHandler(newTestDB(t), newTestConfig(456), nil)
+	mBot := &mockBot{}
+	update := &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 456},
+			Text: "/time",
+		},
+	}
+
+	handler.HandleTime(context.Background(), mBot, update)
+
+	for _, want := range []string{"Часовой пояс: UTC", "Местное время:", "\nUTC: "} {
+		if !strings.Contains(mBot.lastText, want) {
+			t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+		}
+	}
+}
+
+func TestExplainText(t *testing.T) {
+	tests := []struct {
+		name       string
+		duration   time.Duration
+		maxPeriod  time.Duration
+		prediction bool
+		want       string
+	}{
+		{name: "30m", duration: 30 * time.Minute, prediction: true, want: "Запрошенный период: 30m\nЧасов прогноза: 1"},
+		{name: "1h", duration: time.Hour, prediction: true, want: "Запрошенный период: 1h\nЧасов прогноза: 1"},
+		{name: "2h", duration: 2 * time.Hour, prediction: true, want: "Запрошенный период: 2h\nЧасов прогноза: 2"},
+		{name: "4h", duration: 4 * time.Hour, prediction: true, want: "Запрошенный период: 4h\nЧасов прогноза: 2"},
+		{name: "6h", duration: 6 * time.Hour, prediction: true, want: "Запрошенный период: 6h\nЧасов прогноза: 4"},
+		{name: "12h", duration: 12 * time.Hour, prediction: true, want: "Запрошенный период: 12h\nЧасов прогноза: 4"},
+		{name: "18h", duration: 18 * time.Hour, prediction: true, want: "Запрошенный период: 18h\nЧасов прогноза: 6"},
+		{name: "24h", duration: 24 * time.Hour, prediction: true, want: "Запрошенный период: 1d\nЧасов прогноза: 6"},
+		{name: "48h", duration: 48 * time.Hour, prediction: true, want: "Запрошенный период: 2d\nЧасов прогноза: 12"},
+		{
+			name:       "7d",
+			duration:   7 * 24 * time.Hour,
+			prediction: true,
+			want:       "Запрошенный период: 7d\nЧасов прогноза: 12\nДанные усредняются по часам",
+		},
+		{
+			name:       "limited",
+			duration:   7 * 24 * time.Hour,
+			maxPeriod:  12 * time.Hour,
+			prediction: true,
+			want:       "Запрошенный период: 7d\nПериод ограничен до 12h\nЧасов прогноза: 4",
+		},
+		{
+			name:     "without prediction",
+			duration: 18 * time.Hour,
+			want:     "Запрошенный период: 18h\nЧасов прогноза: 6, но прогнозирование отключено",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := explainText(tt.duration, tt.maxPeriod, tt.prediction); got != tt.want {
+				t.Errorf("explainText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleExplain(t *testing.T) {
+	handler := NewBotHandler(newTestDB(t), newTestConfig(456), nil)
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "/explain 18h", want: "Часов прогноза: 6"},
+		{text: "/explain 2 days", want: "Запрошенный период: 2d"},
+		{text: "/explain", want: "Используйте: /explain"},
+		{text: "/explain abc", want: "Используйте: /explain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			mBot := &mockBot{}
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: tt.text,
+				},
+			}
+
+			handler.HandleExplain(context.Background(), mBot, update)
+
+			if mBot.sendMessageCalls != 1 || !strings.Contains(mBot.lastText, tt.want) {
+				t.Errorf("SendMessage called %d times, text %q, want containing %q", mBot.sendMessageCalls, mBot.lastText, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleBackup(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "backups")
+
+	tests := []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{name: "not configured", want: "Каталог резервных копий не настроен."},
+		{name: "created", dir: dir, want: "Резервная копия создана: ggp-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(456)
+			cfg.Database.BackupDir = tt.dir
+			handler := NewBotHandler(newTestDB(t), cfg, nil)
+
+			mBot := &mockBot{}
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: "/backup",
+				},
+			}
+
+			handler.HandleBackup(context.Background(), mBot, update)
+
+			if mBot.sendMessageCalls != 1 || !strings.Contains(mBot.lastText, tt.want) {
+				t.Errorf("SendMessage called %d times, text %q, want containing %q", mBot.sendMessageCalls, mBot.lastText, tt.want)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read backup directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("backup files = %d, want 1", len(entries))
+	}
+}
+
+func TestHandleExport(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	events := []databaser.Event{
+		{Timestamp: now.Add(-60 * 24 * time.Hour), Load: 10},
+		{Timestamp: now.Add(-2 * 24 * time.Hour), Load: 20},
+		{Timestamp: now.Add(-time.Hour), Load: 30},
+		{Timestamp: now.Add(-time.Hour), ClubID: 7, Load: 99},
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	cfg := newTestConfig(456)
+	cfg.Base.TimeLocation = moscow
+
+	tests := []struct {
+		name      string
+		text      string
+		wantLoads []string
+		wantDoc   bool
+	}{
+		{name: "all events", text: "/export", wantLoads: []string{"10", "20", "30"}, wantDoc: true},
+		{name: "period", text: "/export 30d", wantLoads: []string{"20", "30"}, wantDoc: true},
+		{name: "empty period", text: "/export 1m", wantDoc: true},
+		{name: "invalid period", text: "/export month"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{}
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: tt.text,
+				},
+			}
+
+			handler.HandleExport(ctx, mBot, update)
+
+			if !tt.wantDoc {
+				if mBot.sendDocumentCalls != 0 || !strings.Contains(mBot.lastText, "Используйте") {
+					t.Errorf("expected usage message, got documents %d and text: %s", mBot.sendDocumentCalls, mBot.lastText)
+				}
+				return
+			}
+			if mBot.sendDocumentCalls != 1 {
+				t.Fatalf("SendDocument called %d times, want 1", mBot.sendDocumentCalls)
+			}
+
+			records, readErr := csv.NewReader(bytes.NewReader(mBot.lastDocument)).ReadAll()
+			if readErr != nil {
+				t.Fatalf("failed to read CSV: %v", readErr)
+			}
+			if len(records) != len(tt.wantLoads)+1 || strings.Join(records[0], ",") != "time,load" {
+				t.Fatalf("unexpected CSV:\n%s", mBot.lastDocument)
+			}
+			for i, load := range tt.wantLoads {
+				if records[i+1][1] != load {
+					t.Errorf("record %d load = %s, want %s", i, records[i+1][1], load)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleExport_RoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var events []databaser.Event
+	for i := range 20 {
+		events = append(events, databaser.Event{Timestamp: now.Add(-time.Duration(i) * time.Hour), Load: uint8(i * 5)})
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	cfg := newTestConfig(456)
+	handler := NewBotHandler(db, cfg, nil)
+	mBot := &mockBot{}
+	update := &models.Update{
+		Message: &models.Message{Chat: models.Chat{ID: 123}, From: &models.User{ID: 456}, Text: "/export"},
+	}
+	handler.HandleExport(ctx, mBot, update)
+
+	filePath := filepath.Join(t.TempDir(), "events.csv")
+	if err := os.WriteFile(filePath, mBot.lastDocument, 0600); err != nil {
+		t.Fatalf("failed to write CSV: %v", err)
+	}
+
+	imported := newTestDB(t)
+	if err := importer.ImportCSV(imported, filePath, 5*time.Second, cfg.Base.TimeLocation); err != nil {
+		t.Fatalf("ImportCSV() error = %v", err)
+	}
+
+	want, err := db.GetEvents(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetEvents() error = %v", err)
+	}
+	got, err := imported.GetEvents(ctx, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetEvents() of imported error = %v", err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("imported %d events, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Timestamp.Equal(want[i].Timestamp) || got[i].Load != want[i].Load {
+			t.Errorf("imported event %d = %v, want %v", i, &got[i], &want[i])
+		}
+	}
+}
+
+func TestHandleExport_SendError(t *testing.T) {
+	db := newTestDB(t)
+	if err := db.SaveEvent(context.Background(), databaser.Event{Timestamp: time.Now().UTC(), Load: 5}); err != nil {
+		t.Fatalf("failed to save event: %v", err)
+	}
+
+	handler := NewBotHandler(db, newTestConfig(456), nil)
+	mBot := &mockBot{sendDocumentErr: errors.New("upload failed")}
+	update := &models.Update{
+		Message: &models.Message{Chat: models.Chat{ID: 123}, From: &models.User{ID: 456}, Text: "/export"},
+	}
+
+	handler.HandleExport(context.Background(), mBot, update)
+
+	if !strings.Contains(mBot.lastText, "Не удалось выгрузить") {
+		t.Errorf("expected error message, got: %s", mBot.lastText)
+	}
+}
+
+func TestHandleStats(t *testing.T) {
+	update := &models.Update{
+		Message: &models.Message{Chat: models.Chat{ID: 123}, From: &models.User{ID: 456}, Text: "/stats"},
+	}
+	ctx := context.Background()
+
+	t.Run("empty database", func(t *testing.T) {
+		handler := NewBotHandler(newTestDB(t), newTestConfig(456), nil)
+		mBot := &mockBot{}
+
+		handler.HandleStats(ctx, mBot, update)
+
+		year := time.Now().UTC().Year()
+		for _, want := range []string{
+			"Событий: 0", "Первое событие: —", "Последнее событие: —",
+			fmt.Sprintf("Праздников в %d: 0", year), fmt.Sprintf("Праздников в %d: 0", year+1),
+			"одобрено 0, ожидают 0, отклонено 0",
+		} {
+			if !strings.Contains(mBot.lastText, want) {
+				t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+			}
+		}
+	})
+
+	t.Run("with data", func(t *testing.T) {
+		db := newTestDB(t)
+		baseTime := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+		events := []databaser.Event{
+			{Timestamp: baseTime, Load: 10},
+			{Timestamp: baseTime.Add(time.Hour), Load: 20},
+			{Timestamp: baseTime.Add(2 * time.Hour), ClubID: 2, Load: 30},
+		}
+		if err := db.SaveManyEvents(ctx, events); err != nil {
+			t.Fatalf("failed to save events: %v", err)
+		}
+
+		year := time.Now().UTC().Year()
+		d1 := databaser.DateOnly(time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC))
+		d2 := databaser.DateOnly(time.Date(year, 1, 7, 0, 0, 0, 0, time.UTC))
+		holidays := []databaser.Holiday{{Day: &d1, Title: "Новый год"}, {Day: &d2, Title: "Рождество"}}
+		err := databaser.InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+			return databaser.SaveManyHolidaysTx(ctx, tx, holidays)
+		})
+		if err != nil {
+			t.Fatalf("failed to seed holidays: %v", err)
+		}
+
+		seedUser(t, db, 1, databaser.UserApproved, "approved")
+		seedUser(t, db, 2, databaser.UserPending, "pending")
+		seedUser(t, db, 3, databaser.UserRejected, "rejected")
+		seedUser(t, db, 4, databaser.UserApproved, "approved2")
+
+		handler := NewBotHandler(db, newTestConfig(456), nil)
+		mBot := &mockBot{}
+		handler.HandleStats(ctx, mBot, update)
+
+		for _, want := range []string{
+			"Событий: 3", "Первое событие: 15.01.2025 10:00", "Последнее событие: 15.01.2025 12:00",
+			fmt.Sprintf("Праздников в %d: 2", year), fmt.Sprintf("Праздников в %d: 0", year+1),
+			"одобрено 2, ожидают 1, отклонено 1",
+		} {
+			if !strings.Contains(mBot.lastText, want) {
+				t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+			}
+		}
+		if strings.Contains(mBot.lastText, "Ошибка прогноза") {
+			t.Errorf("response should not contain prediction accuracy, got: %s", mBot.lastText)
+		}
+	})
+
+	t.Run("prediction accuracy", func(t *testing.T) {
+		db := newTestDB(t)
+		cfg := newTestConfig(456)
+		cfg.Predictor.TrackAccuracy = true
+		handler := NewBotHandler(db, cfg, nil)
+
+		mBot := &mockBot{}
+		handler.HandleStats(ctx, mBot, update)
+		if want := "Ошибка прогноза за 7d: нет данных"; !strings.Contains(mBot.lastText, want) {
+			t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+		}
+
+		hour := time.Now().UTC().Truncate(time.Hour).Add(-time.Hour)
+		for i, load := range []float64{40, 20} {
+			target := hour.Add(time.Duration(i) * time.Hour)
+			if err := db.SavePrediction(ctx, databaser.Prediction{Target: target, Load: load}); err != nil {
+				t.Fatalf("failed to save prediction: %v", err)
+			}
+			if _, err := db.ReconcilePrediction(ctx, databaser.Event{Timestamp: target, Load: 30}); err != nil {
+				t.Fatalf("failed to reconcile prediction: %v", err)
+			}
+		}
+
+		mBot = &mockBot{}
+		handler.HandleStats(ctx, mBot, update)
+		if want := "Ошибка прогноза за 7d: 10.0% (часов: 2)"; !strings.Contains(mBot.lastText, want) {
+			t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+		}
+	})
+}
+
+func TestHandleAvailability(t *testing.T) {
+	ctx := context.Background()
+	newUpdate := func(text string) *models.Update {
+		return &models.Update{
+			Message: &models.Message{Chat: models.Chat{ID: 123}, From: &models.User{ID: 456}, Text: text},
+		}
+	}
+	newConfig := func() *config.Config {
+		cfg := newTestConfig(456)
+		cfg.Fetcher.Timeout = time.Hour
+		return cfg
+	}
+
+	t.Run("partial coverage", func(t *testing.T) {
+		db := newTestDB(t)
+		now := time.Now().UTC()
+
+		// 24 hourly intervals since the first event, 6 of them are missed
+		events := make([]databaser.Event, 0, 18)
+		for i := range 24 {
+			if i%4 == 1 {
+				continue
+			}
+			events = append(events, databaser.Event{Timestamp: now.Add(-time.Duration(24-i)*time.Hour + time.Minute), Load: 10})
+		}
+		// other clubs are not counted
+		events = append(events, databaser.Event{Timestamp: now.Add(-time.Hour), ClubID: 2, Load: 10})
+		if err := db.SaveManyEvents(ctx, events); err != nil {
+			t.Fatalf("failed to save events: %v", err)
+		}
+
+		handler := NewBotHandler(db, newConfig(), nil)
+		mBot := &mockBot{}
+		handler.HandleAvailability(ctx, mBot, newUpdate("/availability 30d"))
+
+		for _, want := range []string{"Доступность за 30d: 75.0%", "Ожидалось опросов: 24, сохранено: 18, пропущено: 6"} {
+			if !strings.Contains(mBot.lastText, want) {
+				t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+			}
+		}
+	})
+
+	t.Run("period shorter than data", func(t *testing.T) {
+		db := newTestDB(t)
+		now := time.Now().UTC()
+
+		events := make([]databaser.Event, 0, 48)
+		for i := range 48 {
+			events = append(events, databaser.Event{Timestamp: now.Add(-time.Duration(48-i)*time.Hour + time.Minute), Load: 10})
+		}
+		if err := db.SaveManyEvents(ctx, events); err != nil {
+			t.Fatalf("failed to save events: %v", err)
+		}
+
+		handler := NewBotHandler(db, newConfig(), nil)
+		mBot := &mockBot{}
+		handler.HandleAvailability(ctx, mBot, newUpdate("/availability 12h"))
+
+		if want := "Доступность за 12h: 100.0%"; !strings.Contains(mBot.lastText, want) {
+			t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		text     string
+		want     string
+		noFetch  bool
+		noEvents bool
+	}{
+		{name: "invalid period", text: "/availability abc", want: "Используйте: /availability"},
+		{name: "no events", text: "/availability", want: "Нет сохраненных событий.", noEvents: true},
+		{name: "no fetcher", text: "/availability 7d", want: "Опрос загрузки не настроен.", noFetch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			if !tt.noEvents {
+				event := databaser.Event{Timestamp: time.Now().UTC().Add(-time.Hour), Load: 10}
+				if err := db.SaveEvent(ctx, event); err != nil {
+					t.Fatalf("failed to save event: %v", err)
+				}
+			}
+
+			cfg := newConfig()
+			if tt.noFetch {
+				cfg.Fetcher.Timeout = 0
+			}
+
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{}
+			handler.HandleAvailability(ctx, mBot, newUpdate(tt.text))
+
+			if !strings.Contains(mBot.lastText, tt.want) {
+				t.Errorf("response should contain %q, got: %s", tt.want, mBot.lastText)
+			}
+		})
+	}
+}
+
+func TestNewAvailability(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		stored int
+		want   availability
+	}{
+		{name: "full", window: 10 * time.Hour, stored: 10, want: availability{expected: 10, stored: 10, percent: 100}},
+		{name: "partial", window: 10 * time.Hour, stored: 4, want: availability{expected: 10, stored: 4, missed: 6, percent: 40}},
+		{name: "rounded up", window: 9*time.Hour + time.Minute, stored: 5, want: availability{expected: 10, stored: 5, missed: 5, percent: 50}},
+		{name: "extra events", window: 2 * time.Hour, stored: 5, want: availability{expected: 2, stored: 5, percent: 100}},
+		{name: "empty window", window: 0, stored: 1, want: availability{expected: 1, stored: 1, percent: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newAvailability(tt.stored, tt.window, time.Hour); got != tt.want {
+				t.Errorf("newAvailability() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleExportUsers(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	seedUser(t, db, 1, databaser.UserApproved, "alice")
+	seedUser(t, db, 2, databaser.UserPending, `bob, "the builder"`)
+	seedUser(t, db, 3, databaser.UserRejected, "")
+
+	handler := NewBotHandler(db, newTestConfig(456), nil)
+	mBot := &mockBot{}
+	update := &models.Update{
+		Message: &models.Message{Chat: models.Chat{ID: 123}, From: &models.User{ID: 456}, Text: "/exportusers"},
+	}
+	handler.HandleExportUsers(ctx, mBot, update)
+
+	records, err := csv.NewReader(bytes.NewReader(mBot.lastDocument)).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV: %v", err)
+	}
+
+	want, err := db.GetUsers(ctx)
+	if err != nil {
+		t.Fatalf("GetUsers() error = %v", err)
+	}
+	if len(records) != len(want)+1 {
+		t.Fatalf("got %d records, want %d with the header", len(records), len(want)+1)
+	}
+
+	header := strings.Join(records[0], ",")
+	if header != "id,status,username,first_name,last_name,created,updated" {
+		t.Errorf("unexpected header %q", header)
+	}
+
+	for i, user := range want {
+		record := records[i+1]
+		wantRecord := []string{
+			fmt.Sprint(user.ID), fmt.Sprint(user.Status), user.Username, user.FirstName, user.LastName,
+			user.Created.UTC().Format(time.RFC3339), user.Updated.UTC().Format(time.RFC3339),
+		}
+		if strings.Join(record, "|") != strings.Join(wantRecord, "|") {
+			t.Errorf("record %d = %q, want %q", i, record, wantRecord)
+		}
+	}
+}
+
+func TestHandleExportUsers_SendError(t *testing.T) {
+	db := newTestDB(t)
+	seedUser(t, db, 1, databaser.UserApproved, "alice")
+
+	handler := NewBotHandler(db, newTestConfig(456), nil)
+	mBot := &mockBot{sendDocumentErr: errors.New("upload failed")}
+	update := &models.Update{
+		Message: &models.Message{Chat: models.Chat{ID: 123}, From: &models.User{ID: 456}, Text: "/exportusers"},
+	}
+	handler.HandleExportUsers(context.Background(), mBot, update)
+
+	if !strings.Contains(mBot.lastText, "Не удалось выгрузить пользователей.") {
+		t.Errorf("unexpected message %q", mBot.lastText)
+	}
+}
+
+func TestHandleAddHoliday(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantContains string
+		wantTitle    string
+	}{
+		{name: "missing arguments", text: "/addholiday", wantContains: "Используйте"},
+		{name: "missing title", text: "/addholiday 2025-12-24", wantContains: "Используйте"},
+		{name: "empty quoted title", text: `/addholiday 2025-12-24 ""`, wantContains: "Используйте"},
+		{name: "invalid date", text: "/addholiday 2025-13-01 Closed", wantContains: "Неверный формат даты"},
+		{name: "long title", text: "/addholiday 2025-12-24 " + strings.Repeat("я", 256), wantContains: "длиннее 255"},
+		{name: "quoted title", text: `/addholiday 2025-12-24 "Closed for repair"`, wantContains: "Праздник сохранен: 2025-12-24, Closed for repair.", wantTitle: "Closed for repair"},
+		{name: "plain title", text: "/addholiday 2025-12-24 Closed", wantContains: "Праздник сохранен", wantTitle: "Closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			handler := NewBotHandler(db, newTestConfig(456), nil)
+			mBot := &mockBot{}
+			ctx := context.Background()
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: tt.text,
+				},
+			}
+			handler.HandleAddHoliday(ctx, mBot, update)
+
+			if !strings.Contains(mBot.lastText, tt.wantContains) {
+				t.Errorf("response %q should contain %q", mBot.lastText, tt.wantContains)
+			}
+
+			holidays, err := db.GetHolidays(ctx, 2025, time.UTC)
+			if err != nil {
+				t.Fatalf("failed to get holidays: %v", err)
+			}
+			if tt.wantTitle == "" {
+				if len(holidays) != 0 {
+					t.Errorf("holidays = %d, want 0", len(holidays))
+				}
+				return
+			}
+			if len(holidays) != 1 || holidays[0].Title != tt.wantTitle || holidays[0].Source != databaser.HolidayManual {
+				t.Errorf("holidays = %+v, want manual holiday %q", holidays, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestHandleDelHoliday(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantContains string
+		wantCount    int
+	}{
+		{name: "missing date", text: "/delholiday", wantContains: "Используйте", wantCount: 1},
+		{name: "invalid date", text: "/delholiday 24.12.2025", wantContains: "Неверный формат даты", wantCount: 1},
+		{name: "not found", text: "/delholiday 2025-12-25", wantContains: "Праздник 2025-12-25 не найден.", wantCount: 1},
+		{name: "deleted", text: "/delholiday 2025-12-24", wantContains: "Праздник удален: 2025-12-24."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			ctx := context.Background()
+			day := databaser.DateOnly(time.Date(2025, 12, 24, 0, 0, 0, 0, time.UTC))
+			if err := db.AddHoliday(ctx, databaser.Holiday{Day: &day, Title: "Closed"}); err != nil {
+				t.Fatalf("failed to add holiday: %v", err)
+			}
+
+			handler := NewBotHandler(db, newTestConfig(456), nil)
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: tt.text,
+				},
+			}
+			handler.HandleDelHoliday(ctx, mBot, update)
+
+			if !strings.Contains(mBot.lastText, tt.wantContains) {
+				t.Errorf("response %q should contain %q", mBot.lastText, tt.wantContains)
+			}
+
+			holidays, err := db.GetHolidays(ctx, 2025, time.UTC)
+			if err != nil {
+				t.Fatalf("failed to get holidays: %v", err)
+			}
+			if len(holidays) != tt.wantCount {
+				t.Errorf("holidays = %d, want %d", len(holidays), tt.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package watcher
+
+import (
+	"maps"
+	"slices"
+	"sync/atomic"
+)
+
+// AdminSet is a thread-safe set of admin user IDs, it can be replaced on config reload.
+type AdminSet struct {
+	ids atomic.Pointer[map[int64]struct{}]
+}
+
+// NewAdminSet creates a new AdminSet with a copy of the given IDs.
+func NewAdminSet(ids map[int64]struct{}) *AdminSet {
+	a := &AdminSet{}
+	a.Set(ids)
+	return a
+}
+
+// Set replaces admin IDs by a copy of the given ones.
+func (a *AdminSet) Set(ids map[int64]struct{}) {
+	c := maps.Clone(ids)
+	if c == nil {
+		c = make(map[int64]struct{})
+	}
+	a.ids.Store(&c)
+}
+
+// Contains returns true if the user is an admin.
+func (a *AdminSet) Contains(userID int64) bool {
+	_, ok := (*a.ids.Load())[userID]
+	return ok
+}
+
+// IDs returns sorted admin IDs.
+func (a *AdminSet) IDs() []int64 {
+	return slices.Sorted(maps.Keys(*a.ids.Load()))
+}
@@ -0,0 +1,76 @@
+package watcher
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+func TestAdminSet(t *testing.T) {
+	ids := map[int64]struct{}{300: {}, 100: {}}
+	admins := NewAdminSet(ids)
+
+	// the set keeps a copy, so changes of the source map are not applied
+	ids[200] = struct{}{}
+
+	if !admins.Contains(100) || !admins.Contains(300) {
+		t.Error("admins should contain 100 and 300")
+	}
+	if admins.Contains(200) {
+		t.Error("admins should not contain 200")
+	}
+	if got, want := admins.IDs(), []int64{100, 300}; !slices.Equal(got, want) {
+		t.Errorf("IDs() = %v, want %v", got, want)
+	}
+
+	admins.Set(nil)
+	if got := admins.IDs(); len(got) != 0 {
+		t.Errorf("IDs() = %v, want empty", got)
+	}
+}
+
+func TestAdminSet_Reload(t *testing.T) {
+	db := newTestDB(t)
+	handler := NewBotHandler(db, newTestConfig(100), nil)
+	admins := NewAdminSet(map[int64]struct{}{100: {}})
+	handler.SetAdmins(admins)
+
+	var called bool
+	next := func(_ context.Context, _ *bot.Bot, _ *models.Update) {
+		called = true
+	}
+	middleware := BotAdminOnlyMiddleware(admins, LangRU)(next)
+	update := &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 200},
+			Text: "/admins",
+		},
+	}
+
+	// the config is reloaded with a changed admin list
+	admins.Set(map[int64]struct{}{200: {}})
+
+	middleware(context.Background(), nil, update)
+	if !called {
+		t.Error("new admin should be authorized by middleware")
+	}
+
+	if handler.isAdmin(100) {
+		t.Error("removed admin should not be authorized by handler")
+	}
+	if !handler.isAdmin(200) {
+		t.Error("new admin should be authorized by handler")
+	}
+
+	mBot := &mockBot{}
+	handler.HandleAdmins(context.Background(), mBot, update)
+
+	if !strings.Contains(mBot.lastText, "ID: 200") || strings.Contains(mBot.lastText, "ID: 100") {
+		t.Errorf("unexpected admins list: %s", mBot.lastText)
+	}
+}
@@ -0,0 +1,104 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// alertOff is the /alert command argument to unsubscribe from alerts.
+const alertOff = "off"
+
+// WrapHandleAlert wraps HandleAlert for bot.HandlerFunc compatibility.
+func (h *BotHandler) WrapHandleAlert(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleAlert(ctx, b, update)
+}
+
+// HandleAlert handles the /alert command, it subscribes the user to high load alerts with the given threshold
+// or unsubscribes with "off" or zero argument.
+func (h *BotHandler) HandleAlert(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	lang := h.language(update)
+
+	if !h.cfg.Features.Subscriptions {
+		sendErrorMessage(ctx, nil, b, chatID, localize(lang, msgAlertDisabled))
+		return
+	}
+
+	args := strings.Fields(update.Message.Text)
+	if len(args) != 2 {
+		sendErrorMessage(ctx, nil, b, chatID, localize(lang, msgAlertUsage))
+		return
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	if arg := strings.ToLower(args[1]); arg == alertOff || arg == "0" {
+		h.unsubscribeAlert(ctx, opCtx, b, update.Message.From.ID, chatID, lang)
+		return
+	}
+
+	threshold, err := strconv.ParseUint(args[1], 10, 8)
+	if err != nil || threshold > 100 {
+		sendErrorMessage(ctx, err, b, chatID, localize(lang, msgAlertUsage))
+		return
+	}
+
+	if err = h.db.SetSubscription(opCtx, update.Message.From.ID, uint8(threshold)); err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, lang, localize(lang, msgAlertFailed)))
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf(localize(lang, msgAlertSet), threshold),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleAlert", "error", err)
+	}
+}
+
+// unsubscribeAlert deletes the alert subscription of the user and reports the result.
+func (h *BotHandler) unsubscribeAlert(ctx, opCtx context.Context, b BotAPI, userID, chatID int64, lang string) {
+	deleted, err := h.db.DeleteSubscription(opCtx, userID)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, lang, localize(lang, msgAlertFailed)))
+		return
+	}
+
+	text := localize(lang, msgAlertOff)
+	if !deleted {
+		text = localize(lang, msgAlertNone)
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{ChatID: chatID, Text: text})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleAlert unsubscribe", "error", err)
+	}
+}
+
+// SendAlert sends a high load alert of the event to the subscribed user in the default language.
+func (h *BotHandler) SendAlert(ctx context.Context, b BotAPI, subscription databaser.Subscription, event databaser.Event) error {
+	lang := h.cfg.Base.Language
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: subscription.UserID,
+		Text: fmt.Sprintf(
+			localize(lang, msgAlert), event.Load, subscription.Threshold,
+			event.Timestamp.In(h.cfg.Base.TimeLocation).Format(dateTimeFormat),
+		),
+	})
+	if err != nil {
+		return fmt.Errorf("send alert: %w", err)
+	}
+
+	return nil
+}
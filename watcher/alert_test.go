@@ -0,0 +1,142 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func TestHandleAlert(t *testing.T) {
+	tests := []struct {
+		name          string
+		disabled      bool
+		subscribed    bool
+		text          string
+		lang          string
+		wantText      string
+		wantThreshold uint8 // 0 - no subscription
+	}{
+		{
+			name:     "disabled",
+			disabled: true,
+			text:     "/alert 80",
+			wantText: "Уведомления о загрузке отключены администратором.",
+		},
+		{
+			name:     "no argument",
+			text:     "/alert",
+			wantText: "Используйте: /alert <загрузка от 1 до 100>, чтобы получать уведомления, или /alert off, чтобы отписаться.",
+		},
+		{
+			name:     "invalid threshold",
+			text:     "/alert 120",
+			lang:     "en",
+			wantText: "Use: /alert <load from 1 to 100> to get alerts, or /alert off to unsubscribe.",
+		},
+		{
+			name:          "subscribe",
+			text:          "/alert 80",
+			wantText:      "Вы получите уведомление, когда загрузка достигнет 80%.",
+			wantThreshold: 80,
+		},
+		{
+			name:          "update threshold",
+			subscribed:    true,
+			text:          "/alert 60",
+			lang:          "en",
+			wantText:      "You will be alerted when the load reaches 60%.",
+			wantThreshold: 60,
+		},
+		{
+			name:       "unsubscribe",
+			subscribed: true,
+			text:       "/alert off",
+			wantText:   "Уведомления о загрузке отключены.",
+		},
+		{
+			name:     "unsubscribe not subscribed",
+			text:     "/alert 0",
+			wantText: "Вы не подписаны на уведомления о загрузке.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			ctx := context.Background()
+			seedUser(t, db, 789, databaser.UserApproved, "user")
+			if tt.subscribed {
+				if err := db.SetSubscription(ctx, 789, 90); err != nil {
+					t.Fatalf("failed to subscribe: %v", err)
+				}
+			}
+
+			cfg := newTestConfig(456)
+			cfg.Features.Subscriptions = !tt.disabled
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				Message: &models.Message{
+					Text: tt.text,
+					Chat: models.Chat{ID: 789},
+					From: &models.User{ID: 789, LanguageCode: tt.lang},
+				},
+			}
+			handler.HandleAlert(ctx, mBot, update)
+
+			if mBot.lastText != tt.wantText {
+				t.Errorf("text = %q, want %q", mBot.lastText, tt.wantText)
+			}
+
+			subscriptions, err := db.GetAlertSubscriptions(ctx, 100)
+			if err != nil {
+				t.Fatalf("GetAlertSubscriptions() error = %v", err)
+			}
+			if tt.wantThreshold == 0 {
+				if len(subscriptions) != 0 {
+					t.Errorf("subscriptions = %+v, want none", subscriptions)
+				}
+				return
+			}
+			if len(subscriptions) != 1 || subscriptions[0].Threshold != tt.wantThreshold {
+				t.Errorf("subscriptions = %+v, want threshold %d", subscriptions, tt.wantThreshold)
+			}
+		})
+	}
+}
+
+func TestSendAlert(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+
+	cfg := newTestConfig(456)
+	cfg.Base.TimeLocation = moscow
+	handler := NewBotHandler(newTestDB(t), cfg, nil)
+
+	subscription := databaser.Subscription{UserID: 789, Threshold: 80}
+	event := databaser.Event{Timestamp: time.Date(2026, 3, 10, 16, 5, 0, 0, time.UTC), Load: 85}
+
+	mBot := &mockBot{}
+	if err = handler.SendAlert(context.Background(), mBot, subscription, event); err != nil {
+		t.Fatalf("SendAlert() error = %v", err)
+	}
+	if mBot.lastChatID != int64(789) {
+		t.Errorf("chat ID = %v, want 789", mBot.lastChatID)
+	}
+	if want := "⚠️ Загрузка 85%, порог уведомлений 80%\nОбновлено: 10.03.2026 19:05"; mBot.lastText != want {
+		t.Errorf("text = %q, want %q", mBot.lastText, want)
+	}
+
+	mBot = &mockBot{sendMessageErr: errors.New("bot was blocked by the user")}
+	if err = handler.SendAlert(context.Background(), mBot, subscription, event); err == nil {
+		t.Error("SendAlert() error = nil, want send error")
+	}
+}
@@ -0,0 +1,80 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// defaultBusiestDayPeriod is a period of the /busiestday command if it is not set.
+const defaultBusiestDayPeriod = 8 * 7 * 24 * time.Hour
+
+// WrapHandleBusiestDay wraps HandleBusiestDay for bot.HandlerFunc compatibility.
+func (h *BotHandler) WrapHandleBusiestDay(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleBusiestDay(ctx, b, update)
+}
+
+// HandleBusiestDay handles the /busiestday command and sends weekdays ranked by the average load
+// for the given or default period, the period starts from the beginning of its first week.
+func (h *BotHandler) HandleBusiestDay(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	lang := h.language(update)
+	duration := defaultBusiestDayPeriod
+
+	if args := strings.Fields(update.Message.Text); len(args) > 1 {
+		var err error
+		duration, err = parsePeriod(strings.Join(args[1:], " "))
+		if err != nil {
+			sendErrorMessage(ctx, err, b, chatID, localize(lang, msgPeriodInvalid))
+			return
+		}
+	}
+	duration = h.limitPeriod(ctx, b, chatID, lang, duration)
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	end := time.Now().In(h.cfg.Base.TimeLocation)
+	start := weekStart(end.Add(-duration), h.cfg.Base.FirstWeekday)
+
+	loads, err := h.db.LoadByWeekday(opCtx, start, end, h.cfg.Base.TimeLocation, h.cfg.Base.FirstWeekday)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, lang, localize(lang, msgEventsFailed)))
+		return
+	}
+
+	if len(loads) == 0 {
+		sendErrorMessage(ctx, nil, b, chatID, localize(lang, msgBusiestDayEmpty))
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb,
+		localize(lang, msgBusiestDayTitle),
+		start.Format(dateFormat),
+		end.Format(dateFormat),
+	)
+
+	for i, load := range loads {
+		fmt.Fprintf(&sb, "\n%d. %s: %.0f%%", i+1, weekdayName(lang, load.Weekday), load.Avg)
+	}
+
+	sendLongMessage(ctx, b, chatID, sb.String())
+}
+
+// weekStart returns the beginning of the week containing t, the week starts from the first weekday.
+func weekStart(t time.Time, first time.Weekday) time.Time {
+	const daysInWeek = 7
+	days := (int(t.Weekday()) - int(first) + daysInWeek) % daysInWeek
+	year, month, day := t.Date()
+	return time.Date(year, month, day-days, 0, 0, 0, 0, t.Location())
+}
+
+// weekdayName returns the localized name of the weekday.
+func weekdayName(lang string, weekday time.Weekday) string {
+	return localize(lang, msgSunday+msgKey(weekday))
+}
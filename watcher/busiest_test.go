@@ -0,0 +1,139 @@
+package watcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func TestHandleBusiestDay(t *testing.T) {
+	db := newTestDB(t)
+	now := time.Now().UTC()
+
+	// three weeks of hourly events before the last week, the load depends on the weekday
+	var events []databaser.Event
+	for ts := now.AddDate(0, 0, -29); ts.Before(now.AddDate(0, 0, -8)); ts = ts.Add(time.Hour) {
+		events = append(events, databaser.Event{Timestamp: ts.Truncate(time.Second), Load: uint8(10 + 10*int(ts.Weekday()))})
+	}
+	if err := db.SaveManyEvents(context.Background(), events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	// the period starts from the beginning of the week
+	periodStart := func(first time.Weekday) string {
+		start := now.AddDate(0, 0, -21)
+		for start.Weekday() != first {
+			start = start.AddDate(0, 0, -1)
+		}
+		return start.Format(dateFormat) + " - "
+	}
+
+	tests := []struct {
+		name      string
+		text      string
+		lang      string
+		first     time.Weekday
+		wantLines []string
+	}{
+		{
+			name: "default period",
+			text: "/busiestday",
+			wantLines: []string{
+				"1. Суббота: 70%", "2. Пятница: 60%", "3. Четверг: 50%", "4. Среда: 40%",
+				"5. Вторник: 30%", "6. Понедельник: 20%", "7. Воскресенье: 10%",
+			},
+		},
+		{
+			name:      "english",
+			text:      "/busiestday 3 weeks",
+			lang:      "en",
+			first:     time.Monday,
+			wantLines: []string{"Average load by weekdays", periodStart(time.Monday), "1. Saturday: 70%", "7. Sunday: 10%"},
+		},
+		{
+			name:      "week starts on sunday",
+			text:      "/busiestday 3 weeks",
+			first:     time.Sunday,
+			wantLines: []string{periodStart(time.Sunday), "1. Суббота: 70%"},
+		},
+		{
+			name:      "no events for period",
+			text:      "/busiestday 1m",
+			wantLines: []string{localize(LangRU, msgBusiestDayEmpty)},
+		},
+		{
+			name:      "invalid period",
+			text:      "/busiestday soon",
+			wantLines: []string{localize(LangRU, msgPeriodInvalid)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(456)
+			cfg.Base.FirstWeekday = tt.first
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				Message: &models.Message{
+					Text: tt.text,
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456, LanguageCode: tt.lang},
+				},
+			}
+			handler.HandleBusiestDay(context.Background(), mBot, update)
+
+			if mBot.sendMessageCalls != 1 {
+				t.Fatalf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+			}
+			for _, want := range tt.wantLines {
+				if !strings.Contains(mBot.lastText, want) {
+					t.Errorf("message should contain %q, got: %s", want, mBot.lastText)
+				}
+			}
+		})
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	location := time.FixedZone("UTC+3", 3*60*60)
+	wednesday := time.Date(2024, 1, 17, 1, 30, 0, 0, location)
+
+	tests := []struct {
+		name  string
+		t     time.Time
+		first time.Weekday
+		want  time.Time
+	}{
+		{name: "monday", t: wednesday, first: time.Monday, want: time.Date(2024, 1, 15, 0, 0, 0, 0, location)},
+		{name: "sunday", t: wednesday, first: time.Sunday, want: time.Date(2024, 1, 14, 0, 0, 0, 0, location)},
+		{name: "first day", t: wednesday.AddDate(0, 0, -2), first: time.Monday, want: time.Date(2024, 1, 15, 0, 0, 0, 0, location)},
+		{name: "sunday with monday start", t: wednesday.AddDate(0, 0, 4), first: time.Monday, want: time.Date(2024, 1, 15, 0, 0, 0, 0, location)},
+		{name: "sunday with sunday start", t: wednesday.AddDate(0, 0, 4), first: time.Sunday, want: time.Date(2024, 1, 21, 0, 0, 0, 0, location)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weekStart(tt.t, tt.first); !got.Equal(tt.want) {
+				t.Errorf("weekStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWeekdayName(t *testing.T) {
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if got := weekdayName("en", wd); got != wd.String() {
+			t.Errorf("weekdayName(en, %d) = %q, want %q", wd, got, wd.String())
+		}
+		if got := weekdayName(LangRU, wd); got == "" {
+			t.Errorf("empty russian name of %s", wd)
+		}
+	}
+}
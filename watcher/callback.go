@@ -0,0 +1,130 @@
+package watcher
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// CallbackPrefix is the prefix of callback data of period buttons, the handler is registered by it.
+const CallbackPrefix = "period:"
+
+// Callback data of period buttons without CallbackPrefix.
+const (
+	callbackHalfDay = "halfday"
+	callbackDay     = "day"
+	callbackWeek    = "week"
+)
+
+// callbackPeriod is a graph period requested by a button.
+type callbackPeriod struct {
+	duration     time.Duration
+	predictHours uint8
+}
+
+// callbackPeriods maps callback data to graph periods, they are the same as ones of period commands.
+//
+//nolint:gochecknoglobals // package-level lookup table
+var callbackPeriods = map[string]callbackPeriod{
+	callbackHalfDay: {duration: 12 * time.Hour, predictHours: 16},
+	callbackDay:     {duration: 24 * time.Hour, predictHours: 24},
+	callbackWeek:    {duration: 7 * 24 * time.Hour, predictHours: 48},
+}
+
+// CallbackBotAPI defines the methods needed from the Telegram bot to handle callback queries.
+type CallbackBotAPI interface {
+	BotAPI
+	AnswerCallbackQuery(ctx context.Context, params *bot.AnswerCallbackQueryParams) (bool, error)
+}
+
+// WrapHandleCallback wraps HandleCallback for bot.HandlerFunc compatibility.
+func (h *BotHandler) WrapHandleCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleCallback(ctx, b, update)
+}
+
+// periodKeyboard returns inline buttons to request graphs of the periods.
+func periodKeyboard(lang string) *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{{
+			{Text: localize(lang, msgButtonHalfDay), CallbackData: CallbackPrefix + callbackHalfDay},
+			{Text: localize(lang, msgButtonDay), CallbackData: CallbackPrefix + callbackDay},
+			{Text: localize(lang, msgButtonWeek), CallbackData: CallbackPrefix + callbackWeek},
+		}},
+	}
+}
+
+// HandleCallback handles period buttons and sends the graph of the requested period.
+// Callback queries don't pass message middlewares, so the user access and maintenance mode are checked here.
+// The query is always answered, unknown data only stops the button loading indicator.
+func (h *BotHandler) HandleCallback(ctx context.Context, b CallbackBotAPI, update *models.Update) {
+	query := update.CallbackQuery
+	if query == nil {
+		slog.WarnContext(ctx, "callback query is nil")
+		return
+	}
+
+	lang := h.language(update)
+	userID := query.From.ID
+	slog.InfoContext(ctx, "callback query", "user_id", userID, "data", query.Data)
+
+	var (
+		answer string
+		period callbackPeriod
+	)
+	data, ok := strings.CutPrefix(query.Data, CallbackPrefix)
+	if ok {
+		period, ok = callbackPeriods[data]
+	}
+
+	switch {
+	case !ok:
+		slog.WarnContext(ctx, "unknown callback data", "user_id", userID, "data", query.Data)
+	case h.maintenance.Load() && !h.isAdmin(userID):
+		answer, ok = localize(lang, msgMaintenance), false
+	case !h.isAuthorized(ctx, userID):
+		answer, ok = localize(lang, msgAuthRequired), false
+	}
+
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID, Text: answer})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleCallback answer", "error", err)
+	}
+
+	if ok {
+		h.buildGraph(ctx, b, callbackChatID(query), lang, period.duration, period.predictHours)
+	}
+}
+
+// isAuthorized checks if the user is an admin or an approved user.
+func (h *BotHandler) isAuthorized(ctx context.Context, userID int64) bool {
+	if h.isAdmin(userID) {
+		return true
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	user, err := h.db.GetUser(opCtx, userID)
+	if err != nil {
+		slog.InfoContext(ctx, "user not found or error", "user_id", userID, "error", err)
+		return false
+	}
+
+	return user.IsApproved()
+}
+
+// callbackChatID returns the chat of the message with the button, it's the user private chat if it's unknown.
+func callbackChatID(query *models.CallbackQuery) int64 {
+	switch {
+	case query.Message.Message != nil:
+		return query.Message.Message.Chat.ID
+	case query.Message.InaccessibleMessage != nil:
+		return query.Message.InaccessibleMessage.Chat.ID
+	default:
+		return query.From.ID
+	}
+}
@@ -0,0 +1,179 @@
+package watcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func TestCallbackPeriodMapping(t *testing.T) {
+	tests := []struct {
+		data         string
+		duration     time.Duration
+		predictHours uint8
+	}{
+		{data: callbackHalfDay, duration: 12 * time.Hour, predictHours: 16},
+		{data: callbackDay, duration: 24 * time.Hour, predictHours: 24},
+		{data: callbackWeek, duration: 7 * 24 * time.Hour, predictHours: 48},
+	}
+
+	if len(callbackPeriods) != len(tests) {
+		t.Errorf("callback periods = %d, want %d", len(callbackPeriods), len(tests))
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			period, ok := callbackPeriods[tt.data]
+			if !ok {
+				t.Fatalf("callback %q is not mapped", tt.data)
+			}
+			if period.duration != tt.duration || period.predictHours != tt.predictHours {
+				t.Errorf("period = %v/%d, want %v/%d", period.duration, period.predictHours, tt.duration, tt.predictHours)
+			}
+		})
+	}
+
+	// every keyboard button has a mapped period
+	for _, button := range periodKeyboard(LangRU).InlineKeyboard[0] {
+		data, found := strings.CutPrefix(button.CallbackData, CallbackPrefix)
+		if !found {
+			t.Errorf("button %q data %q has no prefix", button.Text, button.CallbackData)
+		}
+		if _, ok := callbackPeriods[data]; !ok {
+			t.Errorf("button %q data %q is not mapped", button.Text, button.CallbackData)
+		}
+	}
+}
+
+func TestHandleCallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		userID      int64
+		status      uint8 // status of the seeded user 789
+		maintenance bool
+		wantAnswer  string
+		wantPhoto   bool
+	}{
+		{name: "admin day", data: CallbackPrefix + callbackDay, userID: 456, wantPhoto: true},
+		{name: "approved week", data: CallbackPrefix + callbackWeek, userID: 789, status: databaser.UserApproved, wantPhoto: true},
+		{name: "unknown data", data: CallbackPrefix + "month", userID: 456},
+		{name: "no prefix", data: "day", userID: 456},
+		{
+			name:       "pending user",
+			data:       CallbackPrefix + callbackHalfDay,
+			userID:     789,
+			status:     databaser.UserPending,
+			wantAnswer: localize(LangRU, msgAuthRequired),
+		},
+		{
+			name:       "unknown user",
+			data:       CallbackPrefix + callbackDay,
+			userID:     1000,
+			wantAnswer: localize(LangRU, msgAuthRequired),
+		},
+		{
+			name:        "maintenance",
+			data:        CallbackPrefix + callbackDay,
+			userID:      789,
+			status:      databaser.UserApproved,
+			maintenance: true,
+			wantAnswer:  localize(LangRU, msgMaintenance),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedEvents(t, db, 10)
+			seedUser(t, db, 789, tt.status, "user")
+
+			handler := NewBotHandler(db, newTestConfig(456), newTestController(t, db))
+			handler.maintenance.Store(tt.maintenance)
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				CallbackQuery: &models.CallbackQuery{
+					ID:      "query",
+					From:    models.User{ID: tt.userID},
+					Data:    tt.data,
+					Message: models.MaybeInaccessibleMessage{Message: &models.Message{Chat: models.Chat{ID: 123}}},
+				},
+			}
+			handler.HandleCallback(context.Background(), mBot, update)
+
+			if mBot.answerCalls != 1 {
+				t.Fatalf("AnswerCallbackQuery called %d times, want 1", mBot.answerCalls)
+			}
+			if mBot.lastAnswer.CallbackQueryID != "query" || mBot.lastAnswer.Text != tt.wantAnswer {
+				t.Errorf("answer = %+v, want text %q", mBot.lastAnswer, tt.wantAnswer)
+			}
+
+			if got := mBot.sendPhotoCalls == 1; got != tt.wantPhoto {
+				t.Fatalf("SendPhoto called %d times, want photo %v", mBot.sendPhotoCalls, tt.wantPhoto)
+			}
+			if tt.wantPhoto && mBot.lastChatID != int64(123) {
+				t.Errorf("chat ID = %v, want 123", mBot.lastChatID)
+			}
+		})
+	}
+}
+
+func TestHandleCallback_NoQuery(t *testing.T) {
+	handler := NewBotHandler(newTestDB(t), newTestConfig(456), nil)
+	mBot := &mockBot{}
+
+	handler.HandleCallback(context.Background(), mBot, &models.Update{})
+
+	if mBot.answerCalls != 0 || mBot.sendPhotoCalls != 0 {
+		t.Errorf("answer calls = %d, photo calls = %d, want none", mBot.answerCalls, mBot.sendPhotoCalls)
+	}
+}
+
+func TestHandleStart_PeriodKeyboard(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       uint8
+		wantKeyboard bool
+	}{
+		{name: "approved", status: databaser.UserApproved, wantKeyboard: true},
+		{name: "pending", status: databaser.UserPending},
+		{name: "rejected", status: databaser.UserRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedUser(t, db, 789, tt.status, "user")
+			handler := NewBotHandler(db, newTestConfig(456), nil)
+			// no admins to notify, so the last message is the user reply
+			handler.SetAdmins(NewAdminSet(nil))
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				Message: &models.Message{Chat: models.Chat{ID: 789}, From: &models.User{ID: 789, LanguageCode: "en"}, Text: "/start"},
+			}
+			handler.HandleStart(context.Background(), mBot, update)
+
+			if mBot.sendMessageCalls != 1 {
+				t.Fatalf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+			}
+
+			keyboard, ok := mBot.lastReplyMarkup.(*models.InlineKeyboardMarkup)
+			if ok != tt.wantKeyboard {
+				t.Fatalf("reply markup = %T, want keyboard %v", mBot.lastReplyMarkup, tt.wantKeyboard)
+			}
+			if ok && keyboard.InlineKeyboard[0][1].CallbackData != CallbackPrefix+callbackDay {
+				t.Errorf("day button data = %q", keyboard.InlineKeyboard[0][1].CallbackData)
+			}
+			if ok && keyboard.InlineKeyboard[0][0].Text != "Half day 🕒" {
+				t.Errorf("half day button text = %q", keyboard.InlineKeyboard[0][0].Text)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// defaultClubPeriod is a graph period of the /club command if it is not set.
+const defaultClubPeriod = 24 * time.Hour
+
+// WrapHandleClub wraps HandleClub for bot.HandlerFunc compatibility.
+func (h *BotHandler) WrapHandleClub(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleClub(ctx, b, update)
+}
+
+// HandleClub handles the /club command and sends the load graph of the club for the given or default period.
+func (h *BotHandler) HandleClub(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	lang := h.language(update)
+	args := strings.Fields(update.Message.Text)
+
+	if len(args) < 2 {
+		sendErrorMessage(ctx, nil, b, chatID, fmt.Sprintf(localize(lang, msgClubUsage), h.clubList()))
+		return
+	}
+
+	clubID, err := strconv.Atoi(args[1])
+	if err != nil || !h.cfg.Fetcher.HasClub(clubID) {
+		sendErrorMessage(ctx, err, b, chatID, fmt.Sprintf(localize(lang, msgClubUsage), h.clubList()))
+		return
+	}
+
+	duration := defaultClubPeriod
+	if len(args) > 2 {
+		duration, err = parsePeriod(strings.Join(args[2:], " "))
+		if err != nil {
+			sendErrorMessage(ctx, err, b, chatID, localize(lang, msgPeriodInvalid))
+			return
+		}
+	}
+
+	duration = h.limitPeriod(ctx, b, chatID, lang, duration)
+	h.buildClubGraph(ctx, b, chatID, lang, duration, calculatePredictHours(duration), clubID)
+}
+
+// clubList returns comma-separated numbers of available clubs.
+func (h *BotHandler) clubList() string {
+	ids := make([]string, 0, len(h.cfg.Fetcher.Clubs)+1)
+	ids = append(ids, strconv.Itoa(databaser.DefaultClubID))
+
+	for _, club := range h.cfg.Fetcher.Clubs {
+		ids = append(ids, strconv.Itoa(club.ID))
+	}
+
+	return strings.Join(ids, ", ")
+}
@@ -0,0 +1,150 @@
+package watcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/config"
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func TestHandleClub(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantPhoto   bool
+		wantText    string
+		wantCaption string
+	}{
+		{
+			name:        "club with default period",
+			text:        "/club 2",
+			wantPhoto:   true,
+			wantCaption: "Клуб 2\n",
+		},
+		{
+			name:        "club with period",
+			text:        "/club 2 2 days",
+			wantPhoto:   true,
+			wantCaption: "Клуб 2\n",
+		},
+		{
+			name:      "main club",
+			text:      "/club 0 12h",
+			wantPhoto: true,
+		},
+		{
+			name:     "missing club",
+			text:     "/club",
+			wantText: "Используйте: /club <номер> [период], доступные клубы: 0, 2.",
+		},
+		{
+			name:     "unknown club",
+			text:     "/club 3",
+			wantText: "Используйте: /club <номер> [период], доступные клубы: 0, 2.",
+		},
+		{
+			name:     "invalid period",
+			text:     "/club 2 yesterday",
+			wantText: localize(LangRU, msgPeriodInvalid),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedEvents(t, db, 10)
+			seedClubEvents(t, db, 2, 10)
+
+			cfg := newTestConfig(456)
+			cfg.Fetcher.Clubs = []config.Club{{ID: 2, URL: "http://localhost/club2"}}
+			handler := NewBotHandler(db, cfg, newTestController(t, db))
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				Message: &models.Message{
+					Text: tt.text,
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+				},
+			}
+			handler.HandleClub(context.Background(), mBot, update)
+
+			if !tt.wantPhoto {
+				if mBot.sendPhotoCalls != 0 {
+					t.Errorf("SendPhoto called %d times, want 0", mBot.sendPhotoCalls)
+				}
+				if mBot.lastText != tt.wantText {
+					t.Errorf("text = %q, want %q", mBot.lastText, tt.wantText)
+				}
+				return
+			}
+
+			if mBot.sendPhotoCalls != 1 {
+				t.Fatalf("SendPhoto called %d times, want 1", mBot.sendPhotoCalls)
+			}
+			if tt.wantCaption == "" {
+				if strings.HasPrefix(mBot.lastCaption, "Клуб") {
+					t.Errorf("unexpected club title in main club caption %q", mBot.lastCaption)
+				}
+				return
+			}
+			if !strings.HasPrefix(mBot.lastCaption, tt.wantCaption) {
+				t.Errorf("caption = %q, want prefix %q", mBot.lastCaption, tt.wantCaption)
+			}
+			// predictions are built only for the main club
+			if strings.Contains(mBot.lastCaption, "%") {
+				t.Errorf("unexpected confidence in club caption %q", mBot.lastCaption)
+			}
+		})
+	}
+}
+
+func TestHandleClub_NoEvents(t *testing.T) {
+	db := newTestDB(t)
+	seedEvents(t, db, 10)
+
+	cfg := newTestConfig(456)
+	cfg.Fetcher.Clubs = []config.Club{{ID: 2, URL: "http://localhost/club2"}}
+	handler := NewBotHandler(db, cfg, nil)
+	mBot := &mockBot{}
+
+	update := &models.Update{
+		Message: &models.Message{
+			Text: "/club 2",
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 456},
+		},
+	}
+	handler.HandleClub(context.Background(), mBot, update)
+
+	if mBot.sendPhotoCalls != 0 {
+		t.Errorf("SendPhoto called %d times, want 0", mBot.sendPhotoCalls)
+	}
+	if want := localize(LangRU, msgTooFewEvents); mBot.lastText != want {
+		t.Errorf("text = %q, want %q", mBot.lastText, want)
+	}
+}
+
+// seedClubEvents saves hourly events of the club before the current time.
+func seedClubEvents(t *testing.T, db *databaser.DB, clubID, count int) {
+	t.Helper()
+	now := time.Now().UTC()
+	events := make([]databaser.Event, count)
+
+	for i := range events {
+		events[i] = databaser.Event{
+			Timestamp: now.Add(-time.Duration(count-i) * time.Hour),
+			ClubID:    clubID,
+			Load:      uint8(10 + i),
+		}
+	}
+
+	if err := db.SaveManyEvents(context.Background(), events); err != nil {
+		t.Fatalf("failed to seed club events: %v", err)
+	}
+}
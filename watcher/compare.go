@@ -0,0 +1,110 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/databaser"
+	"github.com/z0rr0/ggp/plotter"
+)
+
+// defaultComparePeriod is a period of the /compare command if it is not set.
+const defaultComparePeriod = 7 * 24 * time.Hour
+
+// WrapHandleCompare wraps HandleCompare for bot.HandlerFunc compatibility.
+func (h *BotHandler) WrapHandleCompare(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleCompare(ctx, b, update)
+}
+
+// HandleCompare handles the /compare command and sends a graph of the given or default period
+// with the load of the previous period of the same length aligned with it.
+func (h *BotHandler) HandleCompare(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	lang := h.language(update)
+	duration := defaultComparePeriod
+
+	if args := strings.Fields(update.Message.Text); len(args) > 1 {
+		var err error
+		duration, err = parsePeriod(strings.Join(args[1:], " "))
+		if err != nil {
+			sendErrorMessage(ctx, err, b, chatID, localize(lang, msgPeriodInvalid))
+			return
+		}
+	}
+	duration = h.limitPeriod(ctx, b, chatID, lang, duration)
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	events, err := h.clubEvents(opCtx, 2*duration, databaser.DefaultClubID)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, lang, localize(lang, msgEventsFailed)))
+		return
+	}
+
+	end := time.Now()
+	start := end.Add(-duration)
+	current, previous := splitCompare(events, start, duration)
+	if len(current) < 2 || len(previous) < 2 {
+		sendErrorMessage(ctx, nil, b, chatID, localize(lang, msgTooFewEvents))
+		return
+	}
+
+	location := h.cfg.Base.TimeLocation
+	caption := fmt.Sprintf(
+		localize(lang, msgCompareTitle),
+		start.In(location).Format(dateTimeFormat), end.In(location).Format(dateTimeFormat),
+		start.Add(-duration).In(location).Format(dateTimeFormat), start.In(location).Format(dateTimeFormat),
+	)
+
+	imageData, err := h.graph(
+		current, nil, location, plotter.GraphFormat(h.cfg.Telegram.GraphFormat),
+		plotter.WithSeries(plotter.Series{Name: "Previous", Points: previous}),
+		plotter.WithWatermark(h.cfg.Telegram.Watermark),
+		plotter.WithCompact(h.cfg.Telegram.CompactGraph),
+		plotter.WithHolidays(h.holidayChecker()),
+		plotter.WithMaxPoints(maxGraphPoints),
+		plotter.WithCapacity(float64(h.cfg.Base.Capacity)),
+	)
+	if errors.Is(err, plotter.ErrRender) {
+		slog.ErrorContext(ctx, "compare graph render failed, send text summary", "error", err)
+		sendLongMessage(ctx, b, chatID, graphSummary(lang, current)+"\n"+caption)
+		return
+	}
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, localize(lang, msgGraphFailed))
+		return
+	}
+
+	if err = opCtx.Err(); err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, lang, localize(lang, msgGraphFailed)))
+		return
+	}
+
+	h.sendGraph(ctx, b, chatID, lang, imageData, caption)
+}
+
+// splitCompare splits sorted events by the start of the current period,
+// the previous events are shifted forward by the period, so both curves have the same time axis.
+func splitCompare(events []databaser.Event, start time.Time, period time.Duration) ([]databaser.Event, []databaser.Event) {
+	var current, previous []databaser.Event
+
+	for _, event := range events {
+		if !event.Timestamp.Before(start) {
+			current = append(current, event)
+			continue
+		}
+
+		event.Timestamp = event.Timestamp.Add(period)
+		previous = append(previous, event)
+	}
+
+	return current, previous
+}
@@ -0,0 +1,105 @@
+package watcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/databaser"
+	"github.com/z0rr0/ggp/plotter"
+)
+
+func TestHandleCompare(t *testing.T) {
+	db := newTestDB(t)
+	// hourly events of more than two weeks
+	seedEvents(t, db, 400)
+
+	tests := []struct {
+		name        string
+		text        string
+		lang        string
+		wantCaption string
+		wantText    string
+		wantEvents  int
+	}{
+		{name: "default period", text: "/compare", wantCaption: "Пунктир: предыдущий период", wantEvents: 168},
+		{name: "custom period", text: "/compare 2d", lang: "en", wantCaption: "Dashed: the previous period", wantEvents: 48},
+		{name: "too few events", text: "/compare 30d", wantText: localize(LangRU, msgTooFewEvents)},
+		{name: "invalid period", text: "/compare soon", wantText: localize(LangRU, msgPeriodInvalid)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBotHandler(db, newTestConfig(456), nil)
+			mBot := &mockBot{}
+
+			var graphEvents []databaser.Event
+			handler.graph = func(
+				events, prediction []databaser.Event, location *time.Location, format plotter.GraphFormat, opts ...plotter.Option,
+			) ([]byte, error) {
+				graphEvents = events
+				return plotter.GraphWithFormat(events, prediction, location, format, opts...)
+			}
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456, LanguageCode: tt.lang},
+					Text: tt.text,
+				},
+			}
+			handler.HandleCompare(context.Background(), mBot, update)
+
+			if tt.wantText != "" {
+				if mBot.lastText != tt.wantText || mBot.sendPhotoCalls != 0 {
+					t.Errorf("response = %q with %d photos, want %q", mBot.lastText, mBot.sendPhotoCalls, tt.wantText)
+				}
+				return
+			}
+
+			if mBot.sendPhotoCalls != 1 {
+				t.Fatalf("SendPhoto called %d times, want 1", mBot.sendPhotoCalls)
+			}
+			if !strings.Contains(mBot.lastCaption, tt.wantCaption) {
+				t.Errorf("caption %q should contain %q", mBot.lastCaption, tt.wantCaption)
+			}
+			if n := len(graphEvents); n < tt.wantEvents-1 || n > tt.wantEvents {
+				t.Errorf("graph events = %d, want about %d of the current period", n, tt.wantEvents)
+			}
+			if _, ok := handler.graphs.get(123); !ok {
+				t.Error("compare graph should be kept for /again")
+			}
+		})
+	}
+}
+
+func TestSplitCompare(t *testing.T) {
+	start := time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)
+	events := []databaser.Event{
+		{Timestamp: start.Add(-48 * time.Hour), Load: 10},
+		{Timestamp: start.Add(-time.Hour), Load: 20},
+		{Timestamp: start, Load: 30},
+		{Timestamp: start.Add(time.Hour), Load: 40},
+	}
+
+	current, previous := splitCompare(events, start, 24*time.Hour)
+
+	if len(current) != 2 || current[0].Load != 30 || !current[1].Timestamp.Equal(start.Add(time.Hour)) {
+		t.Errorf("current = %v, want the last two events as is", current)
+	}
+	wantPrevious := []time.Time{start.Add(-24 * time.Hour), start.Add(23 * time.Hour)}
+	if len(previous) != len(wantPrevious) {
+		t.Fatalf("previous = %v, want %d events", previous, len(wantPrevious))
+	}
+	for i, want := range wantPrevious {
+		if !previous[i].Timestamp.Equal(want) {
+			t.Errorf("previous[%d] timestamp = %v, want %v shifted by the period", i, previous[i].Timestamp, want)
+		}
+	}
+	if !events[0].Timestamp.Equal(start.Add(-48 * time.Hour)) {
+		t.Error("source events should not be changed")
+	}
+}
@@ -0,0 +1,48 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// WrapHandleCurrent wraps HandleCurrent for bot.HandlerFunc compatibility.
+func (h *BotHandler) WrapHandleCurrent(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleCurrent(ctx, b, update)
+}
+
+// HandleCurrent handles the /current command and sends the latest load value without a graph.
+func (h *BotHandler) HandleCurrent(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	lang := h.language(update)
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	event, err := h.db.GetLatestEvent(opCtx)
+	if err != nil {
+		if errors.Is(err, databaser.ErrEventNotFound) {
+			sendErrorMessage(ctx, nil, b, chatID, localize(lang, msgCurrentEmpty))
+			return
+		}
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, lang, localize(lang, msgEventsFailed)))
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text: fmt.Sprintf(
+			localize(lang, msgCurrent), event.Load,
+			event.Timestamp.In(h.cfg.Base.TimeLocation).Format(dateTimeFormat),
+		),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleCurrent", "error", err)
+	}
+}
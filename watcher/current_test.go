@@ -0,0 +1,76 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func TestHandleCurrent(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("failed to load location: %v", err)
+	}
+	ts := time.Date(2026, 3, 10, 16, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		events   []databaser.Event
+		lang     string
+		wantText string
+	}{
+		{
+			name:     "no events",
+			wantText: "Нет данных о загрузке.",
+		},
+		{
+			name: "latest event",
+			events: []databaser.Event{
+				{Timestamp: ts.Add(-time.Hour), Load: 20},
+				{Timestamp: ts, Load: 42},
+				{Timestamp: ts.Add(time.Minute), ClubID: 2, Load: 90},
+			},
+			wantText: "Текущая загрузка: 42%\nОбновлено: 10.03.2026 19:05",
+		},
+		{
+			name:     "english",
+			events:   []databaser.Event{{Timestamp: ts, Load: 7}},
+			lang:     "en",
+			wantText: "Current load: 7%\nUpdated: 10.03.2026 19:05",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			if err = db.SaveManyEvents(context.Background(), tt.events); err != nil {
+				t.Fatalf("failed to save events: %v", err)
+			}
+
+			cfg := newTestConfig(456)
+			cfg.Base.TimeLocation = moscow
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				Message: &models.Message{
+					Text: "/" + CmdCurrent,
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456, LanguageCode: tt.lang},
+				},
+			}
+			handler.HandleCurrent(context.Background(), mBot, update)
+
+			if mBot.sendMessageCalls != 1 {
+				t.Fatalf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+			}
+			if mBot.lastText != tt.wantText {
+				t.Errorf("text = %q, want %q", mBot.lastText, tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// maxLastGraphs is the maximum number of chats to keep the last sent graph for.
+const maxLastGraphs = 256
+
+// lastGraph is the last graph sent to a chat.
+// If Telegram returned a file ID, it is used to re-send the graph without uploading, otherwise data is kept.
+type lastGraph struct {
+	created  time.Time
+	fileID   string
+	filename string
+	caption  string
+	data     []byte
+	document bool
+}
+
+// graphStore keeps the last sent graph for every chat, the oldest graph is dropped when the limit is reached.
+type graphStore struct {
+	items map[int64]*lastGraph
+	mu    sync.Mutex
+}
+
+// set saves the last graph for the chat.
+func (gs *graphStore) set(chatID int64, graph *lastGraph) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	if gs.items == nil {
+		gs.items = make(map[int64]*lastGraph)
+	}
+
+	if _, ok := gs.items[chatID]; !ok && len(gs.items) >= maxLastGraphs {
+		var (
+			oldestID int64
+			oldest   time.Time
+		)
+		for id, item := range gs.items {
+			if oldest.IsZero() || item.created.Before(oldest) {
+				oldestID, oldest = id, item.created
+			}
+		}
+		delete(gs.items, oldestID)
+	}
+
+	gs.items[chatID] = graph
+}
+
+// get returns the last graph for the chat.
+func (gs *graphStore) get(chatID int64) (*lastGraph, bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	graph, ok := gs.items[chatID]
+	return graph, ok
+}
+
+// WrapHandleAgain wraps HandleAgain for bot.HandlerFunc compatibility.
+func (h *BotHandler) WrapHandleAgain(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleAgain(ctx, b, update)
+}
+
+// HandleAgain re-sends the last graph sent to the chat.
+func (h *BotHandler) HandleAgain(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	lang := h.language(update)
+
+	graph, ok := h.graphs.get(chatID)
+	if !ok {
+		sendErrorMessage(ctx, nil, b, chatID, localize(lang, msgNoGraph))
+		return
+	}
+
+	if err := resendGraph(ctx, b, chatID, graph); err != nil {
+		sendErrorMessage(ctx, err, b, chatID, localize(lang, msgGraphSendFailed))
+	}
+}
+
+// resendGraph sends the saved graph using its file ID if it is known.
+func resendGraph(ctx context.Context, b BotAPI, chatID int64, graph *lastGraph) error {
+	if graph.fileID == "" {
+		_, err := sendImage(ctx, b, chatID, graph.data, graph.filename, graph.caption)
+		return err
+	}
+
+	file := &models.InputFileString{Data: graph.fileID}
+	if graph.document {
+		_, err := b.SendDocument(ctx, &bot.SendDocumentParams{ChatID: chatID, Document: file, Caption: graph.caption})
+		if err != nil {
+			return fmt.Errorf("send document: %w", err)
+		}
+		return nil
+	}
+
+	_, err := b.SendPhoto(ctx, &bot.SendPhotoParams{ChatID: chatID, Photo: file, Caption: graph.caption})
+	if err != nil {
+		return fmt.Errorf("send photo: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestGraphStore(t *testing.T) {
+	var gs graphStore
+	baseTime := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+
+	if _, ok := gs.get(1); ok {
+		t.Fatal("empty store should not have graphs")
+	}
+
+	for i := range maxLastGraphs + 1 {
+		gs.set(int64(i), &lastGraph{created: baseTime.Add(time.Duration(i) * time.Second)})
+	}
+
+	if n := len(gs.items); n != maxLastGraphs {
+		t.Errorf("store has %d graphs, want %d", n, maxLastGraphs)
+	}
+	if _, ok := gs.get(0); ok {
+		t.Error("the oldest graph should be dropped")
+	}
+	if _, ok := gs.get(maxLastGraphs); !ok {
+		t.Error("the newest graph should be kept")
+	}
+
+	// replacing an existing chat graph doesn't drop others
+	gs.set(1, &lastGraph{created: baseTime.Add(time.Hour), caption: "updated"})
+	if n := len(gs.items); n != maxLastGraphs {
+		t.Errorf("store has %d graphs, want %d", n, maxLastGraphs)
+	}
+	if graph, ok := gs.get(1); !ok || graph.caption != "updated" {
+		t.Errorf("graph was not updated: %v", graph)
+	}
+}
+
+func TestHandleAgain(t *testing.T) {
+	tests := []struct {
+		name          string
+		photoFileID   string
+		buildGraph    bool
+		wantPhotoCall int
+		wantMsgCalls  int
+		wantFileID    bool
+	}{
+		{name: "no graph yet", wantMsgCalls: 1},
+		{name: "cached file id", buildGraph: true, photoFileID: "file-123", wantPhotoCall: 2, wantFileID: true},
+		{name: "cached image data", buildGraph: true, wantPhotoCall: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedEvents(t, db, 3)
+			cfg := newTestConfig(456)
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{photoFileID: tt.photoFileID}
+			ctx := context.Background()
+
+			if tt.buildGraph {
+				handler.buildGraph(ctx, mBot, 123, LangRU, 24*time.Hour, 6)
+			}
+			caption := mBot.lastCaption
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: "/again",
+				},
+			}
+			handler.HandleAgain(ctx, mBot, update)
+
+			if mBot.sendPhotoCalls != tt.wantPhotoCall {
+				t.Errorf("SendPhoto called %d times, want %d", mBot.sendPhotoCalls, tt.wantPhotoCall)
+			}
+			if mBot.sendMessageCalls != tt.wantMsgCalls {
+				t.Errorf("SendMessage called %d times, want %d", mBot.sendMessageCalls, tt.wantMsgCalls)
+			}
+			if !tt.buildGraph {
+				return
+			}
+
+			if mBot.lastCaption != caption {
+				t.Errorf("caption = %q, want %q", mBot.lastCaption, caption)
+			}
+
+			file, isFileID := mBot.lastPhoto.(*models.InputFileString)
+			if isFileID != tt.wantFileID {
+				t.Fatalf("photo sent by file id = %v, want %v", isFileID, tt.wantFileID)
+			}
+			if isFileID && file.Data != tt.photoFileID {
+				t.Errorf("file id = %q, want %q", file.Data, tt.photoFileID)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package watcher
+
+import (
+	"strings"
+
+	"github.com/go-telegram/bot/models"
+)
+
+// Supported languages of user messages.
+const (
+	LangRU = "ru"
+	LangEN = "en"
+)
+
+// msgKey is a key of a localized user message.
+type msgKey uint8
+
+// User message keys.
+const (
+	msgTimeout msgKey = iota
+	msgAdminStart
+	msgRequestFailed
+	msgRequestAccepted
+	msgAlreadyActive
+	msgRequestRejected
+	msgStopped
+	msgHolidaysUsage
+	msgHolidaysFailed
+	msgHolidaysEmpty
+	msgHolidaysTitle
+	msgPeriodInvalid
+	msgEventsFailed
+	msgTooFewEvents
+	msgGraphFailed
+	msgGraphSendFailed
+	msgConfidence
+	msgWarmup
+	msgStale
+	msgNoGraph
+	msgAdminOnly
+	msgAuthRequired
+	msgMaintenance
+	msgPredictionDisabled
+	msgTodayOver
+	msgTodayTitle
+	msgTodayCapped
+	msgTodayPeak
+	msgTodayLow
+	msgTodayModerate
+	msgTodayHigh
+	msgPeriodLimited
+	msgClubUsage
+	msgClubTitle
+	msgGraphFallback
+	msgCurrent
+	msgCurrentEmpty
+	msgBusiestDayTitle
+	msgBusiestDayEmpty
+	msgCompareTitle
+	msgRateLimited
+	msgAlertUsage
+	msgAlertSet
+	msgAlertOff
+	msgAlertNone
+	msgAlertFailed
+	msgAlertDisabled
+	msgAlert
+	msgButtonHalfDay
+	msgButtonDay
+	msgButtonWeek
+	// weekday names are ordered as time.Weekday values
+	msgSunday
+	msgMonday
+	msgTuesday
+	msgWednesday
+	msgThursday
+	msgFriday
+	msgSaturday
+)
+
+// catalogs contains user messages by languages, every catalog must have all message keys.
+//
+//nolint:gochecknoglobals // package-level lookup table
+var catalogs = map[string]map[msgKey]string{
+	LangRU: {
+		msgTimeout:            "Превышено время ожидания ответа, попробуйте позже.",
+		msgAdminStart:         "Вы являетесь администратором бота.",
+		msgRequestFailed:      "Не удалось обработать ваш запрос",
+		msgRequestAccepted:    "Ваш запрос принят, дождитесь подтверждения.",
+		msgAlreadyActive:      "Бот уже активен. Используйте команды для получения графиков.",
+		msgRequestRejected:    "Ваш запрос отклонён.",
+		msgStopped:            "Бот остановлен. Чтобы начать снова, используйте команду /start.",
+		msgHolidaysUsage:      "Используйте: /holidays <год>",
+		msgHolidaysFailed:     "Не удалось получить список праздников.",
+		msgHolidaysEmpty:      "Нет данных о праздниках за %d год.",
+		msgHolidaysTitle:      "Праздники за %d год:\n",
+		msgPeriodInvalid:      "Не удалось распознать период, поддерживаемые форматы: 6h, 1h30m, 3d, 1w, 2 days.",
+		msgEventsFailed:       "Не удалось получить данные за указанный период",
+		msgTooFewEvents:       "Слишком мало данных за указанный период для построения графика",
+		msgGraphFailed:        "Не удалось построить график",
+		msgGraphSendFailed:    "Не удалось отправить график",
+		msgConfidence:         "\nДостоверность прогноза: %.0f%%",
+		msgWarmup:             "Недостаточно данных для прогноза, он появится после накопления статистики.",
+		msgStale:              "⚠️ Данные могут быть устаревшими, последнее обновление %s",
+		msgNoGraph:            "Графиков ещё не было, запросите период, например /day",
+		msgAdminOnly:          "Эта команда доступна только администраторам.",
+		msgAuthRequired:       "Команда доступна только после запуска бота и подтверждения администраторами.",
+		msgMaintenance:        "Идут технические работы, попробуйте позже.",
+		msgRateLimited:        "Слишком часто, попробуйте позже.",
+		msgPredictionDisabled: "Прогнозирование отключено.",
+		msgTodayOver:          "День почти закончился, прогноз на сегодня недоступен.",
+		msgTodayTitle:         "Прогноз на остаток дня, %s - %s",
+		msgTodayCapped:        " (ограничен %d ч.)",
+		msgTodayPeak:          "Пик в %s: %.0f%%",
+		msgTodayLow:           "Будет свободно.",
+		msgTodayModerate:      "Ожидается средняя загрузка.",
+		msgTodayHigh:          "Будет многолюдно.",
+		msgPeriodLimited:      "Период ограничен до %s.",
+		msgClubUsage:          "Используйте: /club <номер> [период], доступные клубы: %s.",
+		msgClubTitle:          "Клуб %d",
+		msgCurrent:            "Текущая загрузка: %d%%\nОбновлено: %s",
+		msgCurrentEmpty:       "Нет данных о загрузке.",
+		msgBusiestDayTitle:    "Средняя загрузка по дням недели, %s - %s:",
+		msgBusiestDayEmpty:    "Нет данных за указанный период.",
+		msgCompareTitle:       "Загрузка за %s - %s\nПунктир: предыдущий период, %s - %s",
+		msgAlertUsage:         "Используйте: /alert <загрузка от 1 до 100>, чтобы получать уведомления, или /alert off, чтобы отписаться.",
+		msgAlertSet:           "Вы получите уведомление, когда загрузка достигнет %d%%.",
+		msgAlertOff:           "Уведомления о загрузке отключены.",
+		msgAlertNone:          "Вы не подписаны на уведомления о загрузке.",
+		msgAlertFailed:        "Не удалось сохранить подписку.",
+		msgAlertDisabled:      "Уведомления о загрузке отключены администратором.",
+		msgAlert:              "⚠️ Загрузка %d%%, порог уведомлений %d%%\nОбновлено: %s",
+		msgButtonHalfDay:      "Полдня 🕒",
+		msgButtonDay:          "День 📅",
+		msgButtonWeek:         "Неделя 📆",
+		msgSunday:             "Воскресенье",
+		msgMonday:             "Понедельник",
+		msgTuesday:            "Вторник",
+		msgWednesday:          "Среда",
+		msgThursday:           "Четверг",
+		msgFriday:             "Пятница",
+		msgSaturday:           "Суббота",
+		msgGraphFallback:      "Не удалось построить график, краткая сводка:\n%s\nМин. %d%%, средняя %.0f%%, макс. %d%%, последняя %d%%",
+	},
+	LangEN: {
+		msgTimeout:            "The response timed out, please try again later.",
+		msgAdminStart:         "You are the bot administrator.",
+		msgRequestFailed:      "Failed to process your request",
+		msgRequestAccepted:    "Your request is accepted, please wait for approval.",
+		msgAlreadyActive:      "The bot is already active. Use commands to get graphs.",
+		msgRequestRejected:    "Your request is rejected.",
+		msgStopped:            "The bot is stopped. To start again, use the /start command.",
+		msgHolidaysUsage:      "Usage: /holidays <year>",
+		msgHolidaysFailed:     "Failed to get holidays.",
+		msgHolidaysEmpty:      "No holidays data for %d.",
+		msgHolidaysTitle:      "Holidays of %d:\n",
+		msgPeriodInvalid:      "Failed to parse the period, supported formats: 6h, 1h30m, 3d, 1w, 2 days.",
+		msgEventsFailed:       "Failed to get data for the period",
+		msgTooFewEvents:       "Too little data for the period to build a graph",
+		msgGraphFailed:        "Failed to build the graph",
+		msgGraphSendFailed:    "Failed to send the graph",
+		msgConfidence:         "\nPrediction confidence: %.0f%%",
+		msgWarmup:             "Insufficient data for forecast, it will appear once enough events are collected.",
+		msgStale:              "⚠️ Data may be stale, last update %s",
+		msgNoGraph:            "No graphs yet, request a period, for example /day",
+		msgAdminOnly:          "This command is available to administrators only.",
+		msgAuthRequired:       "The command is available only after starting the bot and approval by administrators.",
+		msgMaintenance:        "Maintenance is in progress, please try again later.",
+		msgRateLimited:        "Too many requests, please try again later.",
+		msgPredictionDisabled: "Prediction is disabled.",
+		msgTodayOver:          "The day is almost over, there is no forecast for today.",
+		msgTodayTitle:         "Forecast for the rest of the day, %s - %s",
+		msgTodayCapped:        " (limited to %d h)",
+		msgTodayPeak:          "Peak at %s: %.0f%%",
+		msgTodayLow:           "It will be free.",
+		msgTodayModerate:      "Moderate load is expected.",
+		msgTodayHigh:          "It will be crowded.",
+		msgPeriodLimited:      "The period is limited to %s.",
+		msgClubUsage:          "Use: /club <number> [period], available clubs: %s.",
+		msgClubTitle:          "Club %d",
+		msgCurrent:            "Current load: %d%%\nUpdated: %s",
+		msgCurrentEmpty:       "No load data yet.",
+		msgBusiestDayTitle:    "Average load by weekdays, %s - %s:",
+		msgBusiestDayEmpty:    "No data for the period.",
+		msgCompareTitle:       "Load for %s - %s\nDashed: the previous period, %s - %s",
+		msgAlertUsage:         "Use: /alert <load from 1 to 100> to get alerts, or /alert off to unsubscribe.",
+		msgAlertSet:           "You will be alerted when the load reaches %d%%.",
+		msgAlertOff:           "Load alerts are disabled.",
+		msgAlertNone:          "You are not subscribed to load alerts.",
+		msgAlertFailed:        "Failed to save the subscription.",
+		msgAlertDisabled:      "Load alerts are disabled by administrators.",
+		msgAlert:              "⚠️ Load is %d%%, alert threshold %d%%\nUpdated: %s",
+		msgButtonHalfDay:      "Half day 🕒",
+		msgButtonDay:          "Day 📅",
+		msgButtonWeek:         "Week 📆",
+		msgSunday:             "Sunday",
+		msgMonday:             "Monday",
+		msgTuesday:            "Tuesday",
+		msgWednesday:          "Wednesday",
+		msgThursday:           "Thursday",
+		msgFriday:             "Friday",
+		msgSaturday:           "Saturday",
+		msgGraphFallback:      "Failed to build the graph, short summary:\n%s\nMin %d%%, average %.0f%%, max %d%%, last %d%%",
+	},
+}
+
+// userLanguage returns the supported language of the update sender,
+// or the default language if there is no catalog for the sender's Telegram language.
+func userLanguage(update *models.Update, defaultLang string) string {
+	var from *models.User
+	if update != nil {
+		switch {
+		case update.Message != nil:
+			from = update.Message.From
+		case update.CallbackQuery != nil:
+			from = &update.CallbackQuery.From
+		}
+	}
+
+	if from != nil {
+		// language code is IETF tag like "en" or "en-US"
+		code, _, _ := strings.Cut(strings.ToLower(from.LanguageCode), "-")
+		if _, ok := catalogs[code]; ok {
+			return code
+		}
+	}
+
+	return defaultLang
+}
+
+// localize returns the message text in the language, Russian is used for unknown languages.
+func localize(lang string, key msgKey) string {
+	catalog, ok := catalogs[lang]
+	if !ok {
+		catalog = catalogs[LangRU]
+	}
+
+	return catalog[key]
+}
+
+// language returns the language of messages for the update sender.
+func (h *BotHandler) language(update *models.Update) string {
+	return userLanguage(update, h.cfg.Base.Language)
+}
@@ -0,0 +1,82 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-telegram/bot/models"
+)
+
+func TestCatalogsComplete(t *testing.T) {
+	ru := catalogs[LangRU]
+	for lang, catalog := range catalogs {
+		if len(catalog) != len(ru) {
+			t.Errorf("catalog %q has %d messages, want %d", lang, len(catalog), len(ru))
+		}
+		for key := range ru {
+			if catalog[key] == "" {
+				t.Errorf("catalog %q has no message %d", lang, key)
+			}
+		}
+	}
+}
+
+func TestUserLanguage(t *testing.T) {
+	tests := []struct {
+		name        string
+		update      *models.Update
+		defaultLang string
+		want        string
+	}{
+		{name: "nil update", defaultLang: LangRU, want: LangRU},
+		{name: "no sender", update: &models.Update{Message: &models.Message{}}, defaultLang: LangEN, want: LangEN},
+		{name: "english", update: updateWithLanguage("en"), defaultLang: LangRU, want: LangEN},
+		{name: "english with region", update: updateWithLanguage("en-US"), defaultLang: LangRU, want: LangEN},
+		{name: "russian", update: updateWithLanguage("RU"), defaultLang: LangEN, want: LangRU},
+		{name: "unsupported language", update: updateWithLanguage("es"), defaultLang: LangEN, want: LangEN},
+		{name: "empty language", update: updateWithLanguage(""), defaultLang: LangRU, want: LangRU},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := userLanguage(tt.update, tt.defaultLang); got != tt.want {
+				t.Errorf("userLanguage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerUserLanguage(t *testing.T) {
+	tests := []struct {
+		name         string
+		languageCode string
+		want         string
+	}{
+		{name: "english user", languageCode: "en", want: catalogs[LangEN][msgHolidaysUsage]},
+		{name: "spanish user falls back", languageCode: "es", want: catalogs[LangRU][msgHolidaysUsage]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBotHandler(nil, newTestConfig(), nil)
+			mBot := &mockBot{}
+			update := updateWithLanguage(tt.languageCode)
+			update.Message.Text = "/holidays abc"
+
+			handler.HandleHolidays(context.Background(), mBot, update)
+
+			if mBot.lastText != tt.want {
+				t.Errorf("got message %q, want %q", mBot.lastText, tt.want)
+			}
+		})
+	}
+}
+
+func updateWithLanguage(code string) *models.Update {
+	return &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 456, LanguageCode: code},
+		},
+	}
+}
@@ -35,7 +35,7 @@ func BotLoggingMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
 }
 
 // BotAdminOnlyMiddleware is a middleware that allows only admin users to proceed.
-func BotAdminOnlyMiddleware(adminUserIDs map[int64]struct{}) func(next bot.HandlerFunc) bot.HandlerFunc {
+func BotAdminOnlyMiddleware(admins *AdminSet, defaultLang string) func(next bot.HandlerFunc) bot.HandlerFunc {
 	return func(next bot.HandlerFunc) bot.HandlerFunc {
 		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
 			if emptyUpdate(update) {
@@ -44,9 +44,9 @@ func BotAdminOnlyMiddleware(adminUserIDs map[int64]struct{}) func(next bot.Handl
 			}
 
 			userID := update.Message.From.ID
-			if _, ok := adminUserIDs[userID]; !ok {
+			if !admins.Contains(userID) {
 				slog.InfoContext(ctx, "unauthorized admin user", "user_id", userID, "username", update.Message.From.Username)
-				sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Эта команда доступна только администраторам.")
+				sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, localize(userLanguage(update, defaultLang), msgAdminOnly))
 				return
 			}
 
@@ -58,7 +58,7 @@ func BotAdminOnlyMiddleware(adminUserIDs map[int64]struct{}) func(next bot.Handl
 
 // BotAuthMiddleware is a middleware that checks if the user is authorized.
 // It's not a clean middleware, but a wrapper to prepare it with admin and DB users.
-func BotAuthMiddleware(adminUserIDs map[int64]struct{}, db *databaser.DB) func(next bot.HandlerFunc) bot.HandlerFunc {
+func BotAuthMiddleware(admins *AdminSet, db *databaser.DB, defaultLang string) func(next bot.HandlerFunc) bot.HandlerFunc {
 	return func(next bot.HandlerFunc) bot.HandlerFunc {
 		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
 			if emptyUpdate(update) {
@@ -67,7 +67,7 @@ func BotAuthMiddleware(adminUserIDs map[int64]struct{}, db *databaser.DB) func(n
 			}
 
 			userID := update.Message.From.ID
-			if _, ok := adminUserIDs[userID]; ok {
+			if admins.Contains(userID) {
 				next(ctx, b, update)
 				return
 			}
@@ -76,13 +76,13 @@ func BotAuthMiddleware(adminUserIDs map[int64]struct{}, db *databaser.DB) func(n
 			user, err := db.GetUser(ctx, userID)
 			if err != nil {
 				slog.InfoContext(ctx, "user not found or error", "user_id", userID, "error", err)
-				sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Команда доступна только после запуска бота и подтверждения администраторами.")
+				sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, localize(userLanguage(update, defaultLang), msgAuthRequired))
 				return
 			}
 
 			if !user.IsApproved() {
 				slog.InfoContext(ctx, "user not approved", "user_id", userID, "username", update.Message.From.Username)
-				sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Команда доступна только после запуска бота и подтверждения администраторами.")
+				sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, localize(userLanguage(update, defaultLang), msgAuthRequired))
 				return
 			}
 
@@ -92,6 +92,55 @@ func BotAuthMiddleware(adminUserIDs map[int64]struct{}, db *databaser.DB) func(n
 	}
 }
 
+// BotRateLimitMiddleware is a middleware that limits requests of each user by a token bucket.
+// The rate is a number of requests per second, burst is a number of requests allowed at once.
+// If the rate is not positive, requests are not limited.
+func BotRateLimitMiddleware(rate float64, burst int, defaultLang string) func(next bot.HandlerFunc) bot.HandlerFunc {
+	if rate <= 0 {
+		return func(next bot.HandlerFunc) bot.HandlerFunc {
+			return next
+		}
+	}
+
+	limiter := newRateLimiter(rate, burst)
+	return func(next bot.HandlerFunc) bot.HandlerFunc {
+		return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			if emptyUpdate(update) {
+				slog.WarnContext(ctx, "rate limit middleware: update is nil")
+				return
+			}
+
+			userID := update.Message.From.ID
+			if !limiter.allow(userID) {
+				slog.InfoContext(ctx, "request rate limited", "user_id", userID)
+				sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, localize(userLanguage(update, defaultLang), msgRateLimited))
+				return
+			}
+
+			next(ctx, b, update)
+		}
+	}
+}
+
+// MaintenanceMiddleware is a middleware that refuses non-admin requests while the maintenance mode is on.
+func (h *BotHandler) MaintenanceMiddleware(next bot.HandlerFunc) bot.HandlerFunc {
+	return func(ctx context.Context, b *bot.Bot, update *models.Update) {
+		if emptyUpdate(update) {
+			slog.WarnContext(ctx, "maintenance middleware: update is nil")
+			return
+		}
+
+		userID := update.Message.From.ID
+		if h.maintenance.Load() && !h.isAdmin(userID) {
+			slog.InfoContext(ctx, "request refused in maintenance mode", "user_id", userID)
+			sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, localize(h.language(update), msgMaintenance))
+			return
+		}
+
+		next(ctx, b, update)
+	}
+}
+
 // generateRequestID generates a new request ID.
 func generateRequestID() uint64 {
 	return rand.Uint64() // #nosec G404 // cryptographically insecure is fine here
@@ -2,6 +2,8 @@ package watcher
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -110,7 +112,7 @@ func TestBotAdminOnlyMiddleware(t *testing.T) {
 				called = true
 			}
 
-			middleware := BotAdminOnlyMiddleware(adminIDs)(next)
+			middleware := BotAdminOnlyMiddleware(NewAdminSet(adminIDs), LangRU)(next)
 			ctx := context.Background()
 
 			middleware(ctx, nil, tt.update)
@@ -193,7 +195,7 @@ func TestBotAuthMiddleware(t *testing.T) {
 				called = true
 			}
 
-			middleware := BotAuthMiddleware(adminIDs, db)(next)
+			middleware := BotAuthMiddleware(NewAdminSet(adminIDs), db, LangRU)(next)
 			ctx := context.Background()
 
 			middleware(ctx, nil, tt.update)
@@ -239,7 +241,7 @@ func TestBotAdminOnlyMiddleware_EmptyAdminList(t *testing.T) {
 		called = true
 	}
 
-	middleware := BotAdminOnlyMiddleware(adminIDs)(next)
+	middleware := BotAdminOnlyMiddleware(NewAdminSet(adminIDs), LangRU)(next)
 
 	// With empty admin list and nil message, middleware should return early without calling next
 	// (nil message test avoids the sendErrorMessage that would panic with nil bot)
@@ -252,3 +254,148 @@ func TestBotAdminOnlyMiddleware_EmptyAdminList(t *testing.T) {
 		t.Error("next should not be called with nil message")
 	}
 }
+
+// newTestBot creates a bot connected to a fake Telegram API server and returns a counter of its requests.
+func newTestBot(t *testing.T) (*bot.Bot, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(`{"ok":true,"result":{"message_id":1}}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	b, err := bot.New("test-token", bot.WithSkipGetMe(), bot.WithServerURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create test bot: %v", err)
+	}
+
+	return b, &requests
+}
+
+func TestMaintenanceMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		userID       int64
+		maintenance  bool
+		wantCalled   bool
+		wantRequests int32
+	}{
+		{
+			name:       "user without maintenance",
+			userID:     200,
+			wantCalled: true,
+		},
+		{
+			name:       "admin without maintenance",
+			userID:     100,
+			wantCalled: true,
+		},
+		{
+			name:         "user in maintenance",
+			userID:       200,
+			maintenance:  true,
+			wantRequests: 1,
+		},
+		{
+			name:        "admin in maintenance",
+			userID:      100,
+			maintenance: true,
+			wantCalled:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBotHandler(newTestDB(t), newTestConfig(100), nil)
+			handler.maintenance.Store(tt.maintenance)
+			b, requests := newTestBot(t)
+
+			var called bool
+			next := func(_ context.Context, _ *bot.Bot, _ *models.Update) {
+				called = true
+			}
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: tt.userID},
+				},
+			}
+
+			handler.MaintenanceMiddleware(next)(context.Background(), b, update)
+
+			if called != tt.wantCalled {
+				t.Errorf("next called = %v, want %v", called, tt.wantCalled)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("bot requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestMaintenanceMiddleware_NilMessage(t *testing.T) {
+	handler := NewBotHandler(newTestDB(t), newTestConfig(100), nil)
+	handler.maintenance.Store(true)
+
+	var called bool
+	next := func(_ context.Context, _ *bot.Bot, _ *models.Update) {
+		called = true
+	}
+
+	handler.MaintenanceMiddleware(next)(context.Background(), nil, &models.Update{})
+
+	if called {
+		t.Error("next should not be called with nil message")
+	}
+}
+
+func TestBotRateLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name         string
+		rate         float64
+		burst        int
+		calls        int
+		wantCalled   int
+		wantRequests int32
+	}{
+		{name: "disabled", calls: 5, wantCalled: 5},
+		{name: "within burst", rate: 0.1, burst: 3, calls: 3, wantCalled: 3},
+		{name: "exceeded burst", rate: 0.1, burst: 3, calls: 5, wantCalled: 3, wantRequests: 2},
+		{name: "zero burst", rate: 0.1, calls: 2, wantCalled: 1, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, requests := newTestBot(t)
+
+			var called int
+			next := func(_ context.Context, _ *bot.Bot, _ *models.Update) {
+				called++
+			}
+			middleware := BotRateLimitMiddleware(tt.rate, tt.burst, LangRU)(next)
+
+			for range tt.calls {
+				middleware(context.Background(), b, &models.Update{
+					Message: &models.Message{Chat: models.Chat{ID: 123}, From: &models.User{ID: 200}},
+				})
+			}
+			// other users are limited separately
+			middleware(context.Background(), b, &models.Update{
+				Message: &models.Message{Chat: models.Chat{ID: 124}, From: &models.User{ID: 201}},
+			})
+
+			if called != tt.wantCalled+1 {
+				t.Errorf("next called %d times, want %d", called, tt.wantCalled+1)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("bot requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errInvalidPeriod is returned if a custom period can not be parsed.
+var errInvalidPeriod = errors.New("invalid period")
+
+// periodToken matches a number with a unit, units can be separated by spaces, e.g. "1 day 2h".
+var periodToken = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([a-z]+)\s*`) //nolint:gochecknoglobals
+
+// periodUnits contains supported units of a custom period.
+//
+//nolint:gochecknoglobals // package-level lookup table
+var periodUnits = map[string]time.Duration{
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"h": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"s": time.Second, "sec": time.Second, "second": time.Second, "seconds": time.Second,
+}
+
+// parsePeriod parses a period like "3d", "1w", "1h30m" or "2 days".
+// Weeks and days suffixes and space-separated units are supported,
+// other values are parsed by time.ParseDuration. The period must be positive.
+func parsePeriod(text string) (time.Duration, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+
+	period, ok := parsePeriodUnits(text)
+	if !ok {
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return 0, fmt.Errorf("%w %q: %w", errInvalidPeriod, text, err)
+		}
+		period = d
+	}
+
+	if period <= 0 {
+		return 0, fmt.Errorf("%w %q: not positive", errInvalidPeriod, text)
+	}
+
+	return period, nil
+}
+
+// parsePeriodUnits parses a sequence of numbers with units, it returns false for unknown formats.
+func parsePeriodUnits(text string) (time.Duration, bool) {
+	var period float64
+
+	if text == "" {
+		return 0, false
+	}
+
+	for text != "" {
+		m := periodToken.FindStringSubmatch(text)
+		if m == nil {
+			return 0, false
+		}
+
+		unit, ok := periodUnits[m[2]]
+		if !ok {
+			return 0, false
+		}
+
+		value, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, false
+		}
+
+		period += value * float64(unit)
+		text = text[len(m[0]):]
+	}
+
+	if period > float64(1<<63-1) {
+		return 0, false // overflow
+	}
+
+	return time.Duration(period), true
+}
+
+// formatPeriod returns a short period representation, whole days are shown as days.
+func formatPeriod(period time.Duration) string {
+	const day = 24 * time.Hour
+
+	if period >= day && period%day == 0 {
+		return fmt.Sprintf("%dd", period/day)
+	}
+
+	s := period.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+
+	return s
+}
@@ -0,0 +1,75 @@
+package watcher
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "hours", text: "6h", want: 6 * time.Hour},
+		{name: "hours and minutes", text: "1h30m", want: 90 * time.Minute},
+		{name: "days", text: "3d", want: 72 * time.Hour},
+		{name: "week", text: "1w", want: 7 * 24 * time.Hour},
+		{name: "day with space", text: "1 day", want: 24 * time.Hour},
+		{name: "days with space", text: "2 days", want: 48 * time.Hour},
+		{name: "mixed units", text: "1w 2d 3h", want: 9*24*time.Hour + 3*time.Hour},
+		{name: "fractional days", text: "1.5d", want: 36 * time.Hour},
+		{name: "upper case", text: " 2D ", want: 48 * time.Hour},
+		{name: "go duration fallback", text: "1500ms", want: 1500 * time.Millisecond},
+		{name: "empty", text: "", wantErr: true},
+		{name: "text", text: "invalid", wantErr: true},
+		{name: "unknown unit", text: "3y", wantErr: true},
+		{name: "number without unit", text: "5", wantErr: true},
+		{name: "zero", text: "0d", wantErr: true},
+		{name: "negative", text: "-1h", wantErr: true},
+		{name: "trailing garbage", text: "1d foo", wantErr: true},
+		{name: "overflow", text: "999999999w", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePeriod(tt.text)
+
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidPeriod) {
+					t.Errorf("parsePeriod(%q) error = %v, want %v", tt.text, err, errInvalidPeriod)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parsePeriod(%q) unexpected error: %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("parsePeriod(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatPeriod(t *testing.T) {
+	tests := []struct {
+		period time.Duration
+		want   string
+	}{
+		{period: 90 * 24 * time.Hour, want: "90d"},
+		{period: 24 * time.Hour, want: "1d"},
+		{period: 36 * time.Hour, want: "36h"},
+		{period: 90 * time.Minute, want: "1h30m"},
+		{period: 30 * time.Second, want: "30s"},
+		{period: 10 * time.Minute, want: "10m"},
+	}
+
+	for _, tt := range tests {
+		if got := formatPeriod(tt.period); got != tt.want {
+			t.Errorf("formatPeriod(%v) = %q, want %q", tt.period, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package watcher
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimitEvictPeriod is a minimal period between removals of idle users buckets.
+const rateLimitEvictPeriod = 10 * time.Minute
+
+// tokenBucket is a rate limit state of one user.
+type tokenBucket struct {
+	last   time.Time
+	tokens float64
+}
+
+// rateLimiter is a token bucket rate limiter keyed by user ID.
+// Buckets of idle users are refilled completely, so they are removed and created again if needed.
+type rateLimiter struct {
+	lastEvict time.Time
+	now       func() time.Time
+	buckets   map[int64]*tokenBucket
+	rate      float64 // tokens per second
+	burst     float64
+	mu        sync.Mutex
+}
+
+// newRateLimiter creates a new rate limiter with the rate in tokens per second and the bucket size burst.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		now:     time.Now,
+		buckets: make(map[int64]*tokenBucket),
+		rate:    rate,
+		burst:   float64(max(burst, 1)),
+	}
+}
+
+// allow takes a token of the user and returns false if there are no tokens.
+func (l *rateLimiter) allow(userID int64) bool {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.evict(now)
+
+	bucket, ok := l.buckets[userID]
+	if !ok {
+		bucket = &tokenBucket{last: now, tokens: l.burst}
+		l.buckets[userID] = bucket
+	}
+
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+	return true
+}
+
+// evict removes buckets which are full again, should be called with lock held.
+func (l *rateLimiter) evict(now time.Time) {
+	if now.Sub(l.lastEvict) < rateLimitEvictPeriod {
+		return
+	}
+	l.lastEvict = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for userID, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(l.buckets, userID)
+		}
+	}
+}
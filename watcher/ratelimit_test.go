@@ -0,0 +1,61 @@
+package watcher
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(0.5, 2)
+	limiter.now = func() time.Time { return now }
+
+	for i, want := range []bool{true, true, false} {
+		if got := limiter.allow(1); got != want {
+			t.Errorf("allow() call %d = %v, want %v", i, got, want)
+		}
+	}
+
+	// one token is refilled in 2 seconds
+	now = now.Add(2 * time.Second)
+	if !limiter.allow(1) {
+		t.Error("allow() = false after refill")
+	}
+	if limiter.allow(1) {
+		t.Error("allow() = true after refilled token is taken")
+	}
+
+	// idle users are evicted
+	limiter.allow(2)
+	now = now.Add(rateLimitEvictPeriod)
+	limiter.allow(3)
+	if _, ok := limiter.buckets[1]; ok {
+		t.Error("idle user bucket is not evicted")
+	}
+	if n := len(limiter.buckets); n != 1 {
+		t.Errorf("buckets count = %d, want 1", n)
+	}
+}
+
+func TestRateLimiter_Concurrent(t *testing.T) {
+	limiter := newRateLimiter(0.001, 10)
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int32
+	)
+	for range 50 {
+		wg.Go(func() {
+			if limiter.allow(1) {
+				allowed.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+
+	if n := allowed.Load(); n != 10 {
+		t.Errorf("allowed = %d, want 10", n)
+	}
+}
@@ -0,0 +1,114 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/config"
+	"github.com/z0rr0/ggp/databaser"
+)
+
+// Load levels of the today forecast, in percent.
+const (
+	todayLowLoad  = 30
+	todayHighLoad = 60
+)
+
+// WrapHandleToday wraps HandleToday for bot.HandlerFunc compatibility.
+func (h *BotHandler) WrapHandleToday(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleToday(ctx, b, update)
+}
+
+// HandleToday handles the /today command and sends a forecast summary until the end of the local day.
+func (h *BotHandler) HandleToday(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	lang := h.language(update)
+
+	if h.pc == nil {
+		sendErrorMessage(ctx, nil, b, chatID, localize(lang, msgPredictionDisabled))
+		return
+	}
+
+	if !h.pc.Ready() {
+		sendErrorMessage(ctx, nil, b, chatID, localize(lang, msgWarmup))
+		return
+	}
+
+	hours, capped := todayHours(time.Now(), h.cfg.Base.TimeLocation, h.pc.Hours)
+	if hours == 0 {
+		sendErrorMessage(ctx, nil, b, chatID, localize(lang, msgTodayOver))
+		return
+	}
+
+	// the first event is the current typical load, not a prediction
+	predictions := h.pc.PredictLoad(hours)[1:]
+
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   todaySummary(lang, predictions, &h.cfg.Base, capped),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleToday", "error", err)
+	}
+}
+
+// todayHours returns the number of whole hours from now until the end of the local day,
+// limited by the predictor horizon, and whether the limit was applied.
+func todayHours(now time.Time, loc *time.Location, horizon uint8) (uint8, bool) {
+	now = now.In(loc)
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+	hours := int(midnight.Sub(now) / time.Hour)
+
+	if hours > int(horizon) {
+		return horizon, true
+	}
+
+	// #nosec G115 -- a day has no more than 25 hours
+	return uint8(hours), false
+}
+
+// todaySummary returns a compact text summary of the predictions: the period, the peak hour and load,
+// and a one-line forecast by the peak load level. Hours are formatted by the base hour format.
+func todaySummary(lang string, predictions []databaser.Event, base *config.Base, capped bool) string {
+	if len(predictions) == 0 {
+		return localize(lang, msgTodayOver)
+	}
+
+	peak := predictions[0]
+	for _, p := range predictions[1:] {
+		if p.Predict > peak.Predict {
+			peak = p
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb,
+		localize(lang, msgTodayTitle),
+		base.HourTime(predictions[0].Timestamp),
+		base.HourTime(predictions[len(predictions)-1].Timestamp),
+	)
+	if capped {
+		fmt.Fprintf(&sb, localize(lang, msgTodayCapped), len(predictions))
+	}
+
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, localize(lang, msgTodayPeak), base.HourLabel(peak.Timestamp), peak.Predict)
+	sb.WriteString("\n")
+
+	switch {
+	case peak.Predict < todayLowLoad:
+		sb.WriteString(localize(lang, msgTodayLow))
+	case peak.Predict < todayHighLoad:
+		sb.WriteString(localize(lang, msgTodayModerate))
+	default:
+		sb.WriteString(localize(lang, msgTodayHigh))
+	}
+
+	return sb.String()
+}
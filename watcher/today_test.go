@@ -0,0 +1,180 @@
+package watcher
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/config"
+	"github.com/z0rr0/ggp/databaser"
+)
+
+func TestTodayHours(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		loc        *time.Location
+		horizon    uint8
+		wantHours  uint8
+		wantCapped bool
+	}{
+		{name: "morning", now: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC), loc: time.UTC, horizon: 24, wantHours: 14},
+		{name: "partial hour", now: time.Date(2025, 1, 6, 10, 30, 0, 0, time.UTC), loc: time.UTC, horizon: 24, wantHours: 13},
+		{name: "last whole hour", now: time.Date(2025, 1, 6, 23, 0, 0, 0, time.UTC), loc: time.UTC, horizon: 24, wantHours: 1},
+		{name: "day is over", now: time.Date(2025, 1, 6, 23, 30, 0, 0, time.UTC), loc: time.UTC, horizon: 24, wantHours: 0},
+		{name: "midnight", now: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), loc: time.UTC, horizon: 24, wantHours: 24},
+		{name: "horizon cap", now: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC), loc: time.UTC, horizon: 4, wantHours: 4, wantCapped: true},
+		{name: "horizon equals rest", now: time.Date(2025, 1, 6, 20, 0, 0, 0, time.UTC), loc: time.UTC, horizon: 4, wantHours: 4},
+		{name: "local day ends earlier", now: time.Date(2025, 1, 6, 20, 0, 0, 0, time.UTC), loc: moscow, horizon: 24, wantHours: 1},
+		{name: "local day has started", now: time.Date(2025, 1, 6, 21, 0, 0, 0, time.UTC), loc: moscow, horizon: 24, wantHours: 24},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hours, capped := todayHours(tt.now, tt.loc, tt.horizon)
+
+			if hours != tt.wantHours {
+				t.Errorf("hours = %d, want %d", hours, tt.wantHours)
+			}
+			if capped != tt.wantCapped {
+				t.Errorf("capped = %v, want %v", capped, tt.wantCapped)
+			}
+		})
+	}
+}
+
+func TestTodaySummary(t *testing.T) {
+	start := time.Date(2025, 1, 6, 15, 0, 0, 0, time.UTC)
+	events := func(loads ...float64) []databaser.Event {
+		result := make([]databaser.Event, len(loads))
+		for i, load := range loads {
+			result[i] = databaser.Event{Timestamp: start.Add(time.Duration(i) * time.Hour), Predict: load}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name         string
+		hourLayout   string
+		hourEnd      string
+		predictions  []databaser.Event
+		capped       bool
+		wantContains []string
+	}{
+		{
+			name:         "no predictions",
+			wantContains: []string{"День почти закончился"},
+		},
+		{
+			name:         "high load peak",
+			predictions:  events(40, 72.4, 55),
+			wantContains: []string{"15:00 - 17:00", "Пик в 16:00: 72%", "Будет многолюдно."},
+		},
+		{
+			name:         "moderate load",
+			predictions:  events(30, 45),
+			wantContains: []string{"Пик в 16:00: 45%", "Ожидается средняя загрузка."},
+		},
+		{
+			name:         "low load capped",
+			predictions:  events(10, 20, 15),
+			capped:       true,
+			wantContains: []string{"(ограничен 3 ч.)", "Пик в 16:00: 20%", "Будет свободно."},
+		},
+		{
+			name:         "hour range format",
+			hourLayout:   "15:04",
+			hourEnd:      "15:04",
+			predictions:  events(40, 72.4, 55),
+			wantContains: []string{"15:00 - 17:00", "Пик в 16:00–17:00: 72%"},
+		},
+		{
+			name:         "12-hour format",
+			hourLayout:   "3 PM",
+			predictions:  events(40, 72.4, 55),
+			wantContains: []string{"3 PM - 5 PM", "Пик в 4 PM: 72%"},
+		},
+		{
+			name:         "12-hour range format",
+			hourLayout:   "3 PM",
+			hourEnd:      "3 PM",
+			predictions:  events(40, 72.4, 55),
+			wantContains: []string{"3 PM - 5 PM", "Пик в 4 PM–5 PM: 72%"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := config.Base{TimeLocation: time.UTC, HourLayout: "15:04", HourEndLayout: tt.hourEnd}
+			if tt.hourLayout != "" {
+				base.HourLayout = tt.hourLayout
+			}
+			got := todaySummary(LangRU, tt.predictions, &base, tt.capped)
+
+			for _, want := range tt.wantContains {
+				if !strings.Contains(got, want) {
+					t.Errorf("summary should contain %q, got: %s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleToday(t *testing.T) {
+	db := newTestDB(t)
+	seedEvents(t, db, 48)
+
+	tests := []struct {
+		name    string
+		handler *BotHandler
+		want    []string
+	}{
+		{
+			name:    "prediction disabled",
+			handler: NewBotHandler(db, newTestConfig(), nil),
+			want:    []string{localize(LangRU, msgPredictionDisabled)},
+		},
+		{
+			name:    "warmup",
+			handler: NewBotHandler(db, newTestConfig(), newTestWarmupController(t, db, 100)),
+			want:    []string{localize(LangRU, msgWarmup)},
+		},
+		{
+			name:    "summary or day is over",
+			handler: NewBotHandler(db, newTestConfig(), newTestController(t, db)),
+			want:    []string{"Прогноз на остаток дня", localize(LangRU, msgTodayOver)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mBot := &mockBot{}
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: "/today",
+				},
+			}
+
+			tt.handler.HandleToday(context.Background(), mBot, update)
+
+			if mBot.sendMessageCalls != 1 {
+				t.Fatalf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+			}
+
+			found := false
+			for _, want := range tt.want {
+				found = found || strings.Contains(mBot.lastText, want)
+			}
+			if !found {
+				t.Errorf("unexpected message: %s", mBot.lastText)
+			}
+		})
+	}
+}
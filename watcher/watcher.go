@@ -4,16 +4,23 @@ package watcher
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
 	"github.com/jmoiron/sqlx"
 
+	"github.com/z0rr0/ggp/cacher"
 	"github.com/z0rr0/ggp/config"
 	"github.com/z0rr0/ggp/databaser"
+	"github.com/z0rr0/ggp/fetcher"
 	"github.com/z0rr0/ggp/plotter"
 	"github.com/z0rr0/ggp/predictor"
 )
@@ -22,20 +29,40 @@ import (
 type BotAPI interface {
 	SendMessage(ctx context.Context, params *bot.SendMessageParams) (*models.Message, error)
 	SendPhoto(ctx context.Context, params *bot.SendPhotoParams) (*models.Message, error)
+	SendDocument(ctx context.Context, params *bot.SendDocumentParams) (*models.Message, error)
 }
 
 // Telegram bot command constants.
 const (
-	CmdStart   = "start"
-	CmdStop    = "stop"
-	CmdID      = "id"
-	CmdWeek    = "week"
-	CmdDay     = "day"
-	CmdHalfDay = "halfday"
+	CmdStart      = "start"
+	CmdStop       = "stop"
+	CmdID         = "id"
+	CmdWeek       = "week"
+	CmdDay        = "day"
+	CmdHalfDay    = "halfday"
+	CmdHolidays   = "holidays"
+	CmdAgain      = "again"
+	CmdToday      = "today"
+	CmdClub       = "club"
+	CmdCurrent    = "current"
+	CmdBusiestDay = "busiestday"
+	CmdAlert      = "alert"
+	CmdCompare    = "compare"
 )
 
 const (
 	dateTimeFormat = "02.01.2006 15:04"
+	dateFormat     = "02.01.2006"
+	// maxMessageLength is Telegram limit for a text message length in runes.
+	maxMessageLength = 4096
+	// maxPhotoSize is Telegram limit for a photo size, larger images are sent as documents.
+	maxPhotoSize = 10 << 20
+	// maxGraphPoints is the maximum number of events drawn on a graph, longer periods are downsampled.
+	maxGraphPoints = 2000
+	// hourlyGraphPeriod is the longest period drawn by fetched events, longer ones are drawn by hourly averages.
+	hourlyGraphPeriod = 48 * time.Hour
+	// sparklineWidth is the number of bars of the text summary sent if a graph can't be rendered.
+	sparklineWidth = 30
 )
 
 var (
@@ -45,6 +72,10 @@ var (
 		//	Command:     CmdStart,
 		//	Description: "Начать работу с ботом 🤖",
 		// },
+		{
+			Command:     CmdCurrent,
+			Description: "Показать текущую загрузку 📊",
+		},
 		{
 			Command:     CmdHalfDay,
 			Description: "Показать график за полдня 🕒",
@@ -57,6 +88,30 @@ var (
 			Command:     CmdWeek,
 			Description: "Показать график за неделю 📆",
 		},
+		{
+			Command:     CmdCompare,
+			Description: "Сравнить с прошлой неделей ⚖️",
+		},
+		{
+			Command:     CmdToday,
+			Description: "Прогноз на остаток дня 🔮",
+		},
+		{
+			Command:     CmdBusiestDay,
+			Description: "Самые загруженные дни недели 📈",
+		},
+		{
+			Command:     CmdAlert,
+			Description: "Уведомлять о высокой загрузке 🔔",
+		},
+		{
+			Command:     CmdAgain,
+			Description: "Повторить последний график 🔁",
+		},
+		{
+			Command:     CmdHolidays,
+			Description: "Показать праздничные дни 🎉",
+		},
 		{
 			Command:     CmdStop,
 			Description: "Остановить работу с ботом 🛑",
@@ -70,15 +125,50 @@ var (
 
 // BotHandler handles Telegram bot interactions for displaying load graphs.
 type BotHandler struct {
-	db       *databaser.DB
-	cfg      *config.Config
-	pc       *predictor.Controller
-	adminIDs map[int64]struct{}
+	db          *databaser.DB
+	cfg         *config.Config
+	pc          *predictor.Controller
+	adminIDs    *AdminSet
+	cache       *cacher.Cache  // recent events of the main club, it's nil if disabled
+	fetcher     FetchScheduler // running fetcher for /nextfetch command, it's nil if disabled
+	graph       graphFunc      // graph renderer, it's replaced in tests
+	graphs      graphStore     // last sent graphs for /again command
+	maintenance atomic.Bool    // maintenance mode, user commands are refused
+}
+
+// FetchScheduler reports when the next load fetch is scheduled, it's implemented by fetcher.Fetcher.
+type FetchScheduler interface {
+	NextFetch() (fetcher.Schedule, bool)
 }
 
+// graphFunc renders a load graph image, it's the signature of plotter.GraphWithFormat.
+type graphFunc func(
+	events, prediction []databaser.Event, location *time.Location, format plotter.GraphFormat, opts ...plotter.Option,
+) ([]byte, error)
+
 // NewBotHandler creates a new BotHandler with the given dependencies.
 func NewBotHandler(db *databaser.DB, cfg *config.Config, pc *predictor.Controller) *BotHandler {
-	return &BotHandler{db: db, cfg: cfg, pc: pc, adminIDs: cfg.Base.AdminIDs}
+	return &BotHandler{db: db, cfg: cfg, pc: pc, adminIDs: NewAdminSet(cfg.Base.AdminIDs), graph: plotter.GraphWithFormat}
+}
+
+// Admins returns the admin set of the handler, it's shared with middlewares and updated on config reload.
+func (h *BotHandler) Admins() *AdminSet {
+	return h.adminIDs
+}
+
+// SetAdmins replaces the admin set of the handler by a shared one, it should be called before handling updates.
+func (h *BotHandler) SetAdmins(admins *AdminSet) {
+	h.adminIDs = admins
+}
+
+// SetCache sets the cache of recent events, short period graphs of the main club are built from it.
+func (h *BotHandler) SetCache(cache *cacher.Cache) {
+	h.cache = cache
+}
+
+// SetFetcher sets the running fetcher to report its schedule.
+func (h *BotHandler) SetFetcher(f FetchScheduler) {
+	h.fetcher = f
 }
 
 // Wrapper methods for bot.HandlerFunc compatibility
@@ -113,6 +203,11 @@ func (h *BotHandler) WrapHandleID(ctx context.Context, b *bot.Bot, update *model
 	h.HandleID(ctx, b, update)
 }
 
+// WrapHandleHolidays wraps HandleHolidays for bot.HandlerFunc compatibility.
+func (h *BotHandler) WrapHandleHolidays(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleHolidays(ctx, b, update)
+}
+
 // WrapDefaultHandler wraps DefaultHandler for bot.HandlerFunc compatibility.
 func (h *BotHandler) WrapDefaultHandler(ctx context.Context, b *bot.Bot, update *models.Update) {
 	h.DefaultHandler(ctx, b, update)
@@ -120,51 +215,65 @@ func (h *BotHandler) WrapDefaultHandler(ctx context.Context, b *bot.Bot, update
 
 // HandleStart handles the /start command and shows the main keyboard.
 func (h *BotHandler) HandleStart(ctx context.Context, b BotAPI, update *models.Update) {
-	if _, ok := h.adminIDs[update.Message.From.ID]; ok {
-		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, "Вы являетесь администратором бота.")
+	lang := h.language(update)
+	if h.isAdmin(update.Message.From.ID) {
+		sendErrorMessage(ctx, nil, b, update.Message.Chat.ID, localize(lang, msgAdminStart))
 		return
 	}
 
 	userFrom := update.Message.From
-	var user *databaser.User
+	var (
+		user    *databaser.User
+		created bool
+	)
 
 	tnxErr := databaser.InTransaction(ctx, h.db, func(tx *sqlx.Tx) error {
-		dbUser, err := databaser.GetOrCreateUser(ctx, tx, userFrom.ID, userFrom.Username, userFrom.FirstName, userFrom.LastName)
+		dbUser, isNew, err := databaser.GetOrCreateUser(ctx, tx, userFrom.ID, userFrom.Username, userFrom.FirstName, userFrom.LastName)
 		if err != nil {
 			return err
 		}
 
-		user = dbUser
+		user, created = dbUser, isNew
 		return nil
 	})
 
 	if tnxErr != nil {
 		slog.ErrorContext(ctx, "HandleStart get or create user", "error", tnxErr)
-		sendErrorMessage(ctx, tnxErr, b, update.Message.Chat.ID, "Не удалось обработать ваш запрос")
+		sendErrorMessage(ctx, tnxErr, b, update.Message.Chat.ID, localize(lang, msgRequestFailed))
 		return
 	}
 
-	var text string
+	var (
+		text        string
+		replyMarkup models.ReplyMarkup
+	)
 
 	switch {
 	case user.IsPending():
-		text = "Ваш запрос принят, дождитесь подтверждения."
+		text = localize(lang, msgRequestAccepted)
 	case user.IsApproved():
-		text = "Бот уже активен. Используйте команды для получения графиков."
+		// approved users get period buttons instead of typing commands
+		text, replyMarkup = localize(lang, msgAlreadyActive), periodKeyboard(lang)
 	default:
-		text = "Ваш запрос отклонён."
+		text = localize(lang, msgRequestRejected)
 	}
 
 	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   text,
+		ChatID:      update.Message.Chat.ID,
+		Text:        text,
+		ReplyMarkup: replyMarkup,
 	})
 
 	if err != nil {
 		slog.ErrorContext(ctx, "HandleStart", "error", err)
 	}
 
-	// notify admins about new users
+	// notify admins about new users, repeated requests of existing users are skipped to avoid spam
+	if !created && !h.cfg.Telegram.NotifyRepeatedStart {
+		slog.DebugContext(ctx, "HandleStart skip admins notification", "userID", user.ID, "status", user.Status)
+		return
+	}
+
 	adminText := fmt.Sprintf(
 		"Пользователь запросил доступ (статус=%d):\nID: %d\n@%s %s %s",
 		user.Status,
@@ -173,7 +282,7 @@ func (h *BotHandler) HandleStart(ctx context.Context, b BotAPI, update *models.U
 		user.FirstName,
 		user.LastName,
 	)
-	for adminID := range h.adminIDs {
+	for _, adminID := range h.adminIDs.IDs() {
 		_, err = b.SendMessage(ctx, &bot.SendMessageParams{
 			ChatID: adminID,
 			Text:   adminText,
@@ -187,15 +296,16 @@ func (h *BotHandler) HandleStart(ctx context.Context, b BotAPI, update *models.U
 
 // HandleStop handles the /stop command and removes the main keyboard.
 func (h *BotHandler) HandleStop(ctx context.Context, b BotAPI, update *models.Update) {
+	lang := h.language(update)
 	err := h.db.DeleteUser(ctx, update.Message.From.ID)
 	if err != nil {
-		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, "Не удалось обработать ваш запрос")
+		sendErrorMessage(ctx, err, b, update.Message.Chat.ID, localize(lang, msgRequestFailed))
 		return
 	}
 
 	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
 		ChatID: update.Message.Chat.ID,
-		Text:   "Бот остановлен. Чтобы начать снова, используйте команду /start.",
+		Text:   localize(lang, msgStopped),
 	})
 
 	if err != nil {
@@ -242,6 +352,50 @@ func (h *BotHandler) HandleID(ctx context.Context, b BotAPI, update *models.Upda
 	}
 }
 
+// HandleHolidays handles the /holidays command and returns holidays for the given or current year.
+func (h *BotHandler) HandleHolidays(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	lang := h.language(update)
+	year := time.Now().In(h.cfg.Base.TimeLocation).Year()
+
+	if args := strings.Fields(update.Message.Text); len(args) > 1 {
+		y, err := strconv.Atoi(args[1])
+		if err != nil || y < 1 || y > 9999 {
+			sendErrorMessage(ctx, err, b, chatID, localize(lang, msgHolidaysUsage))
+			return
+		}
+		year = y
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	holidays, err := h.db.GetHolidays(opCtx, year, h.cfg.Base.TimeLocation)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, lang, localize(lang, msgHolidaysFailed)))
+		return
+	}
+
+	if len(holidays) == 0 {
+		sendErrorMessage(ctx, nil, b, chatID, fmt.Sprintf(localize(lang, msgHolidaysEmpty), year))
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, localize(lang, msgHolidaysTitle), year)
+
+	for _, holiday := range holidays {
+		sb.WriteString(holiday.Day.Format(dateFormat))
+		if holiday.Title != "" {
+			sb.WriteString(" ")
+			sb.WriteString(holiday.Title)
+		}
+		sb.WriteString("\n")
+	}
+
+	sendLongMessage(ctx, b, chatID, sb.String())
+}
+
 // DefaultHandler handles all other messages, allowing admin users to request custom duration graphs.
 func (h *BotHandler) DefaultHandler(ctx context.Context, b BotAPI, update *models.Update) {
 	if emptyUpdate(update) {
@@ -257,15 +411,29 @@ func (h *BotHandler) DefaultHandler(ctx context.Context, b BotAPI, update *model
 		return
 	}
 
+	lang := h.language(update)
 	text := update.Message.Text
-	duration, err := time.ParseDuration(text)
+	duration, err := parsePeriod(text)
 	if err != nil {
-		sendErrorMessage(ctx, err, b, chatID, "не удалось распознать период")
+		sendErrorMessage(ctx, err, b, chatID, localize(lang, msgPeriodInvalid))
 		return
 	}
 
+	duration = h.limitPeriod(ctx, b, chatID, lang, duration)
 	predictHours := calculatePredictHours(duration)
-	h.buildGraph(ctx, b, chatID, duration, predictHours)
+	h.buildGraph(ctx, b, chatID, lang, duration, predictHours)
+}
+
+// limitPeriod returns the period limited by the configured maximum, the user is notified if it's limited.
+func (h *BotHandler) limitPeriod(ctx context.Context, b BotAPI, chatID int64, lang string, duration time.Duration) time.Duration {
+	maxPeriod := h.cfg.Telegram.MaxPeriod
+	if maxPeriod <= 0 || duration <= maxPeriod {
+		return duration
+	}
+
+	slog.InfoContext(ctx, "period is limited", "period", duration, "max", maxPeriod)
+	sendErrorMessage(ctx, nil, b, chatID, fmt.Sprintf(localize(lang, msgPeriodLimited), formatPeriod(maxPeriod)))
+	return maxPeriod
 }
 
 // handlePeriod processes requests for load graphs over a specified duration.
@@ -275,13 +443,30 @@ func (h *BotHandler) handlePeriod(ctx context.Context, b BotAPI, update *models.
 	text := update.Message.Text
 
 	slog.DebugContext(ctx, "handlePeriod", "chatID", chatID, "userID", userID, "text", text)
-	h.buildGraph(ctx, b, chatID, duration, predictHours)
+	h.buildGraph(ctx, b, chatID, h.language(update), duration, predictHours)
+}
+
+// operationContext returns a context for database and rendering operations limited by the handler timeout.
+// Messages to the user should be sent with the parent context, so they are delivered after the timeout.
+func (h *BotHandler) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := h.cfg.Telegram.Timeout; timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// operationErrorText returns the timeout message in the language if the operation context deadline is exceeded,
+// otherwise the text.
+func operationErrorText(opCtx context.Context, lang, text string) string {
+	if errors.Is(opCtx.Err(), context.DeadlineExceeded) {
+		return localize(lang, msgTimeout)
+	}
+	return text
 }
 
 // isAdmin checks if the user is authorized to use the bot.
 func (h *BotHandler) isAdmin(userID int64) bool {
-	_, ok := h.adminIDs[userID]
-	return ok
+	return h.adminIDs.Contains(userID)
 }
 
 // calculatePredictHours determines the number of prediction hours based on the duration.
@@ -315,49 +500,280 @@ func sendErrorMessage(ctx context.Context, err error, b BotAPI, chatID int64, te
 	}
 }
 
-// buildGraph constructs and sends the load graph to the user.
-func (h *BotHandler) buildGraph(ctx context.Context, b BotAPI, chatID int64, duration time.Duration, ph uint8) {
-	events, err := h.db.GetEvents(ctx, duration)
+// sendLongMessage sends a text splitting it into several messages if it exceeds Telegram limit.
+func sendLongMessage(ctx context.Context, b BotAPI, chatID int64, text string) {
+	for _, part := range splitMessage(text, maxMessageLength) {
+		_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+			ChatID: chatID,
+			Text:   part,
+		})
+
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to send message part", "error", err, "chatID", chatID)
+			return
+		}
+	}
+}
+
+// splitMessage splits a text into parts with at most limit runes, breaking at new lines if possible.
+func splitMessage(text string, limit int) []string {
+	var (
+		parts []string
+		sb    strings.Builder
+		size  int
+	)
+
+	flush := func() {
+		if sb.Len() > 0 {
+			parts = append(parts, sb.String())
+			sb.Reset()
+			size = 0
+		}
+	}
+
+	for line := range strings.Lines(text) {
+		n := utf8.RuneCountInString(line)
+		if size+n > limit {
+			flush()
+		}
+
+		// too long line, split it by runes
+		for n > limit {
+			runes := []rune(line)
+			parts = append(parts, string(runes[:limit]))
+			line = string(runes[limit:])
+			n -= limit
+		}
+
+		sb.WriteString(line)
+		size += n
+	}
+
+	flush()
+	return parts
+}
+
+// buildGraph constructs and sends the load graph of the main club to the user.
+func (h *BotHandler) buildGraph(ctx context.Context, b BotAPI, chatID int64, lang string, duration time.Duration, ph uint8) {
+	h.buildClubGraph(ctx, b, chatID, lang, duration, ph, databaser.DefaultClubID)
+}
+
+// buildClubGraph constructs and sends the load graph of the club to the user.
+// Predictions are built only for the main club.
+func (h *BotHandler) buildClubGraph(
+	ctx context.Context, b BotAPI, chatID int64, lang string, duration time.Duration, ph uint8, clubID int,
+) {
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	events, err := h.clubEvents(opCtx, duration, clubID)
 	if err != nil {
-		sendErrorMessage(ctx, err, b, chatID, "Не удалось получить данные за указанный период")
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, lang, localize(lang, msgEventsFailed)))
 		return
 	}
 
 	n := len(events)
 	if n < 2 {
-		sendErrorMessage(ctx, nil, b, chatID, "Слишком мало данных за указанный период для построения графика")
+		sendErrorMessage(ctx, nil, b, chatID, localize(lang, msgTooFewEvents))
 		return
 	}
 
-	var prediction []databaser.Event
-	if h.pc != nil {
-		prediction = h.pc.PredictLoad(ph)
+	pc := h.pc
+	if clubID != databaser.DefaultClubID {
+		pc = nil
 	}
 
-	imageData, err := plotter.Graph(events, prediction, h.cfg.Base.TimeLocation)
-	if err != nil {
-		sendErrorMessage(ctx, err, b, chatID, "Не удалось построить график")
-		return
+	// model lines are omitted until the predictor learns enough events, the caption explains it
+	warmup := pc != nil && !pc.Ready()
+
+	var prediction, typical []databaser.Event
+	if pc != nil && !warmup {
+		prediction = pc.PredictLoad(ph)
+
+		if h.cfg.Telegram.ShowTypical {
+			typical = pc.TypicalLoad(events)
+		}
 	}
 
-	slog.DebugContext(ctx, "graph", "image", len(imageData))
 	caption := fmt.Sprintf(
 		"%s - %s",
 		events[0].Timestamp.In(h.cfg.Base.TimeLocation).Format(dateTimeFormat),
 		events[n-1].Timestamp.In(h.cfg.Base.TimeLocation).Format(dateTimeFormat),
 	)
+	switch {
+	case warmup:
+		caption += "\n" + localize(lang, msgWarmup)
+	case pc != nil && pc.ScaleConfidence():
+		caption += fmt.Sprintf(localize(lang, msgConfidence), pc.Confidence(ph)*100)
+	}
+	if clubID != databaser.DefaultClubID {
+		caption = fmt.Sprintf(localize(lang, msgClubTitle), clubID) + "\n" + caption
+	}
+	now, last := time.Now(), events[n-1].Timestamp
+	if duration > hourlyGraphPeriod {
+		// the last average has the start time of its hour, it may contain events until the hour end
+		last = last.Add(time.Hour)
+		if last.After(now) {
+			last = now
+		}
+	}
+	if warning := h.staleWarning(lang, last, now); warning != "" {
+		caption += "\n" + warning
+	}
 
-	_, err = b.SendPhoto(ctx, &bot.SendPhotoParams{
-		ChatID: chatID,
-		Photo: &models.InputFileUpload{
-			Filename: "load.png",
-			Data:     bytes.NewReader(imageData),
-		},
-		Caption: caption,
-	})
+	imageData, err := h.graph(
+		events, prediction, h.cfg.Base.TimeLocation, plotter.GraphFormat(h.cfg.Telegram.GraphFormat),
+		plotter.WithPoints(h.cfg.Telegram.ShowPoints),
+		plotter.WithTypical(typical),
+		plotter.WithConfidenceBand(h.cfg.Telegram.ShowConfidence),
+		plotter.WithKeepOverlap(h.cfg.Telegram.KeepOverlap),
+		plotter.WithWatermark(h.cfg.Telegram.Watermark),
+		plotter.WithCompact(h.cfg.Telegram.CompactGraph),
+		plotter.WithHolidays(h.holidayChecker()),
+		plotter.WithMaxPoints(maxGraphPoints),
+		plotter.WithCapacity(float64(h.cfg.Base.Capacity)),
+	)
+	if errors.Is(err, plotter.ErrRender) {
+		// the user still gets the data if the image can't be rendered, e.g. under memory pressure
+		slog.ErrorContext(ctx, "graph render failed, send text summary", "error", err)
+		sendLongMessage(ctx, b, chatID, graphSummary(lang, events)+"\n"+caption)
+		return
+	}
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, localize(lang, msgGraphFailed))
+		return
+	}
 
+	if err = opCtx.Err(); err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, lang, localize(lang, msgGraphFailed)))
+		return
+	}
+
+	h.sendGraph(ctx, b, chatID, lang, imageData, caption)
+}
+
+// sendGraph sends the graph image with the caption and keeps it as the last graph of the chat for /again command.
+func (h *BotHandler) sendGraph(ctx context.Context, b BotAPI, chatID int64, lang string, imageData []byte, caption string) {
+	slog.DebugContext(ctx, "graph", "image", len(imageData))
+	filename := "load." + h.cfg.Telegram.GraphFormat
+	fileID, err := sendImage(ctx, b, chatID, imageData, filename, caption)
 	if err != nil {
-		sendErrorMessage(ctx, err, b, chatID, "Не удалось отправить график")
+		sendErrorMessage(ctx, err, b, chatID, localize(lang, msgGraphSendFailed))
 		return
 	}
+
+	graph := &lastGraph{
+		created:  time.Now(),
+		fileID:   fileID,
+		filename: filename,
+		caption:  caption,
+		document: sendAsDocument(imageData, filename),
+	}
+	if fileID == "" {
+		graph.data = imageData
+	}
+	h.graphs.set(chatID, graph)
+}
+
+// holidayChecker returns the holiday checker of graphs, it's nil without the predictor.
+func (h *BotHandler) holidayChecker() plotter.HolidayChecker {
+	if h.pc == nil {
+		return nil
+	}
+	return h.pc.HolidayChecker()
+}
+
+// clubEvents returns events of the club for the period, the main club events are read from the cache if it covers the period.
+// Periods longer than hourlyGraphPeriod are returned as hourly averages, so long graphs are not noisy.
+func (h *BotHandler) clubEvents(ctx context.Context, period time.Duration, clubID int) ([]databaser.Event, error) {
+	if period > hourlyGraphPeriod {
+		return h.db.GetClubHourlyAverages(ctx, period, clubID)
+	}
+
+	if h.cache != nil && clubID == databaser.DefaultClubID {
+		if events, ok := h.cache.Events(period); ok {
+			slog.DebugContext(ctx, "events from cache", "period", period, "events", len(events))
+			return events, nil
+		}
+	}
+
+	return h.db.GetClubEvents(ctx, period, clubID)
+}
+
+// graphSummary returns a text summary of the events with their sparkline.
+func graphSummary(lang string, events []databaser.Event) string {
+	var (
+		sum      int
+		low      = events[0].Load
+		high     = events[0].Load
+		lastLoad = events[len(events)-1].Load
+	)
+
+	for _, event := range events {
+		sum += int(event.Load)
+		low, high = min(low, event.Load), max(high, event.Load)
+	}
+
+	mean := float64(sum) / float64(len(events))
+	return fmt.Sprintf(localize(lang, msgGraphFallback), plotter.Sparkline(events, sparklineWidth), low, mean, high, lastLoad)
+}
+
+// staleWarning returns a warning if the last event is older than the configured number of fetcher periods.
+func (h *BotHandler) staleWarning(lang string, last, now time.Time) string {
+	f := h.cfg.Fetcher
+	if !f.Active || h.cfg.Telegram.StalePeriods < 1 {
+		return ""
+	}
+
+	period := f.Timeout
+	if f.BatchSize > 1 {
+		period *= time.Duration(f.BatchSize) // events are saved by batches
+	}
+
+	if now.Sub(last) <= time.Duration(h.cfg.Telegram.StalePeriods)*period {
+		return ""
+	}
+
+	last, now = last.In(h.cfg.Base.TimeLocation), now.In(h.cfg.Base.TimeLocation)
+	layout := dateTimeFormat
+	if last.Format(time.DateOnly) == now.Format(time.DateOnly) {
+		layout = "15:04"
+	}
+
+	return fmt.Sprintf(localize(lang, msgStale), last.Format(layout))
+}
+
+// sendAsDocument returns true if the image can't be sent as a photo, Telegram photos are raster images
+// with a limited size.
+func sendAsDocument(imageData []byte, filename string) bool {
+	return len(imageData) > maxPhotoSize || strings.HasSuffix(filename, "."+string(plotter.FormatSVG))
+}
+
+// sendImage sends an image as a photo or as a document if it is a vector image or exceeds Telegram photo size limit.
+// It returns Telegram file ID of the sent image if it is known.
+func sendImage(ctx context.Context, b BotAPI, chatID int64, imageData []byte, filename, caption string) (string, error) {
+	upload := &models.InputFileUpload{Filename: filename, Data: bytes.NewReader(imageData)}
+
+	if sendAsDocument(imageData, filename) {
+		if len(imageData) > maxPhotoSize {
+			slog.WarnContext(ctx, "image is too large for photo, send as document", "size", len(imageData))
+		}
+		msg, err := b.SendDocument(ctx, &bot.SendDocumentParams{ChatID: chatID, Document: upload, Caption: caption})
+		if err != nil {
+			return "", fmt.Errorf("send document: %w", err)
+		}
+		if msg != nil && msg.Document != nil {
+			return msg.Document.FileID, nil
+		}
+		return "", nil
+	}
+
+	msg, err := b.SendPhoto(ctx, &bot.SendPhotoParams{ChatID: chatID, Photo: upload, Caption: caption})
+	if err != nil {
+		return "", fmt.Errorf("send photo: %w", err)
+	}
+	if msg != nil && len(msg.Photo) > 0 {
+		return msg.Photo[len(msg.Photo)-1].FileID, nil // the largest size is the last one
+	}
+	return "", nil
 }
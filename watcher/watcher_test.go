@@ -1,42 +1,84 @@
 package watcher
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-telegram/bot"
 	"github.com/go-telegram/bot/models"
+	"github.com/jmoiron/sqlx"
 
 	"github.com/z0rr0/ggp/config"
 	"github.com/z0rr0/ggp/databaser"
+	"github.com/z0rr0/ggp/plotter"
 	"github.com/z0rr0/ggp/predictor"
 )
 
 type mockBot struct {
-	sendMessageCalls int
-	sendPhotoCalls   int
-	lastChatID       any
-	lastText         string
-	lastCaption      string
-	sendMessageErr   error
-	sendPhotoErr     error
+	sendMessageCalls  int
+	sendPhotoCalls    int
+	sendDocumentCalls int
+	lastChatID        any
+	lastText          string
+	lastCaption       string
+	sendMessageErr    error
+	sendPhotoErr      error
+	sendDocumentErr   error
+	photoFileID       string
+	lastPhoto         models.InputFile
+	lastDocument      []byte
+	lastReplyMarkup   models.ReplyMarkup
+	answerCalls       int
+	lastAnswer        *bot.AnswerCallbackQueryParams
 }
 
 func (m *mockBot) SendMessage(_ context.Context, params *bot.SendMessageParams) (*models.Message, error) {
 	m.sendMessageCalls++
 	m.lastChatID = params.ChatID
 	m.lastText = params.Text
+	m.lastReplyMarkup = params.ReplyMarkup
 	return &models.Message{}, m.sendMessageErr
 }
 
+func (m *mockBot) AnswerCallbackQuery(_ context.Context, params *bot.AnswerCallbackQueryParams) (bool, error) {
+	m.answerCalls++
+	m.lastAnswer = params
+	return true, nil
+}
+
 func (m *mockBot) SendPhoto(_ context.Context, params *bot.SendPhotoParams) (*models.Message, error) {
 	m.sendPhotoCalls++
 	m.lastChatID = params.ChatID
 	m.lastCaption = params.Caption
-	return &models.Message{}, m.sendPhotoErr
+	m.lastPhoto = params.Photo
+
+	msg := &models.Message{}
+	if m.photoFileID != "" {
+		msg.Photo = []models.PhotoSize{{FileID: m.photoFileID + "_small"}, {FileID: m.photoFileID}}
+	}
+	return msg, m.sendPhotoErr
+}
+
+func (m *mockBot) SendDocument(_ context.Context, params *bot.SendDocumentParams) (*models.Message, error) {
+	m.sendDocumentCalls++
+	m.lastChatID = params.ChatID
+	m.lastCaption = params.Caption
+	if upload, ok := params.Document.(*models.InputFileUpload); ok {
+		data, err := io.ReadAll(upload.Data)
+		if err != nil {
+			return nil, err
+		}
+		m.lastDocument = data
+	}
+	return &models.Message{}, m.sendDocumentErr
 }
 
 func newTestDB(t *testing.T) *databaser.DB {
@@ -60,6 +102,8 @@ func newTestConfig(adminIDs ...int64) *config.Config {
 			TimeLocation: time.UTC,
 			AdminIDs:     make(map[int64]struct{}),
 			Admins:       adminIDs,
+			Language:     LangRU,
+			HourLayout:   "15:04",
 		},
 		Database: config.Database{
 			Timeout: 5 * time.Second,
@@ -69,6 +113,9 @@ func newTestConfig(adminIDs ...int64) *config.Config {
 			LoadSize: 100,
 			Timeout:  5 * time.Second,
 		},
+		Telegram: config.Telegram{
+			GraphFormat: "png",
+		},
 	}
 	for _, id := range adminIDs {
 		cfg.Base.AdminIDs[id] = struct{}{}
@@ -77,6 +124,12 @@ func newTestConfig(adminIDs ...int64) *config.Config {
 }
 
 func newTestController(t *testing.T, db *databaser.DB) *predictor.Controller {
+	t.Helper()
+	return newTestWarmupController(t, db, 0)
+}
+
+// newTestWarmupController returns a predictor controller which shows predictions after warmup learned events.
+func newTestWarmupController(t *testing.T, db *databaser.DB, warmup uint64) *predictor.Controller {
 	t.Helper()
 	cfg := &config.Config{
 		Base: config.Base{
@@ -86,9 +139,10 @@ func newTestController(t *testing.T, db *databaser.DB) *predictor.Controller {
 			Timeout: 5 * time.Second,
 		},
 		Predictor: config.Predictor{
-			Hours:    6,
-			LoadSize: 100,
-			Timeout:  5 * time.Second,
+			Hours:        6,
+			LoadSize:     100,
+			Timeout:      5 * time.Second,
+			WarmupEvents: warmup,
 		},
 	}
 	ctx := context.Background()
@@ -179,6 +233,47 @@ func TestHandleStart(t *testing.T) {
 	}
 }
 
+func TestHandleStart_RepeatedNotify(t *testing.T) {
+	update := &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 789},
+			From: &models.User{ID: 789, Username: "pending"},
+			Text: "/" + CmdStart,
+		},
+	}
+
+	tests := []struct {
+		name           string
+		notifyRepeated bool
+		wantCalls      int
+	}{
+		{name: "new user only", wantCalls: 3 + 1},
+		{name: "every start", notifyRepeated: true, wantCalls: 3 + 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(456)
+			cfg.Telegram.NotifyRepeatedStart = tt.notifyRepeated
+			handler := NewBotHandler(newTestDB(t), cfg, nil)
+			mBot := &mockBot{}
+			ctx := context.Background()
+
+			// every start gets a reply, admins are notified in addition
+			for range 3 {
+				handler.HandleStart(ctx, mBot, update)
+			}
+
+			if mBot.sendMessageCalls != tt.wantCalls {
+				t.Errorf("SendMessage called %d times, want %d", mBot.sendMessageCalls, tt.wantCalls)
+			}
+			if chatID, ok := mBot.lastChatID.(int64); !ok || (chatID == 456) != tt.notifyRepeated {
+				t.Errorf("lastChatID = %v, notify repeated %v", mBot.lastChatID, tt.notifyRepeated)
+			}
+		})
+	}
+}
+
 func TestHandleStart_SendError(t *testing.T) {
 	db := newTestDB(t)
 	cfg := newTestConfig(456)
@@ -248,6 +343,66 @@ func TestHandleDay(t *testing.T) {
 	}
 }
 
+func TestHandleDay_GraphFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantText []string
+	}{
+		{
+			name: "render failure",
+			err:  fmt.Errorf("%w: cannot allocate memory", plotter.ErrRender),
+			wantText: []string{
+				"Не удалось построить график, краткая сводка:\n",
+				"Мин. 50%",
+				"макс. 59%, последняя 59%",
+			},
+		},
+		{
+			name:     "other failure",
+			err:      errors.New("encode webp"),
+			wantText: []string{localize(LangRU, msgGraphFailed)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedEvents(t, db, 10)
+			handler := NewBotHandler(db, newTestConfig(456), newTestController(t, db))
+			handler.graph = func([]databaser.Event, []databaser.Event, *time.Location, plotter.GraphFormat, ...plotter.Option) ([]byte, error) {
+				return nil, tt.err
+			}
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: CmdDay,
+				},
+			}
+
+			handler.HandleDay(context.Background(), mBot, update)
+
+			if mBot.sendPhotoCalls != 0 {
+				t.Errorf("SendPhoto called %d times, want 0", mBot.sendPhotoCalls)
+			}
+			if mBot.sendMessageCalls != 1 {
+				t.Fatalf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+			}
+			for _, want := range tt.wantText {
+				if !strings.Contains(mBot.lastText, want) {
+					t.Errorf("message %q does not contain %q", mBot.lastText, want)
+				}
+			}
+			if _, ok := handler.graphs.get(123); ok {
+				t.Error("failed graph is stored for /again command")
+			}
+		})
+	}
+}
+
 func TestHandleHalfDay(t *testing.T) {
 	db := newTestDB(t)
 	seedEvents(t, db, 10)
@@ -294,6 +449,13 @@ func TestDefaultHandler(t *testing.T) {
 			wantPhotoCalls: 0,
 			wantMsgCalls:   1,
 		},
+		{
+			name:           "days duration - authorized user",
+			userID:         456,
+			text:           "1 day",
+			wantPhotoCalls: 1,
+			wantMsgCalls:   0,
+		},
 		{
 			name:           "unauthorized user",
 			userID:         999,
@@ -333,6 +495,48 @@ func TestDefaultHandler(t *testing.T) {
 	}
 }
 
+func TestDefaultHandler_MaxPeriod(t *testing.T) {
+	db := newTestDB(t)
+	seedEvents(t, db, 72)
+	cfg := newTestConfig(456)
+	cfg.Telegram.MaxPeriod = 24 * time.Hour
+	handler := NewBotHandler(db, cfg, nil)
+	mBot := &mockBot{}
+
+	update := &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 456},
+			Text: "3d",
+		},
+	}
+
+	handler.DefaultHandler(context.Background(), mBot, update)
+
+	if mBot.sendMessageCalls != 1 {
+		t.Errorf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+	}
+	if want := "Период ограничен до 1d."; mBot.lastText != want {
+		t.Errorf("got message %q, want %q", mBot.lastText, want)
+	}
+	if mBot.sendPhotoCalls != 1 {
+		t.Fatalf("SendPhoto called %d times, want 1", mBot.sendPhotoCalls)
+	}
+
+	start, _, found := strings.Cut(mBot.lastCaption, " - ")
+	if !found {
+		t.Fatalf("unexpected caption: %s", mBot.lastCaption)
+	}
+
+	first, err := time.ParseInLocation(dateTimeFormat, start, time.UTC)
+	if err != nil {
+		t.Fatalf("failed to parse caption start: %v", err)
+	}
+	if time.Since(first) > 25*time.Hour {
+		t.Errorf("graph starts at %v, the period is not limited", first)
+	}
+}
+
 func TestDefaultHandler_NilMessage(t *testing.T) {
 	db := newTestDB(t)
 	cfg := newTestConfig(456)
@@ -501,7 +705,7 @@ func TestBuildGraph(t *testing.T) {
 			mBot := &mockBot{}
 			ctx := context.Background()
 
-			handler.buildGraph(ctx, mBot, 123, 24*time.Hour, 6)
+			handler.buildGraph(ctx, mBot, 123, LangRU, 24*time.Hour, 6)
 
 			if mBot.sendPhotoCalls != tt.wantPhotoCalls {
 				t.Errorf("SendPhoto called %d times, want %d", mBot.sendPhotoCalls, tt.wantPhotoCalls)
@@ -513,6 +717,125 @@ func TestBuildGraph(t *testing.T) {
 	}
 }
 
+func TestBuildGraph_WithTypical(t *testing.T) {
+	db := newTestDB(t)
+	seedEvents(t, db, 10)
+	cfg := newTestConfig(456)
+	cfg.Telegram.ShowTypical = true
+	cfg.Telegram.ShowPoints = true
+	handler := NewBotHandler(db, cfg, newTestController(t, db))
+	mBot := &mockBot{}
+
+	handler.buildGraph(context.Background(), mBot, 123, LangRU, 24*time.Hour, 6)
+
+	if mBot.sendPhotoCalls != 1 {
+		t.Errorf("SendPhoto called %d times, want 1", mBot.sendPhotoCalls)
+	}
+	if mBot.sendMessageCalls != 0 {
+		t.Errorf("SendMessage called %d times, want 0", mBot.sendMessageCalls)
+	}
+}
+
+func TestSendImage(t *testing.T) {
+	tests := []struct {
+		name              string
+		filename          string
+		size              int
+		sendPhotoErr      error
+		sendDocumentErr   error
+		wantPhotoCalls    int
+		wantDocumentCalls int
+		wantErr           bool
+	}{
+		{
+			name:           "regular image as photo",
+			size:           1024,
+			wantPhotoCalls: 1,
+		},
+		{
+			name:              "svg image as document",
+			filename:          "load.svg",
+			size:              1024,
+			wantDocumentCalls: 1,
+		},
+		{
+			name:              "oversized image as document",
+			size:              maxPhotoSize + 1,
+			wantDocumentCalls: 1,
+		},
+		{
+			name:              "document send error",
+			size:              maxPhotoSize + 1,
+			sendDocumentErr:   errors.New("send error"),
+			wantDocumentCalls: 1,
+			wantErr:           true,
+		},
+		{
+			name:           "photo send error",
+			size:           1024,
+			sendPhotoErr:   errors.New("send error"),
+			wantPhotoCalls: 1,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mBot := &mockBot{sendPhotoErr: tt.sendPhotoErr, sendDocumentErr: tt.sendDocumentErr}
+
+			filename := "load.png"
+			if tt.filename != "" {
+				filename = tt.filename
+			}
+
+			_, err := sendImage(context.Background(), mBot, 123, make([]byte, tt.size), filename, "caption")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sendImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if mBot.sendPhotoCalls != tt.wantPhotoCalls {
+				t.Errorf("SendPhoto called %d times, want %d", mBot.sendPhotoCalls, tt.wantPhotoCalls)
+			}
+			if mBot.sendDocumentCalls != tt.wantDocumentCalls {
+				t.Errorf("SendDocument called %d times, want %d", mBot.sendDocumentCalls, tt.wantDocumentCalls)
+			}
+			if mBot.lastCaption != "caption" {
+				t.Errorf("lastCaption = %q, want %q", mBot.lastCaption, "caption")
+			}
+		})
+	}
+}
+
+func TestHandleDay_SVG(t *testing.T) {
+	db := newTestDB(t)
+	seedEvents(t, db, 10)
+	cfg := newTestConfig(456)
+	cfg.Telegram.GraphFormat = "svg"
+	handler := NewBotHandler(db, cfg, newTestController(t, db))
+	mBot := &mockBot{}
+
+	update := &models.Update{
+		Message: &models.Message{
+			Chat: models.Chat{ID: 123},
+			From: &models.User{ID: 456},
+			Text: CmdDay,
+		},
+	}
+	handler.HandleDay(context.Background(), mBot, update)
+
+	if mBot.sendPhotoCalls != 0 || mBot.sendDocumentCalls != 1 {
+		t.Fatalf("SendPhoto called %d times, SendDocument %d times, want a document", mBot.sendPhotoCalls, mBot.sendDocumentCalls)
+	}
+
+	graph, ok := handler.graphs.get(123)
+	if !ok {
+		t.Fatal("graph is not stored for /again command")
+	}
+	if graph.filename != "load.svg" || !graph.document || !bytes.HasPrefix(graph.data, []byte("<svg")) {
+		t.Errorf("unexpected stored graph: filename=%q, document=%v", graph.filename, graph.document)
+	}
+}
+
 func TestBuildGraph_WithoutPredictor(t *testing.T) {
 	db := newTestDB(t)
 	seedEvents(t, db, 10)
@@ -521,7 +844,7 @@ func TestBuildGraph_WithoutPredictor(t *testing.T) {
 	mBot := &mockBot{}
 	ctx := context.Background()
 
-	handler.buildGraph(ctx, mBot, 123, 24*time.Hour, 6)
+	handler.buildGraph(ctx, mBot, 123, LangRU, 24*time.Hour, 6)
 
 	if mBot.sendPhotoCalls != 1 {
 		t.Errorf("SendPhoto called %d times, want 1", mBot.sendPhotoCalls)
@@ -537,7 +860,7 @@ func TestBuildGraph_SendPhotoError(t *testing.T) {
 	mBot := &mockBot{sendPhotoErr: errors.New("photo error")}
 	ctx := context.Background()
 
-	handler.buildGraph(ctx, mBot, 123, 24*time.Hour, 6)
+	handler.buildGraph(ctx, mBot, 123, LangRU, 24*time.Hour, 6)
 
 	if mBot.sendPhotoCalls != 1 {
 		t.Errorf("SendPhoto called %d times, want 1", mBot.sendPhotoCalls)
@@ -589,6 +912,101 @@ func TestSendErrorMessage(t *testing.T) {
 	}
 }
 
+func TestHandleHolidays(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantContains []string
+	}{
+		{
+			name:         "seeded year",
+			text:         "/holidays 2025",
+			wantContains: []string{"Праздники за 2025 год:", "01.01.2025 Новый год", "\n07.01.2025 Рождество"},
+		},
+		{
+			name:         "empty year",
+			text:         "/holidays 2030",
+			wantContains: []string{"Нет данных о праздниках за 2030 год."},
+		},
+		{
+			name:         "invalid year",
+			text:         "/holidays abc",
+			wantContains: []string{"Используйте: /holidays <год>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			ctx := context.Background()
+
+			d1 := databaser.DateOnly(time.Date(2025, 1, 7, 0, 0, 0, 0, time.UTC))
+			d2 := databaser.DateOnly(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+			holidays := []databaser.Holiday{{Day: &d1, Title: "Рождество"}, {Day: &d2, Title: "Новый год"}}
+
+			err := databaser.InTransaction(ctx, db, func(tx *sqlx.Tx) error {
+				return databaser.SaveManyHolidaysTx(ctx, tx, holidays)
+			})
+			if err != nil {
+				t.Fatalf("failed to seed holidays: %v", err)
+			}
+
+			handler := NewBotHandler(db, newTestConfig(456), nil)
+			mBot := &mockBot{}
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: tt.text,
+				},
+			}
+
+			handler.HandleHolidays(ctx, mBot, update)
+
+			if mBot.sendMessageCalls != 1 {
+				t.Errorf("SendMessage called %d times, want 1", mBot.sendMessageCalls)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(mBot.lastText, want) {
+					t.Errorf("response should contain %q, got: %s", want, mBot.lastText)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		limit     int
+		wantParts int
+	}{
+		{name: "empty text", text: "", limit: 10, wantParts: 0},
+		{name: "short text", text: "line1\nline2\n", limit: 20, wantParts: 1},
+		{name: "split by lines", text: "line1\nline2\nline3\n", limit: 12, wantParts: 2},
+		{name: "long line", text: strings.Repeat("я", 25), limit: 10, wantParts: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := splitMessage(tt.text, tt.limit)
+
+			if len(parts) != tt.wantParts {
+				t.Fatalf("splitMessage() returned %d parts, want %d: %q", len(parts), tt.wantParts, parts)
+			}
+			if joined := strings.Join(parts, ""); joined != tt.text {
+				t.Errorf("joined parts = %q, want %q", joined, tt.text)
+			}
+			for i, part := range parts {
+				if n := utf8.RuneCountInString(part); n > tt.limit {
+					t.Errorf("part %d has %d runes, want <= %d", i, n, tt.limit)
+				}
+			}
+		})
+	}
+}
+
 func TestBuildGraph_DatabaseError(t *testing.T) {
 	db := newTestDB(t)
 	cfg := newTestConfig(456)
@@ -600,13 +1018,208 @@ func TestBuildGraph_DatabaseError(t *testing.T) {
 		t.Fatalf("failed to close db: %v", err)
 	}
 
-	handler.buildGraph(ctx, mBot, 123, 24*time.Hour, 6)
+	handler.buildGraph(ctx, mBot, 123, LangRU, 24*time.Hour, 6)
 
 	if mBot.sendMessageCalls != 1 {
 		t.Errorf("SendMessage called %d times, want 1 (error message)", mBot.sendMessageCalls)
 	}
 }
 
+func TestBuildGraph_Timeout(t *testing.T) {
+	db := newTestDB(t)
+	seedEvents(t, db, 3)
+	cfg := newTestConfig(456)
+	cfg.Telegram.Timeout = 50 * time.Millisecond
+	handler := NewBotHandler(db, cfg, nil)
+	mBot := &mockBot{}
+	ctx := context.Background()
+
+	// hold the only database connection, so the query waits for it until the timeout
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("failed to get connection: %v", err)
+	}
+	defer func() {
+		if err = conn.Close(); err != nil {
+			t.Errorf("failed to close connection: %v", err)
+		}
+	}()
+
+	handler.buildGraph(ctx, mBot, 123, LangRU, 24*time.Hour, 6)
+
+	if mBot.sendPhotoCalls != 0 {
+		t.Errorf("SendPhoto called %d times, want 0", mBot.sendPhotoCalls)
+	}
+	if mBot.lastText != localize(LangRU, msgTimeout) {
+		t.Errorf("got message %q, want %q", mBot.lastText, localize(LangRU, msgTimeout))
+	}
+}
+
+func TestStaleWarning(t *testing.T) {
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		fetcher   config.Fetcher
+		periods   int
+		last      time.Time
+		wantEmpty bool
+		want      string
+	}{
+		{
+			name:      "fresh data",
+			fetcher:   config.Fetcher{Active: true, Timeout: time.Minute},
+			periods:   3,
+			last:      now.Add(-2 * time.Minute),
+			wantEmpty: true,
+		},
+		{
+			name:    "stale data today",
+			fetcher: config.Fetcher{Active: true, Timeout: time.Minute},
+			periods: 3,
+			last:    now.Add(-10 * time.Minute),
+			want:    "последнее обновление 11:50",
+		},
+		{
+			name:    "stale data from previous day",
+			fetcher: config.Fetcher{Active: true, Timeout: time.Minute},
+			periods: 3,
+			last:    now.Add(-24 * time.Hour),
+			want:    "последнее обновление 05.01.2025 12:00",
+		},
+		{
+			name:      "batched events are not stale",
+			fetcher:   config.Fetcher{Active: true, Timeout: time.Minute, BatchSize: 10},
+			periods:   3,
+			last:      now.Add(-10 * time.Minute),
+			wantEmpty: true,
+		},
+		{
+			name:      "warning disabled",
+			fetcher:   config.Fetcher{Active: true, Timeout: time.Minute},
+			last:      now.Add(-time.Hour),
+			wantEmpty: true,
+		},
+		{
+			name:      "fetcher inactive",
+			fetcher:   config.Fetcher{Timeout: time.Minute},
+			periods:   3,
+			last:      now.Add(-time.Hour),
+			wantEmpty: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newTestConfig(456)
+			cfg.Fetcher = tt.fetcher
+			cfg.Telegram.StalePeriods = tt.periods
+			handler := NewBotHandler(nil, cfg, nil)
+
+			got := handler.staleWarning(LangRU, tt.last, now)
+			if tt.wantEmpty {
+				if got != "" {
+					t.Errorf("staleWarning() = %q, want empty", got)
+				}
+				return
+			}
+
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("staleWarning() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildGraph_StaleCaption(t *testing.T) {
+	db := newTestDB(t)
+	seedEvents(t, db, 3) // the newest event is an hour old
+	cfg := newTestConfig(456)
+	cfg.Fetcher = config.Fetcher{Active: true, Timeout: time.Minute}
+	handler := NewBotHandler(db, cfg, nil)
+	ctx := context.Background()
+
+	mBot := &mockBot{}
+	handler.buildGraph(ctx, mBot, 123, LangRU, 24*time.Hour, 6)
+	if strings.Contains(mBot.lastCaption, "устаревшими") {
+		t.Errorf("caption should not contain stale warning when disabled: %s", mBot.lastCaption)
+	}
+
+	cfg.Telegram.StalePeriods = 3
+	mBot = &mockBot{}
+	handler.buildGraph(ctx, mBot, 123, LangRU, 24*time.Hour, 6)
+	if !strings.Contains(mBot.lastCaption, "устаревшими") {
+		t.Errorf("caption should contain stale warning: %s", mBot.lastCaption)
+	}
+}
+
+func TestClubEvents_Hourly(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	// events every 10 minutes for 3 days
+	events := make([]databaser.Event, 0, 3*24*6)
+	for i := range cap(events) {
+		events = append(events, databaser.Event{Timestamp: now.Add(-time.Duration(i) * 10 * time.Minute), Load: uint8(i % 100)})
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	handler := NewBotHandler(db, newTestConfig(456), nil)
+
+	raw, err := handler.clubEvents(ctx, 24*time.Hour, databaser.DefaultClubID)
+	if err != nil {
+		t.Fatalf("clubEvents() error = %v", err)
+	}
+	if n := len(raw); n < 24*6 || n > 24*6+1 {
+		t.Errorf("day events = %d, want raw events", n)
+	}
+
+	hourly, err := handler.clubEvents(ctx, 7*24*time.Hour, databaser.DefaultClubID)
+	if err != nil {
+		t.Fatalf("clubEvents() error = %v", err)
+	}
+	if n := len(hourly); n < 72 || n > 73 {
+		t.Errorf("week events = %d, want 72 or 73 hourly averages", n)
+	}
+	for i, event := range hourly {
+		if !event.Timestamp.Equal(event.Timestamp.Truncate(time.Hour)) {
+			t.Errorf("event %d timestamp %v is not an hour start", i, event.Timestamp)
+		}
+	}
+}
+
+func TestBuildGraph_HourlyNotStale(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	events := make([]databaser.Event, 0, 3*24)
+	for i := range cap(events) {
+		events = append(events, databaser.Event{Timestamp: now.Add(-time.Duration(i)*time.Hour - time.Second), Load: 50})
+	}
+	if err := db.SaveManyEvents(ctx, events); err != nil {
+		t.Fatalf("failed to save events: %v", err)
+	}
+
+	cfg := newTestConfig(456)
+	cfg.Fetcher = config.Fetcher{Active: true, Timeout: time.Minute}
+	cfg.Telegram.StalePeriods = 3
+	handler := NewBotHandler(db, cfg, nil)
+
+	// the last hourly average starts up to an hour ago, but the data is fresh
+	mBot := &mockBot{}
+	handler.buildGraph(ctx, mBot, 123, LangRU, 7*24*time.Hour, 6)
+	if mBot.sendPhotoCalls != 1 {
+		t.Fatalf("SendPhoto called %d times, want 1", mBot.sendPhotoCalls)
+	}
+	if strings.Contains(mBot.lastCaption, "устаревшими") {
+		t.Errorf("caption should not contain stale warning: %s", mBot.lastCaption)
+	}
+}
+
 func TestBuildGraph_CaptionFormat(t *testing.T) {
 	db := newTestDB(t)
 	seedEvents(t, db, 3)
@@ -615,13 +1228,35 @@ func TestBuildGraph_CaptionFormat(t *testing.T) {
 	mBot := &mockBot{}
 	ctx := context.Background()
 
-	handler.buildGraph(ctx, mBot, 123, 24*time.Hour, 6)
+	handler.buildGraph(ctx, mBot, 123, LangRU, 24*time.Hour, 6)
 
 	if mBot.lastCaption == "" {
 		t.Error("caption is empty")
 	}
 }
 
+func TestBuildGraph_CaptionConfidence(t *testing.T) {
+	db := newTestDB(t)
+	seedEvents(t, db, 3)
+	cfg := newTestConfig(456)
+
+	for _, scale := range []bool{false, true} {
+		t.Run(strconv.FormatBool(scale), func(t *testing.T) {
+			pc := newTestController(t, db)
+			pc.SetScaleConfidence(scale)
+			handler := NewBotHandler(db, cfg, pc)
+			mBot := &mockBot{}
+
+			handler.buildGraph(context.Background(), mBot, 123, LangRU, 24*time.Hour, 6)
+
+			// the confidence is shown only if it's scaled by the events count
+			if got := strings.Contains(mBot.lastCaption, "Достоверность прогноза:"); got != scale {
+				t.Errorf("caption contains prediction confidence = %v, want %v: %s", got, scale, mBot.lastCaption)
+			}
+		})
+	}
+}
+
 // Ensure mockBot implements BotAPI interface
 var _ BotAPI = (*mockBot)(nil)
 
@@ -644,6 +1279,10 @@ func (b *benchmarkBot) SendPhoto(_ context.Context, params *bot.SendPhotoParams)
 	return &models.Message{}, nil
 }
 
+func (b *benchmarkBot) SendDocument(_ context.Context, _ *bot.SendDocumentParams) (*models.Message, error) {
+	return &models.Message{}, nil
+}
+
 // Ensure benchmarkBot implements BotAPI interface
 var _ BotAPI = (*benchmarkBot)(nil)
 
@@ -687,6 +1326,51 @@ func BenchmarkBuildGraph(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		handler.buildGraph(ctx, bBot, 123, 24*time.Hour, 6)
+		handler.buildGraph(ctx, bBot, 123, LangRU, 24*time.Hour, 6)
+	}
+}
+
+func TestBuildGraph_Warmup(t *testing.T) {
+	tests := []struct {
+		name           string
+		warmup         uint64
+		wantPrediction bool
+		wantCaption    string
+	}{
+		{name: "ready", warmup: 10, wantPrediction: true, wantCaption: "Достоверность прогноза"},
+		{name: "warmup", warmup: 11, wantCaption: localize(LangRU, msgWarmup)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedEvents(t, db, 10)
+			cfg := newTestConfig(456)
+			cfg.Telegram.ShowTypical = true
+
+			pc := newTestWarmupController(t, db, tt.warmup)
+			pc.SetScaleConfidence(true)
+			handler := NewBotHandler(db, cfg, pc)
+			var gotPrediction bool
+			handler.graph = func(
+				events, prediction []databaser.Event, location *time.Location, format plotter.GraphFormat, opts ...plotter.Option,
+			) ([]byte, error) {
+				gotPrediction = len(prediction) > 0
+				return plotter.GraphWithFormat(events, prediction, location, format, opts...)
+			}
+			mBot := &mockBot{}
+
+			handler.buildGraph(context.Background(), mBot, 123, LangRU, 24*time.Hour, 6)
+
+			if mBot.sendPhotoCalls != 1 {
+				t.Fatalf("SendPhoto called %d times, want 1", mBot.sendPhotoCalls)
+			}
+			if gotPrediction != tt.wantPrediction {
+				t.Errorf("graph has prediction = %v, want %v", gotPrediction, tt.wantPrediction)
+			}
+			if !strings.Contains(mBot.lastCaption, tt.wantCaption) {
+				t.Errorf("caption %q should contain %q", mBot.lastCaption, tt.wantCaption)
+			}
+		})
 	}
 }
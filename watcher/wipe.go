@@ -0,0 +1,132 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// WipeCallbackPrefix is the prefix of callback data of /wipeevents confirmation buttons.
+const WipeCallbackPrefix = "wipe:"
+
+// Callback data of wipe confirmation buttons without WipeCallbackPrefix.
+const (
+	wipeConfirm = "confirm"
+	wipeCancel  = "cancel"
+)
+
+// WrapHandleWipeEvents wraps HandleWipeEvents to match bot.HandlerFunc signature.
+func (h *BotHandler) WrapHandleWipeEvents(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleWipeEvents(ctx, b, update)
+}
+
+// WrapHandleWipeCallback wraps HandleWipeCallback for bot.HandlerFunc compatibility.
+func (h *BotHandler) WrapHandleWipeCallback(ctx context.Context, b *bot.Bot, update *models.Update) {
+	h.HandleWipeCallback(ctx, b, update)
+}
+
+// wipeKeyboard returns inline buttons to confirm or cancel removing of all events.
+func wipeKeyboard() *models.InlineKeyboardMarkup {
+	return &models.InlineKeyboardMarkup{
+		InlineKeyboard: [][]models.InlineKeyboardButton{{
+			{Text: "Удалить", CallbackData: WipeCallbackPrefix + wipeConfirm},
+			{Text: "Отмена", CallbackData: WipeCallbackPrefix + wipeCancel},
+		}},
+	}
+}
+
+// HandleWipeEvents asks to confirm removing of all events, it's refused if it's not allowed by the config.
+func (h *BotHandler) HandleWipeEvents(ctx context.Context, b BotAPI, update *models.Update) {
+	chatID := update.Message.Chat.ID
+	if !h.cfg.Telegram.AllowWipeEvents {
+		sendErrorMessage(ctx, nil, b, chatID, "Удаление событий отключено в настройках.")
+		return
+	}
+
+	opCtx, cancel := h.operationContext(ctx)
+	defer cancel()
+
+	count, err := h.db.CountEvents(opCtx)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, operationErrorText(opCtx, LangRU, "Не удалось получить количество событий."))
+		return
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        fmt.Sprintf("Удалить все события (%d)? Восстановить их будет невозможно.", count),
+		ReplyMarkup: wipeKeyboard(),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleWipeEvents", "error", err)
+	}
+}
+
+// HandleWipeCallback handles wipe confirmation buttons, all events are removed and the predictor is reset.
+// Callback queries don't pass message middlewares, so the admin access and the config flag are checked here.
+func (h *BotHandler) HandleWipeCallback(ctx context.Context, b CallbackBotAPI, update *models.Update) {
+	query := update.CallbackQuery
+	if query == nil {
+		slog.WarnContext(ctx, "wipe callback query is nil")
+		return
+	}
+
+	userID := query.From.ID
+	slog.InfoContext(ctx, "wipe callback query", "user_id", userID, "data", query.Data)
+
+	var answer string
+	data, ok := strings.CutPrefix(query.Data, WipeCallbackPrefix)
+
+	switch {
+	case !ok || (data != wipeConfirm && data != wipeCancel):
+		slog.WarnContext(ctx, "unknown wipe callback data", "user_id", userID, "data", query.Data)
+		ok = false
+	case !h.isAdmin(userID):
+		answer, ok = localize(h.language(update), msgAdminOnly), false
+	case !h.cfg.Telegram.AllowWipeEvents:
+		answer, ok = "Удаление событий отключено в настройках.", false
+	case data == wipeCancel:
+		answer, ok = "Удаление отменено.", false
+	}
+
+	_, err := b.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID, Text: answer})
+	if err != nil {
+		slog.ErrorContext(ctx, "HandleWipeCallback answer", "error", err)
+	}
+
+	if ok {
+		h.wipeEvents(ctx, b, callbackChatID(query), userID)
+	}
+}
+
+// wipeEvents removes all events, resets the predictor and the cache and reports the number of removed events.
+func (h *BotHandler) wipeEvents(ctx context.Context, b BotAPI, chatID, userID int64) {
+	// it's a one-shot maintenance operation, so it is not limited by the handler timeout
+	removed, err := h.db.DeleteAllEvents(ctx)
+	if err != nil {
+		sendErrorMessage(ctx, err, b, chatID, "Не удалось удалить события.")
+		return
+	}
+	slog.WarnContext(ctx, "all events wiped", "user_id", userID, "removed", removed)
+
+	if h.pc != nil {
+		h.pc.Reset(ctx)
+	}
+	if h.cache != nil {
+		if err = h.cache.Seed(ctx, h.db); err != nil {
+			slog.ErrorContext(ctx, "failed to reset cache after wipe", "error", err)
+		}
+	}
+
+	_, err = b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("Удалено событий: %d.", removed),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "wipeEvents", "error", err)
+	}
+}
@@ -0,0 +1,170 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-telegram/bot/models"
+
+	"github.com/z0rr0/ggp/cacher"
+)
+
+func TestHandleWipeEvents(t *testing.T) {
+	tests := []struct {
+		name         string
+		wantContains string
+		allow        bool
+		wantKeyboard bool
+	}{
+		{name: "disabled", wantContains: "отключено"},
+		{name: "confirmation", allow: true, wantContains: "Удалить все события (5)?", wantKeyboard: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			seedEvents(t, db, 5)
+			cfg := newTestConfig(456)
+			cfg.Telegram.AllowWipeEvents = tt.allow
+			handler := NewBotHandler(db, cfg, nil)
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				Message: &models.Message{
+					Chat: models.Chat{ID: 123},
+					From: &models.User{ID: 456},
+					Text: "/wipeevents",
+				},
+			}
+			handler.HandleWipeEvents(context.Background(), mBot, update)
+
+			if !strings.Contains(mBot.lastText, tt.wantContains) {
+				t.Errorf("response %q should contain %q", mBot.lastText, tt.wantContains)
+			}
+			if got := mBot.lastReplyMarkup != nil; got != tt.wantKeyboard {
+				t.Errorf("reply markup = %v, want keyboard %v", mBot.lastReplyMarkup, tt.wantKeyboard)
+			}
+
+			count, err := db.CountEvents(context.Background())
+			if err != nil {
+				t.Fatalf("failed to count events: %v", err)
+			}
+			if count != 5 {
+				t.Errorf("events count = %d, want 5 before confirmation", count)
+			}
+		})
+	}
+}
+
+func TestHandleWipeCallback(t *testing.T) {
+	// events of several days, so the predictor has confidence before the reset
+	const wipeTestEvents = 200
+
+	tests := []struct {
+		name       string
+		data       string
+		wantAnswer string
+		userID     int64
+		allow      bool
+		wantEvents int
+	}{
+		{name: "confirm", data: WipeCallbackPrefix + wipeConfirm, userID: 456, allow: true},
+		{name: "cancel", data: WipeCallbackPrefix + wipeCancel, userID: 456, allow: true, wantAnswer: "Удаление отменено.", wantEvents: wipeTestEvents},
+		{name: "unknown data", data: WipeCallbackPrefix + "all", userID: 456, allow: true, wantEvents: wipeTestEvents},
+		{
+			name:       "disabled",
+			data:       WipeCallbackPrefix + wipeConfirm,
+			userID:     456,
+			wantAnswer: "Удаление событий отключено в настройках.",
+			wantEvents: wipeTestEvents,
+		},
+		{
+			name:       "not admin",
+			data:       WipeCallbackPrefix + wipeConfirm,
+			userID:     789,
+			allow:      true,
+			wantAnswer: localize(LangRU, msgAdminOnly),
+			wantEvents: wipeTestEvents,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newTestDB(t)
+			seedEvents(t, db, wipeTestEvents)
+			cfg := newTestConfig(456)
+			cfg.Telegram.AllowWipeEvents = tt.allow
+
+			cache, err := cacher.New(wipeTestEvents, 24*time.Hour)
+			if err != nil {
+				t.Fatalf("failed to create cache: %v", err)
+			}
+			if err = cache.Seed(ctx, db); err != nil {
+				t.Fatalf("failed to seed cache: %v", err)
+			}
+
+			pc := newTestController(t, db)
+			handler := NewBotHandler(db, cfg, pc)
+			handler.SetCache(cache)
+			mBot := &mockBot{}
+
+			update := &models.Update{
+				CallbackQuery: &models.CallbackQuery{
+					ID:      "query",
+					From:    models.User{ID: tt.userID},
+					Data:    tt.data,
+					Message: models.MaybeInaccessibleMessage{Message: &models.Message{Chat: models.Chat{ID: 123}}},
+				},
+			}
+			handler.HandleWipeCallback(ctx, mBot, update)
+
+			if mBot.answerCalls != 1 {
+				t.Fatalf("AnswerCallbackQuery called %d times, want 1", mBot.answerCalls)
+			}
+			if mBot.lastAnswer.Text != tt.wantAnswer {
+				t.Errorf("answer = %q, want %q", mBot.lastAnswer.Text, tt.wantAnswer)
+			}
+
+			count, err := db.CountEvents(ctx)
+			if err != nil {
+				t.Fatalf("failed to count events: %v", err)
+			}
+			if count != tt.wantEvents {
+				t.Fatalf("events count = %d, want %d", count, tt.wantEvents)
+			}
+
+			if tt.wantEvents > 0 {
+				if mBot.sendMessageCalls != 0 {
+					t.Errorf("SendMessage called %d times, want 0", mBot.sendMessageCalls)
+				}
+				return
+			}
+
+			if want := fmt.Sprintf("Удалено событий: %d.", wipeTestEvents); mBot.lastText != want || mBot.lastChatID != int64(123) {
+				t.Errorf("response = %q to %v, want %q to 123", mBot.lastText, mBot.lastChatID, want)
+			}
+			empty := newTestController(t, newTestDB(t))
+			if got, want := pc.Confidence(cfg.Predictor.Hours), empty.Confidence(cfg.Predictor.Hours); got != want {
+				t.Errorf("confidence = %v, want %v without history", got, want)
+			}
+			if events, ok := cache.Events(time.Hour); !ok || len(events) != 0 {
+				t.Errorf("cached events = %d (%v), want none", len(events), ok)
+			}
+		})
+	}
+}
+
+func TestHandleWipeCallback_NoQuery(t *testing.T) {
+	handler := NewBotHandler(newTestDB(t), newTestConfig(456), nil)
+	mBot := &mockBot{}
+
+	handler.HandleWipeCallback(context.Background(), mBot, &models.Update{})
+
+	if mBot.answerCalls != 0 || mBot.sendMessageCalls != 0 {
+		t.Errorf("answer calls = %d, message calls = %d, want none", mBot.answerCalls, mBot.sendMessageCalls)
+	}
+}